@@ -0,0 +1,14 @@
+package main
+
+import (
+	"os"
+
+	"github.com/openebl/openebl/pkg/bu_server"
+)
+
+func main() {
+	app := bu_server.BUServerApp{}
+	if err := app.Run(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/alecthomas/kong v0.8.1
 	github.com/bluexlab/logrus-formatter v0.1.0
 	github.com/go-ozzo/ozzo-validation/v4 v4.3.0
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/go-testfixtures/testfixtures/v3 v3.9.0
 	github.com/gobuffalo/pop v4.13.1+incompatible
 	github.com/golang/mock v1.5.0
@@ -17,6 +18,7 @@ require (
 	github.com/nuts-foundation/go-did v0.11.0
 	github.com/samber/lo v1.38.1
 	github.com/sirupsen/logrus v1.9.3
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/stretchr/testify v1.8.4
 	golang.org/x/crypto v0.16.0
 	golang.org/x/time v0.4.0
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"github.com/openebl/openebl/pkg/bu_server/middleware"
 	"github.com/openebl/openebl/pkg/bu_server/model"
 	"github.com/openebl/openebl/pkg/bu_server/storage/postgres"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
 	"github.com/openebl/openebl/pkg/util"
 	"github.com/sirupsen/logrus"
 )
@@ -27,6 +29,9 @@ type APIConfig struct {
 type API struct {
 	apiKeyMgr  auth.APIKeyAuthenticator
 	buMgr      business_unit.BusinessUnitManager
+	eblCtrl    trade_document.BillOfLadingController
+	dcsaCtrl   trade_document.DCSAController
+	verifier   trade_document.BillOfLadingVerifier
 	httpServer *http.Server
 }
 
@@ -39,7 +44,16 @@ func NewAPIWithConfig(cfg APIConfig) (*API, error) {
 
 	apiKeyMgr := auth.NewAPIKeyAuthenticator(storage)
 	buMgr := business_unit.NewBusinessUnitManager(storage)
-	api, err := NewAPIWithController(apiKeyMgr, buMgr, cfg.LocalAddress)
+	eblCtrl := trade_document.NewBillOfLadingController(
+		storage,
+		storage,
+		trade_document.NewBillOfLadingService(),
+		trade_document.NewBillOfLadingPackSigner(storage),
+		trade_document.NewBillOfLadingPublisher(trade_document.NewBusinessUnitCertificateResolver(storage), storage),
+	)
+	dcsaCtrl := trade_document.NewDCSAController(storage, storage)
+	verifier := trade_document.NewBillOfLadingVerifier(storage)
+	api, err := NewAPIWithController(apiKeyMgr, buMgr, eblCtrl, dcsaCtrl, verifier, cfg.LocalAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -47,13 +61,30 @@ func NewAPIWithConfig(cfg APIConfig) (*API, error) {
 	return api, nil
 }
 
-func NewAPIWithController(apiKeyMgr auth.APIKeyAuthenticator, buMgr business_unit.BusinessUnitManager, localAddress string) (*API, error) {
+func NewAPIWithController(
+	apiKeyMgr auth.APIKeyAuthenticator,
+	buMgr business_unit.BusinessUnitManager,
+	eblCtrl trade_document.BillOfLadingController,
+	dcsaCtrl trade_document.DCSAController,
+	verifier trade_document.BillOfLadingVerifier,
+	localAddress string,
+) (*API, error) {
 	apiServer := &API{
 		apiKeyMgr: apiKeyMgr,
 		buMgr:     buMgr,
+		eblCtrl:   eblCtrl,
+		dcsaCtrl:  dcsaCtrl,
+		verifier:  verifier,
 	}
 
-	r := mux.NewRouter()
+	router := mux.NewRouter()
+
+	// Endpoints under /public are open to anyone without an API key.
+	public := router.PathPrefix("/public").Subrouter()
+	public.HandleFunc("/ebl/verify", apiServer.verifySignedBillOfLading).Methods(http.MethodPost)
+	public.HandleFunc("/ebl/{id}/verify", apiServer.verifyBillOfLadingHash).Methods(http.MethodGet)
+
+	r := router.NewRoute().Subrouter()
 	r.Use(middleware.NewAPIKeyAuth(apiServer.apiKeyMgr).Authenticate)
 	r.HandleFunc("/business_unit", apiServer.createBusinessUnit).Methods(http.MethodPost)
 	r.HandleFunc("/business_unit", apiServer.listBusinessUnit).Methods(http.MethodGet)
@@ -64,10 +95,29 @@ func NewAPIWithController(apiKeyMgr auth.APIKeyAuthenticator, buMgr business_uni
 	r.HandleFunc("/business_unit/{id}/authentication", apiServer.listBusinessUnitAuthentication).Methods(http.MethodGet)
 	r.HandleFunc("/business_unit/{id}/authentication/{authentication_id}", apiServer.getBusinessUnitAuthentication).Methods(http.MethodGet)
 	r.HandleFunc("/business_unit/{id}/authentication/{authentication_id}", apiServer.revokeBusinessUnitAuthentication).Methods(http.MethodDelete)
+	r.HandleFunc("/ebl", apiServer.createBillOfLading).Methods(http.MethodPost)
+	r.HandleFunc("/ebl", apiServer.listBillOfLading).Methods(http.MethodGet)
+	r.HandleFunc("/ebl/{id}", apiServer.getBillOfLading).Methods(http.MethodGet)
+	r.HandleFunc("/ebl/{id}/history", apiServer.getBillOfLadingHistory).Methods(http.MethodGet)
+	r.HandleFunc("/ebl/{id}/file/{hash}", apiServer.getBillOfLadingFile).Methods(http.MethodGet)
+	r.HandleFunc("/ebl/{id}/transfer", apiServer.billOfLadingAction(apiServer.eblCtrl.Transfer)).Methods(http.MethodPost)
+	r.HandleFunc("/ebl/{id}/return", apiServer.billOfLadingAction(apiServer.eblCtrl.Return)).Methods(http.MethodPost)
+	r.HandleFunc("/ebl/{id}/surrender", apiServer.billOfLadingAction(apiServer.eblCtrl.Surrender)).Methods(http.MethodPost)
+	r.HandleFunc("/ebl/{id}/amendment_request", apiServer.billOfLadingAction(apiServer.eblCtrl.AmendmentRequest)).Methods(http.MethodPost)
+	r.HandleFunc("/ebl/{id}/amend", apiServer.billOfLadingAction(apiServer.eblCtrl.Amend)).Methods(http.MethodPost)
+	r.HandleFunc("/ebl/{id}/print_to_paper", apiServer.billOfLadingAction(apiServer.eblCtrl.PrintToPaper)).Methods(http.MethodPost)
+	// DCSA eBL v2 endpoints. Approving draft transport documents by PATCH isn't supported because there are no drafts.
+	r.HandleFunc("/v2/shipping-instructions", apiServer.createShippingInstruction).Methods(http.MethodPost)
+	r.HandleFunc("/v2/shipping-instructions", apiServer.listShippingInstructions).Methods(http.MethodGet)
+	r.HandleFunc("/v2/shipping-instructions/{shippingInstructionReference}", apiServer.getShippingInstruction).Methods(http.MethodGet)
+	r.HandleFunc("/v2/shipping-instructions/{shippingInstructionReference}", apiServer.updateShippingInstruction).Methods(http.MethodPut)
+	r.HandleFunc("/v2/transport-documents", apiServer.listTransportDocuments).Methods(http.MethodGet)
+	r.HandleFunc("/v2/transport-documents/{transportDocumentReference}", apiServer.getTransportDocument).Methods(http.MethodGet)
+	r.HandleFunc("/v2/events", apiServer.listEvents).Methods(http.MethodGet)
 
 	apiServer.httpServer = &http.Server{
 		Addr:    localAddress,
-		Handler: r,
+		Handler: router,
 	}
 	return apiServer, nil
 }
@@ -83,6 +133,11 @@ func (a *API) Close() error {
 	return a.httpServer.Close()
 }
 
+// Shutdown stops accepting new requests and waits for ongoing requests until ctx is done.
+func (a *API) Shutdown(ctx context.Context) error {
+	return a.httpServer.Shutdown(ctx)
+}
+
 func (a *API) createBusinessUnit(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
@@ -2,6 +2,7 @@ package api_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,9 +17,14 @@ import (
 	"github.com/openebl/openebl/pkg/bu_server/auth"
 	"github.com/openebl/openebl/pkg/bu_server/business_unit"
 	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/dcsa_validator"
+	"github.com/openebl/openebl/pkg/envelope"
 	"github.com/openebl/openebl/pkg/util"
 	mock_auth "github.com/openebl/openebl/test/mock/bu_server/auth"
 	mock_business_unit "github.com/openebl/openebl/test/mock/bu_server/business_unit"
+	mock_trade_document "github.com/openebl/openebl/test/mock/bu_server/trade_document"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -29,6 +35,9 @@ type APITestSuite struct {
 	ctrl      *gomock.Controller
 	apiKeyMgr *mock_auth.MockAPIKeyAuthenticator
 	buMgr     *mock_business_unit.MockBusinessUnitManager
+	eblCtrl   *mock_trade_document.MockBillOfLadingController
+	dcsaCtrl  *mock_trade_document.MockDCSAController
+	verifier  *mock_trade_document.MockBillOfLadingVerifier
 
 	basePortNumber int32
 	localAddress   string
@@ -50,10 +59,13 @@ func (s *APITestSuite) SetupTest() {
 	s.ctrl = gomock.NewController(s.T())
 	s.apiKeyMgr = mock_auth.NewMockAPIKeyAuthenticator(s.ctrl)
 	s.buMgr = mock_business_unit.NewMockBusinessUnitManager(s.ctrl)
+	s.eblCtrl = mock_trade_document.NewMockBillOfLadingController(s.ctrl)
+	s.dcsaCtrl = mock_trade_document.NewMockDCSAController(s.ctrl)
+	s.verifier = mock_trade_document.NewMockBillOfLadingVerifier(s.ctrl)
 
 	portNum := atomic.AddInt32(&s.basePortNumber, 1)
 	s.localAddress = fmt.Sprintf("localhost:%d", portNum)
-	api, err := api.NewAPIWithController(s.apiKeyMgr, s.buMgr, s.localAddress)
+	api, err := api.NewAPIWithController(s.apiKeyMgr, s.buMgr, s.eblCtrl, s.dcsaCtrl, s.verifier, s.localAddress)
 	s.Require().NoError(err)
 	s.api = api
 	go func() {
@@ -283,3 +295,417 @@ func (s *APITestSuite) TestSetStatus() {
 	body, _ := io.ReadAll(resp.Body)
 	s.Assert().Equal(util.StructToJSON(newBu), strings.TrimSpace(string(body)))
 }
+
+func (s *APITestSuite) TestCreateBillOfLading() {
+	buId := "did:openebl:carrier"
+	endPoint := fmt.Sprintf("http://%s/ebl", s.localAddress)
+
+	request := trade_document.CreateBillOfLadingRequest{
+		TransferTo:   "did:openebl:shipper",
+		BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+	}
+
+	expectedRequest := request
+	expectedRequest.ApplicationID = s.appId
+	expectedRequest.BusinessUnit = buId
+
+	record := trade_document.BillOfLadingRecord{
+		Pack:      bill_of_lading.BillOfLadingPack{ID: "pack-id", Version: 1, CurrentOwner: "did:openebl:shipper"},
+		Status:    trade_document.BillOfLadingStatusIssued,
+		CreatedAt: 12345,
+	}
+
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.eblCtrl.EXPECT().Create(gomock.Any(), gomock.Any(), expectedRequest).Return(record, nil),
+	)
+
+	httpRequest, _ := http.NewRequestWithContext(s.ctx, http.MethodPost, endPoint, util.StructToJSONReader(request))
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err := http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	s.Require().Equal("application/json", resp.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(resp.Body)
+	s.Assert().Equal(util.StructToJSON(record), strings.TrimSpace(string(body)))
+}
+
+func (s *APITestSuite) TestListBillOfLading() {
+	buId := "did:openebl:shipper"
+	endPoint := fmt.Sprintf("http://%s/ebl?offset=2&limit=5&box=sent&doc_reference=bl-number&status=issued", s.localAddress)
+
+	expectedRequest := trade_document.ListBillOfLadingRequest{
+		Offset:        2,
+		Limit:         5,
+		ApplicationID: s.appId,
+		BusinessUnit:  buId,
+		Box:           trade_document.BillOfLadingBoxSent,
+		DocReference:  "bl-number",
+		Status:        trade_document.BillOfLadingStatusIssued,
+	}
+	result := trade_document.ListBillOfLadingResult{
+		Total: 1,
+		Records: []trade_document.BillOfLadingRecord{
+			{
+				Pack:   bill_of_lading.BillOfLadingPack{ID: "pack-id", Version: 2, CurrentOwner: "did:openebl:bank"},
+				Status: trade_document.BillOfLadingStatusIssued,
+			},
+		},
+	}
+
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.eblCtrl.EXPECT().List(gomock.Any(), expectedRequest).Return(result, nil),
+	)
+
+	httpRequest, _ := http.NewRequestWithContext(s.ctx, http.MethodGet, endPoint, nil)
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err := http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	s.Assert().Equal(util.StructToJSON(result), strings.TrimSpace(string(body)))
+}
+
+func (s *APITestSuite) TestTransferBillOfLading() {
+	buId := "did:openebl:shipper"
+	endPoint := fmt.Sprintf("http://%s/ebl/pack-id/transfer", s.localAddress)
+
+	request := trade_document.BillOfLadingActionRequest{
+		Version:    1,
+		TransferTo: "did:openebl:bank",
+		Note:       "to the bank",
+	}
+
+	expectedRequest := request
+	expectedRequest.ApplicationID = s.appId
+	expectedRequest.BusinessUnit = buId
+	expectedRequest.ID = "pack-id"
+
+	record := trade_document.BillOfLadingRecord{
+		Pack:   bill_of_lading.BillOfLadingPack{ID: "pack-id", Version: 2, CurrentOwner: "did:openebl:bank"},
+		Status: trade_document.BillOfLadingStatusIssued,
+	}
+
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.eblCtrl.EXPECT().Transfer(gomock.Any(), gomock.Any(), expectedRequest).Return(record, nil),
+	)
+
+	httpRequest, _ := http.NewRequestWithContext(s.ctx, http.MethodPost, endPoint, util.StructToJSONReader(request))
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err := http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	s.Assert().Equal(util.StructToJSON(record), strings.TrimSpace(string(body)))
+
+	// Test with outdated version.
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.eblCtrl.EXPECT().Transfer(gomock.Any(), gomock.Any(), expectedRequest).Return(trade_document.BillOfLadingRecord{}, model.ErrBillOfLadingVersionConflict),
+	)
+
+	httpRequest, _ = http.NewRequestWithContext(s.ctx, http.MethodPost, endPoint, util.StructToJSONReader(request))
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err = http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusConflict, resp.StatusCode)
+}
+
+func (s *APITestSuite) TestGetBillOfLadingFile() {
+	buId := "did:openebl:shipper"
+	hash := strings.Repeat("ab", 32)
+	endPoint := fmt.Sprintf("http://%s/ebl/pack-id/file/%s", s.localAddress, hash)
+
+	expectedRequest := trade_document.GetBillOfLadingFileRequest{
+		ApplicationID: s.appId,
+		BusinessUnit:  buId,
+		ID:            "pack-id",
+		Hash:          hash,
+	}
+	file := model.File{Name: "bl.pdf", Content: []byte("%PDF-1.3\n"), Hash: hash, Size: 9, MediaType: "application/pdf"}
+
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.eblCtrl.EXPECT().GetFile(gomock.Any(), expectedRequest).Return(file, nil),
+	)
+
+	httpRequest, _ := http.NewRequestWithContext(s.ctx, http.MethodGet, endPoint, nil)
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err := http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Assert().Equal("application/pdf", resp.Header.Get("Content-Type"))
+	s.Assert().Equal(`attachment; filename=bl.pdf`, resp.Header.Get("Content-Disposition"))
+	body, _ := io.ReadAll(resp.Body)
+	s.Assert().Equal(file.Content, body)
+
+	// The file is not attached to the pack.
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.eblCtrl.EXPECT().GetFile(gomock.Any(), expectedRequest).Return(model.File{}, model.ErrFileNotFound),
+	)
+
+	httpRequest, _ = http.NewRequestWithContext(s.ctx, http.MethodGet, endPoint, nil)
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err = http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *APITestSuite) TestVerifyBillOfLading() {
+	hash := strings.Repeat("ab", 64)
+	endPoint := fmt.Sprintf("http://%s/public/ebl/pack-id/verify?hash=%s", s.localAddress, hash)
+
+	result := trade_document.BillOfLadingVerification{
+		Verified:          true,
+		SignatureVerified: true,
+		HashChainVerified: true,
+		ID:                "pack-id",
+		Version:           2,
+		Hash:              hash,
+		Issuer:            "did:openebl:carrier",
+		Known:             true,
+		LatestVersion:     3,
+		Status:            trade_document.BillOfLadingStatusPrintedToPaper,
+		Superseded:        true,
+	}
+
+	// No API key is needed.
+	s.verifier.EXPECT().VerifyHash(gomock.Any(), gomock.Any(), trade_document.VerifyBillOfLadingHashRequest{ID: "pack-id", Hash: hash}).Return(result, nil)
+
+	httpRequest, _ := http.NewRequestWithContext(s.ctx, http.MethodGet, endPoint, nil)
+	resp, err := http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	s.Assert().Equal(util.StructToJSON(result), strings.TrimSpace(string(body)))
+
+	// Unknown hash.
+	s.verifier.EXPECT().VerifyHash(gomock.Any(), gomock.Any(), gomock.Any()).Return(trade_document.BillOfLadingVerification{}, model.ErrBillOfLadingNotFound)
+
+	httpRequest, _ = http.NewRequestWithContext(s.ctx, http.MethodGet, endPoint, nil)
+	resp, err = http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+
+	// Upload a signed pack.
+	signedPack := envelope.JWS{Protected: "protected", Payload: "payload", Signature: "signature"}
+	s.verifier.EXPECT().VerifySignedPack(gomock.Any(), gomock.Any(), signedPack).Return(result, nil)
+
+	httpRequest, _ = http.NewRequestWithContext(s.ctx, http.MethodPost, fmt.Sprintf("http://%s/public/ebl/verify", s.localAddress), util.StructToJSONReader(signedPack))
+	resp, err = http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	body, _ = io.ReadAll(resp.Body)
+	s.Assert().Equal(util.StructToJSON(result), strings.TrimSpace(string(body)))
+}
+
+func (s *APITestSuite) TestCreateShippingInstruction() {
+	buId := "did:openebl:carrier"
+	endPoint := fmt.Sprintf("http://%s/v2/shipping-instructions", s.localAddress)
+
+	si := bill_of_lading.ShippingInstructionRequest{
+		CarrierBookingReference:   "booking1",
+		TransportDocumentTypeCode: bill_of_lading.BOL_TransportDocumentTypeCode,
+		IsElectronic:              true,
+	}
+	expectedRequest := trade_document.CreateShippingInstructionRequest{
+		ApplicationID:       s.appId,
+		BusinessUnit:        buId,
+		ShippingInstruction: si,
+	}
+	refStatus := bill_of_lading.ShippingInstructionRefStatus{
+		ShippingInstructionReference: "si1",
+		DocumentStatus:               bill_of_lading.RECE_EblDocumentStatus,
+	}
+
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.dcsaCtrl.EXPECT().CreateShippingInstruction(gomock.Any(), gomock.Any(), expectedRequest).Return(refStatus, nil),
+	)
+
+	httpRequest, _ := http.NewRequestWithContext(s.ctx, http.MethodPost, endPoint, util.StructToJSONReader(si))
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err := http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusAccepted, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	s.Assert().Equal(util.StructToJSON(refStatus), strings.TrimSpace(string(body)))
+
+	// Test with invalid shipping instruction.
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.dcsaCtrl.EXPECT().CreateShippingInstruction(gomock.Any(), gomock.Any(), expectedRequest).Return(
+			bill_of_lading.ShippingInstructionRefStatus{},
+			dcsa_validator.ValidationErrors{{Pointer: "/consignmentItems", Code: "validation_required", Message: "cannot be blank"}},
+		),
+	)
+
+	httpRequest, _ = http.NewRequestWithContext(s.ctx, http.MethodPost, endPoint, util.StructToJSONReader(si))
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err = http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	var errResp bill_of_lading.ErrorResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Assert().Equal(http.MethodPost, errResp.HttpMethod)
+	s.Assert().Equal("/v2/shipping-instructions", errResp.RequestUri)
+	s.Assert().EqualValues(http.StatusBadRequest, errResp.StatusCode)
+	s.Assert().Equal(http.StatusText(http.StatusBadRequest), errResp.StatusCodeText)
+	s.Assert().Equal(
+		[]bill_of_lading.DetailedError{{Field: "/consignmentItems", Reason: "validation_required", Message: "cannot be blank"}},
+		errResp.Errors,
+	)
+}
+
+func (s *APITestSuite) TestListDCSAEvents() {
+	buId := "did:openebl:carrier"
+	endPoint := fmt.Sprintf("http://%s/v2/events?limit=1&documentTypeCode=SHI", s.localAddress)
+
+	expectedRequest := trade_document.ListDCSAEventsRequest{
+		Limit:            1,
+		ApplicationID:    s.appId,
+		BusinessUnit:     buId,
+		DocumentTypeCode: bill_of_lading.SHI_EblDocumentTypeCode,
+	}
+	event := bill_of_lading.ShipmentEvent{
+		Metadata: bill_of_lading.Metadata{EventID: "event1", EventType: bill_of_lading.SHIPMENT_EventType},
+		Payload: &bill_of_lading.ShipmentEventPayload{
+			BaseShipmentEvent: bill_of_lading.BaseShipmentEvent{
+				ShipmentEventTypeCode: bill_of_lading.RECE_EblShipmentEventTypeCode,
+				DocumentTypeCode:      bill_of_lading.SHI_EblDocumentTypeCode,
+				DocumentReference:     "si1",
+			},
+		},
+	}
+
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.dcsaCtrl.EXPECT().ListEvents(gomock.Any(), expectedRequest).Return(
+			trade_document.ListShipmentEventsResult{Total: 2, Records: []bill_of_lading.ShipmentEvent{event}},
+			nil,
+		),
+	)
+
+	httpRequest, _ := http.NewRequestWithContext(s.ctx, http.MethodGet, endPoint, nil)
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err := http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	s.Assert().Equal(util.StructToJSON([]bill_of_lading.ShipmentEvent{event}), strings.TrimSpace(string(body)))
+	cursor := resp.Header.Get(api.NextPageCursorHeader)
+	s.Require().NotEmpty(cursor)
+
+	// The next page is the last one.
+	expectedRequest.Offset = 1
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.dcsaCtrl.EXPECT().ListEvents(gomock.Any(), expectedRequest).Return(
+			trade_document.ListShipmentEventsResult{Total: 2, Records: []bill_of_lading.ShipmentEvent{event}},
+			nil,
+		),
+	)
+
+	httpRequest, _ = http.NewRequestWithContext(s.ctx, http.MethodGet, endPoint+"&cursor="+cursor, nil)
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err = http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Assert().Empty(resp.Header.Get(api.NextPageCursorHeader))
+
+	// Test with invalid cursor.
+	s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil)
+
+	httpRequest, _ = http.NewRequestWithContext(s.ctx, http.MethodGet, endPoint+"&cursor=!", nil)
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err = http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *APITestSuite) TestGetTransportDocument() {
+	buId := "did:openebl:shipper"
+	endPoint := fmt.Sprintf("http://%s/v2/transport-documents/bl-number", s.localAddress)
+
+	expectedRequest := trade_document.GetDCSADocumentRequest{
+		ApplicationID: s.appId,
+		BusinessUnit:  buId,
+		Reference:     "bl-number",
+	}
+
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.dcsaCtrl.EXPECT().GetTransportDocument(gomock.Any(), expectedRequest).Return(
+			bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+			nil,
+		),
+	)
+
+	httpRequest, _ := http.NewRequestWithContext(s.ctx, http.MethodGet, endPoint, nil)
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err := http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	s.Assert().Equal(util.StructToJSON(bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"}), strings.TrimSpace(string(body)))
+
+	// Test with unknown transport document.
+	gomock.InOrder(
+		s.apiKeyMgr.EXPECT().Authenticate(gomock.Any(), s.apiKeyString).Return(s.apiKey, nil),
+		s.dcsaCtrl.EXPECT().GetTransportDocument(gomock.Any(), expectedRequest).Return(bill_of_lading.TransportDocument{}, model.ErrBillOfLadingNotFound),
+	)
+
+	httpRequest, _ = http.NewRequestWithContext(s.ctx, http.MethodGet, endPoint, nil)
+	httpRequest.Header.Set("Authorization", "Bearer "+string(s.apiKeyString))
+	httpRequest.Header.Set(api.BusinessUnitHeader, buId)
+	resp, err = http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openebl/openebl/pkg/bu_server/middleware"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/dcsa_validator"
+	"github.com/sirupsen/logrus"
+)
+
+// NextPageCursorHeader carries the cursor of the next page of /v2 list endpoints. It is absent on the last page.
+const NextPageCursorHeader = "Next-Page-Cursor"
+
+const defaultDCSAPageLimit = 100
+
+func (a *API) createShippingInstruction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	req := trade_document.CreateShippingInstructionRequest{
+		ApplicationID: appID,
+		BusinessUnit:  r.Header.Get(BusinessUnitHeader),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req.ShippingInstruction); err != nil {
+		writeDCSAError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := a.dcsaCtrl.CreateShippingInstruction(ctx, time.Now().Unix(), req)
+	if err != nil {
+		writeDCSATradeDocumentError(w, r, err)
+		return
+	}
+	writeDCSAResponse(w, http.StatusAccepted, result)
+}
+
+func (a *API) updateShippingInstruction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	req := trade_document.UpdateShippingInstructionRequest{
+		ApplicationID: appID,
+		BusinessUnit:  r.Header.Get(BusinessUnitHeader),
+		Reference:     mux.Vars(r)["shippingInstructionReference"],
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req.ShippingInstruction); err != nil {
+		writeDCSAError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := a.dcsaCtrl.UpdateShippingInstruction(ctx, time.Now().Unix(), req)
+	if err != nil {
+		writeDCSATradeDocumentError(w, r, err)
+		return
+	}
+	writeDCSAResponse(w, http.StatusOK, result)
+}
+
+func (a *API) listShippingInstructions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	offset, limit, err := parseDCSAPage(r)
+	if err != nil {
+		writeDCSAError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	req := trade_document.ListShippingInstructionSummariesRequest{
+		Offset:                  offset,
+		Limit:                   limit,
+		ApplicationID:           appID,
+		BusinessUnit:            r.Header.Get(BusinessUnitHeader),
+		CarrierBookingReference: r.URL.Query().Get("carrierBookingReference"),
+		DocumentStatus:          bill_of_lading.EblDocumentStatus(r.URL.Query().Get("documentStatus")),
+	}
+
+	result, err := a.dcsaCtrl.ListShippingInstructions(ctx, req)
+	if err != nil {
+		writeDCSATradeDocumentError(w, r, err)
+		return
+	}
+	setNextPageCursor(w, offset, len(result.Records), result.Total)
+	writeDCSAResponse(w, http.StatusOK, result.Records)
+}
+
+func (a *API) getShippingInstruction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	req := trade_document.GetDCSADocumentRequest{
+		ApplicationID: appID,
+		BusinessUnit:  r.Header.Get(BusinessUnitHeader),
+		Reference:     mux.Vars(r)["shippingInstructionReference"],
+	}
+	result, err := a.dcsaCtrl.GetShippingInstruction(ctx, req)
+	if err != nil {
+		writeDCSATradeDocumentError(w, r, err)
+		return
+	}
+	writeDCSAResponse(w, http.StatusOK, result)
+}
+
+func (a *API) listTransportDocuments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	offset, limit, err := parseDCSAPage(r)
+	if err != nil {
+		writeDCSAError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	req := trade_document.ListTransportDocumentSummariesRequest{
+		Offset:         offset,
+		Limit:          limit,
+		ApplicationID:  appID,
+		BusinessUnit:   r.Header.Get(BusinessUnitHeader),
+		DocumentStatus: bill_of_lading.EblDocumentStatus(r.URL.Query().Get("documentStatus")),
+	}
+
+	result, err := a.dcsaCtrl.ListTransportDocuments(ctx, req)
+	if err != nil {
+		writeDCSATradeDocumentError(w, r, err)
+		return
+	}
+	setNextPageCursor(w, offset, len(result.Records), result.Total)
+	writeDCSAResponse(w, http.StatusOK, result.Records)
+}
+
+func (a *API) getTransportDocument(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	req := trade_document.GetDCSADocumentRequest{
+		ApplicationID: appID,
+		BusinessUnit:  r.Header.Get(BusinessUnitHeader),
+		Reference:     mux.Vars(r)["transportDocumentReference"],
+	}
+	result, err := a.dcsaCtrl.GetTransportDocument(ctx, req)
+	if err != nil {
+		writeDCSATradeDocumentError(w, r, err)
+		return
+	}
+	writeDCSAResponse(w, http.StatusOK, result)
+}
+
+func (a *API) listEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	offset, limit, err := parseDCSAPage(r)
+	if err != nil {
+		writeDCSAError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	req := trade_document.ListDCSAEventsRequest{
+		Offset:                offset,
+		Limit:                 limit,
+		ApplicationID:         appID,
+		BusinessUnit:          r.Header.Get(BusinessUnitHeader),
+		DocumentTypeCode:      bill_of_lading.EblDocumentTypeCode(r.URL.Query().Get("documentTypeCode")),
+		DocumentReference:     r.URL.Query().Get("documentReference"),
+		ShipmentEventTypeCode: bill_of_lading.EblShipmentEventTypeCode(r.URL.Query().Get("shipmentEventTypeCode")),
+	}
+
+	result, err := a.dcsaCtrl.ListEvents(ctx, req)
+	if err != nil {
+		writeDCSATradeDocumentError(w, r, err)
+		return
+	}
+	setNextPageCursor(w, offset, len(result.Records), result.Total)
+	writeDCSAResponse(w, http.StatusOK, result.Records)
+}
+
+// parseDCSAPage returns the offset and the limit of the page from the cursor and limit query parameters.
+// The cursor is opaque to clients. It encodes the offset of the page.
+func parseDCSAPage(r *http.Request) (int, int, error) {
+	offset, limit := 0, defaultDCSAPageLimit
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return 0, 0, errors.New("cursor is invalid")
+		}
+		n, err := strconv.ParseInt(string(raw), 10, 32)
+		if err != nil || n < 0 {
+			return 0, 0, errors.New("cursor is invalid")
+		}
+		offset = int(n)
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		n, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || n < 1 {
+			return 0, 0, errors.New("limit is invalid")
+		}
+		limit = int(n)
+	}
+	return offset, limit, nil
+}
+
+func setNextPageCursor(w http.ResponseWriter, offset, count, total int) {
+	if next := offset + count; count > 0 && next < total {
+		w.Header().Set(NextPageCursorHeader, base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(next))))
+	}
+}
+
+func writeDCSAResponse(w http.ResponseWriter, statusCode int, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logrus.Warnf("failed to encode/write DCSA response: %v", err)
+	}
+}
+
+// writeDCSATradeDocumentError writes the error with the same status codes as writeTradeDocumentError.
+func writeDCSATradeDocumentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidParameter):
+		writeDCSAError(w, r, http.StatusBadRequest, err)
+	case errors.Is(err, model.ErrBusinessUnitNotFound), errors.Is(err, model.ErrBillOfLadingNotFound), errors.Is(err, model.ErrShippingInstructionNotFound):
+		writeDCSAError(w, r, http.StatusNotFound, err)
+	case errors.Is(err, model.ErrBusinessUnitInactive):
+		writeDCSAError(w, r, http.StatusForbidden, err)
+	case errors.Is(err, model.ErrTradeDocumentError):
+		writeDCSAError(w, r, http.StatusConflict, err)
+	default:
+		writeDCSAError(w, r, http.StatusInternalServerError, err)
+	}
+}
+
+// writeDCSAError writes the error in the shape of DCSA error response.
+func writeDCSAError(w http.ResponseWriter, r *http.Request, statusCode int, err error) {
+	now := model.NewDateTime(time.Now().UTC())
+	resp := bill_of_lading.ErrorResponse{
+		HttpMethod:     r.Method,
+		RequestUri:     r.URL.RequestURI(),
+		StatusCode:     int32(statusCode),
+		StatusCodeText: http.StatusText(statusCode),
+		ErrorMessage:   err.Error(),
+		ErrorDateTime:  &now,
+		Errors:         []bill_of_lading.DetailedError{},
+	}
+	var validationErrs dcsa_validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		resp.Errors = validationErrs.DetailedErrors()
+	}
+	writeDCSAResponse(w, statusCode, resp)
+}
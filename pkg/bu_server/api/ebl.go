@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openebl/openebl/pkg/bu_server/middleware"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/sirupsen/logrus"
+)
+
+// BusinessUnitHeader carries DID of the business unit the application acts as on /ebl and /v2 endpoints.
+const BusinessUnitHeader = "X-Business-Unit"
+
+// maxSignedPackSize limits the size of signed packs uploaded to be verified.
+const maxSignedPackSize = 32 << 20
+
+func (a *API) createBillOfLading(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	var req trade_document.CreateBillOfLadingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.ApplicationID = appID
+	req.BusinessUnit = r.Header.Get(BusinessUnitHeader)
+
+	result, err := a.eblCtrl.Create(ctx, time.Now().Unix(), req)
+	if err != nil {
+		writeTradeDocumentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logrus.Warnf("createBillOfLading failed to encode/write response: %v", err)
+	}
+}
+
+func (a *API) listBillOfLading(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	req := trade_document.ListBillOfLadingRequest{Limit: 10}
+	offsetStr := r.URL.Query().Get("offset")
+	limitStr := r.URL.Query().Get("limit")
+	if offsetStr != "" {
+		offset, err := strconv.ParseInt(offsetStr, 10, 32)
+		if err != nil || offset < 0 {
+			http.Error(w, "offset is invalid", http.StatusBadRequest)
+			return
+		}
+		req.Offset = int(offset)
+	}
+	if limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || limit < 1 {
+			http.Error(w, "limit is invalid", http.StatusBadRequest)
+			return
+		}
+		req.Limit = int(limit)
+	}
+	req.ApplicationID = appID
+	req.BusinessUnit = r.Header.Get(BusinessUnitHeader)
+	req.Box = trade_document.BillOfLadingBox(r.URL.Query().Get("box"))
+	req.DocReference = r.URL.Query().Get("doc_reference")
+	req.Status = trade_document.BillOfLadingStatus(r.URL.Query().Get("status"))
+
+	result, err := a.eblCtrl.List(ctx, req)
+	if err != nil {
+		writeTradeDocumentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logrus.Warnf("listBillOfLading failed to encode/write response: %v", err)
+	}
+}
+
+func (a *API) getBillOfLading(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	req := trade_document.GetBillOfLadingRequest{
+		ApplicationID: appID,
+		BusinessUnit:  r.Header.Get(BusinessUnitHeader),
+		ID:            mux.Vars(r)["id"],
+	}
+	result, err := a.eblCtrl.Get(ctx, req)
+	if err != nil {
+		writeTradeDocumentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logrus.Warnf("getBillOfLading failed to encode/write response: %v", err)
+	}
+}
+
+func (a *API) getBillOfLadingHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	req := trade_document.GetBillOfLadingRequest{
+		ApplicationID: appID,
+		BusinessUnit:  r.Header.Get(BusinessUnitHeader),
+		ID:            mux.Vars(r)["id"],
+	}
+	result, err := a.eblCtrl.GetHistory(ctx, req)
+	if err != nil {
+		writeTradeDocumentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logrus.Warnf("getBillOfLadingHistory failed to encode/write response: %v", err)
+	}
+}
+
+// getBillOfLadingFile writes the content of the file attached to the bill of lading pack.
+func (a *API) getBillOfLadingFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+	vars := mux.Vars(r)
+	req := trade_document.GetBillOfLadingFileRequest{
+		ApplicationID: appID,
+		BusinessUnit:  r.Header.Get(BusinessUnitHeader),
+		ID:            vars["id"],
+		Hash:          vars["hash"],
+	}
+	file, err := a.eblCtrl.GetFile(ctx, req)
+	if err != nil {
+		writeTradeDocumentError(w, err)
+		return
+	}
+
+	mediaType := file.MediaType
+	if mediaType == "" {
+		mediaType = http.DetectContentType(file.Content)
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(file.Content)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(file.Content); err != nil {
+		logrus.Warnf("getBillOfLadingFile failed to write response: %v", err)
+	}
+}
+
+// billOfLadingAction returns the handler of an action on the bill of lading pack in the path.
+func (a *API) billOfLadingAction(
+	action func(ctx context.Context, ts int64, req trade_document.BillOfLadingActionRequest) (trade_document.BillOfLadingRecord, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		appID, _ := ctx.Value(middleware.APPLICATION_ID).(string)
+
+		var req trade_document.BillOfLadingActionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.ApplicationID = appID
+		req.BusinessUnit = r.Header.Get(BusinessUnitHeader)
+		req.ID = mux.Vars(r)["id"]
+
+		result, err := action(ctx, time.Now().Unix(), req)
+		if err != nil {
+			writeTradeDocumentError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			logrus.Warnf("billOfLadingAction failed to encode/write response: %v", err)
+		}
+	}
+}
+
+func (a *API) verifyBillOfLadingHash(w http.ResponseWriter, r *http.Request) {
+	req := trade_document.VerifyBillOfLadingHashRequest{
+		ID:   mux.Vars(r)["id"],
+		Hash: r.URL.Query().Get("hash"),
+	}
+	result, err := a.verifier.VerifyHash(r.Context(), time.Now().Unix(), req)
+	if err != nil {
+		writeTradeDocumentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logrus.Warnf("verifyBillOfLadingHash failed to encode/write response: %v", err)
+	}
+}
+
+func (a *API) verifySignedBillOfLading(w http.ResponseWriter, r *http.Request) {
+	var signedPack envelope.JWS
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSignedPackSize)).Decode(&signedPack); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := a.verifier.VerifySignedPack(r.Context(), time.Now().Unix(), signedPack)
+	if err != nil {
+		writeTradeDocumentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logrus.Warnf("verifySignedBillOfLading failed to encode/write response: %v", err)
+	}
+}
+
+func writeTradeDocumentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidParameter):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrBusinessUnitNotFound), errors.Is(err, model.ErrBillOfLadingNotFound), errors.Is(err, model.ErrFileNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, model.ErrBusinessUnitInactive), errors.Is(err, model.ErrBillOfLadingNotOwner), errors.Is(err, model.ErrBillOfLadingNotIssuer):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, model.ErrTradeDocumentError), errors.Is(err, model.ErrAuthenticationNotFound):
+		// The bill of lading is changed by others or the action doesn't fit its current state.
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("Internal server error: %s", err.Error()), http.StatusInternalServerError)
+	}
+}
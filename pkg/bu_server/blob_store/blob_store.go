@@ -0,0 +1,161 @@
+// Package blob_store keeps the content of files attached to trade documents, addressed by the SHA256 hash of the
+// content. The content is kept in an object store, either a local directory or an S3 compatible service.
+package blob_store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/object_store"
+)
+
+const DefaultMaxSize = 20 << 20 // 20 MiB
+
+// DefaultMediaTypes are the media types of files accepted by default.
+var DefaultMediaTypes = []string{"application/pdf", "image/png", "image/jpeg"}
+
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type Config struct {
+	Store      object_store.Config `yaml:"store"`
+	MaxSize    int64               `yaml:"max_size"`    // Max size of a file in bytes. DefaultMaxSize if not set.
+	MediaTypes []string            `yaml:"media_types"` // Media types of files accepted. DefaultMediaTypes if not set.
+}
+
+// BlobStore keeps the content of files by its hash.
+type BlobStore interface {
+	// Put stores the content of the file and returns the file referencing the content by its hash, size and media type.
+	// The media type is detected from the content. A file without content has to reference content already stored.
+	Put(ctx context.Context, file model.File) (model.File, error)
+
+	// Get returns the content of the hash. It returns model.ErrFileNotFound if the content isn't stored.
+	Get(ctx context.Context, hash string) ([]byte, error)
+}
+
+type _BlobStore struct {
+	store      object_store.ObjectStore
+	maxSize    int64
+	mediaTypes []string
+}
+
+type BlobStoreOption func(s *_BlobStore)
+
+func WithMaxSize(maxSize int64) BlobStoreOption {
+	return func(s *_BlobStore) {
+		if maxSize > 0 {
+			s.maxSize = maxSize
+		}
+	}
+}
+
+func WithMediaTypes(mediaTypes ...string) BlobStoreOption {
+	return func(s *_BlobStore) {
+		if len(mediaTypes) > 0 {
+			s.mediaTypes = mediaTypes
+		}
+	}
+}
+
+func NewBlobStore(store object_store.ObjectStore, options ...BlobStoreOption) *_BlobStore {
+	s := &_BlobStore{
+		store:      store,
+		maxSize:    DefaultMaxSize,
+		mediaTypes: DefaultMediaTypes,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+func NewBlobStoreWithConfig(cfg Config) (*_BlobStore, error) {
+	store, err := object_store.NewObjectStore(cfg.Store)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlobStore(store, WithMaxSize(cfg.MaxSize), WithMediaTypes(cfg.MediaTypes...)), nil
+}
+
+func (s *_BlobStore) Put(ctx context.Context, file model.File) (model.File, error) {
+	if len(file.Content) == 0 {
+		if !hashPattern.MatchString(file.Hash) {
+			return model.File{}, fmt.Errorf("file has neither content nor valid hash%w", model.ErrInvalidParameter)
+		}
+		content, err := s.Get(ctx, file.Hash)
+		if err != nil {
+			return model.File{}, err
+		}
+		file.Size = int64(len(content))
+		file.MediaType = detectMediaType(content)
+		return file, nil
+	}
+
+	if int64(len(file.Content)) > s.maxSize {
+		return model.File{}, fmt.Errorf("file %q is larger than %d bytes%w", file.Name, s.maxSize, model.ErrInvalidParameter)
+	}
+	mediaType := detectMediaType(file.Content)
+	if !slices.Contains(s.mediaTypes, mediaType) {
+		return model.File{}, fmt.Errorf("media type %q of file %q is not allowed%w", mediaType, file.Name, model.ErrInvalidParameter)
+	}
+
+	hash := sha256.Sum256(file.Content)
+	hexHash := hex.EncodeToString(hash[:])
+	if file.Hash != "" && file.Hash != hexHash {
+		return model.File{}, fmt.Errorf("hash of file %q doesn't match its content%w", file.Name, model.ErrInvalidParameter)
+	}
+	// Contents are immutable, so storing the same content again is harmless.
+	if err := s.store.Put(ctx, objectKey(hexHash), file.Content); err != nil {
+		return model.File{}, err
+	}
+
+	return model.File{
+		Name:        file.Name,
+		Hash:        hexHash,
+		Size:        int64(len(file.Content)),
+		MediaType:   mediaType,
+		CreatedDate: file.CreatedDate,
+	}, nil
+}
+
+func (s *_BlobStore) Get(ctx context.Context, hash string) ([]byte, error) {
+	if !hashPattern.MatchString(hash) {
+		return nil, fmt.Errorf("invalid file hash %q%w", hash, model.ErrInvalidParameter)
+	}
+
+	content, err := s.store.Get(ctx, objectKey(hash))
+	if errors.Is(err, object_store.ErrObjectNotFound) {
+		return nil, model.ErrFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The object store is not trusted to keep the content intact.
+	actual := sha256.Sum256(content)
+	if hex.EncodeToString(actual[:]) != hash {
+		return nil, fmt.Errorf("content of file %q is corrupted", hash)
+	}
+	return content, nil
+}
+
+// objectKey spreads contents over directories by the first byte of their hashes.
+func objectKey(hash string) string {
+	return fmt.Sprintf("sha256/%s/%s", hash[:2], hash)
+}
+
+// detectMediaType returns the media type of the content without parameters, like "text/plain".
+func detectMediaType(content []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(content))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
@@ -0,0 +1,104 @@
+package blob_store_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/bu_server/blob_store"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/object_store"
+	"github.com/stretchr/testify/suite"
+)
+
+type BlobStoreTestSuite struct {
+	suite.Suite
+	ctx       context.Context
+	directory string
+	blobStore blob_store.BlobStore
+}
+
+func TestBlobStore(t *testing.T) {
+	suite.Run(t, new(BlobStoreTestSuite))
+}
+
+func (s *BlobStoreTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.directory = s.T().TempDir()
+	blobStore, err := blob_store.NewBlobStoreWithConfig(blob_store.Config{
+		Store: object_store.Config{
+			Type:  object_store.ObjectStoreTypeLocal,
+			Local: object_store.LocalConfig{Directory: s.directory},
+		},
+		MaxSize: 1024,
+	})
+	s.Require().NoError(err)
+	s.blobStore = blobStore
+}
+
+func (s *BlobStoreTestSuite) TestPutAndGet() {
+	content := []byte("%PDF-1.3\n%fake document\n")
+	hash := sha256.Sum256(content)
+	hexHash := hex.EncodeToString(hash[:])
+	createdDate := model.NewDateTime(time.Unix(1700000000, 0))
+
+	file, err := s.blobStore.Put(s.ctx, model.File{Name: "bl.pdf", Content: content, CreatedDate: createdDate})
+	s.Require().NoError(err)
+	s.Assert().Equal(model.File{
+		Name:        "bl.pdf",
+		Hash:        hexHash,
+		Size:        int64(len(content)),
+		MediaType:   "application/pdf",
+		CreatedDate: createdDate,
+	}, file)
+	s.Assert().FileExists(filepath.Join(s.directory, "sha256", hexHash[:2], hexHash))
+
+	stored, err := s.blobStore.Get(s.ctx, hexHash)
+	s.Require().NoError(err)
+	s.Assert().Equal(content, stored)
+
+	// A file referencing stored content by its hash.
+	ref, err := s.blobStore.Put(s.ctx, model.File{Name: "copy.pdf", Hash: hexHash})
+	s.Require().NoError(err)
+	s.Assert().Equal(model.File{Name: "copy.pdf", Hash: hexHash, Size: int64(len(content)), MediaType: "application/pdf"}, ref)
+}
+
+func (s *BlobStoreTestSuite) TestPutInvalidFile() {
+	_, err := s.blobStore.Put(s.ctx, model.File{Name: "empty.pdf"})
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+
+	_, err = s.blobStore.Put(s.ctx, model.File{Name: "note.txt", Content: []byte("plain text")})
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+
+	large := append([]byte("%PDF-1.3\n"), make([]byte, 1024)...)
+	_, err = s.blobStore.Put(s.ctx, model.File{Name: "large.pdf", Content: large})
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+
+	_, err = s.blobStore.Put(s.ctx, model.File{Name: "bl.pdf", Content: []byte("%PDF-1.3\n"), Hash: "00"})
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+
+	missing := sha256.Sum256([]byte("missing"))
+	_, err = s.blobStore.Put(s.ctx, model.File{Name: "missing.pdf", Hash: hex.EncodeToString(missing[:])})
+	s.Assert().ErrorIs(err, model.ErrFileNotFound)
+}
+
+func (s *BlobStoreTestSuite) TestGet() {
+	_, err := s.blobStore.Get(s.ctx, "../../etc/passwd")
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+
+	missing := sha256.Sum256([]byte("missing"))
+	_, err = s.blobStore.Get(s.ctx, hex.EncodeToString(missing[:]))
+	s.Assert().ErrorIs(err, model.ErrFileNotFound)
+
+	// Content changed in the object store.
+	file, err := s.blobStore.Put(s.ctx, model.File{Name: "bl.pdf", Content: []byte("%PDF-1.3\n")})
+	s.Require().NoError(err)
+	path := filepath.Join(s.directory, "sha256", file.Hash[:2], file.Hash)
+	s.Require().NoError(os.WriteFile(path, []byte("%PDF-1.3\ntampered\n"), 0o644))
+	_, err = s.blobStore.Get(s.ctx, file.Hash)
+	s.Assert().Error(err)
+}
@@ -0,0 +1,409 @@
+package bu_server
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alecthomas/kong"
+	formatter "github.com/bluexlab/logrus-formatter"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/pop/logging"
+	"github.com/openebl/openebl/pkg/bu_server/api"
+	"github.com/openebl/openebl/pkg/bu_server/auth"
+	"github.com/openebl/openebl/pkg/bu_server/blob_store"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/cert_authority"
+	"github.com/openebl/openebl/pkg/bu_server/manager"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/storage/postgres"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/dcsa_validator"
+	"github.com/openebl/openebl/pkg/config"
+	"github.com/openebl/openebl/pkg/pkix"
+	"github.com/openebl/openebl/pkg/relay"
+	"github.com/openebl/openebl/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+const cliRequestUser = "bu_server-cli"
+
+type BUServerApp struct{}
+
+type BUServerCli struct {
+	Server  struct{} `cmd:"" help:"Run the application API and the manager API."`
+	Migrate struct {
+		Migrations string `type:"path" default:"migrations" help:"Path to migration folder."`
+	} `cmd:"" help:"Migrate database."`
+	CreateAdmin struct {
+		Username string   `required:"" help:"User ID of the admin."`
+		Password string   `required:"" env:"BU_SERVER_ADMIN_PASSWORD" help:"Password of the admin. It can be given by BU_SERVER_ADMIN_PASSWORD."`
+		Name     string   `help:"Name of the admin."`
+		Email    []string `help:"Email of the admin. Can be repeated."`
+	} `cmd:"" help:"Create a user of the manager API."`
+	Config string `type:"path" default:"config.yaml" help:"Path to config file."`
+}
+
+type BUServerConfig struct {
+	Database        util.PostgresDatabaseConfig `yaml:"database"`
+	Server          BUServerAPIConfig           `yaml:"server"`
+	Manager         BUServerAPIConfig           `yaml:"manager"`
+	Relay           *BUServerRelayConfig        `yaml:"relay"`            // Exchange bill of lading packs through relay servers. nil disables the relay.
+	ShutdownTimeout time.Duration               `yaml:"shutdown_timeout"` // How long to wait for ongoing requests when the server is stopped.
+	ReferenceData   string                      `yaml:"reference_data"`   // Directory of code lists overriding the bundled ones of DCSA validation.
+	Attachments     *blob_store.Config          `yaml:"attachments"`      // Keep contents of files out of bill of lading packs. nil keeps them in the packs.
+}
+
+type BUServerRelayConfig struct {
+	Servers           []string      `yaml:"servers"`            // URLs of relay servers. Packs are published to the first one and received from all of them.
+	RootCertificates  []string      `yaml:"root_certificates"`  // PEM files of root certificates trusted to verify signers of bill of lading packs.
+	PartyCertificates []string      `yaml:"party_certificates"` // PEM files of certificate chains of business units hosted by other BU servers, leaf first.
+	RetryInterval     time.Duration `yaml:"retry_interval"`     // How long to wait before reconnecting to a relay server.
+}
+
+type BUServerAPIConfig struct {
+	LocalAddress string `yaml:"local_address"`
+}
+
+func (a *BUServerApp) Run() error {
+	formatter.InitLogger()
+
+	var cli BUServerCli
+	kctx := kong.Parse(&cli)
+	switch kctx.Command() {
+	case "server":
+		return a.runServer(cli)
+	case "migrate":
+		return a.runMigrate(cli)
+	case "create-admin":
+		return a.runCreateAdmin(cli)
+	}
+
+	return nil
+}
+
+func (a *BUServerApp) loadConfig(cli BUServerCli) BUServerConfig {
+	cfg := BUServerConfig{}
+	if err := config.FromFile(cli.Config, &cfg); err != nil {
+		logrus.Errorf("failed to load config: %v", err)
+		os.Exit(1)
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 30 * time.Second
+	}
+	return cfg
+}
+
+func (a *BUServerApp) runServer(cli BUServerCli) error {
+	cfg := a.loadConfig(cli)
+
+	if cfg.ReferenceData != "" {
+		referenceData, err := dcsa_validator.LoadReferenceData(cfg.ReferenceData)
+		if err != nil {
+			logrus.Errorf("failed to load reference data: %v", err)
+			os.Exit(1)
+		}
+		dcsa_validator.SetReferenceData(referenceData)
+	}
+	logrus.Infof("validating DCSA documents with reference data %q.", dcsa_validator.DefaultReferenceData().Version)
+	if partialLists := dcsa_validator.DefaultReferenceData().PartialLists(); len(partialLists) > 0 {
+		logrus.Warnf("bundled code lists %q only cover major ports and carriers, and codes missing from them are only checked for their format. Provide complete lists in the reference_data directory.", partialLists)
+	}
+
+	storage, err := postgres.NewStorageWithConfig(cfg.Database)
+	if err != nil {
+		logrus.Errorf("failed to create storage: %v", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	userMgr := auth.NewUserManager(storage)
+	appMgr := auth.NewApplicationManager(storage)
+	apiKeyMgr := auth.NewAPIKeyAuthenticator(storage)
+	ca := cert_authority.NewCertAuthority(storage)
+	buMgr := business_unit.NewBusinessUnitManager(storage)
+
+	var blobStore blob_store.BlobStore
+	if cfg.Attachments != nil {
+		blobStore, err = blob_store.NewBlobStoreWithConfig(*cfg.Attachments)
+		if err != nil {
+			logrus.Errorf("failed to create blob store: %v", err)
+			os.Exit(1)
+		}
+	}
+	var rootCerts []*x509.Certificate
+	var resolverOptions []trade_document.BusinessUnitCertificateResolverOption
+	if cfg.Relay != nil {
+		rootCerts, err = loadRootCertificates(cfg.Relay.RootCertificates)
+		if err != nil {
+			logrus.Errorf("failed to load root certificates: %v", err)
+			os.Exit(1)
+		}
+		directory, err := loadPartyCertificates(cfg.Relay.PartyCertificates, rootCerts)
+		if err != nil {
+			logrus.Errorf("failed to load party certificates: %v", err)
+			os.Exit(1)
+		}
+		resolverOptions = append(resolverOptions, trade_document.WithRemoteCertificateResolver(directory))
+	}
+
+	eblCtrl := trade_document.NewBillOfLadingController(
+		storage,
+		storage,
+		trade_document.NewBillOfLadingService(),
+		trade_document.NewBillOfLadingPackSigner(storage),
+		trade_document.NewBillOfLadingPublisher(
+			trade_document.NewBusinessUnitCertificateResolver(storage, resolverOptions...),
+			storage,
+			trade_document.WithPublisherBlobStore(blobStore),
+		),
+		trade_document.WithBillOfLadingBlobStore(blobStore),
+	)
+
+	dcsaCtrl := trade_document.NewDCSAController(storage, storage)
+
+	verifier := trade_document.NewBillOfLadingVerifier(storage, trade_document.WithVerifierRootCertificates(rootCerts))
+
+	apiServer, err := api.NewAPIWithController(apiKeyMgr, buMgr, eblCtrl, dcsaCtrl, verifier, cfg.Server.LocalAddress)
+	if err != nil {
+		logrus.Errorf("failed to create application API: %v", err)
+		os.Exit(1)
+	}
+
+	var inbox *trade_document.BillOfLadingInbox
+	var outbox *trade_document.OutboxDispatcher
+	var managerOptions []manager.ManagerAPIOption
+	if cfg.Relay == nil {
+		logrus.Warn("no relay is configured. Bill of lading packs are neither delivered to other BU servers nor received from them, and they pile up in the outbox until a relay is configured.")
+	} else {
+		if len(cfg.Relay.Servers) == 0 {
+			logrus.Errorf("no relay server is configured.")
+			os.Exit(1)
+		}
+		inbox = a.newInbox(*cfg.Relay, rootCerts, blobStore, storage, storage)
+		relayClient := relay.NewNostrClient(
+			relay.NostrClientWithServerURL(cfg.Relay.Servers[0]),
+			relay.NostrClientWithConnectionStatusCallback(func(context.Context, context.CancelCauseFunc, relay.RelayClient, string, bool) {}),
+		)
+		defer relayClient.Close()
+		outbox = trade_document.NewOutboxDispatcher(relayClient, storage)
+		managerOptions = append(managerOptions, manager.WithOutboxMonitor(outbox))
+	}
+
+	managerServer, err := manager.NewManagerAPIWithControllers(userMgr, appMgr, apiKeyMgr, ca, cfg.Manager.LocalAddress, managerOptions...)
+	if err != nil {
+		logrus.Errorf("failed to create manager API: %v", err)
+		os.Exit(1)
+	}
+
+	errChan := make(chan error, 4)
+	go func() {
+		logrus.Infof("application API is listening on %q.", cfg.Server.LocalAddress)
+		errChan <- apiServer.Run()
+	}()
+	go func() {
+		logrus.Infof("manager API is listening on %q.", cfg.Manager.LocalAddress)
+		errChan <- managerServer.Run()
+	}()
+	if inbox != nil {
+		go func() {
+			logrus.Infof("receiving bill of lading packs from %q.", cfg.Relay.Servers)
+			if err := inbox.Run(); err != nil {
+				errChan <- err
+			}
+		}()
+	}
+	if outbox != nil {
+		go func() {
+			logrus.Infof("publishing bill of lading packs to %q.", cfg.Relay.Servers[0])
+			if err := outbox.Run(); err != nil {
+				errChan <- err
+			}
+		}()
+	}
+
+	// Stop all components when any of them fails or the process is interrupted.
+	var runErr error
+	select {
+	case runErr = <-errChan:
+		logrus.Errorf("server stopped unexpectedly: %v", runErr)
+	case <-a.interrupted():
+		logrus.Info("Shutting down server......")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := apiServer.Shutdown(ctx); err != nil {
+		logrus.Warnf("failed to shut down application API gracefully: %v", err)
+		apiServer.Close()
+	}
+	if err := managerServer.Shutdown(ctx); err != nil {
+		logrus.Warnf("failed to shut down manager API gracefully: %v", err)
+		managerServer.Close()
+	}
+	if inbox != nil {
+		inbox.Close()
+	}
+	if outbox != nil {
+		outbox.Close()
+	}
+
+	return runErr
+}
+
+// loadRootCertificates loads certificates from PEM files.
+func loadRootCertificates(paths []string) ([]*x509.Certificate, error) {
+	var rootCerts []*x509.Certificate
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		certs, err := pkix.ParseCertificate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for i := range certs {
+			rootCerts = append(rootCerts, &certs[i])
+		}
+	}
+	return rootCerts, nil
+}
+
+// loadPartyCertificates loads certificate chains of business units hosted by other BU servers from PEM files.
+func loadPartyCertificates(paths []string, rootCerts []*x509.Certificate) (trade_document.CertificateResolver, error) {
+	chains := make([][]*x509.Certificate, 0, len(paths))
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		certs, err := pkix.ParseCertificate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		chain := make([]*x509.Certificate, 0, len(certs))
+		for i := range certs {
+			chain = append(chain, &certs[i])
+		}
+		chains = append(chains, chain)
+	}
+	directory, err := trade_document.NewDirectoryCertificateResolver(chains, rootCerts)
+	if err != nil {
+		return nil, err
+	}
+	return directory, nil
+}
+
+func (a *BUServerApp) newInbox(
+	cfg BUServerRelayConfig,
+	rootCerts []*x509.Certificate,
+	blobStore blob_store.BlobStore,
+	buStorage business_unit.BusinessUnitStorage,
+	storage trade_document.BillOfLadingInboxStorage,
+) *trade_document.BillOfLadingInbox {
+	options := []trade_document.BillOfLadingInboxOption{
+		trade_document.WithInboxRelayServers(cfg.Servers...),
+		trade_document.WithInboxRootCertificates(rootCerts),
+		trade_document.WithInboxBlobStore(blobStore),
+	}
+	if cfg.RetryInterval > 0 {
+		options = append(options, trade_document.WithInboxRetryInterval(cfg.RetryInterval))
+	}
+	return trade_document.NewBillOfLadingInbox(buStorage, storage, options...)
+}
+
+func (a *BUServerApp) runMigrate(cli BUServerCli) error {
+	pop.SetLogger(popLogger)
+	cfg := a.loadConfig(cli)
+
+	cd := pop.ConnectionDetails{
+		Dialect:  "postgres",
+		Database: cfg.Database.Database,
+		Host:     cfg.Database.Host,
+		Port:     fmt.Sprintf("%d", cfg.Database.Port),
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+	}
+	conn, err := pop.NewConnection(&cd)
+	if err != nil {
+		logrus.Errorf("failed to create connection: %v", err)
+		os.Exit(1)
+	}
+
+	if err := conn.Dialect.CreateDB(); err != nil {
+		logrus.Warnf("failed to create database: %v", err)
+	}
+
+	migrator, err := pop.NewFileMigrator(cli.Migrate.Migrations, conn)
+	if err != nil {
+		logrus.Errorf("failed to create migrator: %v", err)
+		os.Exit(1)
+	}
+	// Remove SchemaPath to prevent migrator try to dump schema.
+	migrator.SchemaPath = ""
+
+	if err := migrator.Up(); err != nil {
+		logrus.Errorf("failed to migrate: %v", err)
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+func (a *BUServerApp) runCreateAdmin(cli BUServerCli) error {
+	cfg := a.loadConfig(cli)
+
+	storage, err := postgres.NewStorageWithConfig(cfg.Database)
+	if err != nil {
+		logrus.Errorf("failed to create storage: %v", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	req := auth.CreateUserRequest{
+		RequestUser: cliRequestUser,
+		UserID:      cli.CreateAdmin.Username,
+		Password:    auth.RawPassword(cli.CreateAdmin.Password),
+		Name:        cli.CreateAdmin.Name,
+		Emails:      cli.CreateAdmin.Email,
+	}
+	user, err := auth.NewUserManager(storage).CreateUser(context.Background(), time.Now().Unix(), req)
+	if errors.Is(err, model.ErrUserAlreadyExists) {
+		logrus.Errorf("user %q already exists.", req.UserID)
+		os.Exit(1)
+	}
+	if err != nil {
+		logrus.Errorf("failed to create user: %v", err)
+		os.Exit(1)
+	}
+
+	logrus.Infof("user %q is created.", user.ID)
+	return nil
+}
+
+func (a *BUServerApp) interrupted() <-chan os.Signal {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	return quit
+}
+
+func popLogger(lvl logging.Level, s string, args ...interface{}) {
+	switch lvl {
+	case logging.Debug:
+		logrus.Debugf(s, args...)
+	case logging.Info:
+		logrus.Infof(s, args...)
+	case logging.Warn:
+		logrus.Warnf(s, args...)
+	case logging.Error:
+		logrus.Errorf(s, args...)
+	case logging.SQL:
+		// Do nothing because we don't want to log SQL queries.
+	}
+}
@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -30,9 +31,24 @@ type ManagerAPI struct {
 	appMgr     auth.ApplicationManager
 	apiKeyMgr  auth.APIKeyAuthenticator
 	ca         cert_authority.CertAuthority
+	outbox     OutboxMonitor
 	httpServer *http.Server
 }
 
+// OutboxMonitor tells how the outbox of relay events is doing.
+type OutboxMonitor interface {
+	Stats(ctx context.Context) (model.OutboxStats, error)
+}
+
+type ManagerAPIOption func(s *ManagerAPI)
+
+// WithOutboxMonitor serves the stats of the outbox at /outbox/stats.
+func WithOutboxMonitor(outbox OutboxMonitor) ManagerAPIOption {
+	return func(s *ManagerAPI) {
+		s.outbox = outbox
+	}
+}
+
 func NewManagerAPI(cfg ManagerAPIConfig) (*ManagerAPI, error) {
 	storage, err := postgres.NewStorageWithConfig(cfg.Database)
 	if err != nil {
@@ -53,6 +69,7 @@ func NewManagerAPIWithControllers(
 	apiKeyMgr auth.APIKeyAuthenticator,
 	ca cert_authority.CertAuthority,
 	localAddress string,
+	options ...ManagerAPIOption,
 ) (*ManagerAPI, error) {
 	apiServer := &ManagerAPI{}
 
@@ -60,6 +77,9 @@ func NewManagerAPIWithControllers(
 	apiServer.appMgr = appMgr
 	apiServer.apiKeyMgr = apiKeyMgr
 	apiServer.ca = ca
+	for _, option := range options {
+		option(apiServer)
+	}
 
 	userTokenMiddleware := middleware.NewUserTokenAuth(apiServer.userMgr)
 
@@ -88,6 +108,9 @@ func NewManagerAPIWithControllers(
 	mgrRouter.HandleFunc("/ca/certificate", apiServer.getCACertificateList).Methods(http.MethodGet)
 	mgrRouter.HandleFunc("/ca/certificate/{id}", apiServer.getCACertificate).Methods(http.MethodGet)
 	mgrRouter.HandleFunc("/ca/certificate/{id}", apiServer.revokeCACertificate).Methods(http.MethodDelete)
+	if apiServer.outbox != nil {
+		mgrRouter.HandleFunc("/outbox/stats", apiServer.getOutboxStats).Methods(http.MethodGet)
+	}
 
 	httpServer := &http.Server{
 		Addr:         localAddress,
@@ -112,6 +135,11 @@ func (s *ManagerAPI) Close() error {
 	return s.httpServer.Close()
 }
 
+// Shutdown stops accepting new requests and waits for ongoing requests until ctx is done.
+func (s *ManagerAPI) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
 func (s *ManagerAPI) login(w http.ResponseWriter, r *http.Request) {
 	extractBasicAuthCredentials := func(r *http.Request) (string, string, error) {
 		authHeader := r.Header.Get("Authorization")
@@ -766,3 +794,16 @@ func (s *ManagerAPI) revokeCACertificate(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(cert)
 }
+
+func (s *ManagerAPI) getOutboxStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.outbox.Stats(r.Context())
+	if err != nil {
+		logrus.Errorf("failed to get outbox stats: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
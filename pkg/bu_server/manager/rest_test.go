@@ -984,3 +984,38 @@ func (s *ManagerAPITestSuite) TestRevokeCACertificate() {
 	body, _ := io.ReadAll(resp.Body)
 	s.Assert().Equal(util.StructToJSON(cert), strings.TrimSpace(string(body)))
 }
+
+type fakeOutboxMonitor struct {
+	stats model.OutboxStats
+}
+
+func (m fakeOutboxMonitor) Stats(ctx context.Context) (model.OutboxStats, error) {
+	return m.stats, nil
+}
+
+func (s *ManagerAPITestSuite) TestGetOutboxStats() {
+	stats := model.OutboxStats{Pending: 3, Failing: 1, OldestPendingAt: 1700000000}
+	rest, err := manager.NewManagerAPIWithControllers(s.userMgr, s.appMgr, s.apiKeyMgr, s.ca, "localhost:9222", manager.WithOutboxMonitor(fakeOutboxMonitor{stats: stats}))
+	s.Require().NoError(err)
+	go func() { rest.Run() }()
+	time.Sleep(200 * time.Millisecond)
+	defer rest.Close()
+
+	token := "user token"
+	userToken := auth.UserToken{
+		Token:  token,
+		UserID: "user_id",
+	}
+	s.userMgr.EXPECT().TokenAuthorization(gomock.Any(), gomock.Any(), gomock.Eq(token)).Return(userToken, nil)
+
+	httpRequest, err := http.NewRequestWithContext(s.ctx, http.MethodGet, "http://localhost:9222/outbox/stats", nil)
+	s.Require().NoError(err)
+	httpRequest.Header.Set("Authorization", "Bearer "+token)
+	httpResponse, err := http.DefaultClient.Do(httpRequest)
+	s.Require().NoError(err)
+	defer httpResponse.Body.Close()
+
+	s.Assert().Equal(http.StatusOK, httpResponse.StatusCode)
+	body, _ := io.ReadAll(httpResponse.Body)
+	s.Assert().Equal(util.StructToJSON(stats), strings.TrimSpace(string(body)))
+}
@@ -1,6 +1,10 @@
 package model
 
-import "github.com/shopspring/decimal"
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
 
 type Decimal struct {
 	value decimal.Decimal
@@ -27,3 +31,70 @@ func (d *Decimal) UnmarshalJSON(b []byte) error {
 func (d Decimal) String() string {
 	return d.value.String()
 }
+
+func NewDecimalFromInt(i int64) Decimal {
+	return Decimal{value: decimal.NewFromInt(i)}
+}
+
+func (d Decimal) Add(d2 Decimal) Decimal {
+	return Decimal{value: d.value.Add(d2.value)}
+}
+
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	return Decimal{value: d.value.Sub(d2.value)}
+}
+
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	return Decimal{value: d.value.Mul(d2.value)}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: d.value.Abs()}
+}
+
+// Cmp returns -1 if d < d2, 0 if d == d2 and 1 if d > d2.
+func (d Decimal) Cmp(d2 Decimal) int {
+	return d.value.Cmp(d2.value)
+}
+
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.value.Equal(d2.value)
+}
+
+func (d Decimal) IsZero() bool {
+	return d.value.IsZero()
+}
+
+// Units of measure of UN/ECE Recommendation 20 used by trade documents, with their factors to the base unit of their
+// dimension. The factors are exact by definition.
+var unitsOfMeasure = map[string]struct {
+	dimension string
+	factor    decimal.Decimal
+}{
+	"KGM": {"mass", decimal.NewFromInt(1)},                         // Kilogram
+	"TNE": {"mass", decimal.NewFromInt(1000)},                      // Tonne
+	"LBR": {"mass", decimal.RequireFromString("0.45359237")},       // Pound
+	"MTQ": {"volume", decimal.NewFromInt(1)},                       // Cubic metre
+	"LTR": {"volume", decimal.RequireFromString("0.001")},          // Litre
+	"FTQ": {"volume", decimal.RequireFromString("0.028316846592")}, // Cubic foot
+}
+
+// ConvertUnit converts d from one unit of measure to another of the same dimension. Units are codes of UN/ECE
+// Recommendation 20, for example KGM and LBR of weights, MTQ and FTQ of volumes.
+func (d Decimal) ConvertUnit(from, to string) (Decimal, error) {
+	fromUnit, ok := unitsOfMeasure[from]
+	if !ok {
+		return Decimal{}, fmt.Errorf("unknown unit of measure %q%w", from, ErrInvalidParameter)
+	}
+	toUnit, ok := unitsOfMeasure[to]
+	if !ok {
+		return Decimal{}, fmt.Errorf("unknown unit of measure %q%w", to, ErrInvalidParameter)
+	}
+	if fromUnit.dimension != toUnit.dimension {
+		return Decimal{}, fmt.Errorf("cannot convert %s to %s%w", from, to, ErrInvalidParameter)
+	}
+	if from == to {
+		return d, nil
+	}
+	return Decimal{value: d.value.Mul(fromUnit.factor).Div(toUnit.factor)}, nil
+}
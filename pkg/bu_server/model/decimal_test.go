@@ -2,6 +2,7 @@ package model_test
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/openebl/openebl/pkg/bu_server/model"
@@ -39,3 +40,59 @@ func TestDecimalJson(t *testing.T) {
 		t.Fatal("JSON marshaling/unmarshaling is not consistent")
 	}
 }
+
+func TestDecimalConvertUnit(t *testing.T) {
+	testCases := []struct {
+		value    string
+		from     string
+		to       string
+		expected string
+	}{
+		{"1000", "LBR", "KGM", "453.59237"},
+		{"453.59237", "KGM", "LBR", "1000"},
+		{"2.5", "TNE", "KGM", "2500"},
+		{"100", "FTQ", "MTQ", "2.8316846592"},
+		{"1500", "LTR", "MTQ", "1.5"},
+		{"12.3", "KGM", "KGM", "12.3"},
+	}
+	for _, tc := range testCases {
+		d, _ := model.NewDecimalFromString(tc.value)
+		converted, err := d.ConvertUnit(tc.from, tc.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := model.NewDecimalFromString(tc.expected)
+		if !converted.Equal(expected) {
+			t.Fatalf("%s %s should be %s %s, got %s", tc.value, tc.from, tc.expected, tc.to, converted)
+		}
+	}
+
+	d := model.NewDecimalFromInt(1)
+	if _, err := d.ConvertUnit("KGM", "MTQ"); !errors.Is(err, model.ErrInvalidParameter) {
+		t.Fatalf("converting weight to volume should fail, got %v", err)
+	}
+	if _, err := d.ConvertUnit("XXX", "KGM"); !errors.Is(err, model.ErrInvalidParameter) {
+		t.Fatalf("converting unknown unit should fail, got %v", err)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a, _ := model.NewDecimalFromString("1.25")
+	b := model.NewDecimalFromInt(2)
+
+	if sum := a.Add(b); sum.String() != "3.25" {
+		t.Fatalf("unexpected sum %s", sum)
+	}
+	if diff := a.Sub(b); diff.String() != "-0.75" || diff.Abs().String() != "0.75" {
+		t.Fatalf("unexpected difference %s", diff)
+	}
+	if product := a.Mul(b); product.String() != "2.5" {
+		t.Fatalf("unexpected product %s", product)
+	}
+	if a.Cmp(b) != -1 || b.Cmp(a) != 1 || a.Cmp(a) != 0 {
+		t.Fatal("unexpected comparison")
+	}
+	if !a.Sub(a).IsZero() {
+		t.Fatal("a - a should be zero")
+	}
+}
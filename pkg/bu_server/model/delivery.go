@@ -0,0 +1,22 @@
+package model
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"   // The pack is waiting in the outbox to be published.
+	DeliveryStatusDelivered DeliveryStatus = "delivered" // The relay server accepted the pack.
+	DeliveryStatusFailed    DeliveryStatus = "failed"    // The last attempt to publish the pack failed. It is retried later.
+)
+
+// BillOfLadingDelivery is the delivery record of a version of a bill of lading pack to its parties through the relay.
+type BillOfLadingDelivery struct {
+	PackID     string         `json:"pack_id"`    // ID of the bill of lading pack.
+	Version    int64          `json:"version"`    // Version of the bill of lading pack.
+	EventID    string         `json:"event_id"`   // ID of the relay event carrying the encrypted pack.
+	Recipients []string       `json:"recipients"` // DIDs of the parties the pack is encrypted to.
+	Status     DeliveryStatus `json:"status"`     // Status of the delivery.
+	Error      string         `json:"error"`      // Why the last attempt to publish the pack failed.
+
+	CreatedAt int64 `json:"created_at"` // Unix Time (in second) when the delivery was created.
+	UpdatedAt int64 `json:"updated_at"` // Unix Time (in second) when the delivery was last updated.
+}
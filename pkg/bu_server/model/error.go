@@ -11,6 +11,7 @@ var ErrApplicationError = errors.New("")            // Base error for Applicatio
 var ErrUserError = errors.New("")                   // Base error for User
 var ErrBusinessUnitError = errors.New("")           // Base error for Business Unit
 var ErrCertificationAuthorityError = errors.New("") // Base error for Certification Authority
+var ErrTradeDocumentError = errors.New("")          // Base error for Trade Document
 
 // API Key errors
 var ErrInvalidAPIKeyString = fmt.Errorf("invalid API key string%w", ErrAPIKeyError)
@@ -33,7 +34,28 @@ var ErrUserTokenInvalid = fmt.Errorf("user token invalid%w", ErrUserError)
 // Business Unit errors
 var ErrBusinessUnitNotFound = fmt.Errorf("business unit not found%w", ErrBusinessUnitError)
 var ErrAuthenticationNotFound = fmt.Errorf("authentication not found%w", ErrBusinessUnitError)
+var ErrBusinessUnitInactive = fmt.Errorf("business unit is inactive%w", ErrBusinessUnitError)
 
 // Certification Authority errors
 var ErrCertificationNotFound = fmt.Errorf("certification not found%w", ErrCertificationAuthorityError)
 var ErrCertificationExpired = fmt.Errorf("certification expired%w", ErrCertificationAuthorityError)
+
+// Trade Document errors
+var ErrBillOfLadingNotFound = fmt.Errorf("bill of lading not found%w", ErrTradeDocumentError)
+var ErrBillOfLadingVersionConflict = fmt.Errorf("bill of lading is changed by others%w", ErrTradeDocumentError)
+var ErrBillOfLadingNotIssued = fmt.Errorf("bill of lading is not issued%w", ErrTradeDocumentError)
+var ErrBillOfLadingNotOwner = fmt.Errorf("actor is not the current owner of bill of lading%w", ErrTradeDocumentError)
+var ErrBillOfLadingNotIssuer = fmt.Errorf("actor is not the issuer of bill of lading%w", ErrTradeDocumentError)
+var ErrBillOfLadingSurrendered = fmt.Errorf("bill of lading is surrendered%w", ErrTradeDocumentError)
+var ErrBillOfLadingPrintedToPaper = fmt.Errorf("bill of lading is printed to paper%w", ErrTradeDocumentError)
+var ErrBillOfLadingAmendmentRequested = fmt.Errorf("amendment of bill of lading is requested%w", ErrTradeDocumentError)
+var ErrBillOfLadingAmendmentNotRequested = fmt.Errorf("amendment of bill of lading is not requested%w", ErrTradeDocumentError)
+var ErrBillOfLadingInvalidReturn = fmt.Errorf("bill of lading can only be returned to its previous owner%w", ErrTradeDocumentError)
+var ErrBillOfLadingInvalidTransfer = fmt.Errorf("bill of lading can't be transferred to its current owner%w", ErrTradeDocumentError)
+var ErrBillOfLadingBrokenHashChain = fmt.Errorf("hash chain of bill of lading is broken%w", ErrTradeDocumentError)
+var ErrBillOfLadingInvalidSignature = fmt.Errorf("invalid signature of bill of lading%w", ErrTradeDocumentError)
+var ErrBillOfLadingSignerMismatch = fmt.Errorf("signer of bill of lading is not the actor of its latest event%w", ErrTradeDocumentError)
+var ErrBillOfLadingPartyCertificateNotFound = fmt.Errorf("certificate of bill of lading party not found%w", ErrTradeDocumentError)
+var ErrFileNotFound = fmt.Errorf("file not found%w", ErrTradeDocumentError)
+var ErrShippingInstructionNotFound = fmt.Errorf("shipping instruction not found%w", ErrTradeDocumentError)
+var ErrShippingInstructionNotUpdatable = fmt.Errorf("shipping instruction can't be updated in its current status%w", ErrTradeDocumentError)
@@ -1,7 +1,14 @@
 package model
 
+// File is a document attached to a trade document.
+//
+// Files in bill of lading packs only reference their content by Hash, Size and MediaType. The content is kept in the
+// blob store and delivered to the parties of the pack separately, so it isn't repeated in every version of the pack.
 type File struct {
-	Name        string   `json:"name"`    // File name
-	Content     []byte   `json:"content"` // File content.
+	Name        string   `json:"name"`                 // File name
+	Content     []byte   `json:"content"`              // File content. Empty if the file only references its content.
+	Hash        string   `json:"hash,omitempty"`       // Hex encoded SHA256 hash of the content.
+	Size        int64    `json:"size,omitempty"`       // Size of the content in bytes.
+	MediaType   string   `json:"media_type,omitempty"` // Media type of the content, like "application/pdf".
 	CreatedDate DateTime `json:"created_date"`
 }
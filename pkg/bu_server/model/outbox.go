@@ -0,0 +1,35 @@
+package model
+
+type OutboxMessageStatus string
+
+const (
+	OutboxMessageStatusPending   OutboxMessageStatus = "pending"   // The message is waiting to be published.
+	OutboxMessageStatusDelivered OutboxMessageStatus = "delivered" // The relay server acknowledged the message.
+)
+
+// OutboxMessage is an event written in the same transaction as the change of trade documents it carries.
+// The outbox dispatcher publishes it to the relay server after the transaction is committed.
+type OutboxMessage struct {
+	ID         string   `json:"id"`         // ID of the relay event (hex encoded SHA512 of data).
+	Type       int      `json:"type"`       // Type of the relay event.
+	Data       []byte   `json:"data"`       // Data of the relay event.
+	Tags       []string `json:"tags"`       // Tags of the relay event.
+	Recipients []string `json:"recipients"` // DIDs of the parties the event is encrypted to.
+	PackID     string   `json:"pack_id"`    // ID of the bill of lading pack carried by the event.
+	Version    int64    `json:"version"`    // Version of the bill of lading pack carried by the event.
+
+	Status        OutboxMessageStatus `json:"status"`          // Status of the message.
+	Attempts      int                 `json:"attempts"`        // How many times the message failed to be published.
+	NextAttemptAt int64               `json:"next_attempt_at"` // Unix Time (in second) when the message is published next time.
+	LastError     string              `json:"last_error"`      // Why the last attempt failed.
+
+	CreatedAt int64 `json:"created_at"` // Unix Time (in second) when the message was created.
+	UpdatedAt int64 `json:"updated_at"` // Unix Time (in second) when the message was last updated.
+}
+
+// OutboxStats tells how the outbox is doing.
+type OutboxStats struct {
+	Pending         int   `json:"pending"`           // Number of messages waiting to be published.
+	Failing         int   `json:"failing"`           // Number of pending messages which failed to be published at least once.
+	OldestPendingAt int64 `json:"oldest_pending_at"` // Unix Time (in second) when the oldest pending message was created. 0 if nothing is pending.
+}
@@ -0,0 +1,25 @@
+package model
+
+type RelayEventStatus string
+
+const (
+	RelayEventStatusAccepted   RelayEventStatus = "accepted"   // The event carries a new version of a bill of lading pack.
+	RelayEventStatusUnverified RelayEventStatus = "unverified" // The event carries a new version of a bill of lading pack whose earlier versions are unknown. It is stored without being verified against them.
+	RelayEventStatusPending    RelayEventStatus = "pending"    // The event carries a file attached to a version of a bill of lading pack which is not received yet.
+	RelayEventStatusDuplicated RelayEventStatus = "duplicated" // The event carries a version of a bill of lading pack which is already stored.
+	RelayEventStatusRejected   RelayEventStatus = "rejected"   // The event carries an invalid, untrusted or forked bill of lading pack.
+	RelayEventStatusIgnored    RelayEventStatus = "ignored"    // The event is not for business units of this BU server.
+)
+
+// RelayEvent is the record of an event received from a relay server. It prevents the event from being processed twice.
+type RelayEvent struct {
+	ID          string           `json:"id"`           // ID of the event given by the relay server (hex encoded SHA512 of its data).
+	RelayServer string           `json:"relay_server"` // Identity of the relay server the event is received from.
+	Offset      int64            `json:"offset"`       // Offset of the event in the relay server.
+	Type        int              `json:"type"`         // Type of the event.
+	Status      RelayEventStatus `json:"status"`       // Result of processing the event.
+	Reason      string           `json:"reason"`       // Why the event is rejected, ignored or unverified.
+	PackID      string           `json:"pack_id"`      // ID of the bill of lading pack carried by the event.
+	Version     int64            `json:"version"`      // Version of the bill of lading pack carried by the event.
+	CreatedAt   int64            `json:"created_at"`   // Unix Time (in second) when the event was processed.
+}
@@ -59,4 +59,5 @@ type PrintToPaper struct {
 	PrintBy string          `json:"print_by,omitempty"` // DID
 	PrintAt *model.DateTime `json:"print_at,omitempty"`
 	Note    string          `json:"note,omitempty"`
+	File    *model.File     `json:"file,omitempty"` // The printed PDF document.
 }
@@ -1,23 +1,23 @@
 /*
  * DCSA OpenAPI specification for Electronic Bill of Lading
  *
- * API specification issued by DCSA.org.  For explanation to specific values or objects please refer to the [Information Model 2022.Q4](https://dcsa.org/wp-content/uploads/2022/12/DCSA_Information-Model-2022.Q4-final.pdf). **Please be aware that version 2022.Q4 of the Information Model includes Reefers - this API does not include Reefers.** This API does not define the business rules regarding what is allowed to update at what time. For this the [Interface Standard for the Bill of Lading 2.0](https://dcsa.org/wp-content/uploads/2022/12/12-23-2022_DCSA_Interface_Standard_Bill_of_Lading_v2.0.pdf) should be consulted.  All other documents related to the Electronic Bill of Lading publication can be found [here](https://dcsa.org/standards/ebill-of-lading/)  It is possible to use this API as a standalone API. In order to do so it is necessary to use the poll-endPoint - /v2/events  in order to poll event information.  It is recomended to implement the [DCSA Documentation Event Hub](https://app.swaggerhub.com/apis/dcsaorg/DOCUMENTATION_EVENT_HUB) in order to use the push model. Here events are pushed as they occur.  For a changelog please click [here](https://github.com/dcsaorg/DCSA-OpenAPI/tree/master/ebl/v2#v200). Please also [create a GitHub issue](https://github.com/dcsaorg/DCSA-OpenAPI/issues/new) if you have any questions/comments. 
+ * API specification issued by DCSA.org.  For explanation to specific values or objects please refer to the [Information Model 2022.Q4](https://dcsa.org/wp-content/uploads/2022/12/DCSA_Information-Model-2022.Q4-final.pdf). **Please be aware that version 2022.Q4 of the Information Model includes Reefers - this API does not include Reefers.** This API does not define the business rules regarding what is allowed to update at what time. For this the [Interface Standard for the Bill of Lading 2.0](https://dcsa.org/wp-content/uploads/2022/12/12-23-2022_DCSA_Interface_Standard_Bill_of_Lading_v2.0.pdf) should be consulted.  All other documents related to the Electronic Bill of Lading publication can be found [here](https://dcsa.org/standards/ebill-of-lading/)  It is possible to use this API as a standalone API. In order to do so it is necessary to use the poll-endPoint - /v2/events  in order to poll event information.  It is recomended to implement the [DCSA Documentation Event Hub](https://app.swaggerhub.com/apis/dcsaorg/DOCUMENTATION_EVENT_HUB) in order to use the push model. Here events are pushed as they occur.  For a changelog please click [here](https://github.com/dcsaorg/DCSA-OpenAPI/tree/master/ebl/v2#v200). Please also [create a GitHub issue](https://github.com/dcsaorg/DCSA-OpenAPI/issues/new) if you have any questions/comments.
  *
  * API version: 2.0.0
  * Contact: info@dcsa.org
  * Generated by: Swagger Codegen (https://github.com/swagger-api/swagger-codegen.git)
  */
-package swagger
+package bill_of_lading
 
-type AllOfErrorErrorsItems struct {
-	// Standard error code see http://dcsa.org/error-codes (to be created). Examples: 7003 – out or range value, 7004 -  invalid type 
-	ErrorCode int32 `json:"errorCode,omitempty"`
-	// The field that caused the error, e.g. a failed validation. The field can be expressed as a [JSONpath](https://github.com/json-path/JsonPath) 
-	Field string `json:"field,omitempty"`
-	// The value that of the field that caused the error 
-	Value string `json:"value,omitempty"`
-	// High level error message 
-	Reason string `json:"reason"`
-	// Additional information as to why the error occured 
-	Message string `json:"message"`
+import "github.com/openebl/openebl/pkg/bu_server/model"
+
+type ErrorResponse struct {
+	HttpMethod            string          `json:"httpMethod"`                      // The http request method type e.g. GET, POST
+	RequestUri            string          `json:"requestUri"`                      // The request URI as it was sent
+	StatusCode            int32           `json:"statusCode"`                      // The HTTP status code
+	StatusCodeText        string          `json:"statusCodeText"`                  // The textual representation of the status code
+	ErrorMessage          string          `json:"errorMessage,omitempty"`          // Other error information
+	ProviderCorrelationID string          `json:"providerCorrelationID,omitempty"` // A unique identifier for the transaction, e.g. a UUID
+	ErrorDateTime         *model.DateTime `json:"errorDateTime"`                   // The date and time (in ISO 8601 format) the error occurred.
+	Errors                []DetailedError `json:"errors"`                          // List of detailed errors, e.g. fields that could not pass validation
 }
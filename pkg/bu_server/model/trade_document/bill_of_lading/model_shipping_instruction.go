@@ -10,6 +10,9 @@ package bill_of_lading
 import "github.com/openebl/openebl/pkg/bu_server/model"
 
 // The Shipping Instruction is an enrichment to the original booking shared by the shipper to the carrier. The shipping instruction includes volume or weight, cargo items, shipping dates, origin, destination, and other special instructions. The information given by the shipper through the shipping instruction is the information required to create a Transport Document.
+// GET /v2/shipping-instructions/{shippingInstructionReference} responds with it. It flattens ShippingInstructionShallow,
+// ShippingInstructionDeep and BookingCore of the specification, so their generated models in dcsa_reference_data stay
+// disabled.
 type ShippingInstruction struct {
 	ShippingInstructionReference       string                    `json:"shippingInstructionReference"`
 	DocumentStatus                     EblDocumentStatus         `json:"documentStatus"`
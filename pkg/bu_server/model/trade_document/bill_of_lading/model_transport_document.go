@@ -14,6 +14,8 @@ import (
 )
 
 // The document that governs the terms of carriage between shipper and carrier for maritime transportation. Two distinct types of transport documents exist: - Bill of Lading - Sea Waybill.
+// GET /v2/transport-documents/{transportDocumentReference} responds with it. It flattens TransportDocumentRoot and the
+// other allOf parts of the specification, so their generated models in dcsa_reference_data stay disabled.
 type TransportDocument struct {
 	TransportDocumentReference       string                         `json:"transportDocumentReference"`
 	TransportDocumentCreatedDateTime *model.DateTime                `json:"transportDocumentCreatedDateTime,omitempty"`
@@ -53,6 +53,16 @@ func (s *BaseTestSuite) SetupTest() {
 		"api_key_history",
 		"application",
 		"application_history",
+		"bill_of_lading_delivery",
+		"bill_of_lading_pack",
+		"bill_of_lading_pack_history",
+		"relay_checkpoint",
+		"relay_event",
+		"relay_outbox",
+		"pending_bill_of_lading_file",
+		"shipping_instruction",
+		"shipping_instruction_history",
+		"shipment_event",
 	}
 	for _, tableName := range tableNames {
 		_, err := pool.Exec(context.Background(), fmt.Sprintf(`DELETE FROM %q`, tableName))
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+)
+
+// StoreShippingInstruction stores the version of the shipping instruction into its history and the DCSA shipment event
+// of the version. The shipping instruction is updated only if the version is newer than the stored one.
+func (s *_Storage) StoreShippingInstruction(ctx context.Context, tx storage.Tx, record trade_document.ShippingInstructionRecord) error {
+	si := record.ShippingInstruction
+	query := `
+INSERT INTO shipping_instruction (id, "version", business_unit, "status", carrier_booking_reference, shipping_instruction, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+ON CONFLICT (id) DO UPDATE SET
+	"version" = excluded."version",
+	"status" = excluded."status",
+	carrier_booking_reference = excluded.carrier_booking_reference,
+	shipping_instruction = excluded.shipping_instruction,
+	updated_at = excluded.updated_at
+WHERE shipping_instruction."version" < excluded."version"
+`
+	if _, err := tx.Exec(
+		ctx, query,
+		si.ShippingInstructionReference, record.Version, record.BusinessUnit, si.DocumentStatus,
+		si.CarrierBookingReference, record, record.CreatedAt,
+	); err != nil {
+		return err
+	}
+
+	query = `
+INSERT INTO shipping_instruction_history (id, "version", shipping_instruction, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (id, "version") DO NOTHING
+`
+	if _, err := tx.Exec(ctx, query, si.ShippingInstructionReference, record.Version, record, record.CreatedAt); err != nil {
+		return err
+	}
+
+	event := trade_document.GetShippingInstructionShipmentEvent(record)
+	return storeShipmentEvent(ctx, tx, []string{record.BusinessUnit}, event, record.CreatedAt)
+}
+
+func (s *_Storage) ListShippingInstructions(ctx context.Context, tx storage.Tx, req trade_document.ListShippingInstructionsRequest) (trade_document.ListShippingInstructionsResult, error) {
+	query := `
+WITH filtered_record AS (
+	SELECT rec_id, shipping_instruction
+	FROM shipping_instruction
+	WHERE
+		($3 = '' OR business_unit = $3) AND
+		(COALESCE(array_length($4::TEXT[], 1), 0) = 0 OR id = ANY($4)) AND
+		($5 = '' OR carrier_booking_reference = $5) AND
+		(COALESCE(array_length($6::TEXT[], 1), 0) = 0 OR "status" = ANY($6))
+)
+SELECT
+	total,
+	shipping_instruction
+FROM (SELECT COUNT(*) AS total FROM filtered_record) AS report
+FULL OUTER JOIN (SELECT shipping_instruction FROM filtered_record ORDER BY rec_id ASC OFFSET $1 LIMIT $2) AS record ON FALSE
+`
+	rows, err := tx.Query(
+		ctx, query,
+		req.Offset, req.Limit, req.BusinessUnit, req.References, req.CarrierBookingReference, req.DocumentStatuses,
+	)
+	if err != nil {
+		return trade_document.ListShippingInstructionsResult{}, err
+	}
+	defer rows.Close()
+
+	result := trade_document.ListShippingInstructionsResult{}
+	for rows.Next() {
+		var total *int
+		var record *trade_document.ShippingInstructionRecord
+		if err := rows.Scan(&total, &record); err != nil {
+			return trade_document.ListShippingInstructionsResult{}, err
+		}
+		if total != nil {
+			result.Total = *total
+		}
+		if record != nil {
+			result.Records = append(result.Records, *record)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return trade_document.ListShippingInstructionsResult{}, err
+	}
+
+	return result, nil
+}
+
+func (s *_Storage) ListShipmentEvents(ctx context.Context, tx storage.Tx, req trade_document.ListShipmentEventsRequest) (trade_document.ListShipmentEventsResult, error) {
+	query := `
+WITH filtered_record AS (
+	SELECT rec_id, "event"
+	FROM shipment_event
+	WHERE
+		($3 = '' OR $3 = ANY(parties)) AND
+		($4 = '' OR document_type_code = $4) AND
+		($5 = '' OR document_reference = $5) AND
+		($6 = '' OR shipment_event_type_code = $6)
+)
+SELECT
+	total,
+	"event"
+FROM (SELECT COUNT(*) AS total FROM filtered_record) AS report
+FULL OUTER JOIN (SELECT "event" FROM filtered_record ORDER BY rec_id ASC OFFSET $1 LIMIT $2) AS record ON FALSE
+`
+	rows, err := tx.Query(
+		ctx, query,
+		req.Offset, req.Limit, req.BusinessUnit, req.DocumentTypeCode, req.DocumentReference, req.ShipmentEventTypeCode,
+	)
+	if err != nil {
+		return trade_document.ListShipmentEventsResult{}, err
+	}
+	defer rows.Close()
+
+	result := trade_document.ListShipmentEventsResult{}
+	for rows.Next() {
+		var total *int
+		var event *bill_of_lading.ShipmentEvent
+		if err := rows.Scan(&total, &event); err != nil {
+			return trade_document.ListShipmentEventsResult{}, err
+		}
+		if total != nil {
+			result.Total = *total
+		}
+		if event != nil {
+			result.Records = append(result.Records, *event)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return trade_document.ListShipmentEventsResult{}, err
+	}
+
+	return result, nil
+}
+
+// storeShipmentEvent stores the event visible to the parties. The ID of the event is derived from the version of the
+// document, so storing the same version again is a no-op.
+func storeShipmentEvent(ctx context.Context, tx storage.Tx, parties []string, event bill_of_lading.ShipmentEvent, ts int64) error {
+	query := `
+INSERT INTO shipment_event (id, parties, document_type_code, document_reference, shipment_event_type_code, "event", created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (id) DO NOTHING
+`
+	payload := event.Payload
+	if _, err := tx.Exec(
+		ctx, query,
+		event.Metadata.EventID, parties, payload.DocumentTypeCode, payload.DocumentReference,
+		payload.ShipmentEventTypeCode, event, ts,
+	); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package postgres_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/bu_server/storage/postgres"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/stretchr/testify/suite"
+)
+
+type DCSAStorageTestSuite struct {
+	BaseTestSuite
+	storage trade_document.DCSAStorage
+}
+
+func TestDCSAStorage(t *testing.T) {
+	suite.Run(t, new(DCSAStorageTestSuite))
+}
+
+func (s *DCSAStorageTestSuite) SetupTest() {
+	s.BaseTestSuite.SetupTest()
+	s.storage = postgres.NewStorageWithPool(s.pgPool)
+}
+
+func (s *DCSAStorageTestSuite) TearDownTest() {
+	s.BaseTestSuite.TearDownTest()
+}
+
+func (s *DCSAStorageTestSuite) TestShippingInstruction() {
+	ts := time.Now().Unix()
+
+	tx, err := s.storage.CreateTx(s.ctx, storage.TxOptionWithWrite(true))
+	s.Require().NoError(err)
+	defer tx.Rollback(s.ctx)
+
+	v1 := trade_document.ShippingInstructionRecord{
+		BusinessUnit: "did:openebl:carrier",
+		Version:      1,
+		ShippingInstruction: bill_of_lading.ShippingInstruction{
+			ShippingInstructionReference: "si1",
+			DocumentStatus:               bill_of_lading.RECE_EblDocumentStatus,
+			CarrierBookingReference:      "booking1",
+		},
+		CreatedAt: ts,
+	}
+	v2 := v1
+	v2.Version = 2
+	v2.ShippingInstruction.DocumentStatus = bill_of_lading.PENU_EblDocumentStatus
+	v2.CreatedAt = ts + 1
+	other := trade_document.ShippingInstructionRecord{
+		BusinessUnit: "did:openebl:carrier",
+		Version:      1,
+		ShippingInstruction: bill_of_lading.ShippingInstruction{
+			ShippingInstructionReference: "si2",
+			DocumentStatus:               bill_of_lading.RECE_EblDocumentStatus,
+			CarrierBookingReference:      "booking2",
+		},
+		CreatedAt: ts + 2,
+	}
+	// The older version stored after the newer one doesn't overwrite it.
+	for _, record := range []trade_document.ShippingInstructionRecord{v2, v1, other} {
+		s.Require().NoError(s.storage.StoreShippingInstruction(s.ctx, tx, record))
+	}
+
+	req := trade_document.ListShippingInstructionsRequest{Limit: 10, BusinessUnit: "did:openebl:carrier"}
+	result, err := s.storage.ListShippingInstructions(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(2, result.Total)
+	s.Assert().Equal([]trade_document.ShippingInstructionRecord{v2, other}, result.Records)
+
+	req = trade_document.ListShippingInstructionsRequest{Limit: 10, References: []string{"si2"}}
+	result, err = s.storage.ListShippingInstructions(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(1, result.Total)
+	s.Assert().Equal([]trade_document.ShippingInstructionRecord{other}, result.Records)
+
+	req = trade_document.ListShippingInstructionsRequest{Limit: 10, CarrierBookingReference: "booking1"}
+	result, err = s.storage.ListShippingInstructions(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal([]trade_document.ShippingInstructionRecord{v2}, result.Records)
+
+	req = trade_document.ListShippingInstructionsRequest{
+		Limit:            10,
+		DocumentStatuses: []bill_of_lading.EblDocumentStatus{bill_of_lading.RECE_EblDocumentStatus},
+	}
+	result, err = s.storage.ListShippingInstructions(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal([]trade_document.ShippingInstructionRecord{other}, result.Records)
+
+	req = trade_document.ListShippingInstructionsRequest{Limit: 10, BusinessUnit: "did:openebl:shipper"}
+	result, err = s.storage.ListShippingInstructions(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(0, result.Total)
+	s.Assert().Empty(result.Records)
+
+	// Every version has its event.
+	events, err := s.storage.ListShipmentEvents(s.ctx, tx, trade_document.ListShipmentEventsRequest{
+		Limit:             10,
+		BusinessUnit:      "did:openebl:carrier",
+		DocumentReference: "si1",
+	})
+	s.Require().NoError(err)
+	s.Require().Equal(2, events.Total)
+	s.Assert().Equal(trade_document.GetShippingInstructionShipmentEvent(v2), events.Records[0])
+	s.Assert().Equal(trade_document.GetShippingInstructionShipmentEvent(v1), events.Records[1])
+
+	s.Require().NoError(tx.Commit(s.ctx))
+}
+
+func (s *DCSAStorageTestSuite) TestListShipmentEvents() {
+	ts := time.Now().Unix()
+
+	tx, err := s.storage.CreateTx(s.ctx, storage.TxOptionWithWrite(true))
+	s.Require().NoError(err)
+	defer tx.Rollback(s.ctx)
+
+	issue := bill_of_lading.BillOfLadingEvent{
+		BillOfLading: &bill_of_lading.BillOfLading{
+			BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+			CreatedBy:    "did:openebl:carrier",
+			TransferTo:   "did:openebl:shipper",
+		},
+	}
+	records := []trade_document.BillOfLadingPackRecord{
+		{
+			Pack:       bill_of_lading.BillOfLadingPack{ID: "pack1", Version: 1, CurrentOwner: "did:openebl:shipper", Events: []bill_of_lading.BillOfLadingEvent{issue}},
+			SignedPack: envelope.JWS{Protected: "protected", Payload: "v1", Signature: "signature1"},
+			CreatedAt:  ts,
+		},
+		{
+			Pack: bill_of_lading.BillOfLadingPack{
+				ID:           "pack1",
+				Version:      2,
+				CurrentOwner: "did:openebl:bank",
+				Events: []bill_of_lading.BillOfLadingEvent{
+					issue,
+					{Transfer: &bill_of_lading.Transfer{TransferBy: "did:openebl:shipper", TransferTo: "did:openebl:bank"}},
+				},
+			},
+			SignedPack: envelope.JWS{Protected: "protected", Payload: "v2", Signature: "signature2"},
+			CreatedAt:  ts + 1,
+		},
+		{
+			Pack: bill_of_lading.BillOfLadingPack{
+				ID:           "pack1",
+				Version:      3,
+				CurrentOwner: "did:openebl:carrier",
+				Events: []bill_of_lading.BillOfLadingEvent{
+					issue,
+					{Transfer: &bill_of_lading.Transfer{TransferBy: "did:openebl:shipper", TransferTo: "did:openebl:bank"}},
+					{Surrender: &bill_of_lading.Surrender{SurrenderBy: "did:openebl:bank"}},
+				},
+			},
+			SignedPack: envelope.JWS{Protected: "protected", Payload: "v3", Signature: "signature3"},
+			CreatedAt:  ts + 2,
+		},
+	}
+	// The transfer has no event, and storing a version again doesn't duplicate its event.
+	for _, record := range append(records, records[0]) {
+		s.Require().NoError(s.storage.StoreBillOfLadingPack(s.ctx, tx, record))
+	}
+	issued, err := trade_document.GetBillOfLadingShipmentEvent(records[0])
+	s.Require().NoError(err)
+	surrendered, err := trade_document.GetBillOfLadingShipmentEvent(records[2])
+	s.Require().NoError(err)
+
+	req := trade_document.ListShipmentEventsRequest{Limit: 10, BusinessUnit: "did:openebl:bank"}
+	result, err := s.storage.ListShipmentEvents(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(2, result.Total)
+	s.Assert().Equal([]bill_of_lading.ShipmentEvent{*issued, *surrendered}, result.Records)
+
+	req = trade_document.ListShipmentEventsRequest{Offset: 1, Limit: 10, BusinessUnit: "did:openebl:bank"}
+	result, err = s.storage.ListShipmentEvents(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(2, result.Total)
+	s.Assert().Equal([]bill_of_lading.ShipmentEvent{*surrendered}, result.Records)
+
+	req = trade_document.ListShipmentEventsRequest{
+		Limit:                 10,
+		DocumentTypeCode:      bill_of_lading.TRD_EblDocumentTypeCode,
+		DocumentReference:     "bl-number",
+		ShipmentEventTypeCode: bill_of_lading.SURR_EblShipmentEventTypeCode,
+	}
+	result, err = s.storage.ListShipmentEvents(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal([]bill_of_lading.ShipmentEvent{*surrendered}, result.Records)
+
+	req = trade_document.ListShipmentEventsRequest{Limit: 10, DocumentTypeCode: bill_of_lading.SHI_EblDocumentTypeCode}
+	result, err = s.storage.ListShipmentEvents(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(0, result.Total)
+	s.Assert().Empty(result.Records)
+
+	s.Require().NoError(tx.Commit(s.ctx))
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+)
+
+func (s *_Storage) StoreDelivery(ctx context.Context, tx storage.Tx, delivery model.BillOfLadingDelivery) error {
+	query := `
+INSERT INTO bill_of_lading_delivery (pack_id, "version", event_id, "status", created_at, updated_at, delivery)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (pack_id, "version") DO UPDATE SET
+	event_id = excluded.event_id,
+	"status" = excluded."status",
+	updated_at = excluded.updated_at,
+	delivery = excluded.delivery
+`
+	_, err := tx.Exec(ctx, query, delivery.PackID, delivery.Version, delivery.EventID, delivery.Status, delivery.CreatedAt, delivery.UpdatedAt, delivery)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *_Storage) ListDeliveries(ctx context.Context, tx storage.Tx, req trade_document.ListDeliveriesRequest) (trade_document.ListDeliveriesResult, error) {
+	query := `
+WITH filtered_record AS (
+	SELECT rec_id, delivery
+	FROM bill_of_lading_delivery
+	WHERE
+		($3 = '' OR pack_id = $3) AND
+		(COALESCE(array_length($4::TEXT[], 1), 0) = 0 OR "status" = ANY($4))
+)
+SELECT
+	total,
+	delivery
+FROM (SELECT COUNT(*) AS total FROM filtered_record) AS report
+FULL OUTER JOIN (SELECT delivery FROM filtered_record ORDER BY rec_id ASC OFFSET $1 LIMIT $2) AS record ON FALSE
+`
+	rows, err := tx.Query(ctx, query, req.Offset, req.Limit, req.PackID, req.Statuses)
+	if err != nil {
+		return trade_document.ListDeliveriesResult{}, err
+	}
+	defer rows.Close()
+
+	result := trade_document.ListDeliveriesResult{}
+	for rows.Next() {
+		var total *int
+		var delivery *model.BillOfLadingDelivery
+		if err := rows.Scan(&total, &delivery); err != nil {
+			return trade_document.ListDeliveriesResult{}, err
+		}
+		if total != nil {
+			result.Total = *total
+		}
+		if delivery != nil {
+			result.Records = append(result.Records, *delivery)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *_Storage) StoreOutboxMessage(ctx context.Context, tx storage.Tx, msg model.OutboxMessage) error {
+	query := `
+INSERT INTO relay_outbox (id, "status", attempts, next_attempt_at, message, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (id) DO UPDATE SET
+	"status" = excluded."status",
+	attempts = excluded.attempts,
+	next_attempt_at = excluded.next_attempt_at,
+	message = excluded.message,
+	updated_at = excluded.updated_at
+`
+	_, err := tx.Exec(ctx, query, msg.ID, msg.Status, msg.Attempts, msg.NextAttemptAt, msg, msg.CreatedAt, msg.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *_Storage) ListPendingOutboxMessages(ctx context.Context, tx storage.Tx, ts int64, limit int) ([]model.OutboxMessage, error) {
+	query := `
+SELECT message
+FROM relay_outbox
+WHERE "status" = 'pending' AND next_attempt_at <= $1
+ORDER BY rec_id ASC
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+	rows, err := tx.Query(ctx, query, ts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []model.OutboxMessage
+	for rows.Next() {
+		var msg model.OutboxMessage
+		if err := rows.Scan(&msg); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+func (s *_Storage) GetOutboxStats(ctx context.Context, tx storage.Tx) (model.OutboxStats, error) {
+	query := `
+SELECT
+	COUNT(*),
+	COUNT(*) FILTER (WHERE attempts > 0),
+	COALESCE(MIN(created_at), 0)
+FROM relay_outbox
+WHERE "status" = 'pending'
+`
+	var stats model.OutboxStats
+	if err := tx.QueryRow(ctx, query).Scan(&stats.Pending, &stats.Failing, &stats.OldestPendingAt); err != nil {
+		return model.OutboxStats{}, err
+	}
+	return stats, nil
+}
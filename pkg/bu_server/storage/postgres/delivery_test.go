@@ -0,0 +1,143 @@
+package postgres_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/bu_server/storage/postgres"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/stretchr/testify/suite"
+)
+
+type DeliveryStorageTestSuite struct {
+	BaseTestSuite
+	storage trade_document.DeliveryStorage
+}
+
+func TestDeliveryStorage(t *testing.T) {
+	suite.Run(t, new(DeliveryStorageTestSuite))
+}
+
+func (s *DeliveryStorageTestSuite) SetupTest() {
+	s.BaseTestSuite.SetupTest()
+	s.storage = postgres.NewStorageWithPool(s.pgPool)
+}
+
+func (s *DeliveryStorageTestSuite) TearDownTest() {
+	s.BaseTestSuite.TearDownTest()
+}
+
+func (s *DeliveryStorageTestSuite) TestStoreAndListDeliveries() {
+	ts := time.Now().Unix()
+
+	tx, err := s.storage.CreateTx(s.ctx, storage.TxOptionWithWrite(true))
+	s.Require().NoError(err)
+	defer tx.Rollback(s.ctx)
+
+	delivery := model.BillOfLadingDelivery{
+		PackID:     "pack1",
+		Version:    1,
+		EventID:    "event1",
+		Recipients: []string{"did:openebl:bu1", "did:openebl:bu2"},
+		Status:     model.DeliveryStatusPending,
+		CreatedAt:  ts,
+		UpdatedAt:  ts,
+	}
+	s.Require().NoError(s.storage.StoreDelivery(s.ctx, tx, delivery))
+
+	// The delivery of the same version is updated.
+	delivered := delivery
+	delivered.Status = model.DeliveryStatusDelivered
+	delivered.UpdatedAt = ts + 1
+	s.Require().NoError(s.storage.StoreDelivery(s.ctx, tx, delivered))
+
+	failed := delivery
+	failed.Version = 2
+	failed.EventID = "event2"
+	failed.Status = model.DeliveryStatusFailed
+	failed.Error = "connection refused"
+	s.Require().NoError(s.storage.StoreDelivery(s.ctx, tx, failed))
+
+	result, err := s.storage.ListDeliveries(s.ctx, tx, trade_document.ListDeliveriesRequest{Limit: 10, PackID: "pack1"})
+	s.Require().NoError(err)
+	s.Assert().Equal(2, result.Total)
+	s.Require().Len(result.Records, 2)
+	s.Assert().Equal(delivered, result.Records[0])
+	s.Assert().Equal(failed, result.Records[1])
+
+	result, err = s.storage.ListDeliveries(s.ctx, tx, trade_document.ListDeliveriesRequest{Limit: 10, Statuses: []model.DeliveryStatus{model.DeliveryStatusFailed}})
+	s.Require().NoError(err)
+	s.Assert().Equal(1, result.Total)
+	s.Require().Len(result.Records, 1)
+	s.Assert().Equal(failed, result.Records[0])
+
+	result, err = s.storage.ListDeliveries(s.ctx, tx, trade_document.ListDeliveriesRequest{Limit: 1, Offset: 1})
+	s.Require().NoError(err)
+	s.Assert().Equal(2, result.Total)
+	s.Require().Len(result.Records, 1)
+	s.Assert().Equal(failed, result.Records[0])
+
+	s.Require().NoError(tx.Commit(s.ctx))
+}
+
+func (s *DeliveryStorageTestSuite) TestOutbox() {
+	ts := time.Now().Unix()
+
+	tx, err := s.storage.CreateTx(s.ctx, storage.TxOptionWithWrite(true))
+	s.Require().NoError(err)
+	defer tx.Rollback(s.ctx)
+
+	stats, err := s.storage.GetOutboxStats(s.ctx, tx)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.OutboxStats{}, stats)
+
+	msg1 := model.OutboxMessage{
+		ID:            "event1",
+		Type:          trade_document.BillOfLadingPackEventType,
+		Data:          []byte("data1"),
+		Tags:          []string{"did:openebl:bu1", "did:openebl:bu2"},
+		PackID:        "pack1",
+		Version:       1,
+		Status:        model.OutboxMessageStatusPending,
+		NextAttemptAt: ts,
+		CreatedAt:     ts,
+		UpdatedAt:     ts,
+	}
+	msg2 := msg1
+	msg2.ID = "event2"
+	msg2.Version = 2
+	msg2.CreatedAt = ts + 1
+	msg2.NextAttemptAt = ts + 1
+	msg3 := msg1
+	msg3.ID = "event3"
+	msg3.Version = 3
+	msg3.CreatedAt = ts + 2
+	msg3.NextAttemptAt = ts + 2
+	for _, msg := range []model.OutboxMessage{msg1, msg2, msg3} {
+		s.Require().NoError(s.storage.StoreOutboxMessage(s.ctx, tx, msg))
+	}
+
+	// msg1 is delivered and msg2 failed once.
+	msg1.Status = model.OutboxMessageStatusDelivered
+	s.Require().NoError(s.storage.StoreOutboxMessage(s.ctx, tx, msg1))
+	msg2.Attempts = 1
+	msg2.NextAttemptAt = ts + 10
+	msg2.LastError = "connection refused"
+	s.Require().NoError(s.storage.StoreOutboxMessage(s.ctx, tx, msg2))
+
+	msgs, err := s.storage.ListPendingOutboxMessages(s.ctx, tx, ts+2, 10)
+	s.Require().NoError(err)
+	s.Assert().Equal([]model.OutboxMessage{msg3}, msgs)
+
+	msgs, err = s.storage.ListPendingOutboxMessages(s.ctx, tx, ts+10, 1)
+	s.Require().NoError(err)
+	s.Assert().Equal([]model.OutboxMessage{msg2}, msgs)
+
+	stats, err = s.storage.GetOutboxStats(s.ctx, tx)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.OutboxStats{Pending: 2, Failing: 1, OldestPendingAt: ts + 1}, stats)
+
+	s.Require().NoError(tx.Commit(s.ctx))
+}
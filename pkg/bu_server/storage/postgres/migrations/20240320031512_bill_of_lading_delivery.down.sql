@@ -0,0 +1 @@
+DROP TABLE bill_of_lading_delivery;
@@ -0,0 +1,13 @@
+CREATE TABLE bill_of_lading_delivery (
+    rec_id BIGSERIAL,
+    pack_id TEXT NOT NULL,
+    "version" BIGINT NOT NULL,
+    event_id TEXT NOT NULL,
+    "status" TEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    delivery JSONB NOT NULL
+);
+ALTER TABLE bill_of_lading_delivery ADD CONSTRAINT bill_of_lading_delivery_pkey PRIMARY KEY (pack_id, "version");
+CREATE INDEX bill_of_lading_delivery_status_idx ON bill_of_lading_delivery ("status");
+CREATE INDEX bill_of_lading_delivery_event_id_idx ON bill_of_lading_delivery (event_id);
@@ -0,0 +1,4 @@
+DROP TABLE relay_event;
+DROP TABLE relay_checkpoint;
+DROP TABLE bill_of_lading_pack_history;
+DROP TABLE bill_of_lading_pack;
@@ -0,0 +1,36 @@
+CREATE TABLE bill_of_lading_pack (
+    rec_id BIGSERIAL,
+    id TEXT PRIMARY KEY,
+    "version" BIGINT NOT NULL,
+    current_owner TEXT NOT NULL,
+    pack JSONB NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE TABLE bill_of_lading_pack_history (
+    rec_id BIGSERIAL,
+    id TEXT NOT NULL,
+    "version" BIGINT NOT NULL,
+    pack JSONB NOT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (id, "version")
+);
+
+CREATE TABLE relay_checkpoint (
+    relay_server TEXT PRIMARY KEY,
+    "offset" BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE TABLE relay_event (
+    rec_id BIGSERIAL,
+    id TEXT PRIMARY KEY,
+    relay_server TEXT NOT NULL,
+    "offset" BIGINT NOT NULL,
+    "status" TEXT NOT NULL,
+    "event" JSONB NOT NULL,
+    created_at BIGINT NOT NULL
+);
+CREATE INDEX relay_event_status_idx ON relay_event ("status");
@@ -0,0 +1,11 @@
+CREATE TABLE relay_outbox (
+    rec_id BIGSERIAL,
+    id TEXT PRIMARY KEY,
+    "status" TEXT NOT NULL,
+    attempts INT NOT NULL,
+    next_attempt_at BIGINT NOT NULL,
+    message JSONB NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+CREATE INDEX relay_outbox_pending_idx ON relay_outbox (next_attempt_at, rec_id) WHERE "status" = 'pending';
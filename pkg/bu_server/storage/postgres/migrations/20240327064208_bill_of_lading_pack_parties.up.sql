@@ -0,0 +1 @@
+ALTER TABLE bill_of_lading_pack ADD COLUMN parties TEXT[] NOT NULL DEFAULT '{}';
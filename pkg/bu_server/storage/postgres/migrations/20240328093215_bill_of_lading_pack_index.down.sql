@@ -0,0 +1,11 @@
+DROP INDEX bill_of_lading_pack_status_idx;
+DROP INDEX bill_of_lading_pack_doc_reference_idx;
+DROP INDEX bill_of_lading_pack_parties_idx;
+DROP INDEX bill_of_lading_pack_current_owner_idx;
+
+ALTER TABLE bill_of_lading_pack_history DROP COLUMN encrypted_pack;
+ALTER TABLE bill_of_lading_pack_history DROP COLUMN signed_pack;
+ALTER TABLE bill_of_lading_pack DROP COLUMN encrypted_pack;
+ALTER TABLE bill_of_lading_pack DROP COLUMN signed_pack;
+ALTER TABLE bill_of_lading_pack DROP COLUMN "status";
+ALTER TABLE bill_of_lading_pack DROP COLUMN doc_reference;
@@ -0,0 +1,35 @@
+ALTER TABLE bill_of_lading_pack ADD COLUMN doc_reference TEXT NOT NULL DEFAULT '';
+ALTER TABLE bill_of_lading_pack ADD COLUMN "status" TEXT NOT NULL DEFAULT '';
+ALTER TABLE bill_of_lading_pack ADD COLUMN signed_pack BYTEA;
+ALTER TABLE bill_of_lading_pack ADD COLUMN encrypted_pack BYTEA;
+ALTER TABLE bill_of_lading_pack_history ADD COLUMN signed_pack BYTEA;
+ALTER TABLE bill_of_lading_pack_history ADD COLUMN encrypted_pack BYTEA;
+
+-- Backfill from the stored records. The envelopes of existing rows are only available as normalized JSON.
+UPDATE bill_of_lading_pack SET
+    doc_reference = COALESCE((
+        SELECT e->'bill_of_lading'->'bill_of_lading'->>'transportDocumentReference'
+        FROM jsonb_array_elements(pack->'pack'->'events') WITH ORDINALITY AS t(e, i)
+        WHERE e ? 'bill_of_lading'
+        ORDER BY i DESC
+        LIMIT 1
+    ), ''),
+    "status" = CASE
+        WHEN pack->'pack'->'events'->-1 ? 'surrender' THEN 'surrendered'
+        WHEN pack->'pack'->'events'->-1 ? 'print_to_paper' THEN 'printed_to_paper'
+        WHEN pack->'pack'->'events'->-1 ? 'amendment_request' THEN 'amendment_requested'
+        ELSE 'issued'
+    END,
+    signed_pack = convert_to((pack->'signed_pack')::TEXT, 'UTF8'),
+    encrypted_pack = convert_to((pack->'encrypted_pack')::TEXT, 'UTF8');
+UPDATE bill_of_lading_pack_history SET
+    signed_pack = convert_to((pack->'signed_pack')::TEXT, 'UTF8'),
+    encrypted_pack = convert_to((pack->'encrypted_pack')::TEXT, 'UTF8');
+
+ALTER TABLE bill_of_lading_pack ALTER COLUMN signed_pack SET NOT NULL;
+ALTER TABLE bill_of_lading_pack_history ALTER COLUMN signed_pack SET NOT NULL;
+
+CREATE INDEX bill_of_lading_pack_current_owner_idx ON bill_of_lading_pack (current_owner);
+CREATE INDEX bill_of_lading_pack_parties_idx ON bill_of_lading_pack USING GIN (parties);
+CREATE INDEX bill_of_lading_pack_doc_reference_idx ON bill_of_lading_pack (doc_reference);
+CREATE INDEX bill_of_lading_pack_status_idx ON bill_of_lading_pack ("status");
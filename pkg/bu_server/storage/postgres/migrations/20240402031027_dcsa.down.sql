@@ -0,0 +1,3 @@
+DROP TABLE shipment_event;
+DROP TABLE shipping_instruction_history;
+DROP TABLE shipping_instruction;
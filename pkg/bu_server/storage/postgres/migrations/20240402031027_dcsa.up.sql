@@ -0,0 +1,35 @@
+CREATE TABLE shipping_instruction (
+    rec_id BIGSERIAL,
+    id TEXT PRIMARY KEY,
+    "version" BIGINT NOT NULL,
+    business_unit TEXT NOT NULL,
+    "status" TEXT NOT NULL,
+    carrier_booking_reference TEXT NOT NULL,
+    shipping_instruction JSONB NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+CREATE INDEX shipping_instruction_business_unit_idx ON shipping_instruction (business_unit);
+
+CREATE TABLE shipping_instruction_history (
+    rec_id BIGSERIAL,
+    id TEXT NOT NULL,
+    "version" BIGINT NOT NULL,
+    shipping_instruction JSONB NOT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (id, version)
+);
+
+-- Events are derived when documents are stored. Bill of lading packs stored before this migration have no events.
+CREATE TABLE shipment_event (
+    rec_id BIGSERIAL,
+    id TEXT PRIMARY KEY,
+    parties TEXT[] NOT NULL,
+    document_type_code TEXT NOT NULL,
+    document_reference TEXT NOT NULL,
+    shipment_event_type_code TEXT NOT NULL,
+    "event" JSONB NOT NULL,
+    created_at BIGINT NOT NULL
+);
+CREATE INDEX shipment_event_parties_idx ON shipment_event USING GIN (parties);
+CREATE INDEX shipment_event_document_reference_idx ON shipment_event (document_reference);
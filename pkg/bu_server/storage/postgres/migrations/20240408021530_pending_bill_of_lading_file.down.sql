@@ -0,0 +1 @@
+DROP TABLE pending_bill_of_lading_file;
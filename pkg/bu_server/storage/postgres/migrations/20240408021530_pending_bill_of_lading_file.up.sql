@@ -0,0 +1,9 @@
+CREATE TABLE pending_bill_of_lading_file (
+    rec_id BIGSERIAL,
+    event_id TEXT PRIMARY KEY,
+    pack_id TEXT NOT NULL,
+    "version" BIGINT NOT NULL,
+    "file" JSONB NOT NULL,
+    created_at BIGINT NOT NULL
+);
+CREATE INDEX pending_bill_of_lading_file_pack_idx ON pending_bill_of_lading_file (pack_id, "version");
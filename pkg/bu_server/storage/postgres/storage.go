@@ -32,6 +32,11 @@ func NewStorageWithPool(dbPool *pgxpool.Pool) *_Storage {
 	}
 }
 
+// Close closes all connections to the database.
+func (s *_Storage) Close() {
+	s.dbPool.Close()
+}
+
 func NewStorageWithConfig(config util.PostgresDatabaseConfig) (*_Storage, error) {
 	dbPool, err := util.NewPostgresDBPool(config)
 	if err != nil {
@@ -0,0 +1,285 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/envelope"
+)
+
+// StoreBillOfLadingPack stores the version of the pack into its history. The pack is updated only if the version is
+// newer than the stored one, because versions may be received out of order. The DCSA shipment event of the version is
+// stored as well.
+//
+// The raw envelopes of the record are also kept byte for byte besides the JSONB record, so the signatures can be
+// verified as they were received even though JSONB doesn't preserve the original encoding.
+func (s *_Storage) StoreBillOfLadingPack(ctx context.Context, tx storage.Tx, record trade_document.BillOfLadingPackRecord) error {
+	pack := record.Pack
+	status, err := trade_document.GetBillOfLadingStatus(pack)
+	if err != nil {
+		return err
+	}
+	signedPack, encryptedPack, err := marshalEnvelopes(record)
+	if err != nil {
+		return err
+	}
+
+	query := `
+INSERT INTO bill_of_lading_pack (id, "version", current_owner, parties, doc_reference, "status", pack, signed_pack, encrypted_pack, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+ON CONFLICT (id) DO UPDATE SET
+	"version" = excluded."version",
+	current_owner = excluded.current_owner,
+	parties = excluded.parties,
+	doc_reference = excluded.doc_reference,
+	"status" = excluded."status",
+	pack = excluded.pack,
+	signed_pack = excluded.signed_pack,
+	encrypted_pack = excluded.encrypted_pack,
+	updated_at = excluded.updated_at
+WHERE bill_of_lading_pack."version" < excluded."version"
+`
+	parties := trade_document.GetBillOfLadingPackParties(pack)
+	docReference := trade_document.GetDocumentReference(pack)
+	if _, err := tx.Exec(
+		ctx, query,
+		pack.ID, pack.Version, pack.CurrentOwner, parties, docReference, status,
+		record, signedPack, encryptedPack, record.CreatedAt,
+	); err != nil {
+		return err
+	}
+
+	query = `
+INSERT INTO bill_of_lading_pack_history (id, "version", pack, signed_pack, encrypted_pack, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (id, "version") DO NOTHING
+`
+	if _, err := tx.Exec(ctx, query, pack.ID, pack.Version, record, signedPack, encryptedPack, record.CreatedAt); err != nil {
+		return err
+	}
+
+	event, err := trade_document.GetBillOfLadingShipmentEvent(record)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return nil
+	}
+	return storeShipmentEvent(ctx, tx, parties, *event, record.CreatedAt)
+}
+
+func (s *_Storage) ListBillOfLadingPacks(ctx context.Context, tx storage.Tx, req trade_document.ListBillOfLadingPacksRequest) (trade_document.ListBillOfLadingPacksResult, error) {
+	query := `
+WITH filtered_record AS (
+	SELECT rec_id, pack, signed_pack, encrypted_pack
+	FROM bill_of_lading_pack
+	WHERE
+		(COALESCE(array_length($3::TEXT[], 1), 0) = 0 OR id = ANY($3)) AND
+		($4 = '' OR $4 = ANY(parties)) AND
+		($5 <> 'held' OR current_owner = $4) AND
+		($5 <> 'sent' OR current_owner <> $4) AND
+		($6 = '' OR doc_reference = $6) AND
+		(COALESCE(array_length($7::TEXT[], 1), 0) = 0 OR "status" = ANY($7))
+)
+SELECT
+	total,
+	pack,
+	signed_pack,
+	encrypted_pack
+FROM (SELECT COUNT(*) AS total FROM filtered_record) AS report
+FULL OUTER JOIN (SELECT pack, signed_pack, encrypted_pack FROM filtered_record ORDER BY rec_id ASC OFFSET $1 LIMIT $2) AS record ON FALSE
+`
+	rows, err := tx.Query(ctx, query, req.Offset, req.Limit, req.PackIDs, req.BusinessUnit, req.Box, req.DocReference, req.Statuses)
+	if err != nil {
+		return trade_document.ListBillOfLadingPacksResult{}, err
+	}
+	defer rows.Close()
+
+	result := trade_document.ListBillOfLadingPacksResult{}
+	for rows.Next() {
+		var total *int
+		var record *trade_document.BillOfLadingPackRecord
+		var signedPack, encryptedPack []byte
+		if err := rows.Scan(&total, &record, &signedPack, &encryptedPack); err != nil {
+			return trade_document.ListBillOfLadingPacksResult{}, err
+		}
+		if total != nil {
+			result.Total = *total
+		}
+		if record != nil {
+			if err := unmarshalEnvelopes(record, signedPack, encryptedPack); err != nil {
+				return trade_document.ListBillOfLadingPacksResult{}, err
+			}
+			result.Records = append(result.Records, *record)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return trade_document.ListBillOfLadingPacksResult{}, err
+	}
+
+	return result, nil
+}
+
+// GetBillOfLadingPackHistory returns all stored versions of the pack in ascending order of version.
+func (s *_Storage) GetBillOfLadingPackHistory(ctx context.Context, tx storage.Tx, packID string) ([]trade_document.BillOfLadingPackRecord, error) {
+	query := `SELECT pack, signed_pack, encrypted_pack FROM bill_of_lading_pack_history WHERE id = $1 ORDER BY "version" ASC`
+	rows, err := tx.Query(ctx, query, packID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []trade_document.BillOfLadingPackRecord
+	for rows.Next() {
+		var record trade_document.BillOfLadingPackRecord
+		var signedPack, encryptedPack []byte
+		if err := rows.Scan(&record, &signedPack, &encryptedPack); err != nil {
+			return nil, err
+		}
+		if err := unmarshalEnvelopes(&record, signedPack, encryptedPack); err != nil {
+			return nil, err
+		}
+		history = append(history, record)
+	}
+	return history, nil
+}
+
+// marshalEnvelopes returns the raw envelopes of the record. Envelopes without raw ones are serialized the same way as
+// they are published. The encrypted one is nil if the record isn't delivered yet.
+func marshalEnvelopes(record trade_document.BillOfLadingPackRecord) ([]byte, []byte, error) {
+	signedPack := record.RawSignedPack
+	if signedPack == nil {
+		raw, err := json.Marshal(record.SignedPack)
+		if err != nil {
+			return nil, nil, err
+		}
+		signedPack = raw
+	}
+	if record.EncryptedPack == nil {
+		return signedPack, nil, nil
+	}
+	encryptedPack := record.RawEncryptedPack
+	if encryptedPack == nil {
+		raw, err := json.Marshal(record.EncryptedPack)
+		if err != nil {
+			return nil, nil, err
+		}
+		encryptedPack = raw
+	}
+	return signedPack, encryptedPack, nil
+}
+
+// unmarshalEnvelopes replaces the envelopes of the record with the raw ones.
+func unmarshalEnvelopes(record *trade_document.BillOfLadingPackRecord, signedPack, encryptedPack []byte) error {
+	if err := json.Unmarshal(signedPack, &record.SignedPack); err != nil {
+		return err
+	}
+	record.RawSignedPack = signedPack
+	if encryptedPack == nil {
+		record.EncryptedPack = nil
+		record.RawEncryptedPack = nil
+		return nil
+	}
+	record.EncryptedPack = new(envelope.JWE)
+	record.RawEncryptedPack = encryptedPack
+	return json.Unmarshal(encryptedPack, record.EncryptedPack)
+}
+
+// GetRelayCheckpoint returns the offset to resume from. It is 0 if nothing is received from the relay server yet.
+func (s *_Storage) GetRelayCheckpoint(ctx context.Context, tx storage.Tx, relayServer string) (int64, error) {
+	query := `SELECT "offset" FROM relay_checkpoint WHERE relay_server = $1`
+	var offset int64
+	if err := tx.QueryRow(ctx, query, relayServer).Scan(&offset); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+	return offset, nil
+}
+
+// StoreRelayCheckpoint moves the checkpoint of the relay server forward. It never moves backward.
+func (s *_Storage) StoreRelayCheckpoint(ctx context.Context, tx storage.Tx, ts int64, relayServer string, offset int64) error {
+	query := `
+INSERT INTO relay_checkpoint (relay_server, "offset", created_at, updated_at)
+VALUES ($1, $2, $3, $3)
+ON CONFLICT (relay_server) DO UPDATE SET
+	"offset" = GREATEST(relay_checkpoint."offset", excluded."offset"),
+	updated_at = excluded.updated_at
+`
+	if _, err := tx.Exec(ctx, query, relayServer, offset, ts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetRelayEvent returns the processed event of the ID, or nil if the event is not processed yet.
+func (s *_Storage) GetRelayEvent(ctx context.Context, tx storage.Tx, eventID string) (*model.RelayEvent, error) {
+	query := `SELECT "event" FROM relay_event WHERE id = $1`
+	var event model.RelayEvent
+	if err := tx.QueryRow(ctx, query, eventID).Scan(&event); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// StoreRelayEvent stores the processed event. The status of the stored one is updated, so pending events can be
+// settled later.
+func (s *_Storage) StoreRelayEvent(ctx context.Context, tx storage.Tx, event model.RelayEvent) error {
+	query := `
+INSERT INTO relay_event (id, relay_server, "offset", "status", "event", created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (id) DO UPDATE SET
+	"status" = excluded."status",
+	"event" = excluded."event"
+`
+	if _, err := tx.Exec(ctx, query, event.ID, event.RelayServer, event.Offset, event.Status, event, event.CreatedAt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *_Storage) StorePendingFile(ctx context.Context, tx storage.Tx, file trade_document.PendingBillOfLadingFile) error {
+	query := `
+INSERT INTO pending_bill_of_lading_file (event_id, pack_id, "version", "file", created_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (event_id) DO NOTHING
+`
+	if _, err := tx.Exec(ctx, query, file.Event.ID, file.Event.PackID, file.Event.Version, file, file.Event.CreatedAt); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListPendingFiles returns files waiting for the version of the pack in the order they are received.
+func (s *_Storage) ListPendingFiles(ctx context.Context, tx storage.Tx, packID string, version int64) ([]trade_document.PendingBillOfLadingFile, error) {
+	query := `SELECT "file" FROM pending_bill_of_lading_file WHERE pack_id = $1 AND "version" = $2 ORDER BY rec_id ASC`
+	rows, err := tx.Query(ctx, query, packID, version)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []trade_document.PendingBillOfLadingFile
+	for rows.Next() {
+		var file trade_document.PendingBillOfLadingFile
+		if err := rows.Scan(&file); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+func (s *_Storage) DeletePendingFiles(ctx context.Context, tx storage.Tx, packID string, version int64) error {
+	query := `DELETE FROM pending_bill_of_lading_file WHERE pack_id = $1 AND "version" = $2`
+	if _, err := tx.Exec(ctx, query, packID, version); err != nil {
+		return err
+	}
+	return nil
+}
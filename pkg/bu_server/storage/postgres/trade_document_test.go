@@ -0,0 +1,319 @@
+package postgres_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/bu_server/storage/postgres"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/openebl/openebl/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type TradeDocumentStorageTestSuite struct {
+	BaseTestSuite
+	storage trade_document.BillOfLadingInboxStorage
+}
+
+func TestTradeDocumentStorage(t *testing.T) {
+	suite.Run(t, new(TradeDocumentStorageTestSuite))
+}
+
+func (s *TradeDocumentStorageTestSuite) SetupTest() {
+	s.BaseTestSuite.SetupTest()
+	s.storage = postgres.NewStorageWithPool(s.pgPool)
+}
+
+func (s *TradeDocumentStorageTestSuite) TearDownTest() {
+	s.BaseTestSuite.TearDownTest()
+}
+
+func (s *TradeDocumentStorageTestSuite) TestStoreBillOfLadingPack() {
+	ts := time.Now().Unix()
+
+	tx, err := s.storage.CreateTx(s.ctx, storage.TxOptionWithWrite(true))
+	s.Require().NoError(err)
+	defer tx.Rollback(s.ctx)
+
+	issue := bill_of_lading.BillOfLadingEvent{
+		BillOfLading: &bill_of_lading.BillOfLading{
+			BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+			CreatedBy:    "did:openebl:carrier",
+			TransferTo:   "did:openebl:shipper",
+		},
+	}
+	v1 := trade_document.BillOfLadingPackRecord{
+		Pack:       bill_of_lading.BillOfLadingPack{ID: "pack1", Version: 1, CurrentOwner: "did:openebl:shipper", Events: []bill_of_lading.BillOfLadingEvent{issue}},
+		SignedPack: envelope.JWS{Protected: "protected", Payload: "v1", Signature: "signature1"},
+		CreatedAt:  ts,
+	}
+	v3 := trade_document.BillOfLadingPackRecord{
+		Pack: bill_of_lading.BillOfLadingPack{
+			ID:           "pack1",
+			Version:      3,
+			ParentHash:   "hash2",
+			CurrentOwner: "did:openebl:carrier",
+			Events: []bill_of_lading.BillOfLadingEvent{
+				issue,
+				{Transfer: &bill_of_lading.Transfer{TransferBy: "did:openebl:shipper", TransferTo: "did:openebl:bank"}},
+				{Surrender: &bill_of_lading.Surrender{SurrenderBy: "did:openebl:bank"}},
+			},
+		},
+		SignedPack:    envelope.JWS{Protected: "protected", Payload: "v3", Signature: "signature3"},
+		EncryptedPack: &envelope.JWE{Protected: "protected", Ciphertext: "v3", Tag: "tag"},
+		CreatedAt:     ts + 2,
+	}
+	v2 := trade_document.BillOfLadingPackRecord{
+		Pack: bill_of_lading.BillOfLadingPack{
+			ID:           "pack1",
+			Version:      2,
+			ParentHash:   "hash1",
+			CurrentOwner: "did:openebl:bank",
+			Events: []bill_of_lading.BillOfLadingEvent{
+				issue,
+				{Transfer: &bill_of_lading.Transfer{TransferBy: "did:openebl:shipper", TransferTo: "did:openebl:bank"}},
+			},
+		},
+		SignedPack:    envelope.JWS{Protected: "protected", Payload: "v2", Signature: "signature2"},
+		EncryptedPack: &envelope.JWE{Protected: "protected", Ciphertext: "v2", Tag: "tag"},
+		CreatedAt:     ts + 1,
+	}
+	// The envelopes of v2 are received in a serialization different from the one of encoding/json.
+	v2.RawSignedPack = []byte(`{"payload": "v2", "protected": "protected", "signature": "signature2"}`)
+	v2.RawEncryptedPack = []byte(`{"ciphertext": "v2", "protected": "protected", "tag": "tag"}`)
+	// Versions received out of order and more than once are all kept once.
+	for _, record := range []trade_document.BillOfLadingPackRecord{v1, v3, v2, v3} {
+		s.Require().NoError(s.storage.StoreBillOfLadingPack(s.ctx, tx, record))
+	}
+
+	history, err := s.storage.GetBillOfLadingPackHistory(s.ctx, tx, "pack1")
+	s.Require().NoError(err)
+	s.Assert().Equal([]trade_document.BillOfLadingPackRecord{withRawEnvelopes(v1), v2, withRawEnvelopes(v3)}, history)
+
+	var version int64
+	var currentOwner, docReference, status string
+	s.Require().NoError(tx.QueryRow(
+		s.ctx,
+		`SELECT "version", current_owner, doc_reference, "status" FROM bill_of_lading_pack WHERE id = 'pack1'`,
+	).Scan(&version, &currentOwner, &docReference, &status))
+	s.Assert().EqualValues(3, version)
+	s.Assert().Equal("did:openebl:carrier", currentOwner)
+	s.Assert().Equal("bl-number", docReference)
+	s.Assert().EqualValues(trade_document.BillOfLadingStatusSurrendered, status)
+
+	// The raw envelopes are kept as they are stored.
+	var signedPack []byte
+	var encryptedPack []byte
+	s.Require().NoError(tx.QueryRow(
+		s.ctx,
+		`SELECT signed_pack, encrypted_pack FROM bill_of_lading_pack_history WHERE id = 'pack1' AND "version" = 1`,
+	).Scan(&signedPack, &encryptedPack))
+	s.Assert().Equal(util.StructToJSON(v1.SignedPack), string(signedPack))
+	s.Assert().Nil(encryptedPack)
+	s.Require().NoError(tx.QueryRow(
+		s.ctx,
+		`SELECT signed_pack, encrypted_pack FROM bill_of_lading_pack_history WHERE id = 'pack1' AND "version" = 2`,
+	).Scan(&signedPack, &encryptedPack))
+	s.Assert().Equal(v2.RawSignedPack, signedPack)
+	s.Assert().Equal(v2.RawEncryptedPack, encryptedPack)
+
+	history, err = s.storage.GetBillOfLadingPackHistory(s.ctx, tx, "pack2")
+	s.Require().NoError(err)
+	s.Assert().Empty(history)
+
+	s.Require().NoError(tx.Commit(s.ctx))
+}
+
+// withRawEnvelopes returns the record with the raw envelopes serialized by the storage.
+func withRawEnvelopes(record trade_document.BillOfLadingPackRecord) trade_document.BillOfLadingPackRecord {
+	record.RawSignedPack = []byte(util.StructToJSON(record.SignedPack))
+	if record.EncryptedPack != nil {
+		record.RawEncryptedPack = []byte(util.StructToJSON(record.EncryptedPack))
+	}
+	return record
+}
+
+func (s *TradeDocumentStorageTestSuite) TestListBillOfLadingPacks() {
+	ts := time.Now().Unix()
+
+	tx, err := s.storage.CreateTx(s.ctx, storage.TxOptionWithWrite(true))
+	s.Require().NoError(err)
+	defer tx.Rollback(s.ctx)
+
+	issued := trade_document.BillOfLadingPackRecord{
+		Pack: bill_of_lading.BillOfLadingPack{
+			ID:           "pack1",
+			Version:      1,
+			CurrentOwner: "did:openebl:shipper",
+			Events: []bill_of_lading.BillOfLadingEvent{
+				{BillOfLading: &bill_of_lading.BillOfLading{
+					BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number-1"},
+					CreatedBy:    "did:openebl:carrier",
+					TransferTo:   "did:openebl:shipper",
+				}},
+			},
+		},
+		SignedPack: envelope.JWS{Payload: "pack1"},
+		CreatedAt:  ts,
+	}
+	transferred := trade_document.BillOfLadingPackRecord{
+		Pack: bill_of_lading.BillOfLadingPack{
+			ID:           "pack2",
+			Version:      2,
+			CurrentOwner: "did:openebl:bank",
+			Events: []bill_of_lading.BillOfLadingEvent{
+				{BillOfLading: &bill_of_lading.BillOfLading{
+					BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number-2"},
+					CreatedBy:    "did:openebl:carrier",
+					TransferTo:   "did:openebl:shipper",
+				}},
+				{Transfer: &bill_of_lading.Transfer{TransferBy: "did:openebl:shipper", TransferTo: "did:openebl:bank"}},
+			},
+		},
+		SignedPack:    envelope.JWS{Payload: "pack2"},
+		EncryptedPack: &envelope.JWE{Ciphertext: "pack2"},
+		CreatedAt:     ts + 1,
+	}
+	s.Require().NoError(s.storage.StoreBillOfLadingPack(s.ctx, tx, issued))
+	s.Require().NoError(s.storage.StoreBillOfLadingPack(s.ctx, tx, transferred))
+	// Envelopes are read as they are stored.
+	issued, transferred = withRawEnvelopes(issued), withRawEnvelopes(transferred)
+
+	req := trade_document.ListBillOfLadingPacksRequest{Limit: 10, BusinessUnit: "did:openebl:shipper"}
+	result, err := s.storage.ListBillOfLadingPacks(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(trade_document.ListBillOfLadingPacksResult{Total: 2, Records: []trade_document.BillOfLadingPackRecord{issued, transferred}}, result)
+
+	req.Box = trade_document.BillOfLadingBoxHeld
+	result, err = s.storage.ListBillOfLadingPacks(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(trade_document.ListBillOfLadingPacksResult{Total: 1, Records: []trade_document.BillOfLadingPackRecord{issued}}, result)
+
+	req.Box = trade_document.BillOfLadingBoxSent
+	result, err = s.storage.ListBillOfLadingPacks(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(trade_document.ListBillOfLadingPacksResult{Total: 1, Records: []trade_document.BillOfLadingPackRecord{transferred}}, result)
+
+	// Test with offset and pack IDs.
+	req = trade_document.ListBillOfLadingPacksRequest{Offset: 1, Limit: 10, BusinessUnit: "did:openebl:carrier"}
+	result, err = s.storage.ListBillOfLadingPacks(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(trade_document.ListBillOfLadingPacksResult{Total: 2, Records: []trade_document.BillOfLadingPackRecord{transferred}}, result)
+
+	req = trade_document.ListBillOfLadingPacksRequest{Limit: 10, DocReference: "bl-number-2"}
+	result, err = s.storage.ListBillOfLadingPacks(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(trade_document.ListBillOfLadingPacksResult{Total: 1, Records: []trade_document.BillOfLadingPackRecord{transferred}}, result)
+
+	req = trade_document.ListBillOfLadingPacksRequest{Limit: 10, Statuses: []trade_document.BillOfLadingStatus{trade_document.BillOfLadingStatusSurrendered}}
+	result, err = s.storage.ListBillOfLadingPacks(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(0, result.Total)
+
+	req = trade_document.ListBillOfLadingPacksRequest{Limit: 10, PackIDs: []string{"pack1"}, BusinessUnit: "did:openebl:bank"}
+	result, err = s.storage.ListBillOfLadingPacks(s.ctx, tx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(0, result.Total)
+	s.Assert().Empty(result.Records)
+
+	s.Require().NoError(tx.Commit(s.ctx))
+}
+
+func (s *TradeDocumentStorageTestSuite) TestRelayCheckpointAndEvent() {
+	ts := time.Now().Unix()
+
+	tx, err := s.storage.CreateTx(s.ctx, storage.TxOptionWithWrite(true))
+	s.Require().NoError(err)
+	defer tx.Rollback(s.ctx)
+
+	offset, err := s.storage.GetRelayCheckpoint(s.ctx, tx, "relay1")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(0, offset)
+
+	s.Require().NoError(s.storage.StoreRelayCheckpoint(s.ctx, tx, ts, "relay1", 10))
+	s.Require().NoError(s.storage.StoreRelayCheckpoint(s.ctx, tx, ts, "relay1", 5))
+	s.Require().NoError(s.storage.StoreRelayCheckpoint(s.ctx, tx, ts, "relay2", 3))
+	offset, err = s.storage.GetRelayCheckpoint(s.ctx, tx, "relay1")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(10, offset)
+	offset, err = s.storage.GetRelayCheckpoint(s.ctx, tx, "relay2")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(3, offset)
+
+	event, err := s.storage.GetRelayEvent(s.ctx, tx, "event1")
+	s.Require().NoError(err)
+	s.Assert().Nil(event)
+
+	relayEvent := model.RelayEvent{
+		ID:          "event1",
+		RelayServer: "relay1",
+		Offset:      10,
+		Type:        trade_document.BillOfLadingPackEventType,
+		Status:      model.RelayEventStatusAccepted,
+		PackID:      "pack1",
+		Version:     1,
+		CreatedAt:   ts,
+	}
+	s.Require().NoError(s.storage.StoreRelayEvent(s.ctx, tx, relayEvent))
+	event, err = s.storage.GetRelayEvent(s.ctx, tx, "event1")
+	s.Require().NoError(err)
+	s.Require().NotNil(event)
+	s.Assert().Equal(relayEvent, *event)
+
+	// The status of a stored event is updated.
+	relayEvent.Status = model.RelayEventStatusRejected
+	relayEvent.Reason = "rejected"
+	s.Require().NoError(s.storage.StoreRelayEvent(s.ctx, tx, relayEvent))
+	event, err = s.storage.GetRelayEvent(s.ctx, tx, "event1")
+	s.Require().NoError(err)
+	s.Require().NotNil(event)
+	s.Assert().Equal(relayEvent, *event)
+
+	s.Require().NoError(tx.Commit(s.ctx))
+}
+
+func (s *TradeDocumentStorageTestSuite) TestPendingFiles() {
+	ts := time.Now().Unix()
+
+	tx, err := s.storage.CreateTx(s.ctx, storage.TxOptionWithWrite(true))
+	s.Require().NoError(err)
+	defer tx.Rollback(s.ctx)
+
+	pendingFiles := make([]trade_document.PendingBillOfLadingFile, 0, 3)
+	for i, version := range []int64{1, 1, 2} {
+		pendingFile := trade_document.PendingBillOfLadingFile{
+			Event: model.RelayEvent{
+				ID:          fmt.Sprintf("file-event%d", i),
+				RelayServer: "relay1",
+				Offset:      int64(i),
+				Type:        trade_document.BillOfLadingFileEventType,
+				Status:      model.RelayEventStatusPending,
+				PackID:      "pack1",
+				Version:     version,
+				CreatedAt:   ts,
+			},
+			EncryptedFile: []byte(fmt.Sprintf("encrypted file %d", i)),
+		}
+		s.Require().NoError(s.storage.StorePendingFile(s.ctx, tx, pendingFile))
+		pendingFiles = append(pendingFiles, pendingFile)
+	}
+
+	files, err := s.storage.ListPendingFiles(s.ctx, tx, "pack1", 1)
+	s.Require().NoError(err)
+	s.Assert().Equal(pendingFiles[:2], files)
+
+	s.Require().NoError(s.storage.DeletePendingFiles(s.ctx, tx, "pack1", 1))
+	files, err = s.storage.ListPendingFiles(s.ctx, tx, "pack1", 1)
+	s.Require().NoError(err)
+	s.Assert().Empty(files)
+	files, err = s.storage.ListPendingFiles(s.ctx, tx, "pack1", 2)
+	s.Require().NoError(err)
+	s.Assert().Equal(pendingFiles[2:], files)
+
+	s.Require().NoError(tx.Commit(s.ctx))
+}
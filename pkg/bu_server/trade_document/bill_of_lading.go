@@ -0,0 +1,418 @@
+// Package trade_document implements the lifecycle of trade documents like bill of lading.
+package trade_document
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/bill_of_lading_pdf"
+)
+
+// BillOfLadingStatus is the state of a bill of lading pack derived from its events.
+type BillOfLadingStatus string
+
+const (
+	BillOfLadingStatusIssued             BillOfLadingStatus = "issued"              // The bill of lading can be transferred by its current owner.
+	BillOfLadingStatusAmendmentRequested BillOfLadingStatus = "amendment_requested" // The bill of lading is held by its issuer until it is amended or returned.
+	BillOfLadingStatusSurrendered        BillOfLadingStatus = "surrendered"         // The bill of lading is surrendered to its issuer. It is final.
+	BillOfLadingStatusPrintedToPaper     BillOfLadingStatus = "printed_to_paper"    // The bill of lading continues its life on paper. It is final.
+)
+
+// BillOfLadingService applies events to bill of lading packs.
+//
+// Every function returns a new version of the pack and leaves the given one untouched.
+// The rules of transitions are:
+//   - Only the current owner can transfer, return, surrender, request amendment of or print the bill of lading.
+//   - A bill of lading can only be returned to the one who handed it to the current owner. Returns walk back the chain of owners.
+//   - When amendment is requested, the bill of lading is held by its issuer. The issuer can amend it or return it.
+//   - A surrendered or printed bill of lading is final. No event can be applied to it.
+type BillOfLadingService interface {
+	Issue(ts int64, req IssueBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error)
+	Transfer(ts int64, pack bill_of_lading.BillOfLadingPack, req TransferBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error)
+	Return(ts int64, pack bill_of_lading.BillOfLadingPack, req ReturnBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error)
+	Surrender(ts int64, pack bill_of_lading.BillOfLadingPack, req SurrenderBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error)
+	AmendmentRequest(ts int64, pack bill_of_lading.BillOfLadingPack, req AmendmentRequestBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error)
+	Amend(ts int64, pack bill_of_lading.BillOfLadingPack, req AmendBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error)
+	PrintToPaper(ts int64, pack bill_of_lading.BillOfLadingPack, req PrintToPaperBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error)
+}
+
+// IssueBillOfLadingRequest is the request to issue a new bill of lading pack.
+type IssueBillOfLadingRequest struct {
+	Issuer       string                            `json:"issuer"`         // DID of the issuer (usually the carrier).
+	TransferTo   string                            `json:"transfer_to"`    // DID of the first owner (usually the shipper).
+	BillOfLading *bill_of_lading.TransportDocument `json:"bill_of_lading"` // The bill of lading.
+	File         *model.File                       `json:"file"`           // The file of the bill of lading.
+}
+
+// TransferBillOfLadingRequest is the request to transfer a bill of lading to another business unit.
+type TransferBillOfLadingRequest struct {
+	Actor      string `json:"actor"`       // DID of the business unit who transfers the bill of lading.
+	TransferTo string `json:"transfer_to"` // DID of the new owner.
+	Note       string `json:"note"`
+}
+
+// ReturnBillOfLadingRequest is the request to return a bill of lading to its previous owner.
+type ReturnBillOfLadingRequest struct {
+	Actor string `json:"actor"` // DID of the business unit who returns the bill of lading.
+	Note  string `json:"note"`
+}
+
+// SurrenderBillOfLadingRequest is the request to surrender a bill of lading to its issuer.
+type SurrenderBillOfLadingRequest struct {
+	Actor string `json:"actor"` // DID of the business unit who surrenders the bill of lading.
+	Note  string `json:"note"`
+}
+
+// AmendmentRequestBillOfLadingRequest is the request to ask the issuer to amend a bill of lading.
+type AmendmentRequestBillOfLadingRequest struct {
+	Actor string `json:"actor"` // DID of the business unit who requests the amendment.
+	Note  string `json:"note"`
+}
+
+// AmendBillOfLadingRequest is the request to replace the bill of lading after its amendment is requested.
+type AmendBillOfLadingRequest struct {
+	Actor        string                            `json:"actor"`          // DID of the issuer.
+	BillOfLading *bill_of_lading.TransportDocument `json:"bill_of_lading"` // The amended bill of lading.
+	File         *model.File                       `json:"file"`           // The file of the amended bill of lading.
+}
+
+// PrintToPaperBillOfLadingRequest is the request to end the electronic life of a bill of lading by printing it.
+type PrintToPaperBillOfLadingRequest struct {
+	Actor string `json:"actor"` // DID of the business unit who prints the bill of lading.
+	Note  string `json:"note"`
+}
+
+type _BillOfLadingService struct{}
+
+func NewBillOfLadingService() *_BillOfLadingService {
+	return &_BillOfLadingService{}
+}
+
+func (s *_BillOfLadingService) Issue(ts int64, req IssueBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error) {
+	if err := ValidateIssueBillOfLadingRequest(req); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+
+	createdAt := newDateTime(ts)
+	pack := bill_of_lading.BillOfLadingPack{
+		ID:      uuid.NewString(),
+		Version: 1,
+		Events: []bill_of_lading.BillOfLadingEvent{
+			{
+				BillOfLading: &bill_of_lading.BillOfLading{
+					BillOfLading: req.BillOfLading,
+					File:         req.File,
+					TransferTo:   req.TransferTo,
+					CreatedBy:    req.Issuer,
+					CreatedAt:    &createdAt,
+				},
+			},
+		},
+		CurrentOwner: req.TransferTo,
+	}
+	return pack, nil
+}
+
+func (s *_BillOfLadingService) Transfer(ts int64, pack bill_of_lading.BillOfLadingPack, req TransferBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error) {
+	if err := ValidateTransferBillOfLadingRequest(req); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+	if err := checkOwnerAction(pack, req.Actor); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+	if req.TransferTo == pack.CurrentOwner {
+		return bill_of_lading.BillOfLadingPack{}, model.ErrBillOfLadingInvalidTransfer
+	}
+
+	transferAt := newDateTime(ts)
+	event := bill_of_lading.BillOfLadingEvent{
+		Transfer: &bill_of_lading.Transfer{
+			TransferBy: req.Actor,
+			TransferTo: req.TransferTo,
+			TransferAt: &transferAt,
+			Note:       req.Note,
+		},
+	}
+	return nextVersion(pack, event, req.TransferTo)
+}
+
+func (s *_BillOfLadingService) Return(ts int64, pack bill_of_lading.BillOfLadingPack, req ReturnBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error) {
+	if err := ValidateReturnBillOfLadingRequest(req); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+
+	// The issuer holding the bill of lading for amendment can decline it by returning.
+	status, err := GetBillOfLadingStatus(pack)
+	if err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+	if status == BillOfLadingStatusAmendmentRequested {
+		if req.Actor != pack.CurrentOwner {
+			return bill_of_lading.BillOfLadingPack{}, model.ErrBillOfLadingNotOwner
+		}
+	} else if err := checkOwnerAction(pack, req.Actor); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+
+	returnTo := GetPreviousOwner(pack)
+	if returnTo == "" || returnTo == req.Actor {
+		return bill_of_lading.BillOfLadingPack{}, model.ErrBillOfLadingInvalidReturn
+	}
+
+	returnAt := newDateTime(ts)
+	event := bill_of_lading.BillOfLadingEvent{
+		Return: &bill_of_lading.Return{
+			ReturnBy: req.Actor,
+			ReturnTo: returnTo,
+			ReturnAt: &returnAt,
+			Note:     req.Note,
+		},
+	}
+	return nextVersion(pack, event, returnTo)
+}
+
+func (s *_BillOfLadingService) Surrender(ts int64, pack bill_of_lading.BillOfLadingPack, req SurrenderBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error) {
+	if err := ValidateSurrenderBillOfLadingRequest(req); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+	if err := checkOwnerAction(pack, req.Actor); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+
+	surrenderAt := newDateTime(ts)
+	event := bill_of_lading.BillOfLadingEvent{
+		Surrender: &bill_of_lading.Surrender{
+			SurrenderBy: req.Actor,
+			SurrenderAt: &surrenderAt,
+			Note:        req.Note,
+		},
+	}
+	return nextVersion(pack, event, GetIssuer(pack))
+}
+
+func (s *_BillOfLadingService) AmendmentRequest(ts int64, pack bill_of_lading.BillOfLadingPack, req AmendmentRequestBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error) {
+	if err := ValidateAmendmentRequestBillOfLadingRequest(req); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+	if err := checkOwnerAction(pack, req.Actor); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+
+	requestAt := newDateTime(ts)
+	event := bill_of_lading.BillOfLadingEvent{
+		AmendmentRequest: &bill_of_lading.AmendmentRequest{
+			RequestBy: req.Actor,
+			RequestAt: &requestAt,
+			Note:      req.Note,
+		},
+	}
+	return nextVersion(pack, event, GetIssuer(pack))
+}
+
+func (s *_BillOfLadingService) Amend(ts int64, pack bill_of_lading.BillOfLadingPack, req AmendBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error) {
+	if err := ValidateAmendBillOfLadingRequest(req); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+	status, err := GetBillOfLadingStatus(pack)
+	if err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+	if status != BillOfLadingStatusAmendmentRequested {
+		return bill_of_lading.BillOfLadingPack{}, model.ErrBillOfLadingAmendmentNotRequested
+	}
+	if req.Actor != GetIssuer(pack) {
+		return bill_of_lading.BillOfLadingPack{}, model.ErrBillOfLadingNotIssuer
+	}
+
+	// The amended bill of lading goes back to the one who requested the amendment.
+	requester := pack.Events[len(pack.Events)-1].AmendmentRequest.RequestBy
+	createdAt := newDateTime(ts)
+	event := bill_of_lading.BillOfLadingEvent{
+		BillOfLading: &bill_of_lading.BillOfLading{
+			BillOfLading: req.BillOfLading,
+			File:         req.File,
+			TransferTo:   requester,
+			CreatedBy:    req.Actor,
+			CreatedAt:    &createdAt,
+		},
+	}
+	return nextVersion(pack, event, requester)
+}
+
+func (s *_BillOfLadingService) PrintToPaper(ts int64, pack bill_of_lading.BillOfLadingPack, req PrintToPaperBillOfLadingRequest) (bill_of_lading.BillOfLadingPack, error) {
+	if err := ValidatePrintToPaperBillOfLadingRequest(req); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+	if err := checkOwnerAction(pack, req.Actor); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+
+	file, err := renderPaperDocument(ts, pack, req.Actor)
+	if err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+
+	printAt := newDateTime(ts)
+	event := bill_of_lading.BillOfLadingEvent{
+		PrintToPaper: &bill_of_lading.PrintToPaper{
+			PrintBy: req.Actor,
+			PrintAt: &printAt,
+			Note:    req.Note,
+			File:    file,
+		},
+	}
+	return nextVersion(pack, event, req.Actor)
+}
+
+// GetBillOfLadingStatus derives the status of the pack from its last event.
+func GetBillOfLadingStatus(pack bill_of_lading.BillOfLadingPack) (BillOfLadingStatus, error) {
+	if len(pack.Events) == 0 || pack.Events[0].BillOfLading == nil {
+		return "", model.ErrBillOfLadingNotIssued
+	}
+
+	lastEvent := pack.Events[len(pack.Events)-1]
+	switch {
+	case lastEvent.Surrender != nil:
+		return BillOfLadingStatusSurrendered, nil
+	case lastEvent.PrintToPaper != nil:
+		return BillOfLadingStatusPrintedToPaper, nil
+	case lastEvent.AmendmentRequest != nil:
+		return BillOfLadingStatusAmendmentRequested, nil
+	}
+	return BillOfLadingStatusIssued, nil
+}
+
+// GetIssuer returns DID of the issuer of the pack.
+func GetIssuer(pack bill_of_lading.BillOfLadingPack) string {
+	if len(pack.Events) == 0 || pack.Events[0].BillOfLading == nil {
+		return ""
+	}
+	return pack.Events[0].BillOfLading.CreatedBy
+}
+
+// GetTransportDocument returns the latest bill of lading in the pack.
+func GetTransportDocument(pack bill_of_lading.BillOfLadingPack) *bill_of_lading.TransportDocument {
+	for i := len(pack.Events) - 1; i >= 0; i-- {
+		if bl := pack.Events[i].BillOfLading; bl != nil && bl.BillOfLading != nil {
+			return bl.BillOfLading
+		}
+	}
+	return nil
+}
+
+// GetDocumentReference returns the transport document reference of the latest bill of lading in the pack.
+func GetDocumentReference(pack bill_of_lading.BillOfLadingPack) string {
+	if td := GetTransportDocument(pack); td != nil {
+		return td.TransportDocumentReference
+	}
+	return ""
+}
+
+// GetPreviousOwner returns DID of the business unit who the current owner of the pack can return it to.
+// Every return undoes the latest hand-over which is not undone yet, so returning repeatedly walks the
+// bill of lading back to its issuer.
+func GetPreviousOwner(pack bill_of_lading.BillOfLadingPack) string {
+	owners := make([]string, 0, len(pack.Events)+1)
+	for i, event := range pack.Events {
+		switch {
+		case event.BillOfLading != nil && i == 0:
+			owners = append(owners, event.BillOfLading.CreatedBy, event.BillOfLading.TransferTo)
+		case event.BillOfLading != nil:
+			// The amended bill of lading goes back to the requester like the amendment request is returned.
+			owners = owners[:len(owners)-1]
+		case event.Transfer != nil:
+			owners = append(owners, event.Transfer.TransferTo)
+		case event.Return != nil:
+			owners = owners[:len(owners)-1]
+		case event.AmendmentRequest != nil, event.Surrender != nil:
+			owners = append(owners, GetIssuer(pack))
+		}
+		if len(owners) == 0 {
+			return ""
+		}
+	}
+
+	if len(owners) < 2 {
+		return ""
+	}
+	return owners[len(owners)-2]
+}
+
+// checkOwnerAction checks if the actor can apply an event to the pack as its current owner.
+func checkOwnerAction(pack bill_of_lading.BillOfLadingPack, actor string) error {
+	status, err := GetBillOfLadingStatus(pack)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case BillOfLadingStatusSurrendered:
+		return model.ErrBillOfLadingSurrendered
+	case BillOfLadingStatusPrintedToPaper:
+		return model.ErrBillOfLadingPrintedToPaper
+	case BillOfLadingStatusAmendmentRequested:
+		return model.ErrBillOfLadingAmendmentRequested
+	}
+
+	if actor != pack.CurrentOwner {
+		return model.ErrBillOfLadingNotOwner
+	}
+	return nil
+}
+
+// nextVersion appends the event to the pack and chains the new version to the given one with ParentHash.
+func nextVersion(pack bill_of_lading.BillOfLadingPack, event bill_of_lading.BillOfLadingEvent, owner string) (bill_of_lading.BillOfLadingPack, error) {
+	parentHash, err := GetBillOfLadingPackHash(pack)
+	if err != nil {
+		return bill_of_lading.BillOfLadingPack{}, err
+	}
+
+	events := make([]bill_of_lading.BillOfLadingEvent, 0, len(pack.Events)+1)
+	events = append(events, pack.Events...)
+	events = append(events, event)
+
+	pack.Events = events
+	pack.Version++
+	pack.ParentHash = parentHash
+	pack.CurrentOwner = owner
+	return pack, nil
+}
+
+// renderPaperDocument renders the pack as it is before being printed to paper. The QR code on the paper carries the
+// hash of that version, which is the parent hash of the version with the PrintToPaper event.
+func renderPaperDocument(ts int64, pack bill_of_lading.BillOfLadingPack, printBy string) (*model.File, error) {
+	packHash, err := GetBillOfLadingPackHash(pack)
+	if err != nil {
+		return nil, err
+	}
+
+	printAt := time.Unix(ts, 0).UTC()
+	content, err := bill_of_lading_pdf.Render(bill_of_lading_pdf.Document{
+		PackID:            pack.ID,
+		PackVersion:       pack.Version,
+		PackHash:          packHash,
+		TransportDocument: GetTransportDocument(pack),
+		Events:            pack.Events,
+		PrintedBy:         printBy,
+		PrintedAt:         printAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	name := GetDocumentReference(pack)
+	if name == "" {
+		name = pack.ID
+	}
+	return &model.File{
+		Name:        name + ".pdf",
+		Content:     content,
+		CreatedDate: model.NewDateTime(printAt),
+	}, nil
+}
+
+func newDateTime(ts int64) model.DateTime {
+	return model.NewDateTime(time.Unix(ts, 0).UTC())
+}
@@ -0,0 +1,531 @@
+package trade_document
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+
+	"github.com/openebl/openebl/pkg/bu_server/blob_store"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+)
+
+// BillOfLadingController lets applications manage bill of lading packs on behalf of their business units.
+//
+// Every change is applied to the latest version of the pack, signed by the acting business unit, stored and written
+// to the outbox for its parties in one transaction. Actions carry the version they are based on. An action based on
+// an outdated version fails with model.ErrBillOfLadingVersionConflict instead of overwriting the change of others.
+//
+// With a blob store, the content of files attached to the pack is moved to the blob store before the pack is signed,
+// so the pack only references the content by its hash.
+type BillOfLadingController interface {
+	Create(ctx context.Context, ts int64, req CreateBillOfLadingRequest) (BillOfLadingRecord, error)
+	List(ctx context.Context, req ListBillOfLadingRequest) (ListBillOfLadingResult, error)
+	Get(ctx context.Context, req GetBillOfLadingRequest) (BillOfLadingRecord, error)
+	GetHistory(ctx context.Context, req GetBillOfLadingRequest) ([]BillOfLadingRecord, error)
+	Transfer(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error)
+	Return(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error)
+	Surrender(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error)
+	AmendmentRequest(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error)
+	Amend(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error)
+	PrintToPaper(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error)
+	GetFile(ctx context.Context, req GetBillOfLadingFileRequest) (model.File, error)
+}
+
+// BillOfLadingRecord is a version of a bill of lading pack with its status.
+type BillOfLadingRecord struct {
+	Pack      bill_of_lading.BillOfLadingPack `json:"pack"`       // The bill of lading pack.
+	Status    BillOfLadingStatus              `json:"status"`     // Status of the bill of lading in the pack.
+	CreatedAt int64                           `json:"created_at"` // Unix Time (in second) when the version is stored.
+}
+
+// CreateBillOfLadingRequest is the request to issue a bill of lading.
+type CreateBillOfLadingRequest struct {
+	ApplicationID string `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string `json:"business_unit"`  // DID of the issuer.
+
+	TransferTo   string                            `json:"transfer_to"`    // DID of the first owner (usually the shipper).
+	BillOfLading *bill_of_lading.TransportDocument `json:"bill_of_lading"` // The bill of lading.
+	File         *model.File                       `json:"file"`           // The file of the bill of lading.
+}
+
+// ListBillOfLadingRequest is the request to list bill of lading packs of a business unit.
+type ListBillOfLadingRequest struct {
+	Offset int `json:"offset"` // Offset of the packs to be listed.
+	Limit  int `json:"limit"`  // Limit of the packs to be listed.
+
+	ApplicationID string          `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string          `json:"business_unit"`  // DID of the business unit.
+	Box           BillOfLadingBox `json:"box"`            // How the business unit is involved in the packs.
+
+	// Filters
+	DocReference string             `json:"doc_reference"` // Transport document reference of the bill of lading.
+	Status       BillOfLadingStatus `json:"status"`        // Status of the bill of lading.
+}
+
+// ListBillOfLadingResult is the result of listing bill of lading packs.
+type ListBillOfLadingResult struct {
+	Total   int                  `json:"total"`   // Total number of packs.
+	Records []BillOfLadingRecord `json:"records"` // The latest versions of the packs.
+}
+
+// GetBillOfLadingRequest is the request to get a bill of lading pack the business unit is a party of.
+type GetBillOfLadingRequest struct {
+	ApplicationID string `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string `json:"business_unit"`  // DID of the business unit.
+	ID            string `json:"id"`             // ID of the pack.
+}
+
+// GetBillOfLadingFileRequest is the request to get the content of a file attached to a bill of lading pack.
+type GetBillOfLadingFileRequest struct {
+	ApplicationID string `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string `json:"business_unit"`  // DID of the business unit.
+	ID            string `json:"id"`             // ID of the pack.
+	Hash          string `json:"hash"`           // Hex encoded SHA256 hash of the content of the file.
+}
+
+// BillOfLadingActionRequest is the request of a business unit to apply an event to a bill of lading pack.
+type BillOfLadingActionRequest struct {
+	ApplicationID string `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string `json:"business_unit"`  // DID of the acting business unit.
+	ID            string `json:"id"`             // ID of the pack.
+	Version       int64  `json:"version"`        // The latest version of the pack known to the business unit.
+
+	TransferTo   string                            `json:"transfer_to"`    // DID of the new owner. Transfer only.
+	BillOfLading *bill_of_lading.TransportDocument `json:"bill_of_lading"` // The amended bill of lading. Amend only.
+	File         *model.File                       `json:"file"`           // The file of the amended bill of lading. Amend only.
+	Note         string                            `json:"note"`
+}
+
+type _BillOfLadingController struct {
+	buStorage business_unit.BusinessUnitStorage
+	storage   TradeDocumentStorage
+	service   BillOfLadingService
+	signer    BillOfLadingPackSigner
+	publisher BillOfLadingPublisher
+	blobStore blob_store.BlobStore
+}
+
+type BillOfLadingControllerOption func(c *_BillOfLadingController)
+
+// WithBillOfLadingBlobStore sets the blob store keeping the content of files attached to packs.
+// Without a blob store, the content is kept in the packs.
+func WithBillOfLadingBlobStore(blobStore blob_store.BlobStore) BillOfLadingControllerOption {
+	return func(c *_BillOfLadingController) {
+		c.blobStore = blobStore
+	}
+}
+
+func NewBillOfLadingController(
+	buStorage business_unit.BusinessUnitStorage,
+	storage TradeDocumentStorage,
+	service BillOfLadingService,
+	signer BillOfLadingPackSigner,
+	publisher BillOfLadingPublisher,
+	options ...BillOfLadingControllerOption,
+) *_BillOfLadingController {
+	c := &_BillOfLadingController{
+		buStorage: buStorage,
+		storage:   storage,
+		service:   service,
+		signer:    signer,
+		publisher: publisher,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+func (c *_BillOfLadingController) Create(ctx context.Context, ts int64, req CreateBillOfLadingRequest) (BillOfLadingRecord, error) {
+	if err := ValidateCreateBillOfLadingRequest(req); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+
+	pack, err := c.service.Issue(ts, IssueBillOfLadingRequest{
+		Issuer:       req.BusinessUnit,
+		TransferTo:   req.TransferTo,
+		BillOfLading: req.BillOfLading,
+		File:         req.File,
+	})
+	if err != nil {
+		return BillOfLadingRecord{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx, storage.TxOptionWithWrite(true), storage.TxOptionWithIsolationLevel(sql.LevelSerializable))
+	if err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	return c.storeAndPublish(ctx, tx, ts, pack)
+}
+
+func (c *_BillOfLadingController) List(ctx context.Context, req ListBillOfLadingRequest) (ListBillOfLadingResult, error) {
+	if err := ValidateListBillOfLadingRequest(req); err != nil {
+		return ListBillOfLadingResult{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return ListBillOfLadingResult{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx)
+	if err != nil {
+		return ListBillOfLadingResult{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	listReq := ListBillOfLadingPacksRequest{
+		Offset:       req.Offset,
+		Limit:        req.Limit,
+		BusinessUnit: req.BusinessUnit,
+		Box:          req.Box,
+		DocReference: req.DocReference,
+	}
+	if req.Status != "" {
+		listReq.Statuses = []BillOfLadingStatus{req.Status}
+	}
+	listResult, err := c.storage.ListBillOfLadingPacks(ctx, tx, listReq)
+	if err != nil {
+		return ListBillOfLadingResult{}, err
+	}
+
+	result := ListBillOfLadingResult{Total: listResult.Total}
+	for _, packRecord := range listResult.Records {
+		record, err := newBillOfLadingRecord(packRecord)
+		if err != nil {
+			return ListBillOfLadingResult{}, err
+		}
+		result.Records = append(result.Records, record)
+	}
+	return result, nil
+}
+
+func (c *_BillOfLadingController) Get(ctx context.Context, req GetBillOfLadingRequest) (BillOfLadingRecord, error) {
+	if err := ValidateGetBillOfLadingRequest(req); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx)
+	if err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	packRecord, err := c.getPack(ctx, tx, req.BusinessUnit, req.ID)
+	if err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	return newBillOfLadingRecord(packRecord)
+}
+
+// GetHistory returns all versions of the pack known to this BU server in ascending order of version.
+func (c *_BillOfLadingController) GetHistory(ctx context.Context, req GetBillOfLadingRequest) ([]BillOfLadingRecord, error) {
+	if err := ValidateGetBillOfLadingRequest(req); err != nil {
+		return nil, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return nil, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := c.getPack(ctx, tx, req.BusinessUnit, req.ID); err != nil {
+		return nil, err
+	}
+	history, err := c.storage.GetBillOfLadingPackHistory(ctx, tx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]BillOfLadingRecord, 0, len(history))
+	for _, packRecord := range history {
+		record, err := newBillOfLadingRecord(packRecord)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (c *_BillOfLadingController) Transfer(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error) {
+	if err := ValidateTransferBillOfLadingActionRequest(req); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	return c.act(ctx, ts, req, func(pack bill_of_lading.BillOfLadingPack) (bill_of_lading.BillOfLadingPack, error) {
+		return c.service.Transfer(ts, pack, TransferBillOfLadingRequest{Actor: req.BusinessUnit, TransferTo: req.TransferTo, Note: req.Note})
+	})
+}
+
+func (c *_BillOfLadingController) Return(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error) {
+	if err := ValidateBillOfLadingActionRequest(req); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	return c.act(ctx, ts, req, func(pack bill_of_lading.BillOfLadingPack) (bill_of_lading.BillOfLadingPack, error) {
+		return c.service.Return(ts, pack, ReturnBillOfLadingRequest{Actor: req.BusinessUnit, Note: req.Note})
+	})
+}
+
+func (c *_BillOfLadingController) Surrender(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error) {
+	if err := ValidateBillOfLadingActionRequest(req); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	return c.act(ctx, ts, req, func(pack bill_of_lading.BillOfLadingPack) (bill_of_lading.BillOfLadingPack, error) {
+		return c.service.Surrender(ts, pack, SurrenderBillOfLadingRequest{Actor: req.BusinessUnit, Note: req.Note})
+	})
+}
+
+func (c *_BillOfLadingController) AmendmentRequest(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error) {
+	if err := ValidateBillOfLadingActionRequest(req); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	return c.act(ctx, ts, req, func(pack bill_of_lading.BillOfLadingPack) (bill_of_lading.BillOfLadingPack, error) {
+		return c.service.AmendmentRequest(ts, pack, AmendmentRequestBillOfLadingRequest{Actor: req.BusinessUnit, Note: req.Note})
+	})
+}
+
+func (c *_BillOfLadingController) Amend(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error) {
+	if err := ValidateAmendBillOfLadingActionRequest(req); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	return c.act(ctx, ts, req, func(pack bill_of_lading.BillOfLadingPack) (bill_of_lading.BillOfLadingPack, error) {
+		return c.service.Amend(ts, pack, AmendBillOfLadingRequest{Actor: req.BusinessUnit, BillOfLading: req.BillOfLading, File: req.File})
+	})
+}
+
+func (c *_BillOfLadingController) PrintToPaper(ctx context.Context, ts int64, req BillOfLadingActionRequest) (BillOfLadingRecord, error) {
+	if err := ValidateBillOfLadingActionRequest(req); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	return c.act(ctx, ts, req, func(pack bill_of_lading.BillOfLadingPack) (bill_of_lading.BillOfLadingPack, error) {
+		return c.service.PrintToPaper(ts, pack, PrintToPaperBillOfLadingRequest{Actor: req.BusinessUnit, Note: req.Note})
+	})
+}
+
+// GetFile returns the file with the hash attached to any version of the pack, with its content.
+func (c *_BillOfLadingController) GetFile(ctx context.Context, req GetBillOfLadingFileRequest) (model.File, error) {
+	if err := ValidateGetBillOfLadingFileRequest(req); err != nil {
+		return model.File{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return model.File{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx)
+	if err != nil {
+		return model.File{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := c.getPack(ctx, tx, req.BusinessUnit, req.ID); err != nil {
+		return model.File{}, err
+	}
+	history, err := c.storage.GetBillOfLadingPackHistory(ctx, tx, req.ID)
+	if err != nil {
+		return model.File{}, err
+	}
+
+	for _, record := range history {
+		for _, file := range packFiles(record.Pack) {
+			if fileHash(*file) != req.Hash {
+				continue
+			}
+			if len(file.Content) > 0 {
+				return *file, nil
+			}
+			if c.blobStore == nil {
+				return model.File{}, model.ErrFileNotFound
+			}
+			content, err := c.blobStore.Get(ctx, req.Hash)
+			if err != nil {
+				return model.File{}, err
+			}
+			result := *file
+			result.Content = content
+			return result, nil
+		}
+	}
+	return model.File{}, model.ErrFileNotFound
+}
+
+// act applies the event made by apply to the latest version of the pack if it is still req.Version.
+func (c *_BillOfLadingController) act(
+	ctx context.Context,
+	ts int64,
+	req BillOfLadingActionRequest,
+	apply func(pack bill_of_lading.BillOfLadingPack) (bill_of_lading.BillOfLadingPack, error),
+) (BillOfLadingRecord, error) {
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx, storage.TxOptionWithWrite(true), storage.TxOptionWithIsolationLevel(sql.LevelSerializable))
+	if err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	packRecord, err := c.getPack(ctx, tx, req.BusinessUnit, req.ID)
+	if err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	if packRecord.Pack.Version != req.Version {
+		return BillOfLadingRecord{}, model.ErrBillOfLadingVersionConflict
+	}
+
+	pack, err := apply(packRecord.Pack)
+	if err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	return c.storeAndPublish(ctx, tx, ts, pack)
+}
+
+// storeAndPublish signs the pack, writes it to the outbox and stores it with its envelopes in tx, then commits tx.
+func (c *_BillOfLadingController) storeAndPublish(ctx context.Context, tx storage.Tx, ts int64, pack bill_of_lading.BillOfLadingPack) (BillOfLadingRecord, error) {
+	if err := c.storeFiles(ctx, pack); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+
+	signedPack, err := c.signer.Sign(ctx, ts, pack)
+	if err != nil {
+		return BillOfLadingRecord{}, err
+	}
+
+	encryptedPack, _, err := c.publisher.Publish(ctx, tx, ts, signedPack)
+	if err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	packRecord := BillOfLadingPackRecord{
+		Pack:          pack,
+		SignedPack:    signedPack,
+		EncryptedPack: encryptedPack,
+		CreatedAt:     ts,
+	}
+	if err := c.storage.StoreBillOfLadingPack(ctx, tx, packRecord); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	return newBillOfLadingRecord(packRecord)
+}
+
+// storeFiles moves the content of the file attached by the latest event of the pack to the blob store.
+// Files of earlier events are already stored by their own versions.
+func (c *_BillOfLadingController) storeFiles(ctx context.Context, pack bill_of_lading.BillOfLadingPack) error {
+	if c.blobStore == nil || len(pack.Events) == 0 {
+		return nil
+	}
+
+	event := pack.Events[len(pack.Events)-1]
+	var file **model.File
+	switch {
+	case event.BillOfLading != nil:
+		file = &event.BillOfLading.File
+	case event.PrintToPaper != nil:
+		file = &event.PrintToPaper.File
+	}
+	if file == nil || *file == nil {
+		return nil
+	}
+
+	stored, err := c.blobStore.Put(ctx, **file)
+	if err != nil {
+		return err
+	}
+	*file = &stored
+	return nil
+}
+
+// getPack returns the latest version of the pack if the business unit is a party of it.
+func (c *_BillOfLadingController) getPack(ctx context.Context, tx storage.Tx, businessUnit string, packID string) (BillOfLadingPackRecord, error) {
+	listReq := ListBillOfLadingPacksRequest{
+		Limit:        1,
+		PackIDs:      []string{packID},
+		BusinessUnit: businessUnit,
+	}
+	result, err := c.storage.ListBillOfLadingPacks(ctx, tx, listReq)
+	if err != nil {
+		return BillOfLadingPackRecord{}, err
+	}
+	if len(result.Records) == 0 {
+		return BillOfLadingPackRecord{}, model.ErrBillOfLadingNotFound
+	}
+	return result.Records[0], nil
+}
+
+// checkBusinessUnit checks the business unit belongs to the application and is active.
+func checkBusinessUnit(ctx context.Context, buStorage business_unit.BusinessUnitStorage, applicationID string, businessUnit string) error {
+	tx, err := buStorage.CreateTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	listReq := business_unit.ListBusinessUnitsRequest{
+		Limit:           1,
+		ApplicationID:   applicationID,
+		BusinessUnitIDs: []string{businessUnit},
+	}
+	result, err := buStorage.ListBusinessUnits(ctx, tx, listReq)
+	if err != nil {
+		return err
+	}
+	if len(result.Records) == 0 {
+		return model.ErrBusinessUnitNotFound
+	}
+	if result.Records[0].BusinessUnit.Status != model.BusinessUnitStatusActive {
+		return model.ErrBusinessUnitInactive
+	}
+	return nil
+}
+
+func newBillOfLadingRecord(packRecord BillOfLadingPackRecord) (BillOfLadingRecord, error) {
+	status, err := GetBillOfLadingStatus(packRecord.Pack)
+	if err != nil {
+		return BillOfLadingRecord{}, err
+	}
+	return BillOfLadingRecord{
+		Pack:      packRecord.Pack,
+		Status:    status,
+		CreatedAt: packRecord.CreatedAt,
+	}, nil
+}
+
+// packFiles returns files attached by events of the pack.
+func packFiles(pack bill_of_lading.BillOfLadingPack) []*model.File {
+	var files []*model.File
+	for _, event := range pack.Events {
+		switch {
+		case event.BillOfLading != nil && event.BillOfLading.File != nil:
+			files = append(files, event.BillOfLading.File)
+		case event.PrintToPaper != nil && event.PrintToPaper.File != nil:
+			files = append(files, event.PrintToPaper.File)
+		}
+	}
+	return files
+}
+
+// fileHash returns the hash of the content of the file. Files kept in packs before the blob store have no hash.
+func fileHash(file model.File) string {
+	if file.Hash != "" || len(file.Content) == 0 {
+		return file.Hash
+	}
+	hash := sha256.Sum256(file.Content)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,404 @@
+package trade_document_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/dcsa_validator"
+	"github.com/openebl/openebl/pkg/envelope"
+	mock_blob_store "github.com/openebl/openebl/test/mock/bu_server/blob_store"
+	mock_business_unit "github.com/openebl/openebl/test/mock/bu_server/business_unit"
+	mock_storage "github.com/openebl/openebl/test/mock/bu_server/storage"
+	mock_trade_document "github.com/openebl/openebl/test/mock/bu_server/trade_document"
+	"github.com/stretchr/testify/suite"
+)
+
+type BillOfLadingControllerTestSuite struct {
+	suite.Suite
+	ctx        context.Context
+	ctrl       *gomock.Controller
+	buStorage  *mock_business_unit.MockBusinessUnitStorage
+	storage    *mock_trade_document.MockTradeDocumentStorage
+	signer     *mock_trade_document.MockBillOfLadingPackSigner
+	publisher  *mock_trade_document.MockBillOfLadingPublisher
+	blobStore  *mock_blob_store.MockBlobStore
+	tx         *mock_storage.MockTx
+	controller trade_document.BillOfLadingController
+
+	ts            int64
+	appID         string
+	signedPack    envelope.JWS
+	encryptedPack envelope.JWE
+	issued        trade_document.BillOfLadingPackRecord
+}
+
+func TestBillOfLadingController(t *testing.T) {
+	suite.Run(t, new(BillOfLadingControllerTestSuite))
+}
+
+func (s *BillOfLadingControllerTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.ctrl = gomock.NewController(s.T())
+	s.buStorage = mock_business_unit.NewMockBusinessUnitStorage(s.ctrl)
+	s.storage = mock_trade_document.NewMockTradeDocumentStorage(s.ctrl)
+	s.signer = mock_trade_document.NewMockBillOfLadingPackSigner(s.ctrl)
+	s.publisher = mock_trade_document.NewMockBillOfLadingPublisher(s.ctrl)
+	s.blobStore = mock_blob_store.NewMockBlobStore(s.ctrl)
+	s.tx = mock_storage.NewMockTx(s.ctrl)
+	s.controller = trade_document.NewBillOfLadingController(
+		s.buStorage,
+		s.storage,
+		trade_document.NewBillOfLadingService(),
+		s.signer,
+		s.publisher,
+		trade_document.WithBillOfLadingBlobStore(s.blobStore),
+	)
+
+	s.ts = time.Now().Unix()
+	s.appID = "app"
+	s.signedPack = envelope.JWS{Payload: "signed pack"}
+	s.encryptedPack = envelope.JWE{Ciphertext: "encrypted pack"}
+
+	pack, err := trade_document.NewBillOfLadingService().Issue(s.ts, trade_document.IssueBillOfLadingRequest{
+		Issuer:       carrier,
+		TransferTo:   shipper,
+		BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+	})
+	s.Require().NoError(err)
+	s.issued = trade_document.BillOfLadingPackRecord{Pack: pack, CreatedAt: s.ts}
+}
+
+func (s *BillOfLadingControllerTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *BillOfLadingControllerTestSuite) expectBusinessUnit(businessUnit string, status model.BusinessUnitStatus) {
+	gomock.InOrder(
+		s.buStorage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.buStorage.EXPECT().ListBusinessUnits(
+			gomock.Any(),
+			s.tx,
+			business_unit.ListBusinessUnitsRequest{Limit: 1, ApplicationID: s.appID, BusinessUnitIDs: []string{businessUnit}},
+		).Return(
+			business_unit.ListBusinessUnitsResult{
+				Total:   1,
+				Records: []business_unit.ListBusinessUnitsRecord{{BusinessUnit: model.BusinessUnit{ID: did.MustParseDID(businessUnit), Status: status}}},
+			},
+			nil,
+		),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+}
+
+func (s *BillOfLadingControllerTestSuite) expectGetPack(businessUnit string, records ...trade_document.BillOfLadingPackRecord) *gomock.Call {
+	return s.storage.EXPECT().ListBillOfLadingPacks(
+		gomock.Any(),
+		s.tx,
+		trade_document.ListBillOfLadingPacksRequest{Limit: 1, PackIDs: []string{s.issued.Pack.ID}, BusinessUnit: businessUnit},
+	).Return(trade_document.ListBillOfLadingPacksResult{Total: len(records), Records: records}, nil)
+}
+
+// expectStoreAndPublish expects the pack to be signed, published and stored in the same transaction.
+func (s *BillOfLadingControllerTestSuite) expectStoreAndPublish(check func(pack bill_of_lading.BillOfLadingPack)) []*gomock.Call {
+	return []*gomock.Call{
+		s.signer.EXPECT().Sign(gomock.Any(), s.ts, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, ts int64, pack bill_of_lading.BillOfLadingPack) (envelope.JWS, error) {
+				check(pack)
+				return s.signedPack, nil
+			},
+		),
+		s.publisher.EXPECT().Publish(gomock.Any(), s.tx, s.ts, s.signedPack).Return(&s.encryptedPack, model.BillOfLadingDelivery{}, nil),
+		s.storage.EXPECT().StoreBillOfLadingPack(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, record trade_document.BillOfLadingPackRecord) error {
+				check(record.Pack)
+				s.Assert().Equal(s.signedPack, record.SignedPack)
+				s.Assert().Equal(&s.encryptedPack, record.EncryptedPack)
+				return nil
+			},
+		),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	}
+}
+
+func (s *BillOfLadingControllerTestSuite) TestCreate() {
+	req := trade_document.CreateBillOfLadingRequest{
+		ApplicationID: s.appID,
+		BusinessUnit:  carrier,
+		TransferTo:    shipper,
+		BillOfLading:  dcsaTransportDocument("bl-number"),
+	}
+
+	s.expectBusinessUnit(carrier, model.BusinessUnitStatusActive)
+	calls := []*gomock.Call{s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil)}
+	calls = append(calls, s.expectStoreAndPublish(func(pack bill_of_lading.BillOfLadingPack) {
+		s.Assert().EqualValues(1, pack.Version)
+		s.Assert().Equal(shipper, pack.CurrentOwner)
+		s.Assert().Equal(carrier, trade_document.GetIssuer(pack))
+	})...)
+	gomock.InOrder(calls...)
+
+	record, err := s.controller.Create(s.ctx, s.ts, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(trade_document.BillOfLadingStatusIssued, record.Status)
+	s.Assert().Equal(shipper, record.Pack.CurrentOwner)
+	s.Assert().Equal(s.ts, record.CreatedAt)
+
+	// The issuer must be an active business unit of the application.
+	s.expectBusinessUnit(carrier, model.BusinessUnitStatusInactive)
+	_, err = s.controller.Create(s.ctx, s.ts, req)
+	s.Assert().ErrorIs(err, model.ErrBusinessUnitInactive)
+
+	req.BillOfLading = nil
+	_, err = s.controller.Create(s.ctx, s.ts, req)
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+
+	// The bill of lading must follow DCSA rules.
+	req.BillOfLading = dcsaTransportDocument("bl-number")
+	req.BillOfLading.UniversalServiceReference = "SR1234"
+	_, err = s.controller.Create(s.ctx, s.ts, req)
+	var validationErrs dcsa_validator.ValidationErrors
+	s.Require().ErrorAs(err, &validationErrs)
+	s.Assert().Equal("/universalServiceReference", validationErrs[0].Pointer)
+}
+
+func (s *BillOfLadingControllerTestSuite) TestCreateWithFile() {
+	content := []byte("%PDF-1.3\n")
+	stored := model.File{Name: "bl.pdf", Hash: fileHash(content), Size: int64(len(content)), MediaType: "application/pdf"}
+	req := trade_document.CreateBillOfLadingRequest{
+		ApplicationID: s.appID,
+		BusinessUnit:  carrier,
+		TransferTo:    shipper,
+		BillOfLading:  dcsaTransportDocument("bl-number"),
+		File:          &model.File{Name: "bl.pdf", Content: content},
+	}
+
+	s.expectBusinessUnit(carrier, model.BusinessUnitStatusActive)
+	calls := []*gomock.Call{
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.blobStore.EXPECT().Put(gomock.Any(), *req.File).Return(stored, nil),
+	}
+	calls = append(calls, s.expectStoreAndPublish(func(pack bill_of_lading.BillOfLadingPack) {
+		// Only the reference to the content is signed.
+		s.Assert().Equal(&stored, pack.Events[0].BillOfLading.File)
+	})...)
+	gomock.InOrder(calls...)
+
+	record, err := s.controller.Create(s.ctx, s.ts, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(&stored, record.Pack.Events[0].BillOfLading.File)
+
+	// The file is rejected by the blob store.
+	s.expectBusinessUnit(carrier, model.BusinessUnitStatusActive)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.blobStore.EXPECT().Put(gomock.Any(), *req.File).Return(model.File{}, model.ErrInvalidParameter),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	_, err = s.controller.Create(s.ctx, s.ts, req)
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+}
+
+func (s *BillOfLadingControllerTestSuite) TestTransfer() {
+	req := trade_document.BillOfLadingActionRequest{
+		ApplicationID: s.appID,
+		BusinessUnit:  shipper,
+		ID:            s.issued.Pack.ID,
+		Version:       1,
+		TransferTo:    bank,
+		Note:          "to the bank",
+	}
+
+	s.expectBusinessUnit(shipper, model.BusinessUnitStatusActive)
+	calls := []*gomock.Call{
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.expectGetPack(shipper, s.issued),
+	}
+	calls = append(calls, s.expectStoreAndPublish(func(pack bill_of_lading.BillOfLadingPack) {
+		s.Assert().EqualValues(2, pack.Version)
+		s.Assert().Equal(bank, pack.CurrentOwner)
+	})...)
+	gomock.InOrder(calls...)
+
+	record, err := s.controller.Transfer(s.ctx, s.ts, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(bank, record.Pack.CurrentOwner)
+	s.Assert().Equal("to the bank", record.Pack.Events[1].Transfer.Note)
+}
+
+func (s *BillOfLadingControllerTestSuite) TestActionOnOutdatedVersion() {
+	req := trade_document.BillOfLadingActionRequest{
+		ApplicationID: s.appID,
+		BusinessUnit:  shipper,
+		ID:            s.issued.Pack.ID,
+		Version:       1,
+	}
+	latest := s.issued
+	latest.Pack.Version = 2
+
+	s.expectBusinessUnit(shipper, model.BusinessUnitStatusActive)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.expectGetPack(shipper, latest),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	_, err := s.controller.Surrender(s.ctx, s.ts, req)
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingVersionConflict)
+}
+
+func (s *BillOfLadingControllerTestSuite) TestActionOfNonOwner() {
+	req := trade_document.BillOfLadingActionRequest{
+		ApplicationID: s.appID,
+		BusinessUnit:  carrier,
+		ID:            s.issued.Pack.ID,
+		Version:       1,
+	}
+
+	s.expectBusinessUnit(carrier, model.BusinessUnitStatusActive)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.expectGetPack(carrier, s.issued),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	_, err := s.controller.PrintToPaper(s.ctx, s.ts, req)
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingNotOwner)
+}
+
+func (s *BillOfLadingControllerTestSuite) TestGet() {
+	req := trade_document.GetBillOfLadingRequest{ApplicationID: s.appID, BusinessUnit: bank, ID: s.issued.Pack.ID}
+
+	// The bank is not a party of the pack.
+	s.expectBusinessUnit(bank, model.BusinessUnitStatusActive)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.expectGetPack(bank),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	_, err := s.controller.Get(s.ctx, req)
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingNotFound)
+
+	req.BusinessUnit = carrier
+	s.expectBusinessUnit(carrier, model.BusinessUnitStatusActive)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.expectGetPack(carrier, s.issued),
+		s.storage.EXPECT().GetBillOfLadingPackHistory(gomock.Any(), s.tx, s.issued.Pack.ID).Return([]trade_document.BillOfLadingPackRecord{s.issued}, nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	history, err := s.controller.GetHistory(s.ctx, req)
+	s.Require().NoError(err)
+	s.Require().Len(history, 1)
+	s.Assert().Equal(s.issued.Pack, history[0].Pack)
+	s.Assert().Equal(trade_document.BillOfLadingStatusIssued, history[0].Status)
+}
+
+func (s *BillOfLadingControllerTestSuite) TestList() {
+	req := trade_document.ListBillOfLadingRequest{
+		Offset:        1,
+		Limit:         10,
+		ApplicationID: s.appID,
+		BusinessUnit:  shipper,
+		Box:           trade_document.BillOfLadingBoxHeld,
+		Status:        trade_document.BillOfLadingStatusIssued,
+	}
+
+	s.expectBusinessUnit(shipper, model.BusinessUnitStatusActive)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.storage.EXPECT().ListBillOfLadingPacks(
+			gomock.Any(),
+			s.tx,
+			trade_document.ListBillOfLadingPacksRequest{
+				Offset:       1,
+				Limit:        10,
+				BusinessUnit: shipper,
+				Box:          trade_document.BillOfLadingBoxHeld,
+				Statuses:     []trade_document.BillOfLadingStatus{trade_document.BillOfLadingStatusIssued},
+			},
+		).Return(trade_document.ListBillOfLadingPacksResult{Total: 2, Records: []trade_document.BillOfLadingPackRecord{s.issued}}, nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	result, err := s.controller.List(s.ctx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(2, result.Total)
+	s.Require().Len(result.Records, 1)
+	s.Assert().Equal(s.issued.Pack, result.Records[0].Pack)
+
+	req.Box = "unknown"
+	_, err = s.controller.List(s.ctx, req)
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+}
+
+func (s *BillOfLadingControllerTestSuite) TestGetFile() {
+	content := []byte("%PDF-1.3\n")
+	inlineContent := []byte("%PDF-1.3\ninline\n")
+	stored := model.File{Name: "bl.pdf", Hash: fileHash(content), Size: int64(len(content)), MediaType: "application/pdf"}
+	issued := s.issued
+	issued.Pack.Events = []bill_of_lading.BillOfLadingEvent{{BillOfLading: &bill_of_lading.BillOfLading{
+		BillOfLading: s.issued.Pack.Events[0].BillOfLading.BillOfLading,
+		File:         &stored,
+		CreatedBy:    carrier,
+	}}}
+	amended := s.issued
+	amended.Pack.Version = 2
+	amended.Pack.Events = []bill_of_lading.BillOfLadingEvent{{BillOfLading: &bill_of_lading.BillOfLading{
+		BillOfLading: s.issued.Pack.Events[0].BillOfLading.BillOfLading,
+		File:         &model.File{Name: "inline.pdf", Content: inlineContent},
+		CreatedBy:    carrier,
+	}}}
+	history := []trade_document.BillOfLadingPackRecord{issued, amended}
+	req := trade_document.GetBillOfLadingFileRequest{ApplicationID: s.appID, BusinessUnit: shipper, ID: s.issued.Pack.ID, Hash: stored.Hash}
+
+	expectHistory := func() {
+		s.expectBusinessUnit(shipper, model.BusinessUnitStatusActive)
+		gomock.InOrder(
+			s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+			s.expectGetPack(shipper, amended),
+			s.storage.EXPECT().GetBillOfLadingPackHistory(gomock.Any(), s.tx, s.issued.Pack.ID).Return(history, nil),
+			s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+		)
+	}
+
+	// The content is kept in the blob store.
+	expectHistory()
+	s.blobStore.EXPECT().Get(gomock.Any(), stored.Hash).Return(content, nil)
+	file, err := s.controller.GetFile(s.ctx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal("bl.pdf", file.Name)
+	s.Assert().Equal(content, file.Content)
+
+	// The content is kept in the pack.
+	expectHistory()
+	req.Hash = fileHash(inlineContent)
+	file, err = s.controller.GetFile(s.ctx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal("inline.pdf", file.Name)
+	s.Assert().Equal(inlineContent, file.Content)
+
+	// The file is not attached to the pack.
+	expectHistory()
+	req.Hash = fileHash([]byte("other"))
+	_, err = s.controller.GetFile(s.ctx, req)
+	s.Assert().ErrorIs(err, model.ErrFileNotFound)
+
+	req.Hash = "not a hash"
+	_, err = s.controller.GetFile(s.ctx, req)
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+}
+
+func fileHash(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,123 @@
+package trade_document
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/util"
+)
+
+// BrokenHashChainError tells which version of a bill of lading pack breaks the hash chain and why.
+type BrokenHashChainError struct {
+	Version int64  // The first version which doesn't chain to the previous one.
+	Reason  string // Why the version breaks the chain.
+}
+
+func (e *BrokenHashChainError) Error() string {
+	return fmt.Sprintf("version %d: %s: %s", e.Version, e.Reason, model.ErrBillOfLadingBrokenHashChain.Error())
+}
+
+func (e *BrokenHashChainError) Unwrap() error {
+	return model.ErrBillOfLadingBrokenHashChain
+}
+
+// GetBillOfLadingPackHash returns the hex encoded SHA512 hash of the canonical JSON of the pack.
+// The next version of the pack carries it as ParentHash.
+func GetBillOfLadingPackHash(pack bill_of_lading.BillOfLadingPack) (string, error) {
+	raw, err := util.CanonicalJSON(pack)
+	if err != nil {
+		return "", err
+	}
+	hash := sha512.Sum512(raw)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// VerifyBillOfLadingPackHistory walks all versions of a pack from version 1 in order and checks that:
+//   - Versions are consecutive and belong to the same pack. Two different packs of the same version (a fork) break the chain.
+//   - Version 1 has no ParentHash and its only event is the issued bill of lading.
+//   - ParentHash of every other version is the hash of the previous version.
+//   - Every version keeps all events of the previous version unchanged and appends exactly one event.
+//
+// It returns a *BrokenHashChainError of the first version which fails any of the checks.
+func VerifyBillOfLadingPackHistory(history []bill_of_lading.BillOfLadingPack) error {
+	if len(history) == 0 {
+		return &BrokenHashChainError{Version: 1, Reason: "empty history"}
+	}
+
+	for i, pack := range history {
+		version := int64(i + 1)
+		if pack.Version != version {
+			return &BrokenHashChainError{Version: version, Reason: fmt.Sprintf("unexpected version %d", pack.Version)}
+		}
+		if pack.ID != history[0].ID {
+			return &BrokenHashChainError{Version: version, Reason: fmt.Sprintf("unexpected pack ID %q", pack.ID)}
+		}
+
+		if i == 0 {
+			if pack.ParentHash != "" {
+				return &BrokenHashChainError{Version: version, Reason: "first version has parent hash"}
+			}
+			if len(pack.Events) != 1 || pack.Events[0].BillOfLading == nil {
+				return &BrokenHashChainError{Version: version, Reason: "first version is not an issued bill of lading"}
+			}
+			continue
+		}
+
+		if err := VerifyBillOfLadingPackVersion(history[i-1], pack); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VerifyBillOfLadingPackVersion checks the pack is the next version of parent: it carries the hash of parent and
+// appends exactly one event to the unchanged events of parent.
+//
+// It returns a *BrokenHashChainError if the pack doesn't chain to parent.
+func VerifyBillOfLadingPackVersion(parent, pack bill_of_lading.BillOfLadingPack) error {
+	version := parent.Version + 1
+	if pack.Version != version {
+		return &BrokenHashChainError{Version: version, Reason: fmt.Sprintf("unexpected version %d", pack.Version)}
+	}
+	if pack.ID != parent.ID {
+		return &BrokenHashChainError{Version: version, Reason: fmt.Sprintf("unexpected pack ID %q", pack.ID)}
+	}
+
+	parentHash, err := GetBillOfLadingPackHash(parent)
+	if err != nil {
+		return err
+	}
+	if pack.ParentHash != parentHash {
+		return &BrokenHashChainError{Version: version, Reason: "parent hash mismatch"}
+	}
+	if len(pack.Events) != len(parent.Events)+1 {
+		return &BrokenHashChainError{Version: version, Reason: fmt.Sprintf("%d events appended", len(pack.Events)-len(parent.Events))}
+	}
+	for j := range parent.Events {
+		same, err := sameEvent(parent.Events[j], pack.Events[j])
+		if err != nil {
+			return err
+		}
+		if !same {
+			return &BrokenHashChainError{Version: version, Reason: fmt.Sprintf("event %d is changed", j)}
+		}
+	}
+	return nil
+}
+
+func sameEvent(a, b bill_of_lading.BillOfLadingEvent) (bool, error) {
+	rawA, err := util.CanonicalJSON(a)
+	if err != nil {
+		return false, err
+	}
+	rawB, err := util.CanonicalJSON(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(rawA, rawB), nil
+}
@@ -0,0 +1,137 @@
+package trade_document_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/stretchr/testify/suite"
+)
+
+type BillOfLadingHashTestSuite struct {
+	suite.Suite
+	ts      int64
+	service trade_document.BillOfLadingService
+	history []bill_of_lading.BillOfLadingPack
+}
+
+func TestBillOfLadingHash(t *testing.T) {
+	suite.Run(t, new(BillOfLadingHashTestSuite))
+}
+
+func (s *BillOfLadingHashTestSuite) SetupTest() {
+	s.ts = time.Now().Unix()
+	s.service = trade_document.NewBillOfLadingService()
+
+	pack, err := s.service.Issue(s.ts, trade_document.IssueBillOfLadingRequest{
+		Issuer:       carrier,
+		TransferTo:   shipper,
+		BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+	})
+	s.Require().NoError(err)
+	s.history = []bill_of_lading.BillOfLadingPack{pack}
+
+	pack, err = s.service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: bank})
+	s.Require().NoError(err)
+	s.history = append(s.history, pack)
+
+	pack, err = s.service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: bank, TransferTo: consignee})
+	s.Require().NoError(err)
+	s.history = append(s.history, pack)
+
+	pack, err = s.service.Surrender(s.ts, pack, trade_document.SurrenderBillOfLadingRequest{Actor: consignee})
+	s.Require().NoError(err)
+	s.history = append(s.history, pack)
+}
+
+// copyPack returns a deep copy of the pack, so tampering with it leaves the history untouched.
+func (s *BillOfLadingHashTestSuite) copyPack(pack bill_of_lading.BillOfLadingPack) bill_of_lading.BillOfLadingPack {
+	raw, err := json.Marshal(pack)
+	s.Require().NoError(err)
+	var result bill_of_lading.BillOfLadingPack
+	s.Require().NoError(json.Unmarshal(raw, &result))
+	return result
+}
+
+func (s *BillOfLadingHashTestSuite) requireBrokenAt(err error, version int64) {
+	s.Require().ErrorIs(err, model.ErrBillOfLadingBrokenHashChain)
+	var chainErr *trade_document.BrokenHashChainError
+	s.Require().True(errors.As(err, &chainErr))
+	s.Assert().Equal(version, chainErr.Version)
+}
+
+func (s *BillOfLadingHashTestSuite) TestParentHash() {
+	s.Assert().Empty(s.history[0].ParentHash)
+	for i := 1; i < len(s.history); i++ {
+		hash, err := trade_document.GetBillOfLadingPackHash(s.history[i-1])
+		s.Require().NoError(err)
+		s.Assert().Len(hash, 128)
+		s.Assert().Equal(hash, s.history[i].ParentHash)
+	}
+
+	// The hash doesn't depend on how the pack is encoded.
+	hash, err := trade_document.GetBillOfLadingPackHash(s.copyPack(s.history[2]))
+	s.Require().NoError(err)
+	s.Assert().Equal(s.history[3].ParentHash, hash)
+
+	s.Require().NoError(trade_document.VerifyBillOfLadingPackHistory(s.history))
+}
+
+func (s *BillOfLadingHashTestSuite) TestTamperedEvent() {
+	// Changing an event of an old version breaks the chain at the next version.
+	history := append([]bill_of_lading.BillOfLadingPack{}, s.history...)
+	tampered := s.copyPack(history[1])
+	tampered.Events[1].Transfer.TransferTo = "did:openebl:thief"
+	history[1] = tampered
+	s.requireBrokenAt(trade_document.VerifyBillOfLadingPackHistory(history), 3)
+
+	// Rewriting an old event in a later version breaks the chain at that version.
+	history = append([]bill_of_lading.BillOfLadingPack{}, s.history...)
+	tampered = s.copyPack(history[3])
+	tampered.Events[0].BillOfLading.BillOfLading.TransportDocumentReference = "another-bl-number"
+	history[3] = tampered
+	s.requireBrokenAt(trade_document.VerifyBillOfLadingPackHistory(history), 4)
+
+	// A version which doesn't carry the hash of its parent breaks the chain.
+	history = append([]bill_of_lading.BillOfLadingPack{}, s.history...)
+	tampered = s.copyPack(history[3])
+	tampered.ParentHash = history[2].ParentHash
+	history[3] = tampered
+	s.requireBrokenAt(trade_document.VerifyBillOfLadingPackHistory(history), 4)
+}
+
+func (s *BillOfLadingHashTestSuite) TestForkedHistory() {
+	// Another version 3 forked from version 2.
+	fork, err := s.service.Return(s.ts, s.history[1], trade_document.ReturnBillOfLadingRequest{Actor: bank})
+	s.Require().NoError(err)
+	s.Require().EqualValues(3, fork.Version)
+	s.Require().Equal(s.history[2].ParentHash, fork.ParentHash)
+
+	history := append([]bill_of_lading.BillOfLadingPack{}, s.history[:3]...)
+	history = append(history, fork)
+	s.requireBrokenAt(trade_document.VerifyBillOfLadingPackHistory(history), 4)
+
+	// Version 4 built on the fork doesn't chain to the original version 3.
+	forked, err := s.service.Transfer(s.ts, fork, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: consignee})
+	s.Require().NoError(err)
+	history = append([]bill_of_lading.BillOfLadingPack{}, s.history[:3]...)
+	history = append(history, forked)
+	s.requireBrokenAt(trade_document.VerifyBillOfLadingPackHistory(history), 4)
+}
+
+func (s *BillOfLadingHashTestSuite) TestIncompleteHistory() {
+	s.requireBrokenAt(trade_document.VerifyBillOfLadingPackHistory(nil), 1)
+	s.requireBrokenAt(trade_document.VerifyBillOfLadingPackHistory(s.history[1:]), 1)
+
+	history := append([]bill_of_lading.BillOfLadingPack{}, s.history[:2]...)
+	history = append(history, s.history[3])
+	s.requireBrokenAt(trade_document.VerifyBillOfLadingPackHistory(history), 3)
+
+	first := s.copyPack(s.history[0])
+	first.ParentHash = "something"
+	s.requireBrokenAt(trade_document.VerifyBillOfLadingPackHistory([]bill_of_lading.BillOfLadingPack{first}), 1)
+}
@@ -0,0 +1,651 @@
+package trade_document
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/openebl/openebl/pkg/bu_server/blob_store"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/openebl/openebl/pkg/pkix"
+	"github.com/openebl/openebl/pkg/relay"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultInboxRetryInterval   = 5 * time.Second
+	defaultInboxRefreshInterval = time.Minute
+)
+
+// errEventIgnored is the base error of events which are not for local business units. Other errors of opening events
+// come from the storage, and the events are processed again later.
+var errEventIgnored = errors.New("")
+
+// BillOfLadingInbox receives bill of lading packs sent to business units of this BU server from relay servers.
+//
+// Every event is decrypted with private keys of the local business units, then the signature and the hash chain of
+// the pack in it are verified before the pack is stored. Packs whose earlier versions are unknown are stored as
+// unverified, because their hash chain can't be checked from the start. The offset of every relay server is checkpointed in the same
+// transaction with the pack, and events are deduplicated by their IDs, so restarts neither reprocess nor miss events.
+//
+// Contents of files attached to the packs arrive in their own events and are kept in the blob store once the version
+// of the pack attaching them is stored. Files arriving before the pack are pending until then.
+type BillOfLadingInbox struct {
+	buStorage business_unit.BusinessUnitStorage
+	storage   BillOfLadingInboxStorage
+	blobStore blob_store.BlobStore
+
+	relayServers    []string
+	tlsConfig       *tls.Config
+	rootCerts       []*x509.Certificate
+	retryInterval   time.Duration
+	refreshInterval time.Duration // How often the list of local business units is checked for changes.
+
+	keyMux sync.Mutex
+	keys   []any // Private keys of local business units.
+
+	clientMux  sync.Mutex
+	clients    []*relay.NostrClient
+	recipients []string // DIDs of local business units the clients subscribe to.
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+type BillOfLadingInboxOption func(i *BillOfLadingInbox)
+
+func WithInboxRelayServers(relayServers ...string) BillOfLadingInboxOption {
+	return func(i *BillOfLadingInbox) {
+		i.relayServers = relayServers
+	}
+}
+
+func WithInboxTLSConfig(tlsConfig *tls.Config) BillOfLadingInboxOption {
+	return func(i *BillOfLadingInbox) {
+		i.tlsConfig = tlsConfig
+	}
+}
+
+// WithInboxRootCertificates sets the certificates trusted in addition to the system trusted certificates
+// to verify signers of bill of lading packs.
+func WithInboxRootCertificates(rootCerts []*x509.Certificate) BillOfLadingInboxOption {
+	return func(i *BillOfLadingInbox) {
+		i.rootCerts = rootCerts
+	}
+}
+
+// WithInboxBlobStore sets the blob store to keep contents of received files.
+// Without a blob store, events carrying files are ignored.
+func WithInboxBlobStore(blobStore blob_store.BlobStore) BillOfLadingInboxOption {
+	return func(i *BillOfLadingInbox) {
+		i.blobStore = blobStore
+	}
+}
+
+func WithInboxRetryInterval(interval time.Duration) BillOfLadingInboxOption {
+	return func(i *BillOfLadingInbox) {
+		i.retryInterval = interval
+	}
+}
+
+func WithInboxRefreshInterval(interval time.Duration) BillOfLadingInboxOption {
+	return func(i *BillOfLadingInbox) {
+		i.refreshInterval = interval
+	}
+}
+
+func NewBillOfLadingInbox(buStorage business_unit.BusinessUnitStorage, storage BillOfLadingInboxStorage, options ...BillOfLadingInboxOption) *BillOfLadingInbox {
+	inbox := &BillOfLadingInbox{
+		buStorage:       buStorage,
+		storage:         storage,
+		retryInterval:   defaultInboxRetryInterval,
+		refreshInterval: defaultInboxRefreshInterval,
+		closeChan:       make(chan struct{}),
+	}
+	for _, option := range options {
+		option(inbox)
+	}
+	return inbox
+}
+
+// Run subscribes to all relay servers until Close is called.
+// Subscriptions are renewed when business units are added to this BU server.
+func (i *BillOfLadingInbox) Run() error {
+	defer i.stopClients()
+
+	ticker := time.NewTicker(i.refreshInterval)
+	defer ticker.Stop()
+	for {
+		if err := i.refreshSubscriptions(context.Background()); err != nil {
+			logrus.Errorf("BillOfLadingInbox: failed to refresh subscriptions: %v", err)
+		}
+
+		select {
+		case <-i.closeChan:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (i *BillOfLadingInbox) Close() error {
+	i.closeOnce.Do(func() { close(i.closeChan) })
+	return nil
+}
+
+// refreshSubscriptions (re)starts clients of relay servers if the local business units are changed.
+// New clients resume from the checkpoints, so nothing is missed in between.
+func (i *BillOfLadingInbox) refreshSubscriptions(ctx context.Context) error {
+	recipients, err := i.listLocalBusinessUnits(ctx)
+	if err != nil {
+		return err
+	}
+
+	i.clientMux.Lock()
+	defer i.clientMux.Unlock()
+	if len(i.clients) > 0 && slices.Equal(recipients, i.recipients) {
+		return nil
+	}
+
+	for _, client := range i.clients {
+		client.Close()
+	}
+	i.clients = nil
+	i.recipients = recipients
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	for _, relayServer := range i.relayServers {
+		subscription := &inboxSubscription{inbox: i, recipients: recipientTags(recipients)}
+		client := relay.NewNostrClient(
+			relay.NostrClientWithServerURL(relayServer),
+			relay.NostrClientWithEventSink(subscription.EventSink),
+			relay.NostrClientWithConnectionStatusCallback(subscription.OnConnectionStatusChange),
+			relay.NostrClientWithTLSConfig(i.tlsConfig),
+			relay.NostrClientWithRetryInterval(i.retryInterval),
+		)
+		i.clients = append(i.clients, client)
+	}
+	return nil
+}
+
+func (i *BillOfLadingInbox) stopClients() {
+	i.clientMux.Lock()
+	defer i.clientMux.Unlock()
+	for _, client := range i.clients {
+		client.Close()
+	}
+	i.clients = nil
+}
+
+func (i *BillOfLadingInbox) listLocalBusinessUnits(ctx context.Context) ([]string, error) {
+	tx, err := i.buStorage.CreateTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var dids []string
+	req := business_unit.ListBusinessUnitsRequest{Limit: 100}
+	for {
+		result, err := i.buStorage.ListBusinessUnits(ctx, tx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range result.Records {
+			dids = append(dids, record.BusinessUnit.ID.String())
+		}
+		req.Offset += len(result.Records)
+		if len(result.Records) == 0 || req.Offset >= result.Total {
+			break
+		}
+	}
+	sort.Strings(dids)
+	return dids, nil
+}
+
+// ProcessEvent processes an event received from the relay server and returns how it is processed.
+// It is safe to process the same event more than once.
+func (i *BillOfLadingInbox) ProcessEvent(ctx context.Context, ts int64, relayServer string, event relay.Event) (model.RelayEvent, error) {
+	relayEvent := model.RelayEvent{
+		ID:          eventID(event.Data),
+		RelayServer: relayServer,
+		Offset:      event.Offset,
+		Type:        event.Type,
+		CreatedAt:   ts,
+	}
+
+	var record BillOfLadingPackRecord
+	var file *BillOfLadingFile
+	switch event.Type {
+	case BillOfLadingPackEventType:
+		var err error
+		record, err = i.openEvent(ctx, ts, event.Data)
+		switch {
+		case err == nil:
+		case errors.Is(err, model.ErrTradeDocumentError):
+			relayEvent.Status = model.RelayEventStatusRejected
+			relayEvent.Reason = err.Error()
+		case errors.Is(err, errEventIgnored):
+			relayEvent.Status = model.RelayEventStatusIgnored
+			relayEvent.Reason = err.Error()
+		default:
+			return model.RelayEvent{}, err
+		}
+		relayEvent.PackID = record.Pack.ID
+		relayEvent.Version = record.Pack.Version
+	case BillOfLadingFileEventType:
+		var err error
+		file, err = i.openFile(ctx, event.Data, &relayEvent)
+		if err != nil {
+			return model.RelayEvent{}, err
+		}
+	default:
+		relayEvent.Status = model.RelayEventStatusIgnored
+		relayEvent.Reason = fmt.Sprintf("unexpected event type %d", event.Type)
+	}
+
+	tx, err := i.storage.CreateTx(ctx, storage.TxOptionWithWrite(true), storage.TxOptionWithIsolationLevel(sql.LevelSerializable))
+	if err != nil {
+		return model.RelayEvent{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	processedEvent, err := i.storage.GetRelayEvent(ctx, tx, relayEvent.ID)
+	if err != nil {
+		return model.RelayEvent{}, err
+	}
+	if processedEvent != nil {
+		// The event is received again from another relay server or after a restart.
+		relayEvent = *processedEvent
+	} else {
+		switch {
+		case relayEvent.Status != "":
+		case file != nil:
+			if err := i.receiveFile(ctx, tx, *file, event.Data, &relayEvent); err != nil {
+				return model.RelayEvent{}, err
+			}
+		default:
+			history, err := i.storage.GetBillOfLadingPackHistory(ctx, tx, record.Pack.ID)
+			if err != nil {
+				return model.RelayEvent{}, err
+			}
+			relayEvent.Status, err = checkReceivedPack(history, record.Pack)
+			if err != nil {
+				relayEvent.Reason = err.Error()
+			}
+		}
+		stored := relayEvent.Status == model.RelayEventStatusAccepted || relayEvent.Status == model.RelayEventStatusUnverified
+		if stored && relayEvent.Type == BillOfLadingPackEventType {
+			if err := i.storage.StoreBillOfLadingPack(ctx, tx, record); err != nil {
+				return model.RelayEvent{}, err
+			}
+			if err := i.receivePendingFiles(ctx, tx, record.Pack); err != nil {
+				return model.RelayEvent{}, err
+			}
+		}
+		if err := i.storage.StoreRelayEvent(ctx, tx, relayEvent); err != nil {
+			return model.RelayEvent{}, err
+		}
+	}
+
+	if err := i.storage.StoreRelayCheckpoint(ctx, tx, ts, relayServer, event.Offset); err != nil {
+		return model.RelayEvent{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.RelayEvent{}, err
+	}
+
+	switch relayEvent.Status {
+	case model.RelayEventStatusRejected:
+		logrus.Warnf("BillOfLadingInbox: rejected event %q from %q: %s", relayEvent.ID, relayServer, relayEvent.Reason)
+	case model.RelayEventStatusUnverified:
+		logrus.Warnf("BillOfLadingInbox: stored unverified pack %q version %d from %q: %s", relayEvent.PackID, relayEvent.Version, relayServer, relayEvent.Reason)
+	}
+	return relayEvent, nil
+}
+
+// openEvent decrypts the data of the event and verifies the signed pack in it.
+// Errors of trade documents mean the event is for local business units but the pack is invalid, and errEventIgnored
+// means the event is not for them.
+func (i *BillOfLadingInbox) openEvent(ctx context.Context, ts int64, data []byte) (BillOfLadingPackRecord, error) {
+	var encryptedPack envelope.JWE
+	if err := json.Unmarshal(data, &encryptedPack); err != nil {
+		return BillOfLadingPackRecord{}, fmt.Errorf("invalid encrypted pack: %s%w", err.Error(), errEventIgnored)
+	}
+
+	plainText, err := i.decrypt(ctx, encryptedPack)
+	if err != nil {
+		return BillOfLadingPackRecord{}, err
+	}
+
+	var signedPack envelope.JWS
+	if err := json.Unmarshal(plainText, &signedPack); err != nil {
+		return BillOfLadingPackRecord{}, fmt.Errorf("%s: %w", err.Error(), model.ErrBillOfLadingInvalidSignature)
+	}
+	pack, err := VerifySignedBillOfLadingPack(signedPack, i.rootCerts)
+	if err != nil {
+		return BillOfLadingPackRecord{Pack: pack}, err
+	}
+
+	return BillOfLadingPackRecord{
+		Pack:          pack,
+		SignedPack:    signedPack,
+		EncryptedPack: &encryptedPack,
+		CreatedAt:     ts,
+
+		RawSignedPack:    plainText,
+		RawEncryptedPack: data,
+	}, nil
+}
+
+// openFile decrypts the data of the event and checks the content of the file in it against its hash. The file is
+// returned with the status of relayEvent left empty, or nil with the status and the reason set to relayEvent.
+// Only errors of loading private keys are returned.
+func (i *BillOfLadingInbox) openFile(ctx context.Context, data []byte, relayEvent *model.RelayEvent) (*BillOfLadingFile, error) {
+	relayEvent.Status = model.RelayEventStatusIgnored
+	if i.blobStore == nil {
+		relayEvent.Reason = "no blob store to keep files"
+		return nil, nil
+	}
+
+	var encryptedFile envelope.JWE
+	if err := json.Unmarshal(data, &encryptedFile); err != nil {
+		relayEvent.Reason = fmt.Sprintf("invalid encrypted file: %v", err)
+		return nil, nil
+	}
+	plainText, err := i.decrypt(ctx, encryptedFile)
+	if errors.Is(err, errEventIgnored) {
+		relayEvent.Reason = err.Error()
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	relayEvent.Status = model.RelayEventStatusRejected
+	var file BillOfLadingFile
+	if err := json.Unmarshal(plainText, &file); err != nil {
+		relayEvent.Reason = fmt.Sprintf("invalid file: %v", err)
+		return nil, nil
+	}
+	relayEvent.PackID = file.PackID
+	relayEvent.Version = file.Version
+	hash := sha256.Sum256(file.File.Content)
+	if len(file.File.Content) == 0 || hex.EncodeToString(hash[:]) != file.File.Hash {
+		relayEvent.Reason = fmt.Sprintf("content of file %q doesn't match its hash", file.File.Name)
+		return nil, nil
+	}
+
+	relayEvent.Status = ""
+	return &file, nil
+}
+
+// receiveFile keeps the file in the blob store if the version of the pack attaching it is stored. Otherwise the file
+// is pending until the version arrives, because files are published before the packs attaching them.
+// Only errors of the storage and the blob store are returned, so the event is processed again later.
+func (i *BillOfLadingInbox) receiveFile(ctx context.Context, tx storage.Tx, file BillOfLadingFile, data []byte, relayEvent *model.RelayEvent) error {
+	history, err := i.storage.GetBillOfLadingPackHistory(ctx, tx, file.PackID)
+	if err != nil {
+		return err
+	}
+	for j := range history {
+		if history[j].Pack.Version == file.Version {
+			return i.keepFile(ctx, history[j].Pack, file, relayEvent)
+		}
+	}
+
+	relayEvent.Status = model.RelayEventStatusPending
+	return i.storage.StorePendingFile(ctx, tx, PendingBillOfLadingFile{Event: *relayEvent, EncryptedFile: data})
+}
+
+// receivePendingFiles settles files received before the pack, and updates the statuses of their events.
+func (i *BillOfLadingInbox) receivePendingFiles(ctx context.Context, tx storage.Tx, pack bill_of_lading.BillOfLadingPack) error {
+	pendingFiles, err := i.storage.ListPendingFiles(ctx, tx, pack.ID, pack.Version)
+	if err != nil {
+		return err
+	}
+	if len(pendingFiles) == 0 {
+		return nil
+	}
+
+	for _, pendingFile := range pendingFiles {
+		relayEvent := pendingFile.Event
+		file, err := i.openFile(ctx, pendingFile.EncryptedFile, &relayEvent)
+		if err != nil {
+			return err
+		}
+		if file != nil {
+			if err := i.keepFile(ctx, pack, *file, &relayEvent); err != nil {
+				return err
+			}
+		}
+		if err := i.storage.StoreRelayEvent(ctx, tx, relayEvent); err != nil {
+			return err
+		}
+		if relayEvent.Status == model.RelayEventStatusRejected {
+			logrus.Warnf("BillOfLadingInbox: rejected event %q from %q: %s", relayEvent.ID, relayEvent.RelayServer, relayEvent.Reason)
+		}
+	}
+	return i.storage.DeletePendingFiles(ctx, tx, pack.ID, pack.Version)
+}
+
+// keepFile keeps the content of the file in the blob store if the latest event of the pack attaches the file.
+// The result is set to relayEvent. Only errors of the blob store are returned.
+func (i *BillOfLadingInbox) keepFile(ctx context.Context, pack bill_of_lading.BillOfLadingPack, file BillOfLadingFile, relayEvent *model.RelayEvent) error {
+	relayEvent.Status = model.RelayEventStatusRejected
+	var attached []*model.File
+	if len(pack.Events) > 0 {
+		attached = packFiles(bill_of_lading.BillOfLadingPack{Events: pack.Events[len(pack.Events)-1:]})
+	}
+	if len(attached) == 0 || attached[0].Hash != file.File.Hash {
+		relayEvent.Reason = fmt.Sprintf("file %q is not attached to version %d of the pack", file.File.Name, pack.Version)
+		return nil
+	}
+
+	if _, err := i.blobStore.Put(ctx, file.File); errors.Is(err, model.ErrInvalidParameter) {
+		relayEvent.Reason = err.Error()
+		return nil
+	} else if err != nil {
+		return err
+	}
+	relayEvent.Status = model.RelayEventStatusAccepted
+	relayEvent.Reason = ""
+	return nil
+}
+
+// decrypt tries the cached keys first. The keys are reloaded once if none of them works,
+// because the event may be encrypted to a business unit added after the keys were loaded.
+// errEventIgnored is returned if the keys are loaded but none of them works.
+func (i *BillOfLadingInbox) decrypt(ctx context.Context, encryptedPack envelope.JWE) ([]byte, error) {
+	i.keyMux.Lock()
+	defer i.keyMux.Unlock()
+
+	if len(i.keys) > 0 {
+		if plainText, err := envelope.Decrypt(encryptedPack, i.keys); err == nil {
+			return plainText, nil
+		}
+	}
+
+	keys, err := i.loadKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i.keys = keys
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("not encrypted to local business units: no private key%w", errEventIgnored)
+	}
+	plainText, err := envelope.Decrypt(encryptedPack, i.keys)
+	if err != nil {
+		return nil, fmt.Errorf("not encrypted to local business units: %s%w", err.Error(), errEventIgnored)
+	}
+	return plainText, nil
+}
+
+// loadKeys returns private keys of all authentications of local business units. Revoked keys are kept to decrypt
+// packs sent before the revocation.
+func (i *BillOfLadingInbox) loadKeys(ctx context.Context) ([]any, error) {
+	tx, err := i.buStorage.CreateTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var keys []any
+	req := business_unit.ListAuthenticationRequest{Limit: 100}
+	for {
+		result, err := i.buStorage.ListAuthentication(ctx, tx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, auth := range result.Records {
+			key, err := pkix.ParsePrivateKey([]byte(auth.PrivateKey))
+			if err != nil {
+				logrus.Warnf("BillOfLadingInbox: invalid private key of authentication %q: %v", auth.ID, err)
+				continue
+			}
+			keys = append(keys, key)
+		}
+		req.Offset += len(result.Records)
+		if len(result.Records) == 0 || req.Offset >= result.Total {
+			break
+		}
+	}
+	return keys, nil
+}
+
+// checkReceivedPack decides whether the received pack is a new version which chains to the known versions of the pack.
+//
+// A business unit may join a pack in the middle of its life, so versions before the first received one are unknown.
+// Such a pack can only be checked against the known versions, so it is unverified, and the returned error tells
+// which versions are unknown.
+func checkReceivedPack(history []BillOfLadingPackRecord, pack bill_of_lading.BillOfLadingPack) (model.RelayEventStatus, error) {
+	if pack.Version == 1 {
+		if err := VerifyBillOfLadingPackHistory([]bill_of_lading.BillOfLadingPack{pack}); err != nil {
+			return model.RelayEventStatusRejected, err
+		}
+	}
+
+	var latest *bill_of_lading.BillOfLadingPack
+	for j := range history {
+		known := history[j].Pack
+		if known.Version == pack.Version {
+			knownHash, err := GetBillOfLadingPackHash(known)
+			if err != nil {
+				return model.RelayEventStatusRejected, err
+			}
+			packHash, err := GetBillOfLadingPackHash(pack)
+			if err != nil {
+				return model.RelayEventStatusRejected, err
+			}
+			if knownHash != packHash {
+				return model.RelayEventStatusRejected, &BrokenHashChainError{Version: pack.Version, Reason: "forked from the known version"}
+			}
+			return model.RelayEventStatusDuplicated, nil
+		}
+		if known.Version < pack.Version && (latest == nil || known.Version > latest.Version) {
+			latest = &history[j].Pack
+		}
+	}
+
+	switch {
+	case latest == nil && pack.Version == 1:
+		return model.RelayEventStatusAccepted, nil
+	case latest == nil:
+		return model.RelayEventStatusUnverified, fmt.Errorf("versions before %d are unknown", pack.Version)
+	case latest.Version == pack.Version-1:
+		if err := VerifyBillOfLadingPackVersion(*latest, pack); err != nil {
+			return model.RelayEventStatusRejected, err
+		}
+	default:
+		// Versions in between are unknown. At least the events of the known version must be kept.
+		for j := range latest.Events {
+			if j >= len(pack.Events) {
+				return model.RelayEventStatusRejected, &BrokenHashChainError{Version: pack.Version, Reason: "events are removed"}
+			}
+			same, err := sameEvent(latest.Events[j], pack.Events[j])
+			if err != nil {
+				return model.RelayEventStatusRejected, err
+			}
+			if !same {
+				return model.RelayEventStatusRejected, &BrokenHashChainError{Version: pack.Version, Reason: fmt.Sprintf("event %d is changed", j)}
+			}
+		}
+		return model.RelayEventStatusUnverified, fmt.Errorf("versions %d to %d are unknown", latest.Version+1, pack.Version-1)
+	}
+	return model.RelayEventStatusAccepted, nil
+}
+
+// inboxSubscription subscribes to a relay server for the inbox.
+type inboxSubscription struct {
+	inbox      *BillOfLadingInbox
+	recipients []string // Tags of local business units. See RecipientTag.
+
+	// The identity is set by the connection callback and read by the event sink, which run on different goroutines.
+	serverIdentityMux sync.Mutex
+	serverIdentity    string
+}
+
+func (s *inboxSubscription) OnConnectionStatusChange(
+	ctx context.Context,
+	cancel context.CancelCauseFunc,
+	client relay.RelayClient,
+	serverIdentity string,
+	status bool,
+) {
+	if !status {
+		return
+	}
+
+	s.serverIdentityMux.Lock()
+	s.serverIdentity = serverIdentity
+	s.serverIdentityMux.Unlock()
+	offset, err := s.getCheckpoint(ctx, serverIdentity)
+	if err != nil {
+		cancel(err)
+		return
+	}
+
+	if err := client.Subscribe(ctx, offset, s.recipients...); err != nil {
+		logrus.Errorf("BillOfLadingInbox: failed to subscribe to %s: %v", serverIdentity, err)
+		cancel(err)
+		return
+	}
+}
+
+func (s *inboxSubscription) getServerIdentity() string {
+	s.serverIdentityMux.Lock()
+	defer s.serverIdentityMux.Unlock()
+	return s.serverIdentity
+}
+
+func (s *inboxSubscription) getCheckpoint(ctx context.Context, serverIdentity string) (int64, error) {
+	tx, err := s.inbox.storage.CreateTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	return s.inbox.storage.GetRelayCheckpoint(ctx, tx, serverIdentity)
+}
+
+func (s *inboxSubscription) EventSink(ctx context.Context, event relay.Event) (string, error) {
+	relayEvent, err := s.inbox.ProcessEvent(ctx, time.Now().Unix(), s.getServerIdentity(), event)
+	if err != nil {
+		return "", err
+	}
+	return relayEvent.ID, nil
+}
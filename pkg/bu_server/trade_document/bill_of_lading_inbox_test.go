@@ -0,0 +1,433 @@
+package trade_document_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/openebl/openebl/pkg/pkix"
+	"github.com/openebl/openebl/pkg/relay"
+	mock_blob_store "github.com/openebl/openebl/test/mock/bu_server/blob_store"
+	mock_business_unit "github.com/openebl/openebl/test/mock/bu_server/business_unit"
+	mock_storage "github.com/openebl/openebl/test/mock/bu_server/storage"
+	mock_trade_document "github.com/openebl/openebl/test/mock/bu_server/trade_document"
+	"github.com/stretchr/testify/suite"
+)
+
+type BillOfLadingInboxTestSuite struct {
+	suite.Suite
+	ctx       context.Context
+	ctrl      *gomock.Controller
+	buStorage *mock_business_unit.MockBusinessUnitStorage
+	storage   *mock_trade_document.MockBillOfLadingInboxStorage
+	tx        *mock_storage.MockTx
+	inbox     *trade_document.BillOfLadingInbox
+
+	ts              int64
+	rootCert        *x509.Certificate
+	rootKey         *ecdsa.PrivateKey
+	authentications map[string]model.BusinessUnitAuthentication
+	v1              trade_document.BillOfLadingPackRecord
+	v2              bill_of_lading.BillOfLadingPack
+}
+
+func TestBillOfLadingInbox(t *testing.T) {
+	suite.Run(t, new(BillOfLadingInboxTestSuite))
+}
+
+func (s *BillOfLadingInboxTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.ctrl = gomock.NewController(s.T())
+	s.buStorage = mock_business_unit.NewMockBusinessUnitStorage(s.ctrl)
+	s.storage = mock_trade_document.NewMockBillOfLadingInboxStorage(s.ctrl)
+	s.tx = mock_storage.NewMockTx(s.ctrl)
+
+	s.ts = time.Now().Unix()
+	s.rootCert, s.rootKey = newCertificate(s.T(), "root", nil, nil, true)
+	s.inbox = trade_document.NewBillOfLadingInbox(s.buStorage, s.storage, trade_document.WithInboxRootCertificates([]*x509.Certificate{s.rootCert}))
+	s.authentications = make(map[string]model.BusinessUnitAuthentication)
+	for _, bu := range []string{carrier, shipper, bank, consignee} {
+		s.authentications[bu] = newAuthentication(s.T(), bu, bu, s.ts, s.rootCert, s.rootKey)
+	}
+
+	service := trade_document.NewBillOfLadingService()
+	v1, err := service.Issue(s.ts, trade_document.IssueBillOfLadingRequest{
+		Issuer:       carrier,
+		TransferTo:   shipper,
+		BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+	})
+	s.Require().NoError(err)
+	s.v1 = trade_document.BillOfLadingPackRecord{Pack: v1, CreatedAt: s.ts}
+	s.v2, err = service.Transfer(s.ts, v1, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: bank})
+	s.Require().NoError(err)
+}
+
+func (s *BillOfLadingInboxTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// newEvent signs the pack by the actor of its latest event and encrypts it to the recipients.
+func (s *BillOfLadingInboxTestSuite) newEvent(pack bill_of_lading.BillOfLadingPack, offset int64, recipients ...string) relay.Event {
+	signedPack, err := trade_document.SignBillOfLadingPack(pack, s.authentications[trade_document.GetBillOfLadingPackActor(pack)])
+	s.Require().NoError(err)
+	raw, err := json.Marshal(signedPack)
+	s.Require().NoError(err)
+	return relay.Event{Offset: offset, Type: trade_document.BillOfLadingPackEventType, Data: s.encrypt(raw, recipients...)}
+}
+
+// encrypt encrypts the plain text to the recipients and returns the data of an event.
+func (s *BillOfLadingInboxTestSuite) encrypt(plainText []byte, recipients ...string) []byte {
+	var keySettings []envelope.KeyEncryptionSetting
+	for _, recipient := range recipients {
+		certs, err := pkix.ParseCertificate([]byte(s.authentications[recipient].Certificate))
+		s.Require().NoError(err)
+		keySettings = append(keySettings, envelope.KeyEncryptionSetting{PublicKey: certs[0].PublicKey, Algorithm: envelope.KeyEncryptionAlgorithm(jwa.ECDH_ES_A256KW)})
+	}
+	encrypted, err := envelope.Encrypt(plainText, envelope.ContentEncryptionAlgorithm(jwa.A256GCM), keySettings)
+	s.Require().NoError(err)
+	data, err := json.Marshal(encrypted)
+	s.Require().NoError(err)
+	return data
+}
+
+// expectLoadKeys makes the local business units of the BU server be bank only.
+func (s *BillOfLadingInboxTestSuite) expectLoadKeys() {
+	gomock.InOrder(
+		s.buStorage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.buStorage.EXPECT().ListAuthentication(gomock.Any(), s.tx, business_unit.ListAuthenticationRequest{Limit: 100}).Return(
+			business_unit.ListAuthenticationResult{Total: 1, Records: []model.BusinessUnitAuthentication{s.authentications[bank]}}, nil,
+		),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+}
+
+func (s *BillOfLadingInboxTestSuite) TestProcessEvent() {
+	event := s.newEvent(s.v2, 42, carrier, shipper, bank)
+
+	var storedEvent model.RelayEvent
+	s.expectLoadKeys()
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil, nil),
+		s.storage.EXPECT().GetBillOfLadingPackHistory(gomock.Any(), s.tx, s.v2.ID).Return([]trade_document.BillOfLadingPackRecord{s.v1}, nil),
+		s.storage.EXPECT().StoreBillOfLadingPack(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, record trade_document.BillOfLadingPackRecord) error {
+				s.Assert().Equal(s.v2.ParentHash, record.Pack.ParentHash)
+				s.Assert().Equal(bank, record.Pack.CurrentOwner)
+				s.Assert().NotNil(record.EncryptedPack)
+				s.Assert().Equal(s.ts, record.CreatedAt)
+				// The envelopes are kept as they are received.
+				s.Assert().Equal(event.Data, record.RawEncryptedPack)
+				s.Assert().NotEmpty(record.RawSignedPack)
+				return nil
+			},
+		),
+		s.storage.EXPECT().ListPendingFiles(gomock.Any(), s.tx, s.v2.ID, int64(2)).Return(nil, nil),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, event model.RelayEvent) error {
+				storedEvent = event
+				return nil
+			},
+		),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay1", int64(42)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	relayEvent, err := s.inbox.ProcessEvent(s.ctx, s.ts, "relay1", event)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.RelayEventStatusAccepted, relayEvent.Status)
+	s.Assert().Equal(s.v2.ID, relayEvent.PackID)
+	s.Assert().EqualValues(2, relayEvent.Version)
+	s.Assert().Len(relayEvent.ID, 128)
+	s.Assert().Equal(relayEvent, storedEvent)
+
+	// The same event received again (from another relay server) only moves the checkpoint.
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, relayEvent.ID).Return(&storedEvent, nil),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay2", int64(7)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	event.Offset = 7
+	duplicatedEvent, err := s.inbox.ProcessEvent(s.ctx, s.ts, "relay2", event)
+	s.Require().NoError(err)
+	s.Assert().Equal(relayEvent, duplicatedEvent)
+}
+
+func (s *BillOfLadingInboxTestSuite) TestProcessEventWithUnknownEarlierVersions() {
+	event := s.newEvent(s.v2, 9, carrier, shipper, bank)
+
+	s.expectLoadKeys()
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil, nil),
+		s.storage.EXPECT().GetBillOfLadingPackHistory(gomock.Any(), s.tx, s.v2.ID).Return(nil, nil),
+		s.storage.EXPECT().StoreBillOfLadingPack(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+		s.storage.EXPECT().ListPendingFiles(gomock.Any(), s.tx, s.v2.ID, int64(2)).Return(nil, nil),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay1", int64(9)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	// The pack is kept, but it can't be verified against version 1.
+	relayEvent, err := s.inbox.ProcessEvent(s.ctx, s.ts, "relay1", event)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.RelayEventStatusUnverified, relayEvent.Status)
+	s.Assert().Equal("versions before 2 are unknown", relayEvent.Reason)
+}
+
+func (s *BillOfLadingInboxTestSuite) TestProcessEventNotForLocalBusinessUnits() {
+	event := s.newEvent(s.v2, 1, carrier, shipper)
+
+	s.expectLoadKeys()
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil, nil),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay1", int64(1)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	relayEvent, err := s.inbox.ProcessEvent(s.ctx, s.ts, "relay1", event)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.RelayEventStatusIgnored, relayEvent.Status)
+	s.Assert().Empty(relayEvent.PackID)
+}
+
+func (s *BillOfLadingInboxTestSuite) TestProcessEventWithKeysUnavailable() {
+	blobStore := mock_blob_store.NewMockBlobStore(s.ctrl)
+	inbox := trade_document.NewBillOfLadingInbox(s.buStorage, s.storage, trade_document.WithInboxBlobStore(blobStore))
+	_, _, fileEvent := s.newFileEvent([]byte("%PDF-1.3\n"), 2)
+	listErr := errors.New("connection refused")
+
+	// Neither the event nor the checkpoint is stored, so the events are processed again later.
+	for _, event := range []relay.Event{s.newEvent(s.v2, 1, carrier, shipper, bank), fileEvent} {
+		gomock.InOrder(
+			s.buStorage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+			s.buStorage.EXPECT().ListAuthentication(gomock.Any(), s.tx, business_unit.ListAuthenticationRequest{Limit: 100}).Return(business_unit.ListAuthenticationResult{}, listErr),
+			s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+		)
+
+		_, err := inbox.ProcessEvent(s.ctx, s.ts, "relay1", event)
+		s.Assert().ErrorIs(err, listErr)
+	}
+}
+
+// newFileEvent returns a pack issued with the file and the event carrying the content of the file.
+func (s *BillOfLadingInboxTestSuite) newFileEvent(content []byte, offset int64) (trade_document.BillOfLadingPackRecord, trade_document.BillOfLadingFile, relay.Event) {
+	file := model.File{Name: "bl.pdf", Hash: fileHash(content), Size: int64(len(content)), MediaType: "application/pdf"}
+	pack, err := trade_document.NewBillOfLadingService().Issue(s.ts, trade_document.IssueBillOfLadingRequest{
+		Issuer:       carrier,
+		TransferTo:   bank,
+		BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-with-file"},
+		File:         &file,
+	})
+	s.Require().NoError(err)
+
+	file.Content = content
+	billOfLadingFile := trade_document.BillOfLadingFile{PackID: pack.ID, Version: 1, File: file}
+	raw, err := json.Marshal(billOfLadingFile)
+	s.Require().NoError(err)
+	event := relay.Event{Offset: offset, Type: trade_document.BillOfLadingFileEventType, Data: s.encrypt(raw, carrier, bank)}
+	return trade_document.BillOfLadingPackRecord{Pack: pack, CreatedAt: s.ts}, billOfLadingFile, event
+}
+
+func (s *BillOfLadingInboxTestSuite) TestProcessFileEvent() {
+	blobStore := mock_blob_store.NewMockBlobStore(s.ctrl)
+	inbox := trade_document.NewBillOfLadingInbox(s.buStorage, s.storage, trade_document.WithInboxBlobStore(blobStore))
+	record, file, event := s.newFileEvent([]byte("%PDF-1.3\n"), 3)
+
+	var storedEvent model.RelayEvent
+	s.expectLoadKeys()
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil, nil),
+		s.storage.EXPECT().GetBillOfLadingPackHistory(gomock.Any(), s.tx, record.Pack.ID).Return([]trade_document.BillOfLadingPackRecord{record}, nil),
+		blobStore.EXPECT().Put(gomock.Any(), file.File).Return(model.File{}, nil),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, event model.RelayEvent) error {
+				storedEvent = event
+				return nil
+			},
+		),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay1", int64(3)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	relayEvent, err := inbox.ProcessEvent(s.ctx, s.ts, "relay1", event)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.RelayEventStatusAccepted, relayEvent.Status)
+	s.Assert().Equal(trade_document.BillOfLadingFileEventType, relayEvent.Type)
+	s.Assert().Equal(record.Pack.ID, relayEvent.PackID)
+	s.Assert().EqualValues(1, relayEvent.Version)
+	s.Assert().Equal(relayEvent, storedEvent)
+
+	// The content doesn't match the hash.
+	file.File.Content = []byte("%PDF-1.3\ntampered\n")
+	raw, err := json.Marshal(file)
+	s.Require().NoError(err)
+	event = relay.Event{Offset: 4, Type: trade_document.BillOfLadingFileEventType, Data: s.encrypt(raw, bank)}
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil, nil),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay1", int64(4)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	relayEvent, err = inbox.ProcessEvent(s.ctx, s.ts, "relay1", event)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.RelayEventStatusRejected, relayEvent.Status)
+}
+
+func (s *BillOfLadingInboxTestSuite) TestProcessFileEventNotAttached() {
+	blobStore := mock_blob_store.NewMockBlobStore(s.ctrl)
+	inbox := trade_document.NewBillOfLadingInbox(s.buStorage, s.storage, trade_document.WithInboxBlobStore(blobStore))
+	_, _, event := s.newFileEvent([]byte("%PDF-1.3\n"), 3)
+	record, _, _ := s.newFileEvent([]byte("%PDF-1.3\nanother\n"), 0)
+
+	// The stored version of the pack attaches another file, so the content is not kept.
+	s.expectLoadKeys()
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil, nil),
+		s.storage.EXPECT().GetBillOfLadingPackHistory(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, packID string) ([]trade_document.BillOfLadingPackRecord, error) {
+				record.Pack.ID = packID
+				return []trade_document.BillOfLadingPackRecord{record}, nil
+			},
+		),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay1", int64(3)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	relayEvent, err := inbox.ProcessEvent(s.ctx, s.ts, "relay1", event)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.RelayEventStatusRejected, relayEvent.Status)
+	s.Assert().Contains(relayEvent.Reason, "not attached")
+}
+
+func (s *BillOfLadingInboxTestSuite) TestProcessFileEventBeforePack() {
+	blobStore := mock_blob_store.NewMockBlobStore(s.ctrl)
+	inbox := trade_document.NewBillOfLadingInbox(
+		s.buStorage,
+		s.storage,
+		trade_document.WithInboxBlobStore(blobStore),
+		trade_document.WithInboxRootCertificates([]*x509.Certificate{s.rootCert}),
+	)
+	record, file, fileEvent := s.newFileEvent([]byte("%PDF-1.3\n"), 3)
+
+	// The file arrives first, so it is pending.
+	var pendingFile trade_document.PendingBillOfLadingFile
+	s.expectLoadKeys()
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil, nil),
+		s.storage.EXPECT().GetBillOfLadingPackHistory(gomock.Any(), s.tx, record.Pack.ID).Return(nil, nil),
+		s.storage.EXPECT().StorePendingFile(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, file trade_document.PendingBillOfLadingFile) error {
+				pendingFile = file
+				return nil
+			},
+		),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay1", int64(3)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	relayEvent, err := inbox.ProcessEvent(s.ctx, s.ts, "relay1", fileEvent)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.RelayEventStatusPending, relayEvent.Status)
+	s.Assert().Equal(relayEvent, pendingFile.Event)
+	s.Assert().Equal(fileEvent.Data, pendingFile.EncryptedFile)
+
+	// The file is kept when the pack attaching it arrives.
+	packEvent := s.newEvent(record.Pack, 4, carrier, bank)
+	var settledEvent model.RelayEvent
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil, nil),
+		s.storage.EXPECT().GetBillOfLadingPackHistory(gomock.Any(), s.tx, record.Pack.ID).Return(nil, nil),
+		s.storage.EXPECT().StoreBillOfLadingPack(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+		s.storage.EXPECT().ListPendingFiles(gomock.Any(), s.tx, record.Pack.ID, int64(1)).Return([]trade_document.PendingBillOfLadingFile{pendingFile}, nil),
+		blobStore.EXPECT().Put(gomock.Any(), file.File).Return(model.File{}, nil),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, event model.RelayEvent) error {
+				settledEvent = event
+				return nil
+			},
+		),
+		s.storage.EXPECT().DeletePendingFiles(gomock.Any(), s.tx, record.Pack.ID, int64(1)).Return(nil),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay1", int64(4)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	relayEvent, err = inbox.ProcessEvent(s.ctx, s.ts, "relay1", packEvent)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.RelayEventStatusAccepted, relayEvent.Status)
+	s.Assert().Equal(pendingFile.Event.ID, settledEvent.ID)
+	s.Assert().Equal(model.RelayEventStatusAccepted, settledEvent.Status)
+}
+
+func (s *BillOfLadingInboxTestSuite) TestProcessEventOfForkedPack() {
+	service := trade_document.NewBillOfLadingService()
+	forked, err := service.Transfer(s.ts, s.v1.Pack, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: consignee})
+	s.Require().NoError(err)
+	event := s.newEvent(forked, 3, carrier, shipper, bank)
+	known := trade_document.BillOfLadingPackRecord{Pack: s.v2, CreatedAt: s.ts}
+
+	s.expectLoadKeys()
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil, nil),
+		s.storage.EXPECT().GetBillOfLadingPackHistory(gomock.Any(), s.tx, forked.ID).Return([]trade_document.BillOfLadingPackRecord{s.v1, known}, nil),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay1", int64(3)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	relayEvent, err := s.inbox.ProcessEvent(s.ctx, s.ts, "relay1", event)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.RelayEventStatusRejected, relayEvent.Status)
+	s.Assert().Contains(relayEvent.Reason, model.ErrBillOfLadingBrokenHashChain.Error())
+}
+
+func (s *BillOfLadingInboxTestSuite) TestProcessEventOfUntrustedSigner() {
+	s.inbox = trade_document.NewBillOfLadingInbox(s.buStorage, s.storage)
+	event := s.newEvent(s.v2, 5, bank)
+
+	s.expectLoadKeys()
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.storage.EXPECT().GetRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil, nil),
+		s.storage.EXPECT().StoreRelayEvent(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+		s.storage.EXPECT().StoreRelayCheckpoint(gomock.Any(), s.tx, s.ts, "relay1", int64(5)).Return(nil),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	relayEvent, err := s.inbox.ProcessEvent(s.ctx, s.ts, "relay1", event)
+	s.Require().NoError(err)
+	s.Assert().Equal(model.RelayEventStatusRejected, relayEvent.Status)
+	s.Assert().Contains(relayEvent.Reason, model.ErrBillOfLadingInvalidSignature.Error())
+}
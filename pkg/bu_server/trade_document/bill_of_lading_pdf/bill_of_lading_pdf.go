@@ -0,0 +1,711 @@
+// Package bill_of_lading_pdf renders bill of lading packs to PDF when they are printed to paper.
+//
+// The first page is laid out like a standard bill of lading. Whatever doesn't fit in its boxes continues on rider
+// pages, followed by the terms and conditions of the carrier. The last pages list the endorsement and
+// transfer history of the pack. Every page carries the ID and the version of the printed pack, and the first page
+// carries a QR code of QRCodePayload to check the authenticity of the paper later.
+package bill_of_lading_pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+)
+
+// Document is a version of a bill of lading pack to be printed.
+type Document struct {
+	PackID            string
+	PackVersion       int64                              // Version of the pack to be printed.
+	PackHash          string                             // SHA512 hash of the version of the pack to be printed.
+	TransportDocument *bill_of_lading.TransportDocument  // The latest bill of lading of the pack.
+	Events            []bill_of_lading.BillOfLadingEvent // Events of the pack, for its endorsement and transfer history.
+	PrintedBy         string                             // DID of the business unit who prints the bill of lading.
+	PrintedAt         time.Time
+}
+
+const (
+	pageHeight    = 297.0 // A4 in mm.
+	margin        = 10.0
+	contentWidth  = 190.0
+	halfWidth     = contentWidth / 2
+	quarterWidth  = contentWidth / 4
+	contentBottom = pageHeight - 15 // Above the footer.
+	labelHeight   = 3.5
+	lineHeight    = 3.5
+	fontFamily    = "Helvetica"
+
+	continuedOnRider = "(continued on rider page)"
+	dateTimeLayout   = "2006-01-02 15:04 UTC"
+	dateLayout       = "2006-01-02"
+)
+
+// section is content which flows onto as many pages as it needs.
+type section struct {
+	title  string
+	widths []float64
+	header []string
+	rows   [][]string
+}
+
+type renderer struct {
+	pdf    *fpdf.Fpdf
+	tr     func(string) string
+	doc    Document
+	td     *bill_of_lading.TransportDocument
+	si     *bill_of_lading.ShippingInstruction
+	riders []section
+}
+
+// Render renders the document to PDF.
+func Render(doc Document) ([]byte, error) {
+	if doc.TransportDocument == nil {
+		return nil, fmt.Errorf("missing transport document%w", model.ErrInvalidParameter)
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AliasNbPages("")
+	// Keep the output reproducible from the document.
+	pdf.SetCatalogSort(true)
+	pdf.SetCreationDate(doc.PrintedAt)
+	pdf.SetModificationDate(doc.PrintedAt)
+	pdf.SetCreator("openebl", false)
+	pdf.SetTitle(fmt.Sprintf("%s %s", documentTitle(doc.TransportDocument), doc.TransportDocument.TransportDocumentReference), true)
+
+	r := &renderer{
+		pdf: pdf,
+		tr:  pdf.UnicodeTranslatorFromDescriptor(""),
+		doc: doc,
+		td:  doc.TransportDocument,
+		si:  doc.TransportDocument.ShippingInstruction,
+	}
+	if r.si == nil {
+		r.si = &bill_of_lading.ShippingInstruction{}
+	}
+	pdf.SetFooterFunc(r.footer)
+
+	if err := r.renderFirstPage(); err != nil {
+		return nil, err
+	}
+	r.renderSections("RIDER PAGE", append(r.riders, r.clauseSections()...))
+	r.renderSections("ENDORSEMENT AND TRANSFER HISTORY", []section{r.historySection()})
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *renderer) renderFirstPage() error {
+	r.pdf.AddPage()
+	td, si := r.td, r.si
+	left, right := margin, margin+halfWidth
+
+	// Title and references.
+	r.box(left, 10, halfWidth, 34, "Shipper", r.partyLines(bill_of_lading.OS_PartyFunction))
+	r.title(right, 10, halfWidth, 16)
+	r.box(right, 26, quarterWidth, 18, "B/L No.", []string{td.TransportDocumentReference})
+	r.box(right+quarterWidth, 26, quarterWidth, 18, "Booking reference", []string{si.CarrierBookingReference})
+
+	// Parties.
+	r.box(left, 44, halfWidth, 30, "Consignee", r.partyLines(bill_of_lading.CN_PartyFunction))
+	r.box(right, 44, halfWidth, 30, "Carrier", r.carrierLines())
+	r.box(left, 74, halfWidth, 26, "Notify party", r.partyLines(bill_of_lading.N1_PartyFunction))
+	r.box(right, 74, halfWidth, 26, "References", r.referenceLines())
+
+	// Routing.
+	r.box(left, 100, quarterWidth, 12, "Place of receipt", r.locationLines(si.DisplayedNameForPlaceOfReceipt, bill_of_lading.PRE_ShipmentLocationTypeCode, nil))
+	r.box(left+quarterWidth, 100, quarterWidth, 12, "Port of loading", r.locationLines(si.DisplayedNameForPortOfLoad, bill_of_lading.POL_ShipmentLocationTypeCode, r.loadLocation()))
+	r.box(right, 100, quarterWidth, 12, "Port of discharge", r.locationLines(si.DisplayedNameForPortOfDischarge, bill_of_lading.POD_ShipmentLocationTypeCode, r.dischargeLocation()))
+	r.box(right+quarterWidth, 100, quarterWidth, 12, "Place of delivery", r.locationLines(si.DisplayedNameForPlaceOfDelivery, bill_of_lading.PDE_ShipmentLocationTypeCode, nil))
+	r.box(left, 112, halfWidth, 12, "Vessel and voyage", r.vesselLines())
+	onBoardLabel, onBoardDate := r.onBoardDate()
+	r.box(right, 112, quarterWidth, 12, onBoardLabel, []string{onBoardDate})
+	r.box(right+quarterWidth, 112, quarterWidth, 12, "Number of original B/Ls", []string{r.numberOfOriginals()})
+
+	// Particulars of cargo.
+	particulars := r.particularsSection()
+	r.table(left, 124, 76, particulars)
+
+	// Charges and issuance.
+	r.table(left, 200, 30, r.chargesSection())
+	r.box(right, 200, halfWidth, 10, "Declared value", r.declaredValueLines())
+	r.box(right, 210, halfWidth, 10, "Freight payable at", r.locationLines(nil, "", td.InvoicePayableAt))
+	r.box(right, 220, halfWidth, 10, "Place and date of issue", r.issueLines())
+
+	// Carrier clauses and the QR code to verify the paper.
+	clauses := make([]string, 0, len(td.CarrierClauses))
+	for _, clause := range td.CarrierClauses {
+		clauses = append(clauses, clause.ClauseContent)
+	}
+	r.box(left, 230, contentWidth-50, 50, "Carrier clauses", clauses)
+	r.pdf.Rect(margin+contentWidth-50, 230, 50, 50, "D")
+	payload := QRCodePayload{PackID: r.doc.PackID, PackVersion: r.doc.PackVersion, PackHash: r.doc.PackHash}
+	if err := drawQRCode(r.pdf, payload.String(), margin+contentWidth-45, 232, 40); err != nil {
+		return err
+	}
+	r.pdf.SetFont(fontFamily, "", 5.5)
+	r.pdf.SetXY(margin+contentWidth-50, 273)
+	r.pdf.MultiCell(50, 2.5, r.tr("Scan to verify this paper against\nits electronic bill of lading."), "", "C", false)
+
+	r.pdf.SetFont(fontFamily, "I", 6.5)
+	r.pdf.SetXY(left, 281)
+	r.pdf.MultiCell(contentWidth, 2.8, r.tr(fmt.Sprintf(
+		"Electronically issued by %s. This document was printed to paper by %s on %s. "+
+			"The electronic bill of lading is no longer transferable; this paper document replaces it.",
+		issuer(r.doc.Events), r.doc.PrintedBy, r.doc.PrintedAt.UTC().Format(dateTimeLayout),
+	)), "", "L", false)
+	return r.pdf.Error()
+}
+
+func (r *renderer) title(x, y, w, h float64) {
+	r.pdf.Rect(x, y, w, h, "D")
+	r.pdf.SetFont(fontFamily, "B", 14)
+	r.pdf.SetXY(x, y+2)
+	r.pdf.CellFormat(w, 7, r.tr(documentTitle(r.td)), "", 0, "C", false, 0, "")
+	r.pdf.SetFont(fontFamily, "", 7)
+	r.pdf.SetXY(x, y+9)
+	subtitle := "NON-NEGOTIABLE"
+	if r.si.IsToOrder {
+		subtitle = "NEGOTIABLE - TO ORDER"
+	}
+	r.pdf.CellFormat(w, 3, r.tr(subtitle+" - PRINTED FROM AN ELECTRONIC BILL OF LADING"), "", 0, "C", false, 0, "")
+}
+
+func (r *renderer) footer() {
+	r.pdf.SetFont(fontFamily, "", 6)
+	r.pdf.SetTextColor(80, 80, 80)
+	r.pdf.SetXY(margin, pageHeight-9)
+	r.pdf.CellFormat(contentWidth-25, 3, r.tr(fmt.Sprintf(
+		"B/L No. %s - eBL pack %s version %d - printed %s",
+		r.td.TransportDocumentReference, r.doc.PackID, r.doc.PackVersion, r.doc.PrintedAt.UTC().Format(dateTimeLayout),
+	)), "", 0, "L", false, 0, "")
+	r.pdf.CellFormat(25, 3, fmt.Sprintf("Page %d of {nb}", r.pdf.PageNo()), "", 0, "R", false, 0, "")
+	r.pdf.SetTextColor(0, 0, 0)
+}
+
+// box draws a labelled box of lines. Lines that don't fit continue on a rider page.
+func (r *renderer) box(x, y, w, h float64, label string, lines []string) {
+	rows := make([][]string, 0, len(lines))
+	for _, line := range lines {
+		if line != "" {
+			rows = append(rows, []string{line})
+		}
+	}
+	r.table(x, y, h, section{title: label, widths: []float64{w}, rows: rows})
+}
+
+// table draws the section in a labelled box of height h. Rows that don't fit continue on a rider page.
+func (r *renderer) table(x, y, h float64, s section) {
+	width := 0.0
+	for _, w := range s.widths {
+		width += w
+	}
+	r.pdf.Rect(x, y, width, h, "D")
+	r.pdf.SetFont(fontFamily, "B", 6)
+	r.pdf.SetTextColor(80, 80, 80)
+	r.pdf.SetXY(x+1, y+0.5)
+	r.pdf.CellFormat(width-2, labelHeight, r.tr(s.title), "", 0, "L", false, 0, "")
+	r.pdf.SetTextColor(0, 0, 0)
+
+	top, bottom := y+labelHeight+0.5, y+h-0.5
+	if len(s.header) > 0 {
+		top = r.row(x, top, s.widths, s.header, "B")
+	}
+
+	fit := r.fit(s.widths, s.rows, bottom-top)
+	if fit < len(s.rows) {
+		// Leave room for the continuation note.
+		fit = r.fit(s.widths, s.rows, bottom-top-lineHeight)
+	}
+	for _, row := range s.rows[:fit] {
+		top = r.row(x, top, s.widths, row, "")
+	}
+	if fit < len(s.rows) {
+		r.pdf.SetFont(fontFamily, "I", 7)
+		r.pdf.SetXY(x+1, top)
+		r.pdf.CellFormat(width-2, lineHeight, continuedOnRider, "", 0, "L", false, 0, "")
+		r.riders = append(r.riders, r.riderSection(s, s.rows[fit:]))
+	}
+}
+
+// riderSection spreads the rest of the section over the width of a rider page.
+func (r *renderer) riderSection(s section, rows [][]string) section {
+	width := 0.0
+	for _, w := range s.widths {
+		width += w
+	}
+	widths := make([]float64, len(s.widths))
+	for i, w := range s.widths {
+		widths[i] = w * contentWidth / width
+	}
+	return section{title: s.title + " (continued)", widths: widths, header: s.header, rows: rows}
+}
+
+// renderSections draws sections on new pages titled with kind.
+func (r *renderer) renderSections(kind string, sections []section) {
+	if len(sections) == 0 {
+		return
+	}
+
+	var y float64
+	newPage := func() {
+		r.pdf.AddPage()
+		r.pdf.SetFont(fontFamily, "B", 11)
+		r.pdf.SetXY(margin, margin)
+		r.pdf.CellFormat(contentWidth, 6, r.tr(kind), "B", 0, "L", false, 0, "")
+		r.pdf.SetFont(fontFamily, "", 7)
+		r.pdf.SetXY(margin, margin)
+		r.pdf.CellFormat(contentWidth, 6, r.tr(fmt.Sprintf("%s %s", documentTitle(r.td), r.td.TransportDocumentReference)), "", 0, "R", false, 0, "")
+		y = margin + 9
+	}
+	newPage()
+
+	for _, s := range sections {
+		title := s.title
+		rows := s.rows
+		for {
+			// Start the section on a new page if not even its title and first row fit.
+			if y+6+lineHeight*2 > contentBottom {
+				newPage()
+			}
+			r.pdf.SetFont(fontFamily, "B", 8)
+			r.pdf.SetXY(margin, y)
+			r.pdf.CellFormat(contentWidth, 5, r.tr(title), "", 0, "L", false, 0, "")
+			y += 5
+			if len(s.header) > 0 {
+				y = r.row(margin, y, s.widths, s.header, "B")
+			}
+
+			fit := r.fit(s.widths, rows, contentBottom-y)
+			if fit == 0 && len(rows) > 0 {
+				// A row taller than a page is cut rather than moved to the next page forever.
+				rows[0] = r.truncate(s.widths, rows[0], int((contentBottom-y)/lineHeight))
+				fit = 1
+			}
+			for _, row := range rows[:fit] {
+				y = r.row(margin, y, s.widths, row, "")
+			}
+			rows = rows[fit:]
+			if len(rows) == 0 {
+				y += 3
+				break
+			}
+			newPage()
+			title = strings.TrimSuffix(s.title, " (continued)") + " (continued)"
+		}
+	}
+}
+
+// row draws a row of cells from y and returns where the next row starts.
+func (r *renderer) row(x, y float64, widths []float64, cells []string, style string) float64 {
+	r.pdf.SetFont(fontFamily, style, 7.5)
+	lines := r.wrapRow(widths, cells)
+	height := 0
+	for i, cellLines := range lines {
+		for j, line := range cellLines {
+			r.pdf.SetXY(x+1, y+float64(j)*lineHeight)
+			r.pdf.CellFormat(widths[i]-2, lineHeight, line, "", 0, "L", false, 0, "")
+		}
+		height = max(height, len(cellLines))
+		x += widths[i]
+	}
+	return y + float64(max(height, 1))*lineHeight
+}
+
+// fit returns how many rows fit in height.
+func (r *renderer) fit(widths []float64, rows [][]string, height float64) int {
+	r.pdf.SetFont(fontFamily, "", 7.5)
+	used := 0.0
+	for i, row := range rows {
+		rowHeight := 0
+		for _, cellLines := range r.wrapRow(widths, row) {
+			rowHeight = max(rowHeight, len(cellLines))
+		}
+		used += float64(max(rowHeight, 1)) * lineHeight
+		if used > height {
+			return i
+		}
+	}
+	return len(rows)
+}
+
+func (r *renderer) truncate(widths []float64, cells []string, maxLines int) []string {
+	r.pdf.SetFont(fontFamily, "", 7.5)
+	result := make([]string, len(cells))
+	for i, cellLines := range r.wrapRow(widths, cells) {
+		if len(cellLines) > maxLines {
+			cellLines = cellLines[:max(maxLines, 1)]
+		}
+		result[i] = strings.Join(cellLines, "\n")
+	}
+	return result
+}
+
+// wrapRow translates and wraps the cells of a row to their widths with the current font.
+func (r *renderer) wrapRow(widths []float64, cells []string) [][]string {
+	lines := make([][]string, len(cells))
+	for i, cell := range cells {
+		if i >= len(widths) {
+			break
+		}
+		for _, paragraph := range strings.Split(r.tr(cell), "\n") {
+			if paragraph == "" {
+				lines[i] = append(lines[i], "")
+				continue
+			}
+			lines[i] = append(lines[i], r.pdf.SplitText(paragraph, widths[i]-2)...)
+		}
+	}
+	return lines
+}
+
+func (r *renderer) partyLines(function bill_of_lading.PartyFunction) []string {
+	for _, documentParty := range r.si.DocumentParties {
+		if documentParty.PartyFunction == nil || *documentParty.PartyFunction != function {
+			continue
+		}
+		var lines []string
+		if documentParty.Party != nil {
+			lines = append(lines, documentParty.Party.PartyName)
+		}
+		if len(documentParty.DisplayedAddress) > 0 {
+			lines = append(lines, documentParty.DisplayedAddress...)
+		} else if documentParty.Party != nil {
+			lines = append(lines, addressLines(documentParty.Party.Address)...)
+		}
+		if documentParty.Party != nil {
+			lines = append(lines, contactLines(documentParty.Party.PartyContactDetails)...)
+		}
+		return lines
+	}
+	if function == bill_of_lading.CN_PartyFunction && r.si.IsToOrder {
+		return []string{"TO ORDER"}
+	}
+	return nil
+}
+
+func (r *renderer) carrierLines() []string {
+	var lines []string
+	if party := r.td.IssuingParty; party != nil {
+		lines = append(lines, party.PartyName)
+		lines = append(lines, addressLines(party.Address)...)
+		lines = append(lines, contactLines(party.PartyContactDetails)...)
+	}
+	if r.td.CarrierCode != "" {
+		lines = append(lines, fmt.Sprintf("Carrier code: %s (%s)", r.td.CarrierCode, r.td.CarrierCodeListProvider))
+	}
+	return lines
+}
+
+func (r *renderer) referenceLines() []string {
+	var lines []string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, label+": "+value)
+		}
+	}
+	add("Shipping instruction", r.si.ShippingInstructionReference)
+	add("Service contract", r.td.ServiceContractReference)
+	add("Carrier service", strings.TrimSpace(r.td.CarrierServiceCode+" "+r.td.CarrierServiceName))
+	add("Universal service reference", r.td.UniversalServiceReference)
+	add("Universal export voyage reference", r.td.UniversalExportVoyageReference)
+	for _, reference := range r.si.References {
+		add(string(reference.Type), reference.Value)
+	}
+	return lines
+}
+
+func (r *renderer) locationLines(displayed []string, code bill_of_lading.ShipmentLocationTypeCode, fallback *bill_of_lading.Location) []string {
+	if len(displayed) > 0 {
+		return displayed
+	}
+	location := fallback
+	for _, shipmentLocation := range r.td.ShipmentLocations {
+		if code != "" && shipmentLocation.ShipmentLocationTypeCode == code && shipmentLocation.Location != nil {
+			location = shipmentLocation.Location
+			break
+		}
+	}
+	if location == nil {
+		return nil
+	}
+
+	name := location.LocationName
+	if location.Address != nil && location.Address.City != "" {
+		name = strings.TrimPrefix(strings.TrimSpace(name+", "+location.Address.City), ", ")
+	}
+	if location.UNLocationCode != "" {
+		name = strings.TrimSpace(name + " (" + location.UNLocationCode + ")")
+	}
+	return []string{name}
+}
+
+func (r *renderer) loadLocation() *bill_of_lading.Location {
+	if len(r.td.Transports) == 0 {
+		return nil
+	}
+	return r.td.Transports[0].LoadLocation
+}
+
+func (r *renderer) dischargeLocation() *bill_of_lading.Location {
+	if len(r.td.Transports) == 0 {
+		return nil
+	}
+	return r.td.Transports[len(r.td.Transports)-1].DischargeLocation
+}
+
+func (r *renderer) vesselLines() []string {
+	vessel, voyage := r.td.VesselName, r.td.CarrierExportVoyageNumber
+	if len(r.td.Transports) > 0 {
+		transport := r.td.Transports[0]
+		if vessel == "" {
+			vessel = transport.VesselName
+		}
+		if voyage == "" {
+			voyage = transport.CarrierExportVoyageNumber
+		}
+		if transport.VesselIMONumber != "" {
+			vessel = strings.TrimSpace(vessel + " (IMO " + transport.VesselIMONumber + ")")
+		}
+	}
+	if voyage != "" {
+		return []string{vessel + " / " + voyage}
+	}
+	return []string{vessel}
+}
+
+func (r *renderer) onBoardDate() (string, string) {
+	if r.td.ShippedOnBoardDate != nil {
+		return "Shipped on board", formatDate(r.td.ShippedOnBoardDate.Unix())
+	}
+	if r.td.ReceivedForShipmentDate != nil {
+		return "Received for shipment", formatDate(r.td.ReceivedForShipmentDate.Unix())
+	}
+	if r.si.IsShippedOnBoardType {
+		return "Shipped on board", ""
+	}
+	return "Received for shipment", ""
+}
+
+func (r *renderer) numberOfOriginals() string {
+	originals := r.si.NumberOfOriginalsWithCharges + r.si.NumberOfOriginalsWithoutCharges
+	if originals <= 0 {
+		originals = 1
+	}
+	return strconv.Itoa(int(originals))
+}
+
+func (r *renderer) particularsSection() section {
+	seals := make(map[string][]string)
+	for _, equipment := range r.si.UtilizedTransportEquipments {
+		if equipment.Equipment == nil {
+			continue
+		}
+		for _, seal := range equipment.Seals {
+			seals[equipment.Equipment.EquipmentReference] = append(seals[equipment.Equipment.EquipmentReference], seal.Number)
+		}
+	}
+
+	s := section{
+		title:  "Particulars furnished by the shipper",
+		widths: []float64{38, 28, 78, 23, 23},
+		header: []string{"Container / seals", "Packages", "Description of goods", "Gross weight", "Measurement"},
+	}
+	for _, consignmentItem := range r.si.ConsignmentItems {
+		for i, cargoItem := range consignmentItem.CargoItems {
+			container := cargoItem.EquipmentReference
+			if len(seals[container]) > 0 {
+				container += "\nSeal: " + strings.Join(seals[container], ", ")
+			}
+			packages := strings.TrimSpace(fmt.Sprintf("%d %s", cargoItem.NumberOfPackages, cargoItem.PackageCode))
+			if cargoItem.PackageNameOnBL != "" {
+				packages += "\n" + cargoItem.PackageNameOnBL
+			}
+			description := ""
+			if i == 0 {
+				description = consignmentItem.DescriptionOfGoods
+				if consignmentItem.HSCode != "" {
+					description += "\nHS code: " + consignmentItem.HSCode
+				}
+			}
+			s.rows = append(s.rows, []string{
+				container,
+				packages,
+				description,
+				quantity(cargoItem.Weight, string(cargoItem.WeightUnit)),
+				quantity(cargoItem.Volume, string(cargoItem.VolumeUnit)),
+			})
+		}
+	}
+	return s
+}
+
+func (r *renderer) chargesSection() section {
+	s := section{
+		title:  "Freight and charges",
+		widths: []float64{40, 35, 20},
+	}
+	for _, charge := range r.td.Charges {
+		s.rows = append(s.rows, []string{
+			charge.ChargeType,
+			quantity(charge.CurrencyAmount, charge.CurrencyCode),
+			paymentTerm(charge.PaymentTermCode),
+		})
+	}
+	return s
+}
+
+func (r *renderer) declaredValueLines() []string {
+	if r.td.DeclaredValue == nil {
+		return nil
+	}
+	return []string{quantity(r.td.DeclaredValue, r.td.DeclaredValueCurrency)}
+}
+
+func (r *renderer) issueLines() []string {
+	place := r.locationLines(nil, "", r.td.PlaceOfIssue)
+	if len(place) == 0 {
+		place = r.locationLines(nil, "", r.si.PlaceOfIssue)
+	}
+	date := ""
+	if r.td.IssueDate != nil {
+		date = formatDate(r.td.IssueDate.Unix())
+	}
+	return []string{strings.TrimPrefix(strings.Join(append(place, date), ", "), ", ")}
+}
+
+// clauseSections returns the terms and conditions printed on rider pages.
+func (r *renderer) clauseSections() []section {
+	var sections []section
+	if r.td.TermsAndConditions != "" {
+		s := section{title: "Terms and conditions", widths: []float64{contentWidth}}
+		for _, paragraph := range strings.Split(r.td.TermsAndConditions, "\n") {
+			s.rows = append(s.rows, []string{paragraph})
+		}
+		sections = append(sections, s)
+	}
+	return sections
+}
+
+func (r *renderer) historySection() section {
+	s := section{
+		title:  fmt.Sprintf("Events of eBL pack %s", r.doc.PackID),
+		widths: []float64{8, 32, 34, 76, 40},
+		header: []string{"#", "Event", "Date", "Parties", "Note"},
+	}
+	add := func(event string, at *model.DateTime, by, to, note string) {
+		parties := "by " + by
+		if to != "" {
+			parties += "\nto " + to
+		}
+		date := ""
+		if at != nil {
+			date = formatDateTime(at.Unix())
+		}
+		s.rows = append(s.rows, []string{strconv.Itoa(len(s.rows) + 1), event, date, parties, note})
+	}
+
+	for i, event := range r.doc.Events {
+		switch {
+		case event.BillOfLading != nil && i == 0:
+			add("Issued", event.BillOfLading.CreatedAt, event.BillOfLading.CreatedBy, event.BillOfLading.TransferTo, "")
+		case event.BillOfLading != nil:
+			add("Amended", event.BillOfLading.CreatedAt, event.BillOfLading.CreatedBy, event.BillOfLading.TransferTo, "")
+		case event.Transfer != nil:
+			add("Endorsed / transferred", event.Transfer.TransferAt, event.Transfer.TransferBy, event.Transfer.TransferTo, event.Transfer.Note)
+		case event.Return != nil:
+			add("Returned", event.Return.ReturnAt, event.Return.ReturnBy, event.Return.ReturnTo, event.Return.Note)
+		case event.Surrender != nil:
+			add("Surrendered", event.Surrender.SurrenderAt, event.Surrender.SurrenderBy, "", event.Surrender.Note)
+		case event.AmendmentRequest != nil:
+			add("Amendment requested", event.AmendmentRequest.RequestAt, event.AmendmentRequest.RequestBy, "", event.AmendmentRequest.Note)
+		case event.PrintToPaper != nil:
+			add("Printed to paper", event.PrintToPaper.PrintAt, event.PrintToPaper.PrintBy, "", event.PrintToPaper.Note)
+		}
+	}
+	printedAt := model.NewDateTime(r.doc.PrintedAt)
+	add("Printed to paper", &printedAt, r.doc.PrintedBy, "", fmt.Sprintf("This document. Printed from version %d.", r.doc.PackVersion))
+	return s
+}
+
+func documentTitle(td *bill_of_lading.TransportDocument) string {
+	if td.ShippingInstruction != nil && td.ShippingInstruction.TransportDocumentTypeCode == bill_of_lading.SWB_TransportDocumentTypeCode {
+		return "SEA WAYBILL"
+	}
+	return "BILL OF LADING"
+}
+
+func issuer(events []bill_of_lading.BillOfLadingEvent) string {
+	if len(events) == 0 || events[0].BillOfLading == nil {
+		return ""
+	}
+	return events[0].BillOfLading.CreatedBy
+}
+
+func addressLines(address *bill_of_lading.Address) []string {
+	if address == nil {
+		return nil
+	}
+	join := func(parts ...string) string {
+		nonEmpty := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if part != "" {
+				nonEmpty = append(nonEmpty, part)
+			}
+		}
+		return strings.Join(nonEmpty, " ")
+	}
+	return []string{
+		join(address.Street, address.StreetNumber, address.Floor),
+		join(address.PostCode, address.City, address.StateRegion),
+		address.Country,
+	}
+}
+
+func contactLines(contacts []bill_of_lading.PartyContactDetail) []string {
+	lines := make([]string, 0, len(contacts))
+	for _, contact := range contacts {
+		parts := make([]string, 0, 3)
+		for _, part := range []string{contact.Name, contact.Phone, contact.Email} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		lines = append(lines, strings.Join(parts, ", "))
+	}
+	return lines
+}
+
+func quantity(value *model.Decimal, unit string) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(value.String() + " " + unit)
+}
+
+func paymentTerm(code bill_of_lading.PaymentTermCode) string {
+	switch code {
+	case bill_of_lading.PRE_PaymentTermCode:
+		return "Prepaid"
+	case bill_of_lading.COL_PaymentTermCode:
+		return "Collect"
+	}
+	return string(code)
+}
+
+func formatDate(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format(dateLayout)
+}
+
+func formatDateTime(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format(dateTimeLayout)
+}
@@ -0,0 +1,86 @@
+package bill_of_lading_pdf_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/bill_of_lading_pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadDocument(t *testing.T) bill_of_lading_pdf.Document {
+	raw, err := os.ReadFile("../dcsa_validator/testdata/transport_document.json")
+	require.NoError(t, err)
+	var td bill_of_lading.TransportDocument
+	require.NoError(t, json.Unmarshal(raw, &td))
+
+	issuedAt := model.NewDateTime(time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC))
+	transferAt := model.NewDateTime(time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC))
+	return bill_of_lading_pdf.Document{
+		PackID:            "pack-id",
+		PackVersion:       2,
+		PackHash:          "pack-hash",
+		TransportDocument: &td,
+		Events: []bill_of_lading.BillOfLadingEvent{
+			{BillOfLading: &bill_of_lading.BillOfLading{BillOfLading: &td, CreatedBy: "did:openebl:carrier", TransferTo: "did:openebl:shipper", CreatedAt: &issuedAt}},
+			{Transfer: &bill_of_lading.Transfer{TransferBy: "did:openebl:shipper", TransferTo: "did:openebl:consignee", TransferAt: &transferAt}},
+		},
+		PrintedBy: "did:openebl:consignee",
+		PrintedAt: time.Date(2024, 3, 3, 8, 0, 0, 0, time.UTC),
+	}
+}
+
+func pageCount(pdf []byte) int {
+	return bytes.Count(pdf, []byte("/Type /Page\n"))
+}
+
+func TestRender(t *testing.T) {
+	doc := loadDocument(t)
+	pdf, err := bill_of_lading_pdf.Render(doc)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+
+	// The same document renders the same bytes.
+	again, err := bill_of_lading_pdf.Render(doc)
+	require.NoError(t, err)
+	assert.Equal(t, pdf, again)
+
+	_, err = bill_of_lading_pdf.Render(bill_of_lading_pdf.Document{})
+	assert.ErrorIs(t, err, model.ErrInvalidParameter)
+}
+
+func TestRenderRiderPages(t *testing.T) {
+	doc := loadDocument(t)
+	pdf, err := bill_of_lading_pdf.Render(doc)
+	require.NoError(t, err)
+	pages := pageCount(pdf)
+
+	// Cargo items which don't fit on the first page continue on rider pages.
+	si := doc.TransportDocument.ShippingInstruction
+	item := si.ConsignmentItems[0]
+	for i := 0; i < 100; i++ {
+		item.CargoItems = append(item.CargoItems, item.CargoItems[0])
+	}
+	si.ConsignmentItems = []bill_of_lading.ConsignmentItem{item}
+	pdf, err = bill_of_lading_pdf.Render(doc)
+	require.NoError(t, err)
+	assert.Greater(t, pageCount(pdf), pages+1)
+}
+
+func TestQRCodePayload(t *testing.T) {
+	payload := bill_of_lading_pdf.QRCodePayload{PackID: "pack-id", PackVersion: 2, PackHash: "pack-hash"}
+	parsed, err := bill_of_lading_pdf.ParseQRCodePayload(payload.String())
+	require.NoError(t, err)
+	assert.Equal(t, payload, parsed)
+
+	_, err = bill_of_lading_pdf.ParseQRCodePayload("not json")
+	assert.ErrorIs(t, err, model.ErrInvalidParameter)
+	_, err = bill_of_lading_pdf.ParseQRCodePayload(`{"id":"pack-id","version":2}`)
+	assert.ErrorIs(t, err, model.ErrInvalidParameter)
+}
@@ -0,0 +1,65 @@
+package bill_of_lading_pdf
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/skip2/go-qrcode"
+)
+
+// QRCodePayload is the content of the QR code on the paper document. It identifies the version of the bill of lading
+// pack that was printed, so the authenticity of the paper can be checked against the pack later.
+type QRCodePayload struct {
+	PackID      string `json:"id"`      // ID of the bill of lading pack.
+	PackVersion int64  `json:"version"` // Version of the pack that was printed.
+	PackHash    string `json:"hash"`    // SHA512 hash of the printed version of the pack.
+}
+
+// ParseQRCodePayload parses the content of the QR code on the paper document.
+func ParseQRCodePayload(content string) (QRCodePayload, error) {
+	var payload QRCodePayload
+	if err := json.Unmarshal([]byte(content), &payload); err != nil {
+		return QRCodePayload{}, fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+	if payload.PackID == "" || payload.PackVersion <= 0 || payload.PackHash == "" {
+		return QRCodePayload{}, fmt.Errorf("incomplete QR code payload%w", model.ErrInvalidParameter)
+	}
+	return payload, nil
+}
+
+func (p QRCodePayload) String() string {
+	raw, _ := json.Marshal(p)
+	return string(raw)
+}
+
+// drawQRCode draws the QR code of content as vector squares of size x size at (x, y).
+func drawQRCode(pdf *fpdf.Fpdf, content string, x, y, size float64) error {
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return err
+	}
+	code.DisableBorder = true
+	bitmap := code.Bitmap()
+	if len(bitmap) == 0 {
+		return nil
+	}
+
+	module := size / float64(len(bitmap))
+	pdf.SetFillColor(0, 0, 0)
+	for row, modules := range bitmap {
+		// Draw runs of dark modules as one rectangle to keep the document small.
+		for col := 0; col < len(modules); col++ {
+			if !modules[col] {
+				continue
+			}
+			start := col
+			for col+1 < len(modules) && modules[col+1] {
+				col++
+			}
+			pdf.Rect(x+float64(start)*module, y+float64(row)*module, float64(col-start+1)*module, module, "F")
+		}
+	}
+	return nil
+}
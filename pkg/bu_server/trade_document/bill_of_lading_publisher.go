@@ -0,0 +1,425 @@
+package trade_document
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/openebl/openebl/pkg/bu_server/blob_store"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/openebl/openebl/pkg/pkix"
+)
+
+const (
+	BillOfLadingPackEventType = 1001 // Type of relay events carrying encrypted bill of lading packs.
+	BillOfLadingFileEventType = 1002 // Type of relay events carrying encrypted contents of files attached to packs.
+)
+
+// BillOfLadingFile is the file attached to a version of a bill of lading pack with its content.
+// It is carried by relay events of BillOfLadingFileEventType.
+type BillOfLadingFile struct {
+	PackID  string     `json:"pack_id"` // ID of the pack.
+	Version int64      `json:"version"` // Version of the pack attaching the file.
+	File    model.File `json:"file"`    // The file with its content.
+}
+
+// CertificateResolver looks up certificates of business units to encrypt bill of lading packs to them.
+type CertificateResolver interface {
+	ResolveCertificates(ctx context.Context, ts int64, businessUnit string) ([]*x509.Certificate, error)
+}
+
+// DeliveryStorage keeps the outbox of relay events and the delivery status of every version of bill of lading packs.
+type DeliveryStorage interface {
+	CreateTx(ctx context.Context, options ...storage.CreateTxOption) (storage.Tx, error)
+	StoreDelivery(ctx context.Context, tx storage.Tx, delivery model.BillOfLadingDelivery) error
+	ListDeliveries(ctx context.Context, tx storage.Tx, req ListDeliveriesRequest) (ListDeliveriesResult, error)
+	StoreOutboxMessage(ctx context.Context, tx storage.Tx, msg model.OutboxMessage) error
+	// ListPendingOutboxMessages returns pending messages due at ts in the order they were created. The messages are
+	// locked until tx ends, and messages locked by other transactions are skipped.
+	ListPendingOutboxMessages(ctx context.Context, tx storage.Tx, ts int64, limit int) ([]model.OutboxMessage, error)
+	GetOutboxStats(ctx context.Context, tx storage.Tx) (model.OutboxStats, error)
+}
+
+// ListDeliveriesRequest is the request to list deliveries of bill of lading packs.
+type ListDeliveriesRequest struct {
+	Offset int `json:"offset"` // Offset of the deliveries to be listed.
+	Limit  int `json:"limit"`  // Limit of the deliveries to be listed.
+
+	// Filters
+	PackID   string                 `json:"pack_id"`  // ID of the bill of lading pack.
+	Statuses []model.DeliveryStatus `json:"statuses"` // Statuses of the deliveries.
+}
+
+// ListDeliveriesResult is the result of listing deliveries of bill of lading packs.
+type ListDeliveriesResult struct {
+	Total   int                          `json:"total"`   // Total number of deliveries.
+	Records []model.BillOfLadingDelivery `json:"records"` // Records of deliveries.
+}
+
+// BillOfLadingPublisher delivers signed bill of lading packs to all their parties through the relay.
+type BillOfLadingPublisher interface {
+	Publish(ctx context.Context, tx storage.Tx, ts int64, signedPack envelope.JWS) (*envelope.JWE, model.BillOfLadingDelivery, error)
+}
+
+type _BillOfLadingPublisher struct {
+	resolver  CertificateResolver
+	storage   DeliveryStorage
+	blobStore blob_store.BlobStore
+}
+
+type BillOfLadingPublisherOption func(p *_BillOfLadingPublisher)
+
+// WithPublisherBlobStore sets the blob store to read contents of files referenced by packs from.
+// Without a blob store, only the packs are published.
+func WithPublisherBlobStore(blobStore blob_store.BlobStore) BillOfLadingPublisherOption {
+	return func(p *_BillOfLadingPublisher) {
+		p.blobStore = blobStore
+	}
+}
+
+func NewBillOfLadingPublisher(resolver CertificateResolver, storage DeliveryStorage, options ...BillOfLadingPublisherOption) *_BillOfLadingPublisher {
+	p := &_BillOfLadingPublisher{
+		resolver: resolver,
+		storage:  storage,
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// Publish encrypts the signed pack to the certificates of every party of the pack and writes it to the outbox in tx.
+// The events are tagged with RecipientTag of the parties, so their BU servers can subscribe to them without the
+// relay server learning the DIDs. The encrypted pack is
+// returned to be kept with the version of the pack.
+//
+// tx should be the transaction storing the pack, so the pack is published if and only if it is stored.
+// The OutboxDispatcher publishes it to the relay server after tx is committed.
+//
+// If the latest event of the pack references a file in the blob store, the content of the file is encrypted to the
+// same parties and written to the outbox as an event of BillOfLadingFileEventType before the pack.
+func (p *_BillOfLadingPublisher) Publish(ctx context.Context, tx storage.Tx, ts int64, signedPack envelope.JWS) (*envelope.JWE, model.BillOfLadingDelivery, error) {
+	payload, err := signedPack.GetPayload()
+	if err != nil {
+		return nil, model.BillOfLadingDelivery{}, fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+	var pack bill_of_lading.BillOfLadingPack
+	if err := json.Unmarshal(payload, &pack); err != nil {
+		return nil, model.BillOfLadingDelivery{}, fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	parties := GetBillOfLadingPackParties(pack)
+	keySettings := make([]envelope.KeyEncryptionSetting, 0, len(parties))
+	for _, party := range parties {
+		certs, err := p.resolver.ResolveCertificates(ctx, ts, party)
+		if err != nil {
+			return nil, model.BillOfLadingDelivery{}, err
+		}
+		if len(certs) == 0 {
+			return nil, model.BillOfLadingDelivery{}, fmt.Errorf("%s: %w", party, model.ErrBillOfLadingPartyCertificateNotFound)
+		}
+		for _, cert := range certs {
+			keySetting, err := keyEncryptionSetting(cert)
+			if err != nil {
+				return nil, model.BillOfLadingDelivery{}, fmt.Errorf("%s: %w", party, err)
+			}
+			keySettings = append(keySettings, keySetting)
+		}
+	}
+
+	if err := p.publishFile(ctx, tx, ts, pack, parties, keySettings); err != nil {
+		return nil, model.BillOfLadingDelivery{}, err
+	}
+
+	signedPackRaw, err := json.Marshal(signedPack)
+	if err != nil {
+		return nil, model.BillOfLadingDelivery{}, err
+	}
+	encryptedPack, err := envelope.Encrypt(signedPackRaw, envelope.ContentEncryptionAlgorithm(jwa.A256GCM), keySettings)
+	if err != nil {
+		return nil, model.BillOfLadingDelivery{}, err
+	}
+	data, err := json.Marshal(encryptedPack)
+	if err != nil {
+		return nil, model.BillOfLadingDelivery{}, err
+	}
+
+	msg := model.OutboxMessage{
+		ID:            eventID(data),
+		Type:          BillOfLadingPackEventType,
+		Data:          data,
+		Tags:          recipientTags(parties),
+		Recipients:    parties,
+		PackID:        pack.ID,
+		Version:       pack.Version,
+		Status:        model.OutboxMessageStatusPending,
+		NextAttemptAt: ts,
+		CreatedAt:     ts,
+		UpdatedAt:     ts,
+	}
+	if err := p.storage.StoreOutboxMessage(ctx, tx, msg); err != nil {
+		return nil, model.BillOfLadingDelivery{}, err
+	}
+
+	delivery := outboxMessageDelivery(msg)
+	if err := p.storage.StoreDelivery(ctx, tx, delivery); err != nil {
+		return nil, model.BillOfLadingDelivery{}, err
+	}
+	return &encryptedPack, delivery, nil
+}
+
+// publishFile writes the content of the file referenced by the latest event of the pack to the outbox.
+func (p *_BillOfLadingPublisher) publishFile(
+	ctx context.Context,
+	tx storage.Tx,
+	ts int64,
+	pack bill_of_lading.BillOfLadingPack,
+	parties []string,
+	keySettings []envelope.KeyEncryptionSetting,
+) error {
+	if p.blobStore == nil || len(pack.Events) == 0 {
+		return nil
+	}
+	files := packFiles(bill_of_lading.BillOfLadingPack{Events: pack.Events[len(pack.Events)-1:]})
+	if len(files) == 0 || files[0].Hash == "" {
+		return nil
+	}
+
+	file := *files[0]
+	content, err := p.blobStore.Get(ctx, file.Hash)
+	if err != nil {
+		return err
+	}
+	file.Content = content
+	plainText, err := json.Marshal(BillOfLadingFile{PackID: pack.ID, Version: pack.Version, File: file})
+	if err != nil {
+		return err
+	}
+	encryptedFile, err := envelope.Encrypt(plainText, envelope.ContentEncryptionAlgorithm(jwa.A256GCM), keySettings)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(encryptedFile)
+	if err != nil {
+		return err
+	}
+
+	msg := model.OutboxMessage{
+		ID:            eventID(data),
+		Type:          BillOfLadingFileEventType,
+		Data:          data,
+		Tags:          recipientTags(parties),
+		Recipients:    parties,
+		PackID:        pack.ID,
+		Version:       pack.Version,
+		Status:        model.OutboxMessageStatusPending,
+		NextAttemptAt: ts,
+		CreatedAt:     ts,
+		UpdatedAt:     ts,
+	}
+	return p.storage.StoreOutboxMessage(ctx, tx, msg)
+}
+
+// outboxMessageDelivery returns the delivery of the pack carried by the message.
+func outboxMessageDelivery(msg model.OutboxMessage) model.BillOfLadingDelivery {
+	delivery := model.BillOfLadingDelivery{
+		PackID:     msg.PackID,
+		Version:    msg.Version,
+		EventID:    msg.ID,
+		Recipients: msg.Recipients,
+		Status:     model.DeliveryStatusPending,
+		Error:      msg.LastError,
+		CreatedAt:  msg.CreatedAt,
+		UpdatedAt:  msg.UpdatedAt,
+	}
+	switch {
+	case msg.Status == model.OutboxMessageStatusDelivered:
+		delivery.Status = model.DeliveryStatusDelivered
+		delivery.Error = ""
+	case msg.Attempts > 0:
+		delivery.Status = model.DeliveryStatusFailed
+	}
+	return delivery
+}
+
+// GetBillOfLadingPackParties returns DIDs of all business units ever involved in the pack in sorted order.
+func GetBillOfLadingPackParties(pack bill_of_lading.BillOfLadingPack) []string {
+	set := make(map[string]bool)
+	add := func(dids ...string) {
+		for _, did := range dids {
+			if did != "" {
+				set[did] = true
+			}
+		}
+	}
+
+	add(pack.CurrentOwner)
+	for _, event := range pack.Events {
+		switch {
+		case event.BillOfLading != nil:
+			add(event.BillOfLading.CreatedBy, event.BillOfLading.TransferTo)
+		case event.Transfer != nil:
+			add(event.Transfer.TransferBy, event.Transfer.TransferTo)
+		case event.Return != nil:
+			add(event.Return.ReturnBy, event.Return.ReturnTo)
+		case event.Surrender != nil:
+			add(event.Surrender.SurrenderBy)
+		case event.AmendmentRequest != nil:
+			add(event.AmendmentRequest.RequestBy)
+		case event.PrintToPaper != nil:
+			add(event.PrintToPaper.PrintBy)
+		}
+	}
+
+	parties := make([]string, 0, len(set))
+	for did := range set {
+		parties = append(parties, did)
+	}
+	sort.Strings(parties)
+	return parties
+}
+
+func keyEncryptionSetting(cert *x509.Certificate) (envelope.KeyEncryptionSetting, error) {
+	switch cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		return envelope.KeyEncryptionSetting{PublicKey: cert.PublicKey, Algorithm: envelope.KeyEncryptionAlgorithm(jwa.ECDH_ES_A256KW)}, nil
+	case *rsa.PublicKey:
+		return envelope.KeyEncryptionSetting{PublicKey: cert.PublicKey, Algorithm: envelope.KeyEncryptionAlgorithm(jwa.RSA_OAEP_256)}, nil
+	}
+	return envelope.KeyEncryptionSetting{}, fmt.Errorf("unsupported public key type %T", cert.PublicKey)
+}
+
+// RecipientTag returns the routing tag of relay events sent to the business unit of the DID.
+// It is the hex encoded SHA256 of the DID, so relay servers route events without seeing who they are sent to.
+func RecipientTag(did string) string {
+	hash := sha256.Sum256([]byte(did))
+	return hex.EncodeToString(hash[:])
+}
+
+func recipientTags(dids []string) []string {
+	tags := make([]string, 0, len(dids))
+	for _, did := range dids {
+		tags = append(tags, RecipientTag(did))
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// eventID returns the ID the relay server gives to the event carrying data.
+func eventID(data []byte) string {
+	hash := sha512.Sum512(data)
+	return hex.EncodeToString(hash[:])
+}
+
+type _BusinessUnitCertificateResolver struct {
+	buStorage business_unit.BusinessUnitStorage
+	remote    CertificateResolver
+}
+
+type BusinessUnitCertificateResolverOption func(r *_BusinessUnitCertificateResolver)
+
+// WithRemoteCertificateResolver looks up certificates of business units hosted by other BU servers with the resolver.
+func WithRemoteCertificateResolver(resolver CertificateResolver) BusinessUnitCertificateResolverOption {
+	return func(r *_BusinessUnitCertificateResolver) {
+		r.remote = resolver
+	}
+}
+
+// NewBusinessUnitCertificateResolver returns a CertificateResolver which finds certificates from authentications of
+// business units known to this BU server. Business units without any authentication are hosted by other BU servers,
+// and they are looked up with the remote resolver if it is given.
+func NewBusinessUnitCertificateResolver(buStorage business_unit.BusinessUnitStorage, options ...BusinessUnitCertificateResolverOption) *_BusinessUnitCertificateResolver {
+	r := &_BusinessUnitCertificateResolver{
+		buStorage: buStorage,
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// ResolveCertificates returns leaf certificates of all active authentications of the business unit valid at ts.
+func (r *_BusinessUnitCertificateResolver) ResolveCertificates(ctx context.Context, ts int64, businessUnit string) ([]*x509.Certificate, error) {
+	tx, err := r.buStorage.CreateTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	req := business_unit.ListAuthenticationRequest{
+		Limit:          100,
+		BusinessUnitID: businessUnit,
+	}
+	result, err := r.buStorage.ListAuthentication(ctx, tx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Records) == 0 && r.remote != nil {
+		return r.remote.ResolveCertificates(ctx, ts, businessUnit)
+	}
+
+	var certs []*x509.Certificate
+	for _, auth := range result.Records {
+		if auth.Status != model.BusinessUnitAuthenticationStatusActive || !isCertificateValidAt(auth.Certificate, ts) {
+			continue
+		}
+		authCerts, err := pkix.ParseCertificate([]byte(auth.Certificate))
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, &authCerts[0])
+	}
+	return certs, nil
+}
+
+type _DirectoryCertificateResolver struct {
+	certs map[string][]*x509.Certificate // map[DID]leaf certificates
+}
+
+// NewDirectoryCertificateResolver returns a CertificateResolver of business units hosted by other BU servers.
+// Every chain starts from the leaf certificate of a business unit which carries its DID, and it has to chain to one of
+// rootCerts (or the system trusted certificates).
+func NewDirectoryCertificateResolver(chains [][]*x509.Certificate, rootCerts []*x509.Certificate) (*_DirectoryCertificateResolver, error) {
+	r := &_DirectoryCertificateResolver{
+		certs: make(map[string][]*x509.Certificate),
+	}
+	for _, chain := range chains {
+		if len(chain) == 0 {
+			continue
+		}
+		did := certificateDID(chain[0])
+		if did == "" {
+			return nil, fmt.Errorf("certificate %q has no DID%w", chain[0].Subject.CommonName, model.ErrInvalidParameter)
+		}
+		if err := pkix.Verify(chain, rootCerts); err != nil {
+			return nil, fmt.Errorf("untrusted certificate of %q: %s%w", did, err.Error(), model.ErrInvalidParameter)
+		}
+		r.certs[did] = append(r.certs[did], chain[0])
+	}
+	return r, nil
+}
+
+// ResolveCertificates returns certificates of the business unit in the directory valid at ts.
+func (r *_DirectoryCertificateResolver) ResolveCertificates(ctx context.Context, ts int64, businessUnit string) ([]*x509.Certificate, error) {
+	t := time.Unix(ts, 0)
+	var certs []*x509.Certificate
+	for _, cert := range r.certs[businessUnit] {
+		if !t.Before(cert.NotBefore) && !t.After(cert.NotAfter) {
+			certs = append(certs, cert)
+		}
+	}
+	return certs, nil
+}
@@ -0,0 +1,298 @@
+package trade_document_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/openebl/openebl/pkg/pkix"
+	mock_blob_store "github.com/openebl/openebl/test/mock/bu_server/blob_store"
+	mock_business_unit "github.com/openebl/openebl/test/mock/bu_server/business_unit"
+	mock_storage "github.com/openebl/openebl/test/mock/bu_server/storage"
+	mock_trade_document "github.com/openebl/openebl/test/mock/bu_server/trade_document"
+	"github.com/stretchr/testify/suite"
+)
+
+type BillOfLadingPublisherTestSuite struct {
+	suite.Suite
+	ctx             context.Context
+	ctrl            *gomock.Controller
+	resolver        *mock_trade_document.MockCertificateResolver
+	deliveryStorage *mock_trade_document.MockDeliveryStorage
+	tx              *mock_storage.MockTx
+	publisher       trade_document.BillOfLadingPublisher
+
+	ts              int64
+	rootCert        *x509.Certificate
+	rootKey         *ecdsa.PrivateKey
+	authentications map[string]model.BusinessUnitAuthentication
+	signedPack      envelope.JWS
+}
+
+func TestBillOfLadingPublisher(t *testing.T) {
+	suite.Run(t, new(BillOfLadingPublisherTestSuite))
+}
+
+func (s *BillOfLadingPublisherTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.ctrl = gomock.NewController(s.T())
+	s.resolver = mock_trade_document.NewMockCertificateResolver(s.ctrl)
+	s.deliveryStorage = mock_trade_document.NewMockDeliveryStorage(s.ctrl)
+	s.tx = mock_storage.NewMockTx(s.ctrl)
+	s.publisher = trade_document.NewBillOfLadingPublisher(s.resolver, s.deliveryStorage)
+
+	s.ts = time.Now().Unix()
+	s.rootCert, s.rootKey = newCertificate(s.T(), "root", nil, nil, true)
+	s.authentications = make(map[string]model.BusinessUnitAuthentication)
+	for _, bu := range []string{carrier, shipper, bank} {
+		s.authentications[bu] = newAuthentication(s.T(), bu, bu, s.ts, s.rootCert, s.rootKey)
+	}
+
+	service := trade_document.NewBillOfLadingService()
+	pack, err := service.Issue(s.ts, trade_document.IssueBillOfLadingRequest{
+		Issuer:       carrier,
+		TransferTo:   shipper,
+		BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+	})
+	s.Require().NoError(err)
+	pack, err = service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: bank})
+	s.Require().NoError(err)
+	s.signedPack, err = trade_document.SignBillOfLadingPack(pack, s.authentications[shipper])
+	s.Require().NoError(err)
+}
+
+func (s *BillOfLadingPublisherTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *BillOfLadingPublisherTestSuite) expectResolveCertificates() {
+	s.resolver.EXPECT().ResolveCertificates(gomock.Any(), s.ts, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, ts int64, businessUnit string) ([]*x509.Certificate, error) {
+			certs, err := pkix.ParseCertificate([]byte(s.authentications[businessUnit].Certificate))
+			s.Require().NoError(err)
+			return []*x509.Certificate{&certs[0]}, nil
+		},
+	).Times(3)
+}
+
+func (s *BillOfLadingPublisherTestSuite) TestPublish() {
+	var msg model.OutboxMessage
+	s.expectResolveCertificates()
+	gomock.InOrder(
+		s.deliveryStorage.EXPECT().StoreOutboxMessage(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, m model.OutboxMessage) error {
+				msg = m
+				return nil
+			},
+		),
+		s.deliveryStorage.EXPECT().StoreDelivery(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, delivery model.BillOfLadingDelivery) error {
+				s.Assert().Equal(model.DeliveryStatusPending, delivery.Status)
+				return nil
+			},
+		),
+	)
+
+	returnedPack, delivery, err := s.publisher.Publish(s.ctx, s.tx, s.ts, s.signedPack)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(2, delivery.Version)
+	s.Assert().Equal(model.DeliveryStatusPending, delivery.Status)
+	s.Assert().Equal([]string{bank, carrier, shipper}, delivery.Recipients)
+	s.Assert().Len(delivery.EventID, 128)
+
+	// The message is due immediately.
+	s.Assert().Equal(delivery.EventID, msg.ID)
+	s.Assert().Equal(trade_document.BillOfLadingPackEventType, msg.Type)
+	// The relay server sees hashes of the DIDs only.
+	s.Assert().ElementsMatch([]string{trade_document.RecipientTag(bank), trade_document.RecipientTag(carrier), trade_document.RecipientTag(shipper)}, msg.Tags)
+	s.Assert().NotContains(msg.Tags, bank)
+	s.Assert().Len(msg.Tags[0], 64)
+	s.Assert().Equal([]string{bank, carrier, shipper}, msg.Recipients)
+	s.Assert().Equal(model.OutboxMessageStatusPending, msg.Status)
+	s.Assert().Equal(s.ts, msg.NextAttemptAt)
+
+	// Every party can decrypt the pack with its own private key.
+	var encryptedPack envelope.JWE
+	s.Require().NoError(json.Unmarshal(msg.Data, &encryptedPack))
+	s.Require().NotNil(returnedPack)
+	s.Assert().Equal(encryptedPack, *returnedPack)
+	for _, bu := range []string{carrier, shipper, bank} {
+		privateKey, err := pkix.ParsePrivateKey([]byte(s.authentications[bu].PrivateKey))
+		s.Require().NoError(err)
+		plainText, err := envelope.Decrypt(encryptedPack, []any{privateKey})
+		s.Require().NoError(err)
+
+		var signedPack envelope.JWS
+		s.Require().NoError(json.Unmarshal(plainText, &signedPack))
+		pack, err := trade_document.VerifySignedBillOfLadingPack(signedPack, []*x509.Certificate{s.rootCert})
+		s.Require().NoError(err)
+		s.Assert().Equal(bank, pack.CurrentOwner)
+	}
+}
+
+func (s *BillOfLadingPublisherTestSuite) TestPublishFile() {
+	blobStore := mock_blob_store.NewMockBlobStore(s.ctrl)
+	publisher := trade_document.NewBillOfLadingPublisher(s.resolver, s.deliveryStorage, trade_document.WithPublisherBlobStore(blobStore))
+
+	payload, err := s.signedPack.GetPayload()
+	s.Require().NoError(err)
+	var pack bill_of_lading.BillOfLadingPack
+	s.Require().NoError(json.Unmarshal(payload, &pack))
+	pack, err = trade_document.NewBillOfLadingService().PrintToPaper(s.ts, pack, trade_document.PrintToPaperBillOfLadingRequest{Actor: bank})
+	s.Require().NoError(err)
+	printed := pack.Events[len(pack.Events)-1].PrintToPaper
+	content := printed.File.Content
+	printed.File = &model.File{Name: printed.File.Name, Hash: fileHash(content), Size: int64(len(content)), MediaType: "application/pdf"}
+	signedPack, err := trade_document.SignBillOfLadingPack(pack, s.authentications[bank])
+	s.Require().NoError(err)
+
+	var msgs []model.OutboxMessage
+	s.expectResolveCertificates()
+	gomock.InOrder(
+		blobStore.EXPECT().Get(gomock.Any(), printed.File.Hash).Return(content, nil),
+		s.deliveryStorage.EXPECT().StoreOutboxMessage(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, m model.OutboxMessage) error {
+				msgs = append(msgs, m)
+				return nil
+			},
+		).Times(2),
+		s.deliveryStorage.EXPECT().StoreDelivery(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+	)
+
+	_, delivery, err := publisher.Publish(s.ctx, s.tx, s.ts, signedPack)
+	s.Require().NoError(err)
+	s.Require().Len(msgs, 2)
+	s.Assert().Equal(delivery.EventID, msgs[1].ID)
+
+	// The content of the file is published before the pack to the same parties.
+	msg := msgs[0]
+	s.Assert().Equal(trade_document.BillOfLadingFileEventType, msg.Type)
+	s.Assert().Equal(msgs[1].Tags, msg.Tags)
+	s.Assert().Equal([]string{bank, carrier, shipper}, msg.Recipients)
+	s.Assert().Equal(pack.ID, msg.PackID)
+	s.Assert().EqualValues(3, msg.Version)
+	var encryptedFile envelope.JWE
+	s.Require().NoError(json.Unmarshal(msg.Data, &encryptedFile))
+	privateKey, err := pkix.ParsePrivateKey([]byte(s.authentications[carrier].PrivateKey))
+	s.Require().NoError(err)
+	plainText, err := envelope.Decrypt(encryptedFile, []any{privateKey})
+	s.Require().NoError(err)
+	var file trade_document.BillOfLadingFile
+	s.Require().NoError(json.Unmarshal(plainText, &file))
+	s.Assert().Equal(pack.ID, file.PackID)
+	s.Assert().EqualValues(3, file.Version)
+	s.Assert().Equal(printed.File.Hash, file.File.Hash)
+	s.Assert().Equal(content, file.File.Content)
+}
+
+func (s *BillOfLadingPublisherTestSuite) TestPartyWithoutCertificate() {
+	s.resolver.EXPECT().ResolveCertificates(gomock.Any(), s.ts, bank).Return(nil, nil)
+
+	_, _, err := s.publisher.Publish(s.ctx, s.tx, s.ts, s.signedPack)
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingPartyCertificateNotFound)
+}
+
+func (s *BillOfLadingPublisherTestSuite) TestBusinessUnitCertificateResolver() {
+	buStorage := mock_business_unit.NewMockBusinessUnitStorage(s.ctrl)
+	resolver := trade_document.NewBusinessUnitCertificateResolver(buStorage)
+
+	revoked := newAuthentication(s.T(), "revoked", bank, s.ts, s.rootCert, s.rootKey)
+	revoked.Status = model.BusinessUnitAuthenticationStatusRevoked
+	gomock.InOrder(
+		buStorage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		buStorage.EXPECT().ListAuthentication(gomock.Any(), s.tx, business_unit.ListAuthenticationRequest{Limit: 100, BusinessUnitID: bank}).Return(
+			business_unit.ListAuthenticationResult{Total: 2, Records: []model.BusinessUnitAuthentication{revoked, s.authentications[bank]}}, nil,
+		),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	certs, err := resolver.ResolveCertificates(s.ctx, s.ts, bank)
+	s.Require().NoError(err)
+	s.Require().Len(certs, 1)
+	expected, err := pkix.ParseCertificate([]byte(s.authentications[bank].Certificate))
+	s.Require().NoError(err)
+	s.Assert().Equal(expected[0].Raw, certs[0].Raw)
+}
+
+func (s *BillOfLadingPublisherTestSuite) TestPublishToRemoteParty() {
+	// The bank is hosted by another BU server, so its certificate comes from the directory.
+	bankCert, err := pkix.ParseCertificate([]byte(s.authentications[bank].Certificate))
+	s.Require().NoError(err)
+	directory, err := trade_document.NewDirectoryCertificateResolver([][]*x509.Certificate{{&bankCert[0]}}, []*x509.Certificate{s.rootCert})
+	s.Require().NoError(err)
+
+	buStorage := mock_business_unit.NewMockBusinessUnitStorage(s.ctrl)
+	resolver := trade_document.NewBusinessUnitCertificateResolver(buStorage, trade_document.WithRemoteCertificateResolver(directory))
+	publisher := trade_document.NewBillOfLadingPublisher(resolver, s.deliveryStorage)
+
+	buStorage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil).Times(3)
+	buStorage.EXPECT().ListAuthentication(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tx any, req business_unit.ListAuthenticationRequest) (business_unit.ListAuthenticationResult, error) {
+			if req.BusinessUnitID == bank {
+				return business_unit.ListAuthenticationResult{}, nil
+			}
+			return business_unit.ListAuthenticationResult{Total: 1, Records: []model.BusinessUnitAuthentication{s.authentications[req.BusinessUnitID]}}, nil
+		},
+	).Times(3)
+	s.tx.EXPECT().Rollback(gomock.Any()).Return(nil).Times(3)
+
+	var msg model.OutboxMessage
+	gomock.InOrder(
+		s.deliveryStorage.EXPECT().StoreOutboxMessage(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, m model.OutboxMessage) error {
+				msg = m
+				return nil
+			},
+		),
+		s.deliveryStorage.EXPECT().StoreDelivery(gomock.Any(), s.tx, gomock.Any()).Return(nil),
+	)
+
+	_, _, err = publisher.Publish(s.ctx, s.tx, s.ts, s.signedPack)
+	s.Require().NoError(err)
+
+	var encryptedPack envelope.JWE
+	s.Require().NoError(json.Unmarshal(msg.Data, &encryptedPack))
+	privateKey, err := pkix.ParsePrivateKey([]byte(s.authentications[bank].PrivateKey))
+	s.Require().NoError(err)
+	_, err = envelope.Decrypt(encryptedPack, []any{privateKey})
+	s.Assert().NoError(err)
+}
+
+func (s *BillOfLadingPublisherTestSuite) TestDirectoryCertificateResolver() {
+	bankCert, err := pkix.ParseCertificate([]byte(s.authentications[bank].Certificate))
+	s.Require().NoError(err)
+	rootCerts := []*x509.Certificate{s.rootCert}
+
+	directory, err := trade_document.NewDirectoryCertificateResolver([][]*x509.Certificate{{&bankCert[0]}}, rootCerts)
+	s.Require().NoError(err)
+	certs, err := directory.ResolveCertificates(s.ctx, s.ts, bank)
+	s.Require().NoError(err)
+	s.Require().Len(certs, 1)
+	s.Assert().Equal(bankCert[0].Raw, certs[0].Raw)
+
+	// Expired certificates and unknown business units have no certificate.
+	certs, err = directory.ResolveCertificates(s.ctx, s.ts+24*3600, bank)
+	s.Require().NoError(err)
+	s.Assert().Empty(certs)
+	certs, err = directory.ResolveCertificates(s.ctx, s.ts, carrier)
+	s.Require().NoError(err)
+	s.Assert().Empty(certs)
+
+	// Certificates have to be trusted and carry DIDs.
+	otherRoot, otherKey := newCertificate(s.T(), "other root", nil, nil, true)
+	untrusted, _ := newCertificate(s.T(), bank, otherRoot, otherKey, false)
+	_, err = trade_document.NewDirectoryCertificateResolver([][]*x509.Certificate{{untrusted}}, rootCerts)
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+	withoutDID, _ := newCertificate(s.T(), "no did", s.rootCert, s.rootKey, false)
+	_, err = trade_document.NewDirectoryCertificateResolver([][]*x509.Certificate{{withoutDID}}, rootCerts)
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+}
@@ -0,0 +1,218 @@
+package trade_document
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/openebl/openebl/pkg/pkix"
+	"github.com/openebl/openebl/pkg/util"
+)
+
+// BillOfLadingPackSigner signs every new version of bill of lading packs on behalf of the business unit
+// who acts the latest event of the pack.
+type BillOfLadingPackSigner interface {
+	Sign(ctx context.Context, ts int64, pack bill_of_lading.BillOfLadingPack) (envelope.JWS, error)
+}
+
+type _BillOfLadingPackSigner struct {
+	buStorage business_unit.BusinessUnitStorage
+}
+
+func NewBillOfLadingPackSigner(buStorage business_unit.BusinessUnitStorage) *_BillOfLadingPackSigner {
+	return &_BillOfLadingPackSigner{
+		buStorage: buStorage,
+	}
+}
+
+// Sign signs the pack with the latest active authentication of the actor whose certificate is valid at ts.
+func (s *_BillOfLadingPackSigner) Sign(ctx context.Context, ts int64, pack bill_of_lading.BillOfLadingPack) (envelope.JWS, error) {
+	actor := GetBillOfLadingPackActor(pack)
+	if actor == "" {
+		return envelope.JWS{}, model.ErrBillOfLadingNotIssued
+	}
+
+	tx, err := s.buStorage.CreateTx(ctx)
+	if err != nil {
+		return envelope.JWS{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Business units don't have many authentications, so all of them are listed at once.
+	req := business_unit.ListAuthenticationRequest{
+		Limit:          100,
+		BusinessUnitID: actor,
+	}
+	result, err := s.buStorage.ListAuthentication(ctx, tx, req)
+	if err != nil {
+		return envelope.JWS{}, err
+	}
+
+	var authentication *model.BusinessUnitAuthentication
+	for i := range result.Records {
+		auth := result.Records[i]
+		if auth.Status != model.BusinessUnitAuthenticationStatusActive || !isCertificateValidAt(auth.Certificate, ts) {
+			continue
+		}
+		if authentication == nil || auth.CreatedAt >= authentication.CreatedAt {
+			authentication = &auth
+		}
+	}
+	if authentication == nil {
+		return envelope.JWS{}, model.ErrAuthenticationNotFound
+	}
+
+	return SignBillOfLadingPack(pack, *authentication)
+}
+
+// SignBillOfLadingPack wraps the canonical JSON of the pack in a JWS signed by the private key of the authentication.
+// The certificate chain of the authentication is attached as x5c.
+func SignBillOfLadingPack(pack bill_of_lading.BillOfLadingPack, authentication model.BusinessUnitAuthentication) (envelope.JWS, error) {
+	if authentication.BusinessUnit.String() != GetBillOfLadingPackActor(pack) {
+		return envelope.JWS{}, model.ErrBillOfLadingSignerMismatch
+	}
+
+	privateKey, err := pkix.ParsePrivateKey([]byte(authentication.PrivateKey))
+	if err != nil {
+		return envelope.JWS{}, err
+	}
+	algorithm, err := signatureAlgorithm(privateKey)
+	if err != nil {
+		return envelope.JWS{}, err
+	}
+	certChain, err := authenticationCertChain(authentication)
+	if err != nil {
+		return envelope.JWS{}, err
+	}
+
+	payload, err := util.CanonicalJSON(pack)
+	if err != nil {
+		return envelope.JWS{}, err
+	}
+	return envelope.Sign(payload, algorithm, privateKey, certChain)
+}
+
+// VerifySignedBillOfLadingPack verifies the signature of the signed pack and returns the pack in it.
+//
+// The certificate chain in x5c has to chain to one of rootCerts (or the system trusted certificates), and the DID
+// of the signer has to be the actor of the latest event of the pack.
+func VerifySignedBillOfLadingPack(signedPack envelope.JWS, rootCerts []*x509.Certificate) (bill_of_lading.BillOfLadingPack, error) {
+	if err := signedPack.VerifySignature(); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, fmt.Errorf("%s: %w", err.Error(), model.ErrBillOfLadingInvalidSignature)
+	}
+	certChain, err := signedPack.GetCertificateChain()
+	if err != nil {
+		return bill_of_lading.BillOfLadingPack{}, fmt.Errorf("%s: %w", err.Error(), model.ErrBillOfLadingInvalidSignature)
+	}
+	if err := pkix.Verify(certChain, rootCerts); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, fmt.Errorf("untrusted certificate: %s: %w", err.Error(), model.ErrBillOfLadingInvalidSignature)
+	}
+
+	payload, err := signedPack.GetPayload()
+	if err != nil {
+		return bill_of_lading.BillOfLadingPack{}, fmt.Errorf("%s: %w", err.Error(), model.ErrBillOfLadingInvalidSignature)
+	}
+	var pack bill_of_lading.BillOfLadingPack
+	if err := json.Unmarshal(payload, &pack); err != nil {
+		return bill_of_lading.BillOfLadingPack{}, fmt.Errorf("%s: %w", err.Error(), model.ErrBillOfLadingInvalidSignature)
+	}
+
+	actor := GetBillOfLadingPackActor(pack)
+	if actor == "" || certificateDID(certChain[0]) != actor {
+		return bill_of_lading.BillOfLadingPack{}, model.ErrBillOfLadingSignerMismatch
+	}
+	return pack, nil
+}
+
+// GetBillOfLadingPackActor returns DID of the business unit who made the latest event of the pack.
+func GetBillOfLadingPackActor(pack bill_of_lading.BillOfLadingPack) string {
+	if len(pack.Events) == 0 {
+		return ""
+	}
+
+	lastEvent := pack.Events[len(pack.Events)-1]
+	switch {
+	case lastEvent.BillOfLading != nil:
+		return lastEvent.BillOfLading.CreatedBy
+	case lastEvent.Transfer != nil:
+		return lastEvent.Transfer.TransferBy
+	case lastEvent.Return != nil:
+		return lastEvent.Return.ReturnBy
+	case lastEvent.Surrender != nil:
+		return lastEvent.Surrender.SurrenderBy
+	case lastEvent.AmendmentRequest != nil:
+		return lastEvent.AmendmentRequest.RequestBy
+	case lastEvent.PrintToPaper != nil:
+		return lastEvent.PrintToPaper.PrintBy
+	}
+	return ""
+}
+
+// certificateDID returns the DID the certificate is issued to. The DID is carried as a URI in Subject Alternative Name.
+// Certificates without such URI fall back to use the DID as their Common Name.
+func certificateDID(cert *x509.Certificate) string {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "did" {
+			return uri.String()
+		}
+	}
+	if strings.HasPrefix(cert.Subject.CommonName, "did:") {
+		return cert.Subject.CommonName
+	}
+	return ""
+}
+
+// authenticationCertChain returns the certificate chain of the authentication from its leaf certificate.
+func authenticationCertChain(authentication model.BusinessUnitAuthentication) ([]*x509.Certificate, error) {
+	pems := append([]string{authentication.Certificate}, authentication.IntermediateCerts...)
+
+	var certChain []*x509.Certificate
+	for _, pem := range pems {
+		certs, err := pkix.ParseCertificate([]byte(pem))
+		if err != nil {
+			return nil, err
+		}
+		for i := range certs {
+			certChain = append(certChain, &certs[i])
+		}
+	}
+	return certChain, nil
+}
+
+func isCertificateValidAt(certPEM string, ts int64) bool {
+	certs, err := pkix.ParseCertificate([]byte(certPEM))
+	if err != nil {
+		return false
+	}
+	t := time.Unix(ts, 0)
+	return !t.Before(certs[0].NotBefore) && !t.After(certs[0].NotAfter)
+}
+
+func signatureAlgorithm(privateKey any) (envelope.SignatureAlgorithm, error) {
+	switch key := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return envelope.SignatureAlgorithm(jwa.ES256), nil
+		case elliptic.P384():
+			return envelope.SignatureAlgorithm(jwa.ES384), nil
+		case elliptic.P521():
+			return envelope.SignatureAlgorithm(jwa.ES512), nil
+		}
+	case *rsa.PrivateKey:
+		return envelope.SignatureAlgorithm(jwa.RS256), nil
+	}
+	return "", errors.New("unsupported private key type")
+}
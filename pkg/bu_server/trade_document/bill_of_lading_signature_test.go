@@ -0,0 +1,209 @@
+package trade_document_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/envelope"
+	mock_business_unit "github.com/openebl/openebl/test/mock/bu_server/business_unit"
+	mock_storage "github.com/openebl/openebl/test/mock/bu_server/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type BillOfLadingSignatureTestSuite struct {
+	suite.Suite
+	ctx       context.Context
+	ctrl      *gomock.Controller
+	buStorage *mock_business_unit.MockBusinessUnitStorage
+	tx        *mock_storage.MockTx
+	signer    trade_document.BillOfLadingPackSigner
+
+	ts       int64
+	rootCert *x509.Certificate
+	rootKey  *ecdsa.PrivateKey
+	pack     bill_of_lading.BillOfLadingPack
+}
+
+func TestBillOfLadingSignature(t *testing.T) {
+	suite.Run(t, new(BillOfLadingSignatureTestSuite))
+}
+
+func (s *BillOfLadingSignatureTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.ctrl = gomock.NewController(s.T())
+	s.buStorage = mock_business_unit.NewMockBusinessUnitStorage(s.ctrl)
+	s.tx = mock_storage.NewMockTx(s.ctrl)
+	s.signer = trade_document.NewBillOfLadingPackSigner(s.buStorage)
+
+	s.ts = time.Now().Unix()
+	s.rootCert, s.rootKey = newCertificate(s.T(), "root", nil, nil, true)
+
+	service := trade_document.NewBillOfLadingService()
+	pack, err := service.Issue(s.ts, trade_document.IssueBillOfLadingRequest{
+		Issuer:       carrier,
+		TransferTo:   shipper,
+		BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+	})
+	s.Require().NoError(err)
+	pack, err = service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: bank})
+	s.Require().NoError(err)
+	s.pack = pack
+}
+
+func (s *BillOfLadingSignatureTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// newCertificate creates a certificate signed by the parent. The certificate is self-signed if parent is nil.
+// Certificates of business units carry their DID in Subject Alternative Name.
+func newCertificate(t *testing.T, subject string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: subject},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if uri, err := url.Parse(subject); err == nil && uri.Scheme == "did" {
+		template.URIs = []*url.URL{uri}
+		template.Subject.CommonName = "business unit"
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// newAuthentication creates an active authentication of the business unit with a certificate signed by the root.
+func newAuthentication(t *testing.T, id string, businessUnit string, createdAt int64, rootCert *x509.Certificate, rootKey *ecdsa.PrivateKey) model.BusinessUnitAuthentication {
+	cert, key := newCertificate(t, businessUnit, rootCert, rootKey, false)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	buDID, err := did.ParseDID(businessUnit)
+	require.NoError(t, err)
+	return model.BusinessUnitAuthentication{
+		ID:           id,
+		Version:      1,
+		BusinessUnit: *buDID,
+		Status:       model.BusinessUnitAuthenticationStatusActive,
+		CreatedAt:    createdAt,
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+		Certificate:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+	}
+}
+
+func (s *BillOfLadingSignatureTestSuite) newAuthentication(id string, businessUnit string, createdAt int64) model.BusinessUnitAuthentication {
+	return newAuthentication(s.T(), id, businessUnit, createdAt, s.rootCert, s.rootKey)
+}
+
+func (s *BillOfLadingSignatureTestSuite) TestSign() {
+	revoked := s.newAuthentication("revoked", shipper, s.ts+10)
+	revoked.Status = model.BusinessUnitAuthenticationStatusRevoked
+	older := s.newAuthentication("older", shipper, s.ts-10)
+	latest := s.newAuthentication("latest", shipper, s.ts)
+
+	gomock.InOrder(
+		s.buStorage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.buStorage.EXPECT().ListAuthentication(gomock.Any(), s.tx, business_unit.ListAuthenticationRequest{Limit: 100, BusinessUnitID: shipper}).Return(
+			business_unit.ListAuthenticationResult{Total: 3, Records: []model.BusinessUnitAuthentication{older, latest, revoked}}, nil,
+		),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	signedPack, err := s.signer.Sign(s.ctx, s.ts, s.pack)
+	s.Require().NoError(err)
+
+	certChain, err := signedPack.GetCertificateChain()
+	s.Require().NoError(err)
+	latestCerts, err := x509.ParseCertificate(mustDecodePEM(latest.Certificate))
+	s.Require().NoError(err)
+	s.Assert().Equal(latestCerts.Raw, certChain[0].Raw)
+
+	pack, err := trade_document.VerifySignedBillOfLadingPack(signedPack, []*x509.Certificate{s.rootCert})
+	s.Require().NoError(err)
+	s.Assert().Equal(s.pack.ID, pack.ID)
+	s.Assert().Equal(s.pack.ParentHash, pack.ParentHash)
+	s.Assert().Equal(s.pack.CurrentOwner, pack.CurrentOwner)
+}
+
+func (s *BillOfLadingSignatureTestSuite) TestSignWithoutActiveAuthentication() {
+	revoked := s.newAuthentication("revoked", shipper, s.ts)
+	revoked.Status = model.BusinessUnitAuthenticationStatusRevoked
+
+	gomock.InOrder(
+		s.buStorage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.buStorage.EXPECT().ListAuthentication(gomock.Any(), s.tx, gomock.Any()).Return(
+			business_unit.ListAuthenticationResult{Total: 1, Records: []model.BusinessUnitAuthentication{revoked}}, nil,
+		),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	_, err := s.signer.Sign(s.ctx, s.ts, s.pack)
+	s.Assert().ErrorIs(err, model.ErrAuthenticationNotFound)
+}
+
+func (s *BillOfLadingSignatureTestSuite) TestVerify() {
+	// The pack is signed by someone who is not the actor of the latest event.
+	_, err := trade_document.SignBillOfLadingPack(s.pack, s.newAuthentication("bank", bank, s.ts))
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingSignerMismatch)
+
+	bankAuthentication := s.newAuthentication("bank", bank, s.ts)
+	bankAuthentication.BusinessUnit = s.newAuthentication("shipper", shipper, s.ts).BusinessUnit
+	signedPack, err := trade_document.SignBillOfLadingPack(s.pack, bankAuthentication)
+	s.Require().NoError(err)
+	_, err = trade_document.VerifySignedBillOfLadingPack(signedPack, []*x509.Certificate{s.rootCert})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingSignerMismatch)
+
+	// The certificate doesn't chain to the trusted root.
+	signedPack, err = trade_document.SignBillOfLadingPack(s.pack, s.newAuthentication("shipper", shipper, s.ts))
+	s.Require().NoError(err)
+	otherRoot, _ := newCertificate(s.T(), "other root", nil, nil, true)
+	_, err = trade_document.VerifySignedBillOfLadingPack(signedPack, []*x509.Certificate{otherRoot})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingInvalidSignature)
+
+	// The payload is changed after signing.
+	_, err = trade_document.VerifySignedBillOfLadingPack(signedPack, []*x509.Certificate{s.rootCert})
+	s.Require().NoError(err)
+	tampered := s.pack
+	tampered.CurrentOwner = "did:openebl:thief"
+	anotherSignedPack, err := trade_document.SignBillOfLadingPack(tampered, s.newAuthentication("shipper", shipper, s.ts))
+	s.Require().NoError(err)
+	signedPack.Payload = anotherSignedPack.Payload
+	_, err = trade_document.VerifySignedBillOfLadingPack(signedPack, []*x509.Certificate{s.rootCert})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingInvalidSignature)
+
+	_, err = trade_document.VerifySignedBillOfLadingPack(envelope.JWS{}, []*x509.Certificate{s.rootCert})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingInvalidSignature)
+}
+
+func mustDecodePEM(s string) []byte {
+	block, _ := pem.Decode([]byte(s))
+	return block.Bytes
+}
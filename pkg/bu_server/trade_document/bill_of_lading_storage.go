@@ -0,0 +1,134 @@
+package trade_document
+
+import (
+	"context"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/envelope"
+)
+
+// BillOfLadingPackRecord is a version of a bill of lading pack with the envelopes it is signed and delivered in.
+type BillOfLadingPackRecord struct {
+	Pack          bill_of_lading.BillOfLadingPack `json:"pack"`                     // The bill of lading pack.
+	SignedPack    envelope.JWS                    `json:"signed_pack"`              // The pack signed by the actor of its latest event.
+	EncryptedPack *envelope.JWE                   `json:"encrypted_pack,omitempty"` // The signed pack encrypted to its parties. nil if it isn't delivered yet.
+	CreatedAt     int64                           `json:"created_at"`               // Unix Time (in second) when the version is stored.
+
+	// The envelopes as serialized when they were received from the relay server. The storage keeps them as they are.
+	// If they are nil, the envelopes are serialized by the storage, the same way they are published.
+	RawSignedPack    []byte `json:"-"`
+	RawEncryptedPack []byte `json:"-"`
+}
+
+// BillOfLadingBox tells how a business unit is involved in bill of lading packs.
+type BillOfLadingBox string
+
+const (
+	BillOfLadingBoxAll  BillOfLadingBox = ""     // All packs the business unit is a party of.
+	BillOfLadingBoxHeld BillOfLadingBox = "held" // Packs currently owned by the business unit.
+	BillOfLadingBoxSent BillOfLadingBox = "sent" // Packs the business unit handed over and doesn't own now.
+)
+
+// ListBillOfLadingPacksRequest is the request to list the latest versions of bill of lading packs.
+type ListBillOfLadingPacksRequest struct {
+	Offset int `json:"offset"` // Offset of the packs to be listed.
+	Limit  int `json:"limit"`  // Limit of the packs to be listed.
+
+	// Filters
+	PackIDs      []string             `json:"pack_ids"`      // IDs of the packs.
+	BusinessUnit string               `json:"business_unit"` // DID of a party of the packs.
+	Box          BillOfLadingBox      `json:"box"`           // How the business unit is involved in the packs. Requires BusinessUnit.
+	DocReference string               `json:"doc_reference"` // Transport document reference of the latest bill of lading in the packs.
+	Statuses     []BillOfLadingStatus `json:"statuses"`      // Statuses of the packs.
+}
+
+// ListBillOfLadingPacksResult is the result of listing bill of lading packs.
+type ListBillOfLadingPacksResult struct {
+	Total   int                      `json:"total"`   // Total number of packs.
+	Records []BillOfLadingPackRecord `json:"records"` // The latest versions of the packs.
+}
+
+// TradeDocumentStorage keeps the latest version of every bill of lading pack with the history of all its versions.
+// Every version is kept with the raw envelopes it is signed and delivered in, as the evidence of the event.
+type TradeDocumentStorage interface {
+	CreateTx(ctx context.Context, options ...storage.CreateTxOption) (storage.Tx, error)
+	StoreBillOfLadingPack(ctx context.Context, tx storage.Tx, record BillOfLadingPackRecord) error
+	ListBillOfLadingPacks(ctx context.Context, tx storage.Tx, req ListBillOfLadingPacksRequest) (ListBillOfLadingPacksResult, error)
+	GetBillOfLadingPackHistory(ctx context.Context, tx storage.Tx, packID string) ([]BillOfLadingPackRecord, error)
+}
+
+// BillOfLadingInboxStorage keeps bill of lading packs received from relay servers with the progress of the relay servers.
+type BillOfLadingInboxStorage interface {
+	TradeDocumentStorage
+	GetRelayCheckpoint(ctx context.Context, tx storage.Tx, relayServer string) (int64, error)
+	StoreRelayCheckpoint(ctx context.Context, tx storage.Tx, ts int64, relayServer string, offset int64) error
+	GetRelayEvent(ctx context.Context, tx storage.Tx, eventID string) (*model.RelayEvent, error)
+	StoreRelayEvent(ctx context.Context, tx storage.Tx, event model.RelayEvent) error
+	StorePendingFile(ctx context.Context, tx storage.Tx, file PendingBillOfLadingFile) error
+	ListPendingFiles(ctx context.Context, tx storage.Tx, packID string, version int64) ([]PendingBillOfLadingFile, error)
+	DeletePendingFiles(ctx context.Context, tx storage.Tx, packID string, version int64) error
+}
+
+// PendingBillOfLadingFile is a file received before the version of the pack attaching it.
+// It is kept encrypted until the version arrives.
+type PendingBillOfLadingFile struct {
+	Event         model.RelayEvent `json:"event"`          // The event carrying the file. Its status is pending.
+	EncryptedFile []byte           `json:"encrypted_file"` // Data of the event.
+}
+
+// ShippingInstructionRecord is a version of a shipping instruction received by a business unit.
+type ShippingInstructionRecord struct {
+	BusinessUnit        string                             `json:"business_unit"` // DID of the business unit (usually the carrier) the shipping instruction is submitted to.
+	Version             int64                              `json:"version"`       // Version of the shipping instruction. It starts from 1.
+	ShippingInstruction bill_of_lading.ShippingInstruction `json:"shipping_instruction"`
+	CreatedAt           int64                              `json:"created_at"` // Unix Time (in second) when the version is stored.
+}
+
+// ListShippingInstructionsRequest is the request to list the latest versions of shipping instructions.
+type ListShippingInstructionsRequest struct {
+	Offset int `json:"offset"` // Offset of the shipping instructions to be listed.
+	Limit  int `json:"limit"`  // Limit of the shipping instructions to be listed.
+
+	// Filters
+	BusinessUnit            string                             `json:"business_unit"`             // DID of the business unit the shipping instructions are submitted to.
+	References              []string                           `json:"references"`                // References of the shipping instructions.
+	CarrierBookingReference string                             `json:"carrier_booking_reference"` // Carrier booking reference of the shipping instructions.
+	DocumentStatuses        []bill_of_lading.EblDocumentStatus `json:"document_statuses"`         // Statuses of the shipping instructions.
+}
+
+// ListShippingInstructionsResult is the result of listing shipping instructions.
+type ListShippingInstructionsResult struct {
+	Total   int                         `json:"total"`   // Total number of shipping instructions.
+	Records []ShippingInstructionRecord `json:"records"` // The latest versions of the shipping instructions.
+}
+
+// ListShipmentEventsRequest is the request to list DCSA shipment events in the order they were created.
+type ListShipmentEventsRequest struct {
+	Offset int `json:"offset"` // Offset of the events to be listed.
+	Limit  int `json:"limit"`  // Limit of the events to be listed.
+
+	// Filters
+	BusinessUnit          string                                  `json:"business_unit"`            // DID of a business unit the events are visible to.
+	DocumentTypeCode      bill_of_lading.EblDocumentTypeCode      `json:"document_type_code"`       // Type of the documents.
+	DocumentReference     string                                  `json:"document_reference"`       // Reference of the document.
+	ShipmentEventTypeCode bill_of_lading.EblShipmentEventTypeCode `json:"shipment_event_type_code"` // Type of the events.
+}
+
+// ListShipmentEventsResult is the result of listing DCSA shipment events.
+type ListShipmentEventsResult struct {
+	Total   int                            `json:"total"`   // Total number of events.
+	Records []bill_of_lading.ShipmentEvent `json:"records"` // Records of events.
+}
+
+// DCSAStorage keeps shipping instructions and the DCSA shipment events of all documents besides bill of lading packs.
+//
+// Shipment events are derived from the documents when they are stored, so the events are always in line with the
+// history of the documents.
+type DCSAStorage interface {
+	TradeDocumentStorage
+	StoreShippingInstruction(ctx context.Context, tx storage.Tx, record ShippingInstructionRecord) error
+	ListShippingInstructions(ctx context.Context, tx storage.Tx, req ListShippingInstructionsRequest) (ListShippingInstructionsResult, error)
+	ListShipmentEvents(ctx context.Context, tx storage.Tx, req ListShipmentEventsRequest) (ListShipmentEventsResult, error)
+}
@@ -0,0 +1,184 @@
+package trade_document_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	carrier   = "did:openebl:carrier"
+	shipper   = "did:openebl:shipper"
+	consignee = "did:openebl:consignee"
+	bank      = "did:openebl:bank"
+)
+
+type BillOfLadingServiceTestSuite struct {
+	suite.Suite
+	ts      int64
+	service trade_document.BillOfLadingService
+	pack    bill_of_lading.BillOfLadingPack
+}
+
+func TestBillOfLadingService(t *testing.T) {
+	suite.Run(t, new(BillOfLadingServiceTestSuite))
+}
+
+func (s *BillOfLadingServiceTestSuite) SetupTest() {
+	s.ts = time.Now().Unix()
+	s.service = trade_document.NewBillOfLadingService()
+
+	pack, err := s.service.Issue(s.ts, trade_document.IssueBillOfLadingRequest{
+		Issuer:       carrier,
+		TransferTo:   shipper,
+		BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+	})
+	s.Require().NoError(err)
+	s.pack = pack
+}
+
+func (s *BillOfLadingServiceTestSuite) TestIssue() {
+	s.Assert().NotEmpty(s.pack.ID)
+	s.Assert().EqualValues(1, s.pack.Version)
+	s.Assert().Equal(shipper, s.pack.CurrentOwner)
+	s.Require().Len(s.pack.Events, 1)
+	s.Require().NotNil(s.pack.Events[0].BillOfLading)
+	s.Assert().Equal(carrier, s.pack.Events[0].BillOfLading.CreatedBy)
+	s.Assert().Equal(shipper, s.pack.Events[0].BillOfLading.TransferTo)
+	s.Assert().Equal(s.ts, s.pack.Events[0].BillOfLading.CreatedAt.Unix())
+	s.Assert().Equal(carrier, trade_document.GetIssuer(s.pack))
+
+	status, err := trade_document.GetBillOfLadingStatus(s.pack)
+	s.Require().NoError(err)
+	s.Assert().Equal(trade_document.BillOfLadingStatusIssued, status)
+
+	_, err = s.service.Issue(s.ts, trade_document.IssueBillOfLadingRequest{Issuer: carrier, TransferTo: shipper})
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+}
+
+func (s *BillOfLadingServiceTestSuite) TestTransferAndReturn() {
+	pack, err := s.service.Transfer(s.ts, s.pack, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: bank, Note: "to bank"})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(2, pack.Version)
+	s.Assert().Equal(bank, pack.CurrentOwner)
+	s.Require().Len(pack.Events, 2)
+	s.Assert().Equal(&bill_of_lading.Transfer{TransferBy: shipper, TransferTo: bank, TransferAt: pack.Events[1].Transfer.TransferAt, Note: "to bank"}, pack.Events[1].Transfer)
+
+	// The given pack is untouched.
+	s.Assert().EqualValues(1, s.pack.Version)
+	s.Assert().Len(s.pack.Events, 1)
+
+	// Only the current owner can transfer.
+	_, err = s.service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: consignee})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingNotOwner)
+	_, err = s.service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: bank, TransferTo: bank})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingInvalidTransfer)
+	_, err = s.service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: bank})
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+
+	// Return goes back to the previous owner.
+	pack, err = s.service.Return(s.ts, pack, trade_document.ReturnBillOfLadingRequest{Actor: bank})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(3, pack.Version)
+	s.Assert().Equal(shipper, pack.CurrentOwner)
+	s.Assert().Equal(shipper, pack.Events[2].Return.ReturnTo)
+
+	// Returning again walks the bill of lading back to its issuer instead of bouncing it.
+	pack, err = s.service.Return(s.ts, pack, trade_document.ReturnBillOfLadingRequest{Actor: shipper})
+	s.Require().NoError(err)
+	s.Assert().Equal(carrier, pack.CurrentOwner)
+	_, err = s.service.Return(s.ts, pack, trade_document.ReturnBillOfLadingRequest{Actor: carrier})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingInvalidReturn)
+}
+
+func (s *BillOfLadingServiceTestSuite) TestSurrender() {
+	pack, err := s.service.Transfer(s.ts, s.pack, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: consignee})
+	s.Require().NoError(err)
+
+	_, err = s.service.Surrender(s.ts, pack, trade_document.SurrenderBillOfLadingRequest{Actor: shipper})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingNotOwner)
+
+	pack, err = s.service.Surrender(s.ts, pack, trade_document.SurrenderBillOfLadingRequest{Actor: consignee})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(3, pack.Version)
+	s.Assert().Equal(carrier, pack.CurrentOwner)
+	status, err := trade_document.GetBillOfLadingStatus(pack)
+	s.Require().NoError(err)
+	s.Assert().Equal(trade_document.BillOfLadingStatusSurrendered, status)
+
+	// A surrendered bill of lading is final, even to the issuer who holds it.
+	_, err = s.service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: carrier, TransferTo: consignee})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingSurrendered)
+	_, err = s.service.Return(s.ts, pack, trade_document.ReturnBillOfLadingRequest{Actor: carrier})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingSurrendered)
+	_, err = s.service.PrintToPaper(s.ts, pack, trade_document.PrintToPaperBillOfLadingRequest{Actor: carrier})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingSurrendered)
+	_, err = s.service.AmendmentRequest(s.ts, pack, trade_document.AmendmentRequestBillOfLadingRequest{Actor: carrier})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingSurrendered)
+}
+
+func (s *BillOfLadingServiceTestSuite) TestPrintToPaper() {
+	pack, err := s.service.PrintToPaper(s.ts, s.pack, trade_document.PrintToPaperBillOfLadingRequest{Actor: shipper})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(2, pack.Version)
+	s.Assert().Equal(shipper, pack.CurrentOwner)
+
+	// The printed PDF is kept on the pack.
+	file := pack.Events[len(pack.Events)-1].PrintToPaper.File
+	s.Require().NotNil(file)
+	s.Assert().Equal("bl-number.pdf", file.Name)
+	s.Assert().True(bytes.HasPrefix(file.Content, []byte("%PDF-")))
+	s.Assert().Equal(s.ts, file.CreatedDate.Unix())
+
+	_, err = s.service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: consignee})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingPrintedToPaper)
+	_, err = s.service.Surrender(s.ts, pack, trade_document.SurrenderBillOfLadingRequest{Actor: shipper})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingPrintedToPaper)
+}
+
+func (s *BillOfLadingServiceTestSuite) TestAmendment() {
+	pack, err := s.service.AmendmentRequest(s.ts, s.pack, trade_document.AmendmentRequestBillOfLadingRequest{Actor: shipper, Note: "wrong weight"})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(2, pack.Version)
+	s.Assert().Equal(carrier, pack.CurrentOwner)
+	status, err := trade_document.GetBillOfLadingStatus(pack)
+	s.Require().NoError(err)
+	s.Assert().Equal(trade_document.BillOfLadingStatusAmendmentRequested, status)
+
+	// The issuer holds the bill of lading but can't transfer it.
+	_, err = s.service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: carrier, TransferTo: consignee})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingAmendmentRequested)
+
+	// Only the issuer can amend.
+	amended := &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number-amended"}
+	_, err = s.service.Amend(s.ts, pack, trade_document.AmendBillOfLadingRequest{Actor: shipper, BillOfLading: amended})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingNotIssuer)
+	_, err = s.service.Amend(s.ts, s.pack, trade_document.AmendBillOfLadingRequest{Actor: carrier, BillOfLading: amended})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingAmendmentNotRequested)
+
+	amendedPack, err := s.service.Amend(s.ts, pack, trade_document.AmendBillOfLadingRequest{Actor: carrier, BillOfLading: amended})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(3, amendedPack.Version)
+	s.Assert().Equal(shipper, amendedPack.CurrentOwner)
+	s.Assert().Equal(amended, amendedPack.Events[2].BillOfLading.BillOfLading)
+	s.Assert().Equal(carrier, trade_document.GetIssuer(amendedPack))
+
+	// The issuer can also decline the amendment by returning the bill of lading.
+	declinedPack, err := s.service.Return(s.ts, pack, trade_document.ReturnBillOfLadingRequest{Actor: carrier, Note: "weight is correct"})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(3, declinedPack.Version)
+	s.Assert().Equal(shipper, declinedPack.CurrentOwner)
+	_, err = s.service.Return(s.ts, pack, trade_document.ReturnBillOfLadingRequest{Actor: shipper})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingNotOwner)
+}
+
+func (s *BillOfLadingServiceTestSuite) TestNotIssued() {
+	_, err := s.service.Transfer(s.ts, bill_of_lading.BillOfLadingPack{}, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: consignee})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingNotIssued)
+	s.Assert().ErrorIs(err, model.ErrTradeDocumentError)
+}
@@ -0,0 +1,197 @@
+package trade_document
+
+import (
+	"fmt"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/dcsa_validator"
+)
+
+func ValidateIssueBillOfLadingRequest(req IssueBillOfLadingRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Issuer, validation.Required),
+		validation.Field(&req.TransferTo, validation.Required),
+		validation.Field(&req.BillOfLading, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateTransferBillOfLadingRequest(req TransferBillOfLadingRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Actor, validation.Required),
+		validation.Field(&req.TransferTo, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateReturnBillOfLadingRequest(req ReturnBillOfLadingRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Actor, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateSurrenderBillOfLadingRequest(req SurrenderBillOfLadingRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Actor, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateAmendmentRequestBillOfLadingRequest(req AmendmentRequestBillOfLadingRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Actor, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateAmendBillOfLadingRequest(req AmendBillOfLadingRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Actor, validation.Required),
+		validation.Field(&req.BillOfLading, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidatePrintToPaperBillOfLadingRequest(req PrintToPaperBillOfLadingRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Actor, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateCreateBillOfLadingRequest(req CreateBillOfLadingRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+		validation.Field(&req.TransferTo, validation.Required),
+		validation.Field(&req.BillOfLading, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return dcsa_validator.ValidateTransportDocument(req.BillOfLading)
+}
+
+func ValidateListBillOfLadingRequest(req ListBillOfLadingRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Limit, validation.Required),
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+		validation.Field(&req.Box, validation.In(BillOfLadingBoxAll, BillOfLadingBoxHeld, BillOfLadingBoxSent)),
+		validation.Field(&req.Status, validation.In(
+			BillOfLadingStatusIssued,
+			BillOfLadingStatusAmendmentRequested,
+			BillOfLadingStatusSurrendered,
+			BillOfLadingStatusPrintedToPaper,
+		)),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateGetBillOfLadingRequest(req GetBillOfLadingRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+		validation.Field(&req.ID, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateBillOfLadingActionRequest(req BillOfLadingActionRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+		validation.Field(&req.ID, validation.Required),
+		validation.Field(&req.Version, validation.Required, validation.Min(int64(1))),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateTransferBillOfLadingActionRequest(req BillOfLadingActionRequest) error {
+	if err := ValidateBillOfLadingActionRequest(req); err != nil {
+		return err
+	}
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.TransferTo, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateAmendBillOfLadingActionRequest(req BillOfLadingActionRequest) error {
+	if err := ValidateBillOfLadingActionRequest(req); err != nil {
+		return err
+	}
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.BillOfLading, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return dcsa_validator.ValidateTransportDocument(req.BillOfLading)
+}
+
+// packHashPattern matches hashes of bill of lading packs returned by GetBillOfLadingPackHash.
+var packHashPattern = regexp.MustCompile(`^[0-9a-f]{128}$`)
+
+func ValidateVerifyBillOfLadingHashRequest(req VerifyBillOfLadingHashRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.ID, validation.Required),
+		validation.Field(&req.Hash, validation.Required, validation.Match(packHashPattern)),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+// fileHashPattern matches hashes of file contents kept in the blob store.
+var fileHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+func ValidateGetBillOfLadingFileRequest(req GetBillOfLadingFileRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+		validation.Field(&req.ID, validation.Required),
+		validation.Field(&req.Hash, validation.Required, validation.Match(fileHashPattern)),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
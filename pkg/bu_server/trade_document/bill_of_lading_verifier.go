@@ -0,0 +1,257 @@
+package trade_document
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/envelope"
+)
+
+// BillOfLadingVerifier lets anyone who is handed a bill of lading, printed or forwarded, check it against the packs
+// known to this BU server. It needs no authentication, so its result tells only about the authenticity and the status
+// of the bill of lading and never about the cargo.
+type BillOfLadingVerifier interface {
+	VerifyHash(ctx context.Context, ts int64, req VerifyBillOfLadingHashRequest) (BillOfLadingVerification, error)
+	VerifySignedPack(ctx context.Context, ts int64, signedPack envelope.JWS) (BillOfLadingVerification, error)
+}
+
+// VerifyBillOfLadingHashRequest is the request to verify a version of a pack by its hash, like the one carried in the
+// QR code of a bill of lading printed to paper.
+type VerifyBillOfLadingHashRequest struct {
+	ID   string `json:"id"`   // ID of the pack.
+	Hash string `json:"hash"` // Hex encoded SHA512 hash of the version of the pack.
+}
+
+// BillOfLadingVerification is the result of verifying a version of a bill of lading pack.
+type BillOfLadingVerification struct {
+	Verified          bool   `json:"verified"`            // Whether both the signatures and the hash chain of the version verify.
+	SignatureVerified bool   `json:"signature_verified"`  // Whether the version and all versions before it are signed by their actors.
+	HashChainVerified bool   `json:"hash_chain_verified"` // Whether the version chains to the issued bill of lading.
+	Reason            string `json:"reason,omitempty"`    // Why the version doesn't verify.
+
+	ID           string `json:"id"`            // ID of the pack.
+	Version      int64  `json:"version"`       // The verified version of the pack.
+	Hash         string `json:"hash"`          // Hash of the verified version.
+	DocReference string `json:"doc_reference"` // Transport document reference of the bill of lading.
+	Issuer       string `json:"issuer"`        // DID of the issuer.
+	IssuerName   string `json:"issuer_name"`   // Name of the issuer in its certificate. Empty if the certificate is unknown.
+	IssuedAt     int64  `json:"issued_at"`     // Unix Time (in second) when the bill of lading is issued.
+
+	// The latest version known to this BU server. Empty if the verified version is unknown to this BU server.
+	Known         bool               `json:"known"`                    // Whether the pack is known to this BU server.
+	LatestVersion int64              `json:"latest_version,omitempty"` // The latest version of the pack.
+	Status        BillOfLadingStatus `json:"status,omitempty"`         // Status of the latest version, like surrendered or printed_to_paper.
+	Superseded    bool               `json:"superseded"`               // Whether there are versions after the verified one.
+
+	VerifiedAt int64 `json:"verified_at"` // Unix Time (in second) of the verification.
+}
+
+type _BillOfLadingVerifier struct {
+	storage   TradeDocumentStorage
+	rootCerts []*x509.Certificate
+}
+
+type BillOfLadingVerifierOption func(v *_BillOfLadingVerifier)
+
+// WithVerifierRootCertificates sets the certificates trusted in addition to the system trusted certificates
+// to verify signers of bill of lading packs.
+func WithVerifierRootCertificates(rootCerts []*x509.Certificate) BillOfLadingVerifierOption {
+	return func(v *_BillOfLadingVerifier) {
+		v.rootCerts = rootCerts
+	}
+}
+
+func NewBillOfLadingVerifier(storage TradeDocumentStorage, options ...BillOfLadingVerifierOption) *_BillOfLadingVerifier {
+	v := &_BillOfLadingVerifier{
+		storage: storage,
+	}
+	for _, option := range options {
+		option(v)
+	}
+	return v
+}
+
+// VerifyHash verifies the version of the pack with the hash. It fails with model.ErrBillOfLadingNotFound if no version
+// of the pack has the hash, so the pack can't be looked up by its ID alone.
+func (v *_BillOfLadingVerifier) VerifyHash(ctx context.Context, ts int64, req VerifyBillOfLadingHashRequest) (BillOfLadingVerification, error) {
+	if err := ValidateVerifyBillOfLadingHashRequest(req); err != nil {
+		return BillOfLadingVerification{}, err
+	}
+
+	history, err := v.getHistory(ctx, req.ID)
+	if err != nil {
+		return BillOfLadingVerification{}, err
+	}
+	for i := range history {
+		hash, err := GetBillOfLadingPackHash(history[i].Pack)
+		if err != nil {
+			return BillOfLadingVerification{}, err
+		}
+		if hash == req.Hash {
+			return v.verifyHistory(ts, history, i)
+		}
+	}
+	return BillOfLadingVerification{}, model.ErrBillOfLadingNotFound
+}
+
+// VerifySignedPack verifies the signature of the signed pack. The hash chain of the pack is verified against the
+// versions known to this BU server. It doesn't verify for packs unknown to this BU server.
+func (v *_BillOfLadingVerifier) VerifySignedPack(ctx context.Context, ts int64, signedPack envelope.JWS) (BillOfLadingVerification, error) {
+	pack, err := VerifySignedBillOfLadingPack(signedPack, v.rootCerts)
+	if errors.Is(err, model.ErrBillOfLadingInvalidSignature) || errors.Is(err, model.ErrBillOfLadingSignerMismatch) {
+		return BillOfLadingVerification{Reason: err.Error(), VerifiedAt: ts}, nil
+	}
+	if err != nil {
+		return BillOfLadingVerification{}, err
+	}
+	hash, err := GetBillOfLadingPackHash(pack)
+	if err != nil {
+		return BillOfLadingVerification{}, err
+	}
+
+	history, err := v.getHistory(ctx, pack.ID)
+	if errors.Is(err, model.ErrBillOfLadingNotFound) {
+		result := newBillOfLadingVerification(ts, pack, hash)
+		result.SignatureVerified = true
+		result.Reason = "the pack is unknown to this BU server"
+		return result, nil
+	}
+	if err != nil {
+		return BillOfLadingVerification{}, err
+	}
+
+	for i := range history {
+		if history[i].Pack.Version != pack.Version {
+			continue
+		}
+		knownHash, err := GetBillOfLadingPackHash(history[i].Pack)
+		if err != nil {
+			return BillOfLadingVerification{}, err
+		}
+		if knownHash == hash {
+			return v.verifyHistory(ts, history, i)
+		}
+	}
+
+	result := newBillOfLadingVerification(ts, pack, hash)
+	result.SignatureVerified = true
+	// Nothing about the known versions is told for a pack matching none of them, like a forged version.
+	result.Reason = fmt.Sprintf("version %d is not a version of the pack known to this BU server", pack.Version)
+	return result, nil
+}
+
+func (v *_BillOfLadingVerifier) getHistory(ctx context.Context, packID string) ([]BillOfLadingPackRecord, error) {
+	tx, err := v.storage.CreateTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	history, err := v.storage.GetBillOfLadingPackHistory(ctx, tx, packID)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, model.ErrBillOfLadingNotFound
+	}
+	return history, nil
+}
+
+// verifyHistory verifies the signatures and the hash chain of the index-th version of history.
+func (v *_BillOfLadingVerifier) verifyHistory(ts int64, history []BillOfLadingPackRecord, index int) (BillOfLadingVerification, error) {
+	pack := history[index].Pack
+	hash, err := GetBillOfLadingPackHash(pack)
+	if err != nil {
+		return BillOfLadingVerification{}, err
+	}
+	result := newBillOfLadingVerification(ts, pack, hash)
+	result.IssuerName = certificateName(history[0].SignedPack)
+	v.setLatest(&result, history)
+
+	result.SignatureVerified = true
+	for _, record := range history[:index+1] {
+		if err := v.verifySignature(record); err != nil {
+			result.SignatureVerified = false
+			result.Reason = fmt.Sprintf("version %d: %s", record.Pack.Version, err.Error())
+			break
+		}
+	}
+
+	packs := make([]bill_of_lading.BillOfLadingPack, 0, index+1)
+	for _, record := range history[:index+1] {
+		packs = append(packs, record.Pack)
+	}
+	err = VerifyBillOfLadingPackHistory(packs)
+	var brokenErr *BrokenHashChainError
+	if errors.As(err, &brokenErr) {
+		if result.Reason == "" {
+			result.Reason = brokenErr.Error()
+		}
+	} else if err != nil {
+		return BillOfLadingVerification{}, err
+	} else {
+		result.HashChainVerified = true
+	}
+
+	result.Verified = result.SignatureVerified && result.HashChainVerified
+	return result, nil
+}
+
+// verifySignature checks the stored pack is the one signed in its envelope.
+func (v *_BillOfLadingVerifier) verifySignature(record BillOfLadingPackRecord) error {
+	signedPack, err := VerifySignedBillOfLadingPack(record.SignedPack, v.rootCerts)
+	if err != nil {
+		return err
+	}
+	signedHash, err := GetBillOfLadingPackHash(signedPack)
+	if err != nil {
+		return err
+	}
+	hash, err := GetBillOfLadingPackHash(record.Pack)
+	if err != nil {
+		return err
+	}
+	if signedHash != hash {
+		return fmt.Errorf("the pack is not the signed one: %w", model.ErrBillOfLadingInvalidSignature)
+	}
+	return nil
+}
+
+func (v *_BillOfLadingVerifier) setLatest(result *BillOfLadingVerification, history []BillOfLadingPackRecord) {
+	latest := history[len(history)-1].Pack
+	result.Known = true
+	result.LatestVersion = latest.Version
+	result.Status, _ = GetBillOfLadingStatus(latest)
+	result.Superseded = latest.Version > result.Version
+}
+
+func newBillOfLadingVerification(ts int64, pack bill_of_lading.BillOfLadingPack, hash string) BillOfLadingVerification {
+	result := BillOfLadingVerification{
+		ID:           pack.ID,
+		Version:      pack.Version,
+		Hash:         hash,
+		DocReference: GetDocumentReference(pack),
+		Issuer:       GetIssuer(pack),
+		VerifiedAt:   ts,
+	}
+	if len(pack.Events) > 0 && pack.Events[0].BillOfLading != nil && pack.Events[0].BillOfLading.CreatedAt != nil {
+		result.IssuedAt = pack.Events[0].BillOfLading.CreatedAt.Unix()
+	}
+	return result
+}
+
+// certificateName returns the organization (or the common name) of the signer of the signed pack.
+func certificateName(signedPack envelope.JWS) string {
+	certChain, err := signedPack.GetCertificateChain()
+	if err != nil || len(certChain) == 0 {
+		return ""
+	}
+	if len(certChain[0].Subject.Organization) > 0 {
+		return certChain[0].Subject.Organization[0]
+	}
+	return certChain[0].Subject.CommonName
+}
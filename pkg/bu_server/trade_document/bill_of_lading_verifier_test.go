@@ -0,0 +1,178 @@
+package trade_document_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/openebl/openebl/pkg/envelope"
+	mock_storage "github.com/openebl/openebl/test/mock/bu_server/storage"
+	mock_trade_document "github.com/openebl/openebl/test/mock/bu_server/trade_document"
+	"github.com/stretchr/testify/suite"
+)
+
+type BillOfLadingVerifierTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	ctrl     *gomock.Controller
+	storage  *mock_trade_document.MockTradeDocumentStorage
+	tx       *mock_storage.MockTx
+	verifier trade_document.BillOfLadingVerifier
+
+	ts       int64
+	rootCert *x509.Certificate
+	rootKey  *ecdsa.PrivateKey
+	history  []trade_document.BillOfLadingPackRecord
+}
+
+func TestBillOfLadingVerifier(t *testing.T) {
+	suite.Run(t, new(BillOfLadingVerifierTestSuite))
+}
+
+func (s *BillOfLadingVerifierTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.ctrl = gomock.NewController(s.T())
+	s.storage = mock_trade_document.NewMockTradeDocumentStorage(s.ctrl)
+	s.tx = mock_storage.NewMockTx(s.ctrl)
+
+	s.ts = time.Now().Unix()
+	s.rootCert, s.rootKey = newCertificate(s.T(), "root", nil, nil, true)
+	s.verifier = trade_document.NewBillOfLadingVerifier(s.storage, trade_document.WithVerifierRootCertificates([]*x509.Certificate{s.rootCert}))
+
+	// Issued, transferred to the bank and printed to paper by the bank.
+	service := trade_document.NewBillOfLadingService()
+	pack, err := service.Issue(s.ts, trade_document.IssueBillOfLadingRequest{
+		Issuer:       carrier,
+		TransferTo:   shipper,
+		BillOfLading: &bill_of_lading.TransportDocument{TransportDocumentReference: "bl-number"},
+	})
+	s.Require().NoError(err)
+	s.history = []trade_document.BillOfLadingPackRecord{s.newRecord(pack)}
+	pack, err = service.Transfer(s.ts, pack, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: bank})
+	s.Require().NoError(err)
+	s.history = append(s.history, s.newRecord(pack))
+	pack, err = service.PrintToPaper(s.ts, pack, trade_document.PrintToPaperBillOfLadingRequest{Actor: bank})
+	s.Require().NoError(err)
+	s.history = append(s.history, s.newRecord(pack))
+}
+
+func (s *BillOfLadingVerifierTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *BillOfLadingVerifierTestSuite) newRecord(pack bill_of_lading.BillOfLadingPack) trade_document.BillOfLadingPackRecord {
+	actor := trade_document.GetBillOfLadingPackActor(pack)
+	signedPack, err := trade_document.SignBillOfLadingPack(pack, newAuthentication(s.T(), actor, actor, s.ts, s.rootCert, s.rootKey))
+	s.Require().NoError(err)
+	return trade_document.BillOfLadingPackRecord{Pack: pack, SignedPack: signedPack, CreatedAt: s.ts}
+}
+
+func (s *BillOfLadingVerifierTestSuite) expectHistory(history []trade_document.BillOfLadingPackRecord) {
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.storage.EXPECT().GetBillOfLadingPackHistory(gomock.Any(), s.tx, s.history[0].Pack.ID).Return(history, nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+}
+
+func (s *BillOfLadingVerifierTestSuite) hash(version int) string {
+	hash, err := trade_document.GetBillOfLadingPackHash(s.history[version-1].Pack)
+	s.Require().NoError(err)
+	return hash
+}
+
+func (s *BillOfLadingVerifierTestSuite) TestVerifyHash() {
+	// The hash printed on the paper is the one of the version before it is printed.
+	s.expectHistory(s.history)
+	result, err := s.verifier.VerifyHash(s.ctx, s.ts, trade_document.VerifyBillOfLadingHashRequest{ID: s.history[0].Pack.ID, Hash: s.hash(2)})
+	s.Require().NoError(err)
+	s.Assert().Equal(trade_document.BillOfLadingVerification{
+		Verified:          true,
+		SignatureVerified: true,
+		HashChainVerified: true,
+		ID:                s.history[0].Pack.ID,
+		Version:           2,
+		Hash:              s.hash(2),
+		DocReference:      "bl-number",
+		Issuer:            carrier,
+		IssuerName:        "business unit",
+		IssuedAt:          s.ts,
+		Known:             true,
+		LatestVersion:     3,
+		Status:            trade_document.BillOfLadingStatusPrintedToPaper,
+		Superseded:        true,
+		VerifiedAt:        s.ts,
+	}, result)
+
+	// Nothing is told about a pack without the hash of one of its versions.
+	s.expectHistory(s.history)
+	_, err = s.verifier.VerifyHash(s.ctx, s.ts, trade_document.VerifyBillOfLadingHashRequest{ID: s.history[0].Pack.ID, Hash: s.hash(2)[1:] + "0"})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingNotFound)
+
+	s.expectHistory(nil)
+	_, err = s.verifier.VerifyHash(s.ctx, s.ts, trade_document.VerifyBillOfLadingHashRequest{ID: s.history[0].Pack.ID, Hash: s.hash(2)})
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingNotFound)
+
+	_, err = s.verifier.VerifyHash(s.ctx, s.ts, trade_document.VerifyBillOfLadingHashRequest{ID: s.history[0].Pack.ID, Hash: "not a hash"})
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+}
+
+func (s *BillOfLadingVerifierTestSuite) TestVerifyHashWithInvalidSignature() {
+	// The stored pack is changed after it is signed.
+	history := append([]trade_document.BillOfLadingPackRecord{}, s.history...)
+	history[0].SignedPack = s.history[1].SignedPack
+
+	s.expectHistory(history)
+	result, err := s.verifier.VerifyHash(s.ctx, s.ts, trade_document.VerifyBillOfLadingHashRequest{ID: s.history[0].Pack.ID, Hash: s.hash(3)})
+	s.Require().NoError(err)
+	s.Assert().False(result.Verified)
+	s.Assert().False(result.SignatureVerified)
+	s.Assert().True(result.HashChainVerified)
+	s.Assert().Contains(result.Reason, "version 1")
+}
+
+func (s *BillOfLadingVerifierTestSuite) TestVerifySignedPack() {
+	s.expectHistory(s.history)
+	result, err := s.verifier.VerifySignedPack(s.ctx, s.ts, s.history[2].SignedPack)
+	s.Require().NoError(err)
+	s.Assert().True(result.Verified)
+	s.Assert().EqualValues(3, result.Version)
+	s.Assert().False(result.Superseded)
+	s.Assert().Equal(trade_document.BillOfLadingStatusPrintedToPaper, result.Status)
+
+	// The signature verifies but the hash chain can't be verified without the versions before.
+	s.expectHistory(nil)
+	result, err = s.verifier.VerifySignedPack(s.ctx, s.ts, s.history[1].SignedPack)
+	s.Require().NoError(err)
+	s.Assert().False(result.Verified)
+	s.Assert().True(result.SignatureVerified)
+	s.Assert().False(result.Known)
+	s.Assert().Equal(carrier, result.Issuer)
+
+	// A fork of the known pack tells nothing about the known versions.
+	forked := s.history[1].Pack
+	forked.CurrentOwner = consignee
+	s.expectHistory(s.history)
+	result, err = s.verifier.VerifySignedPack(s.ctx, s.ts, s.newRecord(forked).SignedPack)
+	s.Require().NoError(err)
+	s.Assert().False(result.Verified)
+	s.Assert().True(result.SignatureVerified)
+	s.Assert().False(result.Known)
+	s.Assert().Zero(result.LatestVersion)
+	s.Assert().Empty(result.Status)
+	s.Assert().False(result.Superseded)
+
+	// The signature doesn't verify.
+	result, err = s.verifier.VerifySignedPack(s.ctx, s.ts, envelope.JWS{})
+	s.Require().NoError(err)
+	s.Assert().False(result.Verified)
+	s.Assert().False(result.SignatureVerified)
+	s.Assert().NotEmpty(result.Reason)
+	s.Assert().Empty(result.ID)
+}
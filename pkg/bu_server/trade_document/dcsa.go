@@ -0,0 +1,134 @@
+package trade_document
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+)
+
+// dcsaEventNamespace derives stable IDs of shipment events, so storing a version again doesn't duplicate its event.
+var dcsaEventNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://openebl.io/dcsa/events"))
+
+// GetTransportDocumentStatus returns the DCSA document status of the bill of lading in the pack.
+func GetTransportDocumentStatus(pack bill_of_lading.BillOfLadingPack) (bill_of_lading.EblDocumentStatus, error) {
+	status, err := GetBillOfLadingStatus(pack)
+	if err != nil {
+		return "", err
+	}
+
+	switch status {
+	case BillOfLadingStatusAmendmentRequested:
+		return bill_of_lading.PENU_EblDocumentStatus, nil
+	case BillOfLadingStatusSurrendered:
+		return bill_of_lading.SURR_EblDocumentStatus, nil
+	case BillOfLadingStatusPrintedToPaper:
+		return bill_of_lading.VOID_EblDocumentStatus, nil
+	}
+	return bill_of_lading.ISSU_EblDocumentStatus, nil
+}
+
+// GetBillOfLadingShipmentEvent returns the DCSA shipment event of the version of the pack.
+//
+// Transfers and returns change the owner of the bill of lading only, which DCSA eBL v2 doesn't have events for.
+// nil is returned for them.
+func GetBillOfLadingShipmentEvent(record BillOfLadingPackRecord) (*bill_of_lading.ShipmentEvent, error) {
+	pack := record.Pack
+	if len(pack.Events) == 0 {
+		return nil, model.ErrBillOfLadingNotIssued
+	}
+	td := GetTransportDocument(pack)
+	if td == nil {
+		return nil, model.ErrBillOfLadingNotIssued
+	}
+
+	payload := bill_of_lading.ShipmentEventPayload{
+		BaseShipmentEvent: bill_of_lading.BaseShipmentEvent{
+			EventClassifierCode: "ACT",
+			DocumentTypeCode:    bill_of_lading.TRD_EblDocumentTypeCode,
+			DocumentReference:   td.TransportDocumentReference,
+		},
+	}
+	lastEvent := pack.Events[len(pack.Events)-1]
+	switch {
+	case lastEvent.BillOfLading != nil:
+		payload.ShipmentEventTypeCode = bill_of_lading.ISSU_EblShipmentEventTypeCode
+		payload.EventDateTime = lastEvent.BillOfLading.CreatedAt
+	case lastEvent.AmendmentRequest != nil:
+		payload.ShipmentEventTypeCode = bill_of_lading.PENU_EblShipmentEventTypeCode
+		payload.EventDateTime = lastEvent.AmendmentRequest.RequestAt
+		payload.Reason = lastEvent.AmendmentRequest.Note
+	case lastEvent.Surrender != nil:
+		payload.ShipmentEventTypeCode = bill_of_lading.SURR_EblShipmentEventTypeCode
+		payload.EventDateTime = lastEvent.Surrender.SurrenderAt
+	case lastEvent.PrintToPaper != nil:
+		payload.ShipmentEventTypeCode = bill_of_lading.VOID_EblShipmentEventTypeCode
+		payload.EventDateTime = lastEvent.PrintToPaper.PrintAt
+		payload.Reason = "printed to paper"
+	default:
+		return nil, nil
+	}
+
+	if si := td.ShippingInstruction; si != nil {
+		payload.References = si.References
+		if si.ShippingInstructionReference != "" {
+			payload.RelatedDocumentReferences = append(
+				payload.RelatedDocumentReferences,
+				bill_of_lading.RelatedDocumentReference{Type: "SHI", Value: si.ShippingInstructionReference},
+			)
+		}
+		if si.CarrierBookingReference != "" {
+			payload.RelatedDocumentReferences = append(
+				payload.RelatedDocumentReferences,
+				bill_of_lading.RelatedDocumentReference{Type: "BKG", Value: si.CarrierBookingReference},
+			)
+		}
+	}
+
+	createdAt := newDateTime(record.CreatedAt)
+	return &bill_of_lading.ShipmentEvent{
+		Metadata: bill_of_lading.Metadata{
+			EventID:              shipmentEventID(bill_of_lading.TRD_EblDocumentTypeCode, pack.ID, pack.Version),
+			EventCreatedDateTime: &createdAt,
+			EventType:            bill_of_lading.SHIPMENT_EventType,
+		},
+		Payload: &payload,
+	}, nil
+}
+
+// GetShippingInstructionShipmentEvent returns the DCSA shipment event of the version of the shipping instruction.
+func GetShippingInstructionShipmentEvent(record ShippingInstructionRecord) bill_of_lading.ShipmentEvent {
+	si := record.ShippingInstruction
+	payload := bill_of_lading.ShipmentEventPayload{
+		BaseEvent: bill_of_lading.BaseEvent{
+			EventDateTime: si.ShippingInstructionUpdatedDateTime,
+		},
+		BaseShipmentEvent: bill_of_lading.BaseShipmentEvent{
+			EventClassifierCode:   "ACT",
+			ShipmentEventTypeCode: bill_of_lading.EblShipmentEventTypeCode(si.DocumentStatus),
+			DocumentTypeCode:      bill_of_lading.SHI_EblDocumentTypeCode,
+			DocumentReference:     si.ShippingInstructionReference,
+			References:            si.References,
+		},
+	}
+	if si.CarrierBookingReference != "" {
+		payload.RelatedDocumentReferences = []bill_of_lading.RelatedDocumentReference{
+			{Type: "BKG", Value: si.CarrierBookingReference},
+		}
+	}
+
+	createdAt := newDateTime(record.CreatedAt)
+	return bill_of_lading.ShipmentEvent{
+		Metadata: bill_of_lading.Metadata{
+			EventID:              shipmentEventID(bill_of_lading.SHI_EblDocumentTypeCode, si.ShippingInstructionReference, record.Version),
+			EventCreatedDateTime: &createdAt,
+			EventType:            bill_of_lading.SHIPMENT_EventType,
+		},
+		Payload: &payload,
+	}
+}
+
+func shipmentEventID(documentType bill_of_lading.EblDocumentTypeCode, id string, version int64) string {
+	return uuid.NewSHA1(dcsaEventNamespace, []byte(fmt.Sprintf("%s/%s/%d", documentType, id, version))).String()
+}
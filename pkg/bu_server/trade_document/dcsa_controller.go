@@ -0,0 +1,507 @@
+package trade_document
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/dcsa_validator"
+)
+
+// DCSAController serves the documents of a business unit in the shape of DCSA eBL v2, so systems speaking DCSA
+// (carrier TMS for example) can integrate with the business unit directly.
+type DCSAController interface {
+	CreateShippingInstruction(ctx context.Context, ts int64, req CreateShippingInstructionRequest) (bill_of_lading.ShippingInstructionRefStatus, error)
+	UpdateShippingInstruction(ctx context.Context, ts int64, req UpdateShippingInstructionRequest) (bill_of_lading.ShippingInstructionRefStatus, error)
+	ListShippingInstructions(ctx context.Context, req ListShippingInstructionSummariesRequest) (ListShippingInstructionSummariesResult, error)
+	GetShippingInstruction(ctx context.Context, req GetDCSADocumentRequest) (bill_of_lading.ShippingInstruction, error)
+	ListTransportDocuments(ctx context.Context, req ListTransportDocumentSummariesRequest) (ListTransportDocumentSummariesResult, error)
+	GetTransportDocument(ctx context.Context, req GetDCSADocumentRequest) (bill_of_lading.TransportDocument, error)
+	ListEvents(ctx context.Context, req ListDCSAEventsRequest) (ListShipmentEventsResult, error)
+}
+
+// CreateShippingInstructionRequest is the request to submit a shipping instruction to a business unit.
+type CreateShippingInstructionRequest struct {
+	ApplicationID string `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string `json:"business_unit"`  // DID of the business unit (usually the carrier) receiving the shipping instruction.
+
+	ShippingInstruction bill_of_lading.ShippingInstructionRequest `json:"shipping_instruction"`
+}
+
+// UpdateShippingInstructionRequest is the request to update a shipping instruction submitted to a business unit.
+type UpdateShippingInstructionRequest struct {
+	ApplicationID string `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string `json:"business_unit"`  // DID of the business unit (usually the carrier) receiving the shipping instruction.
+	Reference     string `json:"reference"`      // Reference of the shipping instruction.
+
+	ShippingInstruction bill_of_lading.ShippingInstructionRequest `json:"shipping_instruction"`
+}
+
+// GetDCSADocumentRequest is the request to get a document of a business unit by its DCSA reference.
+type GetDCSADocumentRequest struct {
+	ApplicationID string `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string `json:"business_unit"`  // DID of the business unit.
+	Reference     string `json:"reference"`      // Shipping instruction reference or transport document reference.
+}
+
+// ListShippingInstructionSummariesRequest is the request to list shipping instructions submitted to a business unit.
+type ListShippingInstructionSummariesRequest struct {
+	Offset int `json:"offset"` // Offset of the shipping instructions to be listed.
+	Limit  int `json:"limit"`  // Limit of the shipping instructions to be listed.
+
+	ApplicationID string `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string `json:"business_unit"`  // DID of the business unit.
+
+	// Filters
+	CarrierBookingReference string                           `json:"carrier_booking_reference"`
+	DocumentStatus          bill_of_lading.EblDocumentStatus `json:"document_status"`
+}
+
+// ListShippingInstructionSummariesResult is the result of listing shipping instructions.
+type ListShippingInstructionSummariesResult struct {
+	Total   int                                         `json:"total"`   // Total number of shipping instructions.
+	Records []bill_of_lading.ShippingInstructionSummary `json:"records"` // Summaries of the shipping instructions.
+}
+
+// ListTransportDocumentSummariesRequest is the request to list transport documents a business unit is a party of.
+type ListTransportDocumentSummariesRequest struct {
+	Offset int `json:"offset"` // Offset of the transport documents to be listed.
+	Limit  int `json:"limit"`  // Limit of the transport documents to be listed.
+
+	ApplicationID string `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string `json:"business_unit"`  // DID of the business unit.
+
+	// Filters
+	DocumentStatus bill_of_lading.EblDocumentStatus `json:"document_status"`
+}
+
+// ListTransportDocumentSummariesResult is the result of listing transport documents.
+type ListTransportDocumentSummariesResult struct {
+	Total   int                                       `json:"total"`   // Total number of transport documents.
+	Records []bill_of_lading.TransportDocumentSummary `json:"records"` // Summaries of the transport documents.
+}
+
+// ListDCSAEventsRequest is the request to list shipment events visible to a business unit.
+type ListDCSAEventsRequest struct {
+	Offset int `json:"offset"` // Offset of the events to be listed.
+	Limit  int `json:"limit"`  // Limit of the events to be listed.
+
+	ApplicationID string `json:"application_id"` // The ID of the application the business unit belongs to.
+	BusinessUnit  string `json:"business_unit"`  // DID of the business unit.
+
+	// Filters
+	DocumentTypeCode      bill_of_lading.EblDocumentTypeCode      `json:"document_type_code"`
+	DocumentReference     string                                  `json:"document_reference"`
+	ShipmentEventTypeCode bill_of_lading.EblShipmentEventTypeCode `json:"shipment_event_type_code"`
+}
+
+type _DCSAController struct {
+	buStorage business_unit.BusinessUnitStorage
+	storage   DCSAStorage
+}
+
+func NewDCSAController(buStorage business_unit.BusinessUnitStorage, storage DCSAStorage) *_DCSAController {
+	return &_DCSAController{
+		buStorage: buStorage,
+		storage:   storage,
+	}
+}
+
+// CreateShippingInstruction stores the shipping instruction as received (RECE).
+func (c *_DCSAController) CreateShippingInstruction(ctx context.Context, ts int64, req CreateShippingInstructionRequest) (bill_of_lading.ShippingInstructionRefStatus, error) {
+	if err := ValidateCreateShippingInstructionRequest(req); err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	si := newShippingInstruction(req.ShippingInstruction)
+	si.ShippingInstructionReference = uuid.NewString()
+	si.DocumentStatus = bill_of_lading.RECE_EblDocumentStatus
+	if err := dcsa_validator.ValidateShippingInstruction(&si); err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+
+	now := newDateTime(ts)
+	si.ShippingInstructionCreatedDateTime = &now
+	si.ShippingInstructionUpdatedDateTime = &now
+	record := ShippingInstructionRecord{
+		BusinessUnit:        req.BusinessUnit,
+		Version:             1,
+		ShippingInstruction: si,
+		CreatedAt:           ts,
+	}
+
+	tx, err := c.storage.CreateTx(ctx, storage.TxOptionWithWrite(true))
+	if err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := c.storage.StoreShippingInstruction(ctx, tx, record); err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	return newShippingInstructionRefStatus(si), nil
+}
+
+// UpdateShippingInstruction replaces the shipping instruction while it is received (RECE) or pending update (PENU).
+// The updated shipping instruction is received (RECE) again.
+func (c *_DCSAController) UpdateShippingInstruction(ctx context.Context, ts int64, req UpdateShippingInstructionRequest) (bill_of_lading.ShippingInstructionRefStatus, error) {
+	if err := ValidateUpdateShippingInstructionRequest(req); err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	si := newShippingInstruction(req.ShippingInstruction)
+	si.ShippingInstructionReference = req.Reference
+	si.DocumentStatus = bill_of_lading.RECE_EblDocumentStatus
+	if err := dcsa_validator.ValidateShippingInstruction(&si); err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx, storage.TxOptionWithWrite(true), storage.TxOptionWithIsolationLevel(sql.LevelSerializable))
+	if err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	oldRecord, err := c.getShippingInstruction(ctx, tx, req.BusinessUnit, req.Reference)
+	if err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	oldSI := oldRecord.ShippingInstruction
+	if oldSI.DocumentStatus != bill_of_lading.RECE_EblDocumentStatus && oldSI.DocumentStatus != bill_of_lading.PENU_EblDocumentStatus {
+		return bill_of_lading.ShippingInstructionRefStatus{}, model.ErrShippingInstructionNotUpdatable
+	}
+
+	now := newDateTime(ts)
+	si.ShippingInstructionCreatedDateTime = oldSI.ShippingInstructionCreatedDateTime
+	si.ShippingInstructionUpdatedDateTime = &now
+	record := ShippingInstructionRecord{
+		BusinessUnit:        oldRecord.BusinessUnit,
+		Version:             oldRecord.Version + 1,
+		ShippingInstruction: si,
+		CreatedAt:           ts,
+	}
+	if err := c.storage.StoreShippingInstruction(ctx, tx, record); err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return bill_of_lading.ShippingInstructionRefStatus{}, err
+	}
+	return newShippingInstructionRefStatus(si), nil
+}
+
+func (c *_DCSAController) ListShippingInstructions(ctx context.Context, req ListShippingInstructionSummariesRequest) (ListShippingInstructionSummariesResult, error) {
+	if err := ValidateListShippingInstructionSummariesRequest(req); err != nil {
+		return ListShippingInstructionSummariesResult{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return ListShippingInstructionSummariesResult{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx)
+	if err != nil {
+		return ListShippingInstructionSummariesResult{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	listReq := ListShippingInstructionsRequest{
+		Offset:                  req.Offset,
+		Limit:                   req.Limit,
+		BusinessUnit:            req.BusinessUnit,
+		CarrierBookingReference: req.CarrierBookingReference,
+	}
+	if req.DocumentStatus != "" {
+		listReq.DocumentStatuses = []bill_of_lading.EblDocumentStatus{req.DocumentStatus}
+	}
+	listResult, err := c.storage.ListShippingInstructions(ctx, tx, listReq)
+	if err != nil {
+		return ListShippingInstructionSummariesResult{}, err
+	}
+
+	result := ListShippingInstructionSummariesResult{
+		Total:   listResult.Total,
+		Records: make([]bill_of_lading.ShippingInstructionSummary, 0, len(listResult.Records)),
+	}
+	for _, record := range listResult.Records {
+		result.Records = append(result.Records, newShippingInstructionSummary(record.ShippingInstruction))
+	}
+	return result, nil
+}
+
+func (c *_DCSAController) GetShippingInstruction(ctx context.Context, req GetDCSADocumentRequest) (bill_of_lading.ShippingInstruction, error) {
+	if err := ValidateGetDCSADocumentRequest(req); err != nil {
+		return bill_of_lading.ShippingInstruction{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return bill_of_lading.ShippingInstruction{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx)
+	if err != nil {
+		return bill_of_lading.ShippingInstruction{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	record, err := c.getShippingInstruction(ctx, tx, req.BusinessUnit, req.Reference)
+	if err != nil {
+		return bill_of_lading.ShippingInstruction{}, err
+	}
+	return record.ShippingInstruction, nil
+}
+
+func (c *_DCSAController) ListTransportDocuments(ctx context.Context, req ListTransportDocumentSummariesRequest) (ListTransportDocumentSummariesResult, error) {
+	if err := ValidateListTransportDocumentSummariesRequest(req); err != nil {
+		return ListTransportDocumentSummariesResult{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return ListTransportDocumentSummariesResult{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx)
+	if err != nil {
+		return ListTransportDocumentSummariesResult{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	listReq := ListBillOfLadingPacksRequest{
+		Offset:       req.Offset,
+		Limit:        req.Limit,
+		BusinessUnit: req.BusinessUnit,
+	}
+	if req.DocumentStatus != "" {
+		listReq.Statuses = billOfLadingStatuses(req.DocumentStatus)
+		if len(listReq.Statuses) == 0 {
+			// No bill of lading can be in the status.
+			return ListTransportDocumentSummariesResult{Records: []bill_of_lading.TransportDocumentSummary{}}, nil
+		}
+	}
+	listResult, err := c.storage.ListBillOfLadingPacks(ctx, tx, listReq)
+	if err != nil {
+		return ListTransportDocumentSummariesResult{}, err
+	}
+
+	result := ListTransportDocumentSummariesResult{
+		Total:   listResult.Total,
+		Records: make([]bill_of_lading.TransportDocumentSummary, 0, len(listResult.Records)),
+	}
+	for _, record := range listResult.Records {
+		summary, err := newTransportDocumentSummary(record.Pack)
+		if err != nil {
+			return ListTransportDocumentSummariesResult{}, err
+		}
+		result.Records = append(result.Records, summary)
+	}
+	return result, nil
+}
+
+func (c *_DCSAController) GetTransportDocument(ctx context.Context, req GetDCSADocumentRequest) (bill_of_lading.TransportDocument, error) {
+	if err := ValidateGetDCSADocumentRequest(req); err != nil {
+		return bill_of_lading.TransportDocument{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return bill_of_lading.TransportDocument{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx)
+	if err != nil {
+		return bill_of_lading.TransportDocument{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	listReq := ListBillOfLadingPacksRequest{
+		Limit:        1,
+		BusinessUnit: req.BusinessUnit,
+		DocReference: req.Reference,
+	}
+	result, err := c.storage.ListBillOfLadingPacks(ctx, tx, listReq)
+	if err != nil {
+		return bill_of_lading.TransportDocument{}, err
+	}
+	if len(result.Records) == 0 {
+		return bill_of_lading.TransportDocument{}, model.ErrBillOfLadingNotFound
+	}
+
+	td := GetTransportDocument(result.Records[0].Pack)
+	if td == nil {
+		return bill_of_lading.TransportDocument{}, model.ErrBillOfLadingNotIssued
+	}
+	return *td, nil
+}
+
+func (c *_DCSAController) ListEvents(ctx context.Context, req ListDCSAEventsRequest) (ListShipmentEventsResult, error) {
+	if err := ValidateListDCSAEventsRequest(req); err != nil {
+		return ListShipmentEventsResult{}, err
+	}
+	if err := checkBusinessUnit(ctx, c.buStorage, req.ApplicationID, req.BusinessUnit); err != nil {
+		return ListShipmentEventsResult{}, err
+	}
+
+	tx, err := c.storage.CreateTx(ctx)
+	if err != nil {
+		return ListShipmentEventsResult{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	listReq := ListShipmentEventsRequest{
+		Offset:                req.Offset,
+		Limit:                 req.Limit,
+		BusinessUnit:          req.BusinessUnit,
+		DocumentTypeCode:      req.DocumentTypeCode,
+		DocumentReference:     req.DocumentReference,
+		ShipmentEventTypeCode: req.ShipmentEventTypeCode,
+	}
+	result, err := c.storage.ListShipmentEvents(ctx, tx, listReq)
+	if err != nil {
+		return ListShipmentEventsResult{}, err
+	}
+	if result.Records == nil {
+		result.Records = []bill_of_lading.ShipmentEvent{}
+	}
+	return result, nil
+}
+
+// getShippingInstruction returns the latest version of the shipping instruction submitted to the business unit.
+func (c *_DCSAController) getShippingInstruction(ctx context.Context, tx storage.Tx, businessUnit string, reference string) (ShippingInstructionRecord, error) {
+	listReq := ListShippingInstructionsRequest{
+		Limit:        1,
+		BusinessUnit: businessUnit,
+		References:   []string{reference},
+	}
+	result, err := c.storage.ListShippingInstructions(ctx, tx, listReq)
+	if err != nil {
+		return ShippingInstructionRecord{}, err
+	}
+	if len(result.Records) == 0 {
+		return ShippingInstructionRecord{}, model.ErrShippingInstructionNotFound
+	}
+	return result.Records[0], nil
+}
+
+// billOfLadingStatuses returns statuses of bill of lading which are the DCSA document status.
+func billOfLadingStatuses(documentStatus bill_of_lading.EblDocumentStatus) []BillOfLadingStatus {
+	switch documentStatus {
+	case bill_of_lading.ISSU_EblDocumentStatus:
+		return []BillOfLadingStatus{BillOfLadingStatusIssued}
+	case bill_of_lading.PENU_EblDocumentStatus:
+		return []BillOfLadingStatus{BillOfLadingStatusAmendmentRequested}
+	case bill_of_lading.SURR_EblDocumentStatus:
+		return []BillOfLadingStatus{BillOfLadingStatusSurrendered}
+	case bill_of_lading.VOID_EblDocumentStatus:
+		return []BillOfLadingStatus{BillOfLadingStatusPrintedToPaper}
+	}
+	return nil
+}
+
+func newShippingInstruction(req bill_of_lading.ShippingInstructionRequest) bill_of_lading.ShippingInstruction {
+	return bill_of_lading.ShippingInstruction{
+		TransportDocumentTypeCode:       req.TransportDocumentTypeCode,
+		IsShippedOnBoardType:            req.IsShippedOnBoardType,
+		NumberOfCopiesWithCharges:       req.NumberOfCopiesWithCharges,
+		NumberOfCopiesWithoutCharges:    req.NumberOfCopiesWithoutCharges,
+		NumberOfOriginalsWithCharges:    req.NumberOfOriginalsWithCharges,
+		NumberOfOriginalsWithoutCharges: req.NumberOfOriginalsWithoutCharges,
+		IsElectronic:                    req.IsElectronic,
+		IsToOrder:                       req.IsToOrder,
+		DisplayedNameForPlaceOfReceipt:  req.DisplayedNameForPlaceOfReceipt,
+		DisplayedNameForPortOfLoad:      req.DisplayedNameForPortOfLoad,
+		DisplayedNameForPortOfDischarge: req.DisplayedNameForPortOfDischarge,
+		DisplayedNameForPlaceOfDelivery: req.DisplayedNameForPlaceOfDelivery,
+		CarrierBookingReference:         req.CarrierBookingReference,
+		PlaceOfIssue:                    req.PlaceOfIssue,
+		ConsignmentItems:                req.ConsignmentItems,
+		UtilizedTransportEquipments:     req.UtilizedTransportEquipments,
+		DocumentParties:                 req.DocumentParties,
+		References:                      req.References,
+	}
+}
+
+func newShippingInstructionRefStatus(si bill_of_lading.ShippingInstruction) bill_of_lading.ShippingInstructionRefStatus {
+	return bill_of_lading.ShippingInstructionRefStatus{
+		ShippingInstructionReference:       si.ShippingInstructionReference,
+		DocumentStatus:                     si.DocumentStatus,
+		ShippingInstructionCreatedDateTime: si.ShippingInstructionCreatedDateTime,
+		ShippingInstructionUpdatedDateTime: si.ShippingInstructionUpdatedDateTime,
+	}
+}
+
+func newShippingInstructionSummary(si bill_of_lading.ShippingInstruction) bill_of_lading.ShippingInstructionSummary {
+	return bill_of_lading.ShippingInstructionSummary{
+		ShippingInstructionReference:       si.ShippingInstructionReference,
+		DocumentStatus:                     si.DocumentStatus,
+		ShippingInstructionCreatedDateTime: si.ShippingInstructionCreatedDateTime,
+		ShippingInstructionUpdatedDateTime: si.ShippingInstructionUpdatedDateTime,
+		AmendToTransportDocument:           si.AmendToTransportDocument,
+		TransportDocumentTypeCode:          si.TransportDocumentTypeCode,
+		IsShippedOnBoardType:               si.IsShippedOnBoardType,
+		NumberOfCopiesWithCharges:          si.NumberOfCopiesWithCharges,
+		NumberOfCopiesWithoutCharges:       si.NumberOfCopiesWithoutCharges,
+		NumberOfOriginalsWithCharges:       si.NumberOfOriginalsWithCharges,
+		NumberOfOriginalsWithoutCharges:    si.NumberOfOriginalsWithoutCharges,
+		IsElectronic:                       si.IsElectronic,
+		IsToOrder:                          si.IsToOrder,
+		DisplayedNameForPlaceOfReceipt:     si.DisplayedNameForPlaceOfReceipt,
+		DisplayedNameForPortOfLoad:         si.DisplayedNameForPortOfLoad,
+		DisplayedNameForPortOfDischarge:    si.DisplayedNameForPortOfDischarge,
+		DisplayedNameForPlaceOfDelivery:    si.DisplayedNameForPlaceOfDelivery,
+		CarrierBookingReferences:           carrierBookingReferences(si),
+	}
+}
+
+func newTransportDocumentSummary(pack bill_of_lading.BillOfLadingPack) (bill_of_lading.TransportDocumentSummary, error) {
+	td := GetTransportDocument(pack)
+	if td == nil {
+		return bill_of_lading.TransportDocumentSummary{}, model.ErrBillOfLadingNotIssued
+	}
+	status, err := GetTransportDocumentStatus(pack)
+	if err != nil {
+		return bill_of_lading.TransportDocumentSummary{}, err
+	}
+
+	summary := bill_of_lading.TransportDocumentSummary{
+		TransportDocumentReference:       td.TransportDocumentReference,
+		TransportDocumentCreatedDateTime: td.TransportDocumentCreatedDateTime,
+		TransportDocumentUpdatedDateTime: td.TransportDocumentUpdatedDateTime,
+		IssueDate:                        td.IssueDate,
+		ShippedOnBoardDate:               td.ShippedOnBoardDate,
+		ReceivedForShipmentDate:          td.ReceivedForShipmentDate,
+		CarrierCode:                      td.CarrierCode,
+		CarrierCodeListProvider:          td.CarrierCodeListProvider,
+		IssuingParty:                     td.IssuingParty,
+		NumberOfRiderPages:               td.NumberOfRiderPages,
+		DocumentStatus:                   status,
+	}
+	if td.ShippingInstruction != nil {
+		summary.ShippingInstructionReference = td.ShippingInstruction.ShippingInstructionReference
+		summary.CarrierBookingReferences = carrierBookingReferences(*td.ShippingInstruction)
+	}
+	return summary, nil
+}
+
+// carrierBookingReferences returns all carrier booking references used in the root and the consignment items of the
+// shipping instruction without duplication.
+func carrierBookingReferences(si bill_of_lading.ShippingInstruction) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		if ref != "" && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	add(si.CarrierBookingReference)
+	for _, item := range si.ConsignmentItems {
+		add(item.CarrierBookingReference)
+	}
+	return refs
+}
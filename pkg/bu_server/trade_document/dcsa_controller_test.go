@@ -0,0 +1,355 @@
+package trade_document_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/openebl/openebl/pkg/bu_server/business_unit"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	mock_business_unit "github.com/openebl/openebl/test/mock/bu_server/business_unit"
+	mock_storage "github.com/openebl/openebl/test/mock/bu_server/storage"
+	mock_trade_document "github.com/openebl/openebl/test/mock/bu_server/trade_document"
+	"github.com/stretchr/testify/suite"
+)
+
+type DCSAControllerTestSuite struct {
+	suite.Suite
+	ctx        context.Context
+	ctrl       *gomock.Controller
+	buStorage  *mock_business_unit.MockBusinessUnitStorage
+	storage    *mock_trade_document.MockDCSAStorage
+	tx         *mock_storage.MockTx
+	controller trade_document.DCSAController
+
+	ts    int64
+	appID string
+	siReq bill_of_lading.ShippingInstructionRequest
+}
+
+func TestDCSAController(t *testing.T) {
+	suite.Run(t, new(DCSAControllerTestSuite))
+}
+
+func (s *DCSAControllerTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.ctrl = gomock.NewController(s.T())
+	s.buStorage = mock_business_unit.NewMockBusinessUnitStorage(s.ctrl)
+	s.storage = mock_trade_document.NewMockDCSAStorage(s.ctrl)
+	s.tx = mock_storage.NewMockTx(s.ctrl)
+	s.controller = trade_document.NewDCSAController(s.buStorage, s.storage)
+
+	s.ts = time.Now().Unix()
+	s.appID = "app"
+	si := dcsaShippingInstruction()
+	s.siReq = bill_of_lading.ShippingInstructionRequest{
+		CarrierBookingReference:     si.CarrierBookingReference,
+		ConsignmentItems:            si.ConsignmentItems,
+		UtilizedTransportEquipments: si.UtilizedTransportEquipments,
+		TransportDocumentTypeCode:   si.TransportDocumentTypeCode,
+		IsElectronic:                si.IsElectronic,
+	}
+}
+
+func (s *DCSAControllerTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *DCSAControllerTestSuite) expectBusinessUnit(businessUnit string) {
+	gomock.InOrder(
+		s.buStorage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.buStorage.EXPECT().ListBusinessUnits(
+			gomock.Any(),
+			s.tx,
+			business_unit.ListBusinessUnitsRequest{Limit: 1, ApplicationID: s.appID, BusinessUnitIDs: []string{businessUnit}},
+		).Return(
+			business_unit.ListBusinessUnitsResult{
+				Total:   1,
+				Records: []business_unit.ListBusinessUnitsRecord{{BusinessUnit: model.BusinessUnit{ID: did.MustParseDID(businessUnit), Status: model.BusinessUnitStatusActive}}},
+			},
+			nil,
+		),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+}
+
+func (s *DCSAControllerTestSuite) expectGetShippingInstruction(records ...trade_document.ShippingInstructionRecord) *gomock.Call {
+	return s.storage.EXPECT().ListShippingInstructions(
+		gomock.Any(),
+		s.tx,
+		trade_document.ListShippingInstructionsRequest{Limit: 1, BusinessUnit: carrier, References: []string{"si1"}},
+	).Return(trade_document.ListShippingInstructionsResult{Total: len(records), Records: records}, nil)
+}
+
+func (s *DCSAControllerTestSuite) shippingInstructionRecord(version int64, status bill_of_lading.EblDocumentStatus) trade_document.ShippingInstructionRecord {
+	createdAt := model.NewDateTime(time.Unix(s.ts-100, 0).UTC())
+	return trade_document.ShippingInstructionRecord{
+		BusinessUnit: carrier,
+		Version:      version,
+		ShippingInstruction: bill_of_lading.ShippingInstruction{
+			ShippingInstructionReference:       "si1",
+			DocumentStatus:                     status,
+			ShippingInstructionCreatedDateTime: &createdAt,
+			ShippingInstructionUpdatedDateTime: &createdAt,
+			CarrierBookingReference:            "booking1",
+		},
+		CreatedAt: s.ts - 100,
+	}
+}
+
+func (s *DCSAControllerTestSuite) TestCreateShippingInstruction() {
+	req := trade_document.CreateShippingInstructionRequest{
+		ApplicationID:       s.appID,
+		BusinessUnit:        carrier,
+		ShippingInstruction: s.siReq,
+	}
+
+	var stored trade_document.ShippingInstructionRecord
+	s.expectBusinessUnit(carrier)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(1)).Return(s.tx, nil),
+		s.storage.EXPECT().StoreShippingInstruction(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, record trade_document.ShippingInstructionRecord) error {
+				stored = record
+				return nil
+			},
+		),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	refStatus, err := s.controller.CreateShippingInstruction(s.ctx, s.ts, req)
+	s.Require().NoError(err)
+	s.Assert().NotEmpty(refStatus.ShippingInstructionReference)
+	s.Assert().Equal(bill_of_lading.RECE_EblDocumentStatus, refStatus.DocumentStatus)
+	s.Assert().Equal(s.ts, refStatus.ShippingInstructionCreatedDateTime.Unix())
+
+	s.Assert().Equal(carrier, stored.BusinessUnit)
+	s.Assert().EqualValues(1, stored.Version)
+	s.Assert().Equal(refStatus.ShippingInstructionReference, stored.ShippingInstruction.ShippingInstructionReference)
+	s.Assert().Equal(s.siReq.ConsignmentItems, stored.ShippingInstruction.ConsignmentItems)
+	s.Assert().True(stored.ShippingInstruction.IsElectronic)
+
+	// Invalid shipping instruction.
+	req.ShippingInstruction.TransportDocumentTypeCode = "XXX"
+	_, err = s.controller.CreateShippingInstruction(s.ctx, s.ts, req)
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+}
+
+func (s *DCSAControllerTestSuite) TestUpdateShippingInstruction() {
+	req := trade_document.UpdateShippingInstructionRequest{
+		ApplicationID:       s.appID,
+		BusinessUnit:        carrier,
+		Reference:           "si1",
+		ShippingInstruction: s.siReq,
+	}
+	old := s.shippingInstructionRecord(1, bill_of_lading.PENU_EblDocumentStatus)
+
+	s.expectBusinessUnit(carrier)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.expectGetShippingInstruction(old),
+		s.storage.EXPECT().StoreShippingInstruction(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, record trade_document.ShippingInstructionRecord) error {
+				s.Assert().EqualValues(2, record.Version)
+				s.Assert().Equal("si1", record.ShippingInstruction.ShippingInstructionReference)
+				s.Assert().Equal(bill_of_lading.RECE_EblDocumentStatus, record.ShippingInstruction.DocumentStatus)
+				s.Assert().Equal(old.ShippingInstruction.ShippingInstructionCreatedDateTime, record.ShippingInstruction.ShippingInstructionCreatedDateTime)
+				s.Assert().Equal(s.ts, record.ShippingInstruction.ShippingInstructionUpdatedDateTime.Unix())
+				return nil
+			},
+		),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	refStatus, err := s.controller.UpdateShippingInstruction(s.ctx, s.ts, req)
+	s.Require().NoError(err)
+	s.Assert().Equal("si1", refStatus.ShippingInstructionReference)
+	s.Assert().Equal(bill_of_lading.RECE_EblDocumentStatus, refStatus.DocumentStatus)
+}
+
+func (s *DCSAControllerTestSuite) TestUpdateShippingInstructionNotUpdatable() {
+	req := trade_document.UpdateShippingInstructionRequest{
+		ApplicationID:       s.appID,
+		BusinessUnit:        carrier,
+		Reference:           "si1",
+		ShippingInstruction: s.siReq,
+	}
+
+	s.expectBusinessUnit(carrier)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.expectGetShippingInstruction(s.shippingInstructionRecord(2, bill_of_lading.APPR_EblDocumentStatus)),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	_, err := s.controller.UpdateShippingInstruction(s.ctx, s.ts, req)
+	s.Assert().ErrorIs(err, model.ErrShippingInstructionNotUpdatable)
+
+	s.expectBusinessUnit(carrier)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.expectGetShippingInstruction(),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	_, err = s.controller.UpdateShippingInstruction(s.ctx, s.ts, req)
+	s.Assert().ErrorIs(err, model.ErrShippingInstructionNotFound)
+}
+
+func (s *DCSAControllerTestSuite) TestListShippingInstructions() {
+	req := trade_document.ListShippingInstructionSummariesRequest{
+		Limit:          10,
+		ApplicationID:  s.appID,
+		BusinessUnit:   carrier,
+		DocumentStatus: bill_of_lading.RECE_EblDocumentStatus,
+	}
+	record := s.shippingInstructionRecord(1, bill_of_lading.RECE_EblDocumentStatus)
+	record.ShippingInstruction.ConsignmentItems = []bill_of_lading.ConsignmentItem{
+		{CarrierBookingReference: "booking1"},
+		{CarrierBookingReference: "booking2"},
+	}
+
+	s.expectBusinessUnit(carrier)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.storage.EXPECT().ListShippingInstructions(
+			gomock.Any(),
+			s.tx,
+			trade_document.ListShippingInstructionsRequest{
+				Limit:            10,
+				BusinessUnit:     carrier,
+				DocumentStatuses: []bill_of_lading.EblDocumentStatus{bill_of_lading.RECE_EblDocumentStatus},
+			},
+		).Return(trade_document.ListShippingInstructionsResult{Total: 1, Records: []trade_document.ShippingInstructionRecord{record}}, nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	result, err := s.controller.ListShippingInstructions(s.ctx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(1, result.Total)
+	s.Require().Len(result.Records, 1)
+	s.Assert().Equal("si1", result.Records[0].ShippingInstructionReference)
+	s.Assert().Equal([]string{"booking1", "booking2"}, result.Records[0].CarrierBookingReferences)
+}
+
+func (s *DCSAControllerTestSuite) TestTransportDocuments() {
+	pack, err := trade_document.NewBillOfLadingService().Issue(s.ts, trade_document.IssueBillOfLadingRequest{
+		Issuer:     carrier,
+		TransferTo: shipper,
+		BillOfLading: &bill_of_lading.TransportDocument{
+			TransportDocumentReference: "bl-number",
+			ShippingInstruction: &bill_of_lading.ShippingInstruction{
+				ShippingInstructionReference: "si1",
+				CarrierBookingReference:      "booking1",
+			},
+		},
+	})
+	s.Require().NoError(err)
+	pack, err = trade_document.NewBillOfLadingService().Surrender(s.ts, pack, trade_document.SurrenderBillOfLadingRequest{Actor: shipper})
+	s.Require().NoError(err)
+	record := trade_document.BillOfLadingPackRecord{Pack: pack, CreatedAt: s.ts}
+
+	listReq := trade_document.ListTransportDocumentSummariesRequest{
+		Limit:          10,
+		ApplicationID:  s.appID,
+		BusinessUnit:   shipper,
+		DocumentStatus: bill_of_lading.SURR_EblDocumentStatus,
+	}
+	s.expectBusinessUnit(shipper)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.storage.EXPECT().ListBillOfLadingPacks(
+			gomock.Any(),
+			s.tx,
+			trade_document.ListBillOfLadingPacksRequest{
+				Limit:        10,
+				BusinessUnit: shipper,
+				Statuses:     []trade_document.BillOfLadingStatus{trade_document.BillOfLadingStatusSurrendered},
+			},
+		).Return(trade_document.ListBillOfLadingPacksResult{Total: 1, Records: []trade_document.BillOfLadingPackRecord{record}}, nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	result, err := s.controller.ListTransportDocuments(s.ctx, listReq)
+	s.Require().NoError(err)
+	s.Assert().Equal(1, result.Total)
+	s.Require().Len(result.Records, 1)
+	s.Assert().Equal("bl-number", result.Records[0].TransportDocumentReference)
+	s.Assert().Equal("si1", result.Records[0].ShippingInstructionReference)
+	s.Assert().Equal(bill_of_lading.SURR_EblDocumentStatus, result.Records[0].DocumentStatus)
+	s.Assert().Equal([]string{"booking1"}, result.Records[0].CarrierBookingReferences)
+
+	// No bill of lading is in a status of shipping instructions.
+	listReq.DocumentStatus = bill_of_lading.RECE_EblDocumentStatus
+	s.expectBusinessUnit(shipper)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	result, err = s.controller.ListTransportDocuments(s.ctx, listReq)
+	s.Require().NoError(err)
+	s.Assert().Equal(0, result.Total)
+	s.Assert().Empty(result.Records)
+
+	getReq := trade_document.GetDCSADocumentRequest{ApplicationID: s.appID, BusinessUnit: shipper, Reference: "bl-number"}
+	s.expectBusinessUnit(shipper)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.storage.EXPECT().ListBillOfLadingPacks(
+			gomock.Any(),
+			s.tx,
+			trade_document.ListBillOfLadingPacksRequest{Limit: 1, BusinessUnit: shipper, DocReference: "bl-number"},
+		).Return(trade_document.ListBillOfLadingPacksResult{Total: 1, Records: []trade_document.BillOfLadingPackRecord{record}}, nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	td, err := s.controller.GetTransportDocument(s.ctx, getReq)
+	s.Require().NoError(err)
+	s.Assert().Equal("bl-number", td.TransportDocumentReference)
+
+	s.expectBusinessUnit(shipper)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.storage.EXPECT().ListBillOfLadingPacks(gomock.Any(), s.tx, gomock.Any()).Return(trade_document.ListBillOfLadingPacksResult{}, nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	_, err = s.controller.GetTransportDocument(s.ctx, getReq)
+	s.Assert().ErrorIs(err, model.ErrBillOfLadingNotFound)
+}
+
+func (s *DCSAControllerTestSuite) TestListEvents() {
+	req := trade_document.ListDCSAEventsRequest{
+		Limit:                 10,
+		ApplicationID:         s.appID,
+		BusinessUnit:          carrier,
+		DocumentTypeCode:      bill_of_lading.SHI_EblDocumentTypeCode,
+		ShipmentEventTypeCode: bill_of_lading.RECE_EblShipmentEventTypeCode,
+	}
+	event := trade_document.GetShippingInstructionShipmentEvent(s.shippingInstructionRecord(1, bill_of_lading.RECE_EblDocumentStatus))
+
+	s.expectBusinessUnit(carrier)
+	gomock.InOrder(
+		s.storage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.storage.EXPECT().ListShipmentEvents(
+			gomock.Any(),
+			s.tx,
+			trade_document.ListShipmentEventsRequest{
+				Limit:                 10,
+				BusinessUnit:          carrier,
+				DocumentTypeCode:      bill_of_lading.SHI_EblDocumentTypeCode,
+				ShipmentEventTypeCode: bill_of_lading.RECE_EblShipmentEventTypeCode,
+			},
+		).Return(trade_document.ListShipmentEventsResult{Total: 1, Records: []bill_of_lading.ShipmentEvent{event}}, nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	result, err := s.controller.ListEvents(s.ctx, req)
+	s.Require().NoError(err)
+	s.Assert().Equal(1, result.Total)
+	s.Assert().Equal([]bill_of_lading.ShipmentEvent{event}, result.Records)
+
+	req.ShipmentEventTypeCode = "XXXX"
+	_, err = s.controller.ListEvents(s.ctx, req)
+	s.Assert().ErrorIs(err, model.ErrInvalidParameter)
+}
@@ -0,0 +1,108 @@
+package trade_document_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dcsaTransportDocument returns a transport document following DCSA rules.
+func dcsaTransportDocument(reference string) *bill_of_lading.TransportDocument {
+	si := dcsaShippingInstruction()
+	si.ShippingInstructionReference = "si1"
+	si.DocumentStatus = bill_of_lading.ISSU_EblDocumentStatus
+	return &bill_of_lading.TransportDocument{
+		TransportDocumentReference: reference,
+		CarrierCode:                "HLC",
+		CarrierCodeListProvider:    bill_of_lading.SMDG_CarrierCodeListProvider,
+		IssuingParty: &bill_of_lading.Party{
+			PartyName:           "Carrier",
+			PartyContactDetails: []bill_of_lading.PartyContactDetail{{Name: "Documentation desk", Email: "doc@example.com"}},
+		},
+		ShippingInstruction: &si,
+	}
+}
+
+// dcsaShippingInstruction returns a shipping instruction following DCSA rules without its reference and status.
+func dcsaShippingInstruction() bill_of_lading.ShippingInstruction {
+	weight, _ := model.NewDecimalFromString("4000")
+	weightUnit := bill_of_lading.KGM_WeightUnit
+	return bill_of_lading.ShippingInstruction{
+		TransportDocumentTypeCode: bill_of_lading.BOL_TransportDocumentTypeCode,
+		IsElectronic:              true,
+		CarrierBookingReference:   "booking1",
+		ConsignmentItems: []bill_of_lading.ConsignmentItem{{
+			CarrierBookingReference: "booking2",
+			DescriptionOfGoods:      "Shoes",
+			HSCode:                  "640299",
+			CargoItems: []bill_of_lading.CargoItem{{
+				EquipmentReference: "APZU4812090",
+				Weight:             &weight,
+				WeightUnit:         weightUnit,
+				NumberOfPackages:   200,
+				PackageCode:        "5H",
+			}},
+		}},
+		UtilizedTransportEquipments: []bill_of_lading.UtilizedTransportEquipment{{
+			Equipment:            &bill_of_lading.Equipment{EquipmentReference: "APZU4812090"},
+			CargoGrossWeight:     &weight,
+			CargoGrossWeightUnit: &weightUnit,
+		}},
+	}
+}
+
+func TestGetBillOfLadingShipmentEvent(t *testing.T) {
+	ts := time.Now().Unix()
+	service := trade_document.NewBillOfLadingService()
+
+	issued, err := service.Issue(ts, trade_document.IssueBillOfLadingRequest{
+		Issuer:     carrier,
+		TransferTo: shipper,
+		BillOfLading: &bill_of_lading.TransportDocument{
+			TransportDocumentReference: "bl-number",
+			ShippingInstruction: &bill_of_lading.ShippingInstruction{
+				ShippingInstructionReference: "si1",
+				CarrierBookingReference:      "booking1",
+			},
+		},
+	})
+	require.NoError(t, err)
+	event, err := trade_document.GetBillOfLadingShipmentEvent(trade_document.BillOfLadingPackRecord{Pack: issued, CreatedAt: ts})
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, bill_of_lading.SHIPMENT_EventType, event.Metadata.EventType)
+	assert.Equal(t, bill_of_lading.ISSU_EblShipmentEventTypeCode, event.Payload.ShipmentEventTypeCode)
+	assert.Equal(t, bill_of_lading.TRD_EblDocumentTypeCode, event.Payload.DocumentTypeCode)
+	assert.Equal(t, "bl-number", event.Payload.DocumentReference)
+	assert.Equal(
+		t,
+		[]bill_of_lading.RelatedDocumentReference{{Type: "SHI", Value: "si1"}, {Type: "BKG", Value: "booking1"}},
+		event.Payload.RelatedDocumentReferences,
+	)
+
+	// The ID of the event is stable.
+	again, err := trade_document.GetBillOfLadingShipmentEvent(trade_document.BillOfLadingPackRecord{Pack: issued, CreatedAt: ts + 1})
+	require.NoError(t, err)
+	assert.Equal(t, event.Metadata.EventID, again.Metadata.EventID)
+
+	transferred, err := service.Transfer(ts, issued, trade_document.TransferBillOfLadingRequest{Actor: shipper, TransferTo: bank})
+	require.NoError(t, err)
+	event, err = trade_document.GetBillOfLadingShipmentEvent(trade_document.BillOfLadingPackRecord{Pack: transferred, CreatedAt: ts})
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	printed, err := service.PrintToPaper(ts, transferred, trade_document.PrintToPaperBillOfLadingRequest{Actor: bank})
+	require.NoError(t, err)
+	event, err = trade_document.GetBillOfLadingShipmentEvent(trade_document.BillOfLadingPackRecord{Pack: printed, CreatedAt: ts})
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, bill_of_lading.VOID_EblShipmentEventTypeCode, event.Payload.ShipmentEventTypeCode)
+	status, err := trade_document.GetTransportDocumentStatus(printed)
+	require.NoError(t, err)
+	assert.Equal(t, bill_of_lading.VOID_EblDocumentStatus, status)
+}
@@ -0,0 +1,114 @@
+package trade_document
+
+import (
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+)
+
+func ValidateCreateShippingInstructionRequest(req CreateShippingInstructionRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateUpdateShippingInstructionRequest(req UpdateShippingInstructionRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+		validation.Field(&req.Reference, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateGetDCSADocumentRequest(req GetDCSADocumentRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+		validation.Field(&req.Reference, validation.Required),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateListShippingInstructionSummariesRequest(req ListShippingInstructionSummariesRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Limit, validation.Required),
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+		validation.Field(&req.DocumentStatus, validation.In(
+			bill_of_lading.RECE_EblDocumentStatus,
+			bill_of_lading.PENU_EblDocumentStatus,
+			bill_of_lading.DRFT_EblDocumentStatus,
+			bill_of_lading.PENA_EblDocumentStatus,
+			bill_of_lading.APPR_EblDocumentStatus,
+			bill_of_lading.ISSU_EblDocumentStatus,
+			bill_of_lading.SURR_EblDocumentStatus,
+			bill_of_lading.VOID_EblDocumentStatus,
+		)),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateListTransportDocumentSummariesRequest(req ListTransportDocumentSummariesRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Limit, validation.Required),
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+		validation.Field(&req.DocumentStatus, validation.In(
+			bill_of_lading.RECE_EblDocumentStatus,
+			bill_of_lading.PENU_EblDocumentStatus,
+			bill_of_lading.DRFT_EblDocumentStatus,
+			bill_of_lading.PENA_EblDocumentStatus,
+			bill_of_lading.APPR_EblDocumentStatus,
+			bill_of_lading.ISSU_EblDocumentStatus,
+			bill_of_lading.SURR_EblDocumentStatus,
+			bill_of_lading.VOID_EblDocumentStatus,
+		)),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+func ValidateListDCSAEventsRequest(req ListDCSAEventsRequest) error {
+	if err := validation.ValidateStruct(&req,
+		validation.Field(&req.Limit, validation.Required),
+		validation.Field(&req.ApplicationID, validation.Required),
+		validation.Field(&req.BusinessUnit, validation.Required),
+		validation.Field(&req.DocumentTypeCode, validation.In(
+			bill_of_lading.SHI_EblDocumentTypeCode,
+			bill_of_lading.TRD_EblDocumentTypeCode,
+		)),
+		validation.Field(&req.ShipmentEventTypeCode, validation.In(
+			bill_of_lading.RECE_EblShipmentEventTypeCode,
+			bill_of_lading.PENU_EblShipmentEventTypeCode,
+			bill_of_lading.DRFT_EblShipmentEventTypeCode,
+			bill_of_lading.PENA_EblShipmentEventTypeCode,
+			bill_of_lading.APPR_EblShipmentEventTypeCode,
+			bill_of_lading.ISSU_EblShipmentEventTypeCode,
+			bill_of_lading.SURR_EblShipmentEventTypeCode,
+			bill_of_lading.VOID_EblShipmentEventTypeCode,
+		)),
+	); err != nil {
+		return fmt.Errorf("%s%w", err.Error(), model.ErrInvalidParameter)
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package dcsa_validator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+)
+
+// containerNumberPattern matches ISO 6346 container numbers: owner code, equipment category, serial number and check digit.
+var containerNumberPattern = regexp.MustCompile(`^[A-Z]{3}[UJZ]\d{7}$`)
+
+var errInvalidCheckDigit = validation.NewError("validation_check_digit_invalid", "must have a valid ISO 6346 check digit")
+
+// conversionTolerance is how far a total may be off after its quantities are converted between units, because
+// converted quantities are usually rounded to whole kilograms, pounds, cubic metres or cubic feet by the shipper.
+var conversionTolerance = model.NewDecimalFromInt(1)
+
+// CheckCargo returns ValidationErrors if cargo items, consignment items and utilized transport equipments of the
+// shipping instruction don't agree with each other:
+//   - Every cargo item references a declared equipment.
+//   - Weights, volumes and package counts of cargo items add up to those of their equipment and consignment item.
+//
+// Quantities in different units are converted before they are compared. Totals that are not declared are not checked.
+func CheckCargo(si *bill_of_lading.ShippingInstruction) error {
+	if si == nil {
+		return nil
+	}
+	if errs := checkCargo(si, ""); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func checkCargo(si *bill_of_lading.ShippingInstruction, pointer string) ValidationErrors {
+	var errs ValidationErrors
+
+	equipments := make(map[string]int, len(si.UtilizedTransportEquipments))
+	for i, equipment := range si.UtilizedTransportEquipments {
+		if equipment.Equipment == nil || equipment.Equipment.EquipmentReference == "" {
+			continue
+		}
+		reference := equipment.Equipment.EquipmentReference
+		if _, ok := equipments[reference]; ok {
+			errs = append(errs, FieldError{
+				Pointer: fmt.Sprintf("%s/utilizedTransportEquipments/%d/equipment/equipmentReference", pointer, i),
+				Value:   reference,
+				Code:    "validation_duplicated",
+				Message: "must be unique",
+			})
+			continue
+		}
+		equipments[reference] = i
+	}
+
+	cargoItems := make(map[string][]bill_of_lading.CargoItem, len(equipments))
+	for i, consignmentItem := range si.ConsignmentItems {
+		for j, cargoItem := range consignmentItem.CargoItems {
+			// Missing equipment references and utilized transport equipments are reported by the rules of the fields.
+			if _, ok := equipments[cargoItem.EquipmentReference]; !ok && cargoItem.EquipmentReference != "" && len(si.UtilizedTransportEquipments) > 0 {
+				errs = append(errs, FieldError{
+					Pointer: fmt.Sprintf("%s/consignmentItems/%d/cargoItems/%d/equipmentReference", pointer, i, j),
+					Value:   cargoItem.EquipmentReference,
+					Code:    "validation_unknown_equipment",
+					Message: "must reference a utilized transport equipment",
+				})
+				continue
+			}
+			cargoItems[cargoItem.EquipmentReference] = append(cargoItems[cargoItem.EquipmentReference], cargoItem)
+		}
+
+		itemPointer := fmt.Sprintf("%s/consignmentItems/%d", pointer, i)
+		errs = appendTotalError(errs, itemPointer+"/weight", "weight", consignmentItem.Weight, string(consignmentItem.WeightUnit), consignmentItem.CargoItems, cargoItemWeight)
+		errs = appendTotalError(errs, itemPointer+"/volume", "volume", consignmentItem.Volume, string(consignmentItem.VolumeUnit), consignmentItem.CargoItems, cargoItemVolume)
+	}
+
+	for i, equipment := range si.UtilizedTransportEquipments {
+		if equipment.Equipment == nil || equipments[equipment.Equipment.EquipmentReference] != i {
+			continue
+		}
+		items := cargoItems[equipment.Equipment.EquipmentReference]
+		equipmentPointer := fmt.Sprintf("%s/utilizedTransportEquipments/%d", pointer, i)
+
+		if equipment.CargoGrossWeightUnit != nil {
+			errs = appendTotalError(errs, equipmentPointer+"/cargoGrossWeight", "weight", equipment.CargoGrossWeight, string(*equipment.CargoGrossWeightUnit), items, cargoItemWeight)
+		}
+		if equipment.CargoGrossVolumeUnit != nil {
+			errs = appendTotalError(errs, equipmentPointer+"/cargoGrossVolume", "volume", equipment.CargoGrossVolume, string(*equipment.CargoGrossVolumeUnit), items, cargoItemVolume)
+		}
+		if equipment.NumberOfPackages > 0 {
+			numberOfPackages := int32(0)
+			for _, item := range items {
+				numberOfPackages += item.NumberOfPackages
+			}
+			if numberOfPackages != equipment.NumberOfPackages {
+				errs = append(errs, FieldError{
+					Pointer: equipmentPointer + "/numberOfPackages",
+					Value:   strconv.Itoa(int(equipment.NumberOfPackages)),
+					Code:    "validation_total_mismatch",
+					Message: fmt.Sprintf("must equal the number of packages of its cargo items (%d)", numberOfPackages),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Pointer < errs[j].Pointer })
+	return errs
+}
+
+func cargoItemWeight(item bill_of_lading.CargoItem) (*model.Decimal, string) {
+	return item.Weight, string(item.WeightUnit)
+}
+
+func cargoItemVolume(item bill_of_lading.CargoItem) (*model.Decimal, string) {
+	return item.Volume, string(item.VolumeUnit)
+}
+
+// appendTotalError appends an error if the declared total doesn't equal the sum of the quantities of the cargo items.
+// Nothing is checked if the total or any of the quantities is missing or in an unknown unit.
+func appendTotalError(
+	errs ValidationErrors,
+	pointer string,
+	quantity string,
+	total *model.Decimal,
+	unit string,
+	items []bill_of_lading.CargoItem,
+	itemQuantity func(bill_of_lading.CargoItem) (*model.Decimal, string),
+) ValidationErrors {
+	if total == nil || unit == "" || len(items) == 0 {
+		return errs
+	}
+
+	sum := model.NewDecimalFromInt(0)
+	converted := false
+	for _, item := range items {
+		value, itemUnit := itemQuantity(item)
+		if value == nil || itemUnit == "" {
+			return errs
+		}
+		v, err := value.ConvertUnit(itemUnit, unit)
+		if err != nil {
+			return errs
+		}
+		sum = sum.Add(v)
+		converted = converted || itemUnit != unit
+	}
+
+	diff := sum.Sub(*total).Abs()
+	if diff.IsZero() || (converted && diff.Cmp(conversionTolerance) < 0) {
+		return errs
+	}
+	return append(errs, FieldError{
+		Pointer: pointer,
+		Value:   total.String(),
+		Code:    "validation_total_mismatch",
+		Message: fmt.Sprintf("must equal the %s of its cargo items (%s %s)", quantity, sum, unit),
+	})
+}
+
+// containerNumberRule checks the check digit of ISO 6346 container numbers. Other equipment references are skipped.
+func containerNumberRule(v any) error {
+	reference, _ := v.(string)
+	if !containerNumberPattern.MatchString(reference) {
+		return nil
+	}
+	if containerCheckDigit(reference[:10]) != int(reference[10]-'0') {
+		return errInvalidCheckDigit
+	}
+	return nil
+}
+
+// containerCheckDigit calculates the ISO 6346 check digit of the owner code, equipment category and serial number.
+func containerCheckDigit(s string) int {
+	sum := 0
+	for i, c := range s {
+		value := int(c - '0')
+		if c >= 'A' && c <= 'Z' {
+			// Letters are numbered from 10 skipping multiples of 11: A=10, B=12, ..., K=21, L=23, ..., U=32, V=34, ...
+			value = int(c-'A') + 10
+			value += (value - 1) / 10
+		}
+		sum += value << i
+	}
+	return sum % 11 % 10
+}
@@ -0,0 +1,129 @@
+package dcsa_validator_test
+
+import (
+	"testing"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/dcsa_validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decimal(t *testing.T, s string) *model.Decimal {
+	d, err := model.NewDecimalFromString(s)
+	require.NoError(t, err)
+	return &d
+}
+
+func TestCheckCargo(t *testing.T) {
+	si := loadTransportDocument(t).ShippingInstruction
+	assert.NoError(t, dcsa_validator.CheckCargo(si))
+
+	// Split the cargo into two items in different units, and a second container.
+	lbr, ftq, mtq := bill_of_lading.LBR_WeightUnit, bill_of_lading.FTQ_VolumeUnit, bill_of_lading.MTQ_VolumeUnit
+	item := &si.ConsignmentItems[0]
+	item.CargoItems[0].Weight = decimal(t, "3000")
+	item.CargoItems[0].Volume = decimal(t, "20")
+	item.CargoItems[0].VolumeUnit = mtq
+	item.CargoItems = append(item.CargoItems, bill_of_lading.CargoItem{
+		EquipmentReference: "APZU4812090",
+		Weight:             decimal(t, "2204.62"),
+		WeightUnit:         lbr,
+		Volume:             decimal(t, "353.15"),
+		VolumeUnit:         ftq,
+		NumberOfPackages:   50,
+		PackageCode:        "5H",
+	}, bill_of_lading.CargoItem{
+		EquipmentReference: "CSQU3054383",
+		Weight:             decimal(t, "1000"),
+		WeightUnit:         bill_of_lading.KGM_WeightUnit,
+		NumberOfPackages:   10,
+		PackageCode:        "5H",
+	})
+	item.Weight = decimal(t, "5000")
+	item.WeightUnit = bill_of_lading.KGM_WeightUnit
+	si.UtilizedTransportEquipments[0].CargoGrossVolume = decimal(t, "30")
+	si.UtilizedTransportEquipments[0].CargoGrossVolumeUnit = &mtq
+	si.UtilizedTransportEquipments[0].NumberOfPackages = 250
+	si.UtilizedTransportEquipments = append(si.UtilizedTransportEquipments, bill_of_lading.UtilizedTransportEquipment{
+		Equipment:            &bill_of_lading.Equipment{EquipmentReference: "CSQU3054383"},
+		CargoGrossWeight:     decimal(t, "2204.62"),
+		CargoGrossWeightUnit: &lbr,
+	})
+	assert.NoError(t, dcsa_validator.CheckCargo(si))
+	assert.NoError(t, dcsa_validator.ValidateShippingInstruction(si))
+
+	item.Weight = decimal(t, "5500")
+	item.CargoItems[2].EquipmentReference = "MSKU1234565"
+	si.UtilizedTransportEquipments[0].CargoGrossWeight = decimal(t, "4100")
+	si.UtilizedTransportEquipments[0].CargoGrossVolume = decimal(t, "32")
+	si.UtilizedTransportEquipments[0].NumberOfPackages = 200
+	err := dcsa_validator.CheckCargo(si)
+	assert.ErrorIs(t, err, model.ErrInvalidParameter)
+	assert.Equal(
+		t,
+		[]string{
+			"/consignmentItems/0/cargoItems/2/equipmentReference",
+			"/consignmentItems/0/weight",
+			"/utilizedTransportEquipments/0/cargoGrossVolume",
+			"/utilizedTransportEquipments/0/cargoGrossWeight",
+			"/utilizedTransportEquipments/0/numberOfPackages",
+		},
+		pointers(t, err),
+	)
+
+	var errs dcsa_validator.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, dcsa_validator.FieldError{
+		Pointer: "/utilizedTransportEquipments/0/numberOfPackages",
+		Value:   "200",
+		Code:    "validation_total_mismatch",
+		Message: "must equal the number of packages of its cargo items (250)",
+	}, errs[4])
+}
+
+func TestValidateTransportDocumentCargo(t *testing.T) {
+	td := loadTransportDocument(t)
+	td.ShippingInstruction.UtilizedTransportEquipments[0].CargoGrossWeight = decimal(t, "4001")
+	td.ShippingInstruction.UtilizedTransportEquipments = append(
+		td.ShippingInstruction.UtilizedTransportEquipments,
+		td.ShippingInstruction.UtilizedTransportEquipments[0],
+	)
+
+	err := dcsa_validator.ValidateTransportDocument(td)
+	assert.Equal(
+		t,
+		[]string{
+			"/shippingInstruction/utilizedTransportEquipments/0/cargoGrossWeight",
+			"/shippingInstruction/utilizedTransportEquipments/1/equipment/equipmentReference",
+		},
+		pointers(t, err),
+	)
+}
+
+func TestValidateContainerNumber(t *testing.T) {
+	si := loadTransportDocument(t).ShippingInstruction
+	for _, reference := range []string{"CSQU3054383", "MSKU1234565", "TGHU9876542"} {
+		si.UtilizedTransportEquipments[0].Equipment.EquipmentReference = reference
+		si.ConsignmentItems[0].CargoItems[0].EquipmentReference = reference
+		assert.NoError(t, dcsa_validator.ValidateShippingInstruction(si), reference)
+	}
+
+	// Equipment references other than container numbers have no check digit.
+	si.UtilizedTransportEquipments[0].Equipment.EquipmentReference = "TRAILER-1"
+	si.ConsignmentItems[0].CargoItems[0].EquipmentReference = "TRAILER-1"
+	assert.NoError(t, dcsa_validator.ValidateShippingInstruction(si))
+
+	si.UtilizedTransportEquipments[0].Equipment.EquipmentReference = "CSQU3054384"
+	si.ConsignmentItems[0].CargoItems[0].EquipmentReference = "CSQU3054384"
+	err := dcsa_validator.ValidateShippingInstruction(si)
+	var errs dcsa_validator.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, dcsa_validator.ValidationErrors{{
+		Pointer: "/utilizedTransportEquipments/0/equipment/equipmentReference",
+		Value:   "CSQU3054384",
+		Code:    "validation_check_digit_invalid",
+		Message: "must have a valid ISO 6346 check digit",
+	}}, errs)
+}
@@ -0,0 +1,137 @@
+package dcsa_validator
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+)
+
+// FieldError is a violation of DCSA rules by a field of the document.
+type FieldError struct {
+	Pointer string `json:"pointer"`         // JSON pointer (RFC 6901) of the field in the document. Example: /issuingParty/partyContactDetails
+	Value   string `json:"value,omitempty"` // The value of the field if it's a scalar.
+	Code    string `json:"code"`            // Code of the violated rule. Example: validation_required
+	Message string `json:"message"`         // Example: cannot be blank
+}
+
+// ValidationErrors are all violations of DCSA rules by a document, in the order of their pointers.
+//
+// It wraps model.ErrInvalidParameter, so it's reported the same way as other invalid parameters.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		msgs = append(msgs, fieldErr.Pointer+": "+fieldErr.Message)
+	}
+	return strings.Join(msgs, "; ") + "."
+}
+
+func (e ValidationErrors) Unwrap() error {
+	return model.ErrInvalidParameter
+}
+
+// DetailedErrors returns the errors in the shape of DCSA error response.
+func (e ValidationErrors) DetailedErrors() []bill_of_lading.DetailedError {
+	result := make([]bill_of_lading.DetailedError, 0, len(e))
+	for _, fieldErr := range e {
+		result = append(result, bill_of_lading.DetailedError{
+			Field:   fieldErr.Pointer,
+			Value:   fieldErr.Value,
+			Reason:  fieldErr.Code,
+			Message: fieldErr.Message,
+		})
+	}
+	return result
+}
+
+// newValidationErrors flattens nested errors of ozzo-validation into field errors of the document.
+// Values of the fields are looked up from JSON of the document.
+func newValidationErrors(doc any, err error) error {
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	var raw any
+	if b, err := json.Marshal(doc); err == nil {
+		_ = json.Unmarshal(b, &raw)
+	}
+
+	var result ValidationErrors
+	flatten(&result, raw, "", errs)
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Pointer < result[j].Pointer })
+	return result
+}
+
+func flatten(result *ValidationErrors, raw any, pointer string, err error) {
+	if errs, ok := err.(validation.Errors); ok {
+		for key, fieldErr := range errs {
+			if fieldErr == nil {
+				continue
+			}
+			flatten(result, child(raw, key), pointer+"/"+escapePointerToken(key), fieldErr)
+		}
+		return
+	}
+
+	fieldErr := FieldError{
+		Pointer: pointer,
+		Code:    "validation_invalid",
+		Message: err.Error(),
+	}
+	if vErr, ok := err.(validation.Error); ok {
+		fieldErr.Code = vErr.Code()
+	}
+	switch v := raw.(type) {
+	case string:
+		fieldErr.Value = v
+	case float64:
+		fieldErr.Value = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		fieldErr.Value = strconv.FormatBool(v)
+	}
+	*result = append(*result, fieldErr)
+}
+
+// child returns the member of the JSON object or the element of the JSON array by the key.
+func child(raw any, key string) any {
+	switch v := raw.(type) {
+	case map[string]any:
+		return v[key]
+	case []any:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(v) {
+			return nil
+		}
+		return v[i]
+	}
+	return nil
+}
+
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// mergeValidationErrors merges errs into the result of newValidationErrors.
+func mergeValidationErrors(err error, errs ValidationErrors) error {
+	if len(errs) == 0 {
+		return err
+	}
+	if err == nil {
+		return errs
+	}
+	result, ok := err.(ValidationErrors)
+	if !ok {
+		return err
+	}
+	result = append(result, errs...)
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Pointer < result[j].Pointer })
+	return result
+}
@@ -0,0 +1,233 @@
+package dcsa_validator
+
+import (
+	"embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+)
+
+const (
+	countriesFile      = "countries.csv"       // code,name. ISO 3166-1 alpha-2 country codes.
+	currenciesFile     = "currencies.csv"      // code,minor_unit,name. ISO 4217 currency codes.
+	unLocationsFile    = "un_locode.csv"       // code,name. UN/LOCODE location codes.
+	carrierCodesFile   = "carrier_codes.csv"   // provider,code,name. SMDG and NMFTA (SCAC) carrier codes.
+	partyFunctionsFile = "party_functions.csv" // code,description. DCSA party function codes.
+	versionFile        = "VERSION"
+)
+
+// The bundled UN/LOCODE and carrier code lists only cover major ports and carriers. Unknown codes are accepted as
+// long as their format is valid until complete lists are provided by LoadReferenceData.
+//
+//go:embed reference_data/*.csv reference_data/VERSION
+var bundledReferenceData embed.FS
+
+// ReferenceData is a versioned set of code lists that DCSA documents are validated against.
+type ReferenceData struct {
+	Version string
+
+	countries      codeList
+	currencies     codeList
+	unLocations    codeList
+	carrierCodes   map[bill_of_lading.CarrierCodeListProvider]codeList
+	partyFunctions codeList
+}
+
+type codeList struct {
+	codes   map[string]string // code -> name
+	partial bool              // A partial list accepts unknown codes.
+}
+
+func (l codeList) contains(code string) bool {
+	_, ok := l.codes[code]
+	return ok || l.partial
+}
+
+var defaultReferenceData atomic.Pointer[ReferenceData]
+
+func init() {
+	data, err := LoadReferenceData("")
+	if err != nil {
+		panic(fmt.Sprintf("dcsa_validator: invalid bundled reference data: %v", err))
+	}
+	defaultReferenceData.Store(data)
+}
+
+// DefaultReferenceData returns the reference data used to validate documents.
+func DefaultReferenceData() *ReferenceData {
+	return defaultReferenceData.Load()
+}
+
+// SetReferenceData replaces the reference data used to validate documents.
+func SetReferenceData(data *ReferenceData) {
+	if data != nil {
+		defaultReferenceData.Store(data)
+	}
+}
+
+// LoadReferenceData loads the bundled reference data and overrides it with files of the same names in dir.
+// Lists loaded from dir are considered complete. Empty dir loads the bundled reference data only, see PartialLists.
+func LoadReferenceData(dir string) (*ReferenceData, error) {
+	bundled, err := fs.Sub(bundledReferenceData, "reference_data")
+	if err != nil {
+		return nil, err
+	}
+	var override fs.FS
+	if dir != "" {
+		override = os.DirFS(dir)
+	}
+
+	open := func(name string) (io.ReadCloser, bool, error) {
+		if override != nil {
+			f, err := override.Open(name)
+			if err == nil {
+				return f, true, nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, false, err
+			}
+		}
+		f, err := bundled.Open(name)
+		return f, false, err
+	}
+
+	data := &ReferenceData{carrierCodes: make(map[bill_of_lading.CarrierCodeListProvider]codeList)}
+
+	f, _, err := open(versionFile)
+	if err != nil {
+		return nil, err
+	}
+	version, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	data.Version = strings.TrimSpace(string(version))
+
+	lists := []struct {
+		name       string
+		list       *codeList
+		partial    bool // Whether the bundled list is partial.
+		codeColumn int
+		nameColumn int
+	}{
+		{countriesFile, &data.countries, false, 0, 1},
+		{currenciesFile, &data.currencies, false, 0, 2},
+		{unLocationsFile, &data.unLocations, true, 0, 1},
+		{partyFunctionsFile, &data.partyFunctions, false, 0, 1},
+	}
+	for _, l := range lists {
+		f, overridden, err := open(l.name)
+		if err != nil {
+			return nil, err
+		}
+		records, err := readCSV(f, l.nameColumn+1)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", l.name, err)
+		}
+		l.list.codes = make(map[string]string, len(records))
+		l.list.partial = l.partial && !overridden
+		for _, record := range records {
+			l.list.codes[record[l.codeColumn]] = record[l.nameColumn]
+		}
+	}
+
+	f, overridden, err := open(carrierCodesFile)
+	if err != nil {
+		return nil, err
+	}
+	records, err := readCSV(f, 3)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", carrierCodesFile, err)
+	}
+	for _, provider := range []bill_of_lading.CarrierCodeListProvider{bill_of_lading.SMDG_CarrierCodeListProvider, bill_of_lading.NMFTA_CarrierCodeListProvider} {
+		data.carrierCodes[provider] = codeList{codes: make(map[string]string), partial: !overridden}
+	}
+	for _, record := range records {
+		list, ok := data.carrierCodes[bill_of_lading.CarrierCodeListProvider(record[0])]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown carrier code list provider %q", carrierCodesFile, record[0])
+		}
+		list.codes[record[1]] = record[2]
+	}
+
+	return data, nil
+}
+
+// readCSV reads records of a CSV file with a header row and at least minColumns columns.
+func readCSV(r io.Reader, minColumns int) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("missing header")
+	}
+	records = records[1:]
+	for i, record := range records {
+		if len(record) < minColumns {
+			return nil, fmt.Errorf("line %d: expected %d columns, got %d", i+2, minColumns, len(record))
+		}
+		for j := range record {
+			record[j] = strings.TrimSpace(record[j])
+		}
+	}
+	return records, nil
+}
+
+// PartialLists returns the names of the files of bundled lists known to miss codes which are not overridden.
+// Codes missing from them are only checked for their format, so they should be overridden by complete lists in
+// production.
+func (d *ReferenceData) PartialLists() []string {
+	var names []string
+	if d.unLocations.partial {
+		names = append(names, unLocationsFile)
+	}
+	if d.carrierCodes[bill_of_lading.SMDG_CarrierCodeListProvider].partial {
+		names = append(names, carrierCodesFile)
+	}
+	return names
+}
+
+// IsCountry reports whether code is an ISO 3166-1 alpha-2 country code.
+func (d *ReferenceData) IsCountry(code string) bool {
+	return d.countries.contains(code)
+}
+
+// IsCurrency reports whether code is an ISO 4217 currency code.
+func (d *ReferenceData) IsCurrency(code string) bool {
+	return d.currencies.contains(code)
+}
+
+// IsUNLocationCode reports whether code is a UN/LOCODE. The first two letters of the code are the country.
+func (d *ReferenceData) IsUNLocationCode(code string) bool {
+	if !unLocationCodePattern.MatchString(code) || !d.countries.contains(code[:2]) {
+		return false
+	}
+	return d.unLocations.contains(code)
+}
+
+// IsCarrierCode reports whether code is a carrier code of the code list provider.
+func (d *ReferenceData) IsCarrierCode(provider bill_of_lading.CarrierCodeListProvider, code string) bool {
+	pattern, ok := carrierCodePatterns[provider]
+	if !ok || !pattern.MatchString(code) {
+		return false
+	}
+	return d.carrierCodes[provider].contains(code)
+}
+
+// IsPartyFunction reports whether code is a DCSA party function code.
+func (d *ReferenceData) IsPartyFunction(code string) bool {
+	return d.partyFunctions.contains(code)
+}
@@ -0,0 +1,130 @@
+package dcsa_validator_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/dcsa_validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundledReferenceData(t *testing.T) {
+	data, err := dcsa_validator.LoadReferenceData("")
+	require.NoError(t, err)
+	assert.NotEmpty(t, data.Version)
+
+	assert.True(t, data.IsCountry("TW"))
+	assert.False(t, data.IsCountry("XX"))
+	assert.True(t, data.IsCurrency("EUR"))
+	assert.False(t, data.IsCurrency("EUX"))
+	assert.True(t, data.IsPartyFunction("OS"))
+	assert.False(t, data.IsPartyFunction("XX"))
+
+	// The bundled UN/LOCODE and carrier code lists are partial, so unknown codes only need a valid format.
+	assert.Equal(t, []string{"un_locode.csv", "carrier_codes.csv"}, data.PartialLists())
+	assert.True(t, data.IsUNLocationCode("DEHAM"))
+	assert.True(t, data.IsUNLocationCode("DEXYZ"))
+	assert.False(t, data.IsUNLocationCode("XXHAM"))
+	assert.False(t, data.IsUNLocationCode("hamburg"))
+	assert.True(t, data.IsCarrierCode(bill_of_lading.SMDG_CarrierCodeListProvider, "HLC"))
+	assert.True(t, data.IsCarrierCode(bill_of_lading.SMDG_CarrierCodeListProvider, "XYZ"))
+	assert.False(t, data.IsCarrierCode(bill_of_lading.SMDG_CarrierCodeListProvider, "HLCU"))
+	assert.True(t, data.IsCarrierCode(bill_of_lading.NMFTA_CarrierCodeListProvider, "HLCU"))
+	assert.False(t, data.IsCarrierCode("XXX", "HLCU"))
+}
+
+func TestLoadReferenceData(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "VERSION"), []byte("2024.05-local\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "un_locode.csv"), []byte("code,name\nDEHAM,Hamburg\nTWKHH,Kaohsiung\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "carrier_codes.csv"), []byte("provider,code,name\nSMDG,HLC,Hapag-Lloyd\n"), 0644))
+
+	data, err := dcsa_validator.LoadReferenceData(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "2024.05-local", data.Version)
+
+	// Lists dropped in the directory are complete.
+	assert.Empty(t, data.PartialLists())
+	assert.True(t, data.IsUNLocationCode("TWKHH"))
+	assert.False(t, data.IsUNLocationCode("DEXYZ"))
+	assert.False(t, data.IsUNLocationCode("USNYC"))
+	assert.True(t, data.IsCarrierCode(bill_of_lading.SMDG_CarrierCodeListProvider, "HLC"))
+	assert.False(t, data.IsCarrierCode(bill_of_lading.SMDG_CarrierCodeListProvider, "XYZ"))
+	assert.False(t, data.IsCarrierCode(bill_of_lading.NMFTA_CarrierCodeListProvider, "HLCU"))
+
+	// Other lists stay bundled.
+	assert.True(t, data.IsCurrency("USD"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "carrier_codes.csv"), []byte("provider,code,name\nIATA,CI,China Airlines\n"), 0644))
+	_, err = dcsa_validator.LoadReferenceData(dir)
+	assert.ErrorContains(t, err, "carrier_codes.csv")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "currencies.csv"), []byte("code,minor_unit,name\nUSD,2\n"), 0644))
+	_, err = dcsa_validator.LoadReferenceData(dir)
+	assert.ErrorContains(t, err, "currencies.csv")
+}
+
+func TestValidateTransportDocumentReferenceData(t *testing.T) {
+	td := loadTransportDocument(t)
+	td.CarrierCode = "HLCU"
+	td.DeclaredValueCurrency = "EUX"
+	td.Transports[0].DischargeLocation.UNLocationCode = "XXNYC"
+	td.Charges[0].CurrencyCode = "USX"
+	td.IssuingParty.IdentifyingCodes = []bill_of_lading.IdentifyingCode{{DCSAResponsibleAgencyCode: "XXX"}}
+	partyFunction := bill_of_lading.PartyFunction("XX")
+	td.ShippingInstruction.DocumentParties[0].PartyFunction = &partyFunction
+	td.Transports[0].LoadLocation.FacilityCode = "CTA"
+
+	err := dcsa_validator.ValidateTransportDocument(td)
+	assert.Equal(
+		t,
+		[]string{
+			"/carrierCode",
+			"/charges/0/currencyCode",
+			"/declaredValueCurrency",
+			"/issuingParty/identifyingCodes/0/DCSAResponsibleAgencyCode",
+			"/issuingParty/identifyingCodes/0/partyCode",
+			"/shippingInstruction/documentParties/0/partyFunction",
+			"/transports/0/dischargeLocation/UNLocationCode",
+			"/transports/0/loadLocation/facilityCodeListProvider",
+		},
+		pointers(t, err),
+	)
+
+	var errs dcsa_validator.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, dcsa_validator.FieldError{
+		Pointer: "/carrierCode",
+		Value:   "HLCU",
+		Code:    "validation_unknown_carrier_code",
+		Message: "must be a known carrier code of the carrier code list provider",
+	}, errs[0])
+
+	// Well-formed codes missing from the bundled lists are accepted, but not malformed ones.
+	td = loadTransportDocument(t)
+	td.Transports[0].DischargeLocation.UNLocationCode = "USXYZ"
+	assert.NoError(t, dcsa_validator.ValidateTransportDocument(td))
+	td.Transports[0].DischargeLocation.UNLocationCode = "US-XY"
+	err = dcsa_validator.ValidateTransportDocument(td)
+	assert.Equal(t, []string{"/transports/0/dischargeLocation/UNLocationCode"}, pointers(t, err))
+
+	// Switching the reference data changes the result of validation.
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "un_locode.csv"), []byte("code,name\nDEHAM,Hamburg\n"), 0644))
+	data, err := dcsa_validator.LoadReferenceData(dir)
+	require.NoError(t, err)
+	dcsa_validator.SetReferenceData(data)
+	defer dcsa_validator.SetReferenceData(mustLoadReferenceData(t))
+
+	err = dcsa_validator.ValidateTransportDocument(loadTransportDocument(t))
+	assert.Equal(t, []string{"/transports/0/dischargeLocation/UNLocationCode"}, pointers(t, err))
+}
+
+func mustLoadReferenceData(t *testing.T) *dcsa_validator.ReferenceData {
+	data, err := dcsa_validator.LoadReferenceData("")
+	require.NoError(t, err)
+	return data
+}
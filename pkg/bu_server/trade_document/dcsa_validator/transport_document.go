@@ -0,0 +1,237 @@
+// Package dcsa_validator validates documents against the business rules of DCSA eBL v2.
+//
+// The generated models of bill_of_lading only describe the shape of the documents. The rules here cover what the
+// DCSA specification and the Interface Standard for the Bill of Lading 2.0 require on top of it: mandatory fields,
+// patterns and enumerations.
+package dcsa_validator
+
+import (
+	"reflect"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+)
+
+var (
+	universalServiceReferencePattern = regexp.MustCompile(`^SR\d{5}[A-Z]$`)
+	universalVoyageReferencePattern  = regexp.MustCompile(`^\d{2}[0-9A-Z]{2}[NEWS]$`)
+	vesselIMONumberPattern           = regexp.MustCompile(`^\d{7}$`)
+	unLocationCodePattern            = regexp.MustCompile(`^[A-Z]{2}[A-Z2-9]{3}$`)
+
+	carrierCodePatterns = map[bill_of_lading.CarrierCodeListProvider]*regexp.Regexp{
+		bill_of_lading.SMDG_CarrierCodeListProvider:  regexp.MustCompile(`^[0-9A-Z]{3}$`),
+		bill_of_lading.NMFTA_CarrierCodeListProvider: regexp.MustCompile(`^[A-Z]{2,4}$`),
+	}
+)
+
+var (
+	errUnknownUNLocationCode = validation.NewError("validation_unknown_un_location_code", "must be a known UN/LOCODE")
+	errUnknownCurrency       = validation.NewError("validation_unknown_currency", "must be a known ISO 4217 currency code")
+	errUnknownCarrierCode    = validation.NewError("validation_unknown_carrier_code", "must be a known carrier code of the carrier code list provider")
+	errUnknownPartyFunction  = validation.NewError("validation_unknown_party_function", "must be a known DCSA party function code")
+)
+
+// ValidateTransportDocument returns ValidationErrors if the transport document violates DCSA rules.
+func ValidateTransportDocument(td *bill_of_lading.TransportDocument) error {
+	if td == nil {
+		return ValidationErrors{{Pointer: "", Code: "validation_required", Message: "cannot be blank"}}
+	}
+	var cargoErrs ValidationErrors
+	if td.ShippingInstruction != nil {
+		cargoErrs = checkCargo(td.ShippingInstruction, "/shippingInstruction")
+	}
+	return mergeValidationErrors(newValidationErrors(td, transportDocumentRule(td)), cargoErrs)
+}
+
+// ValidateShippingInstruction returns ValidationErrors if the shipping instruction violates DCSA rules.
+func ValidateShippingInstruction(si *bill_of_lading.ShippingInstruction) error {
+	if si == nil {
+		return ValidationErrors{{Pointer: "", Code: "validation_required", Message: "cannot be blank"}}
+	}
+	return mergeValidationErrors(newValidationErrors(si, shippingInstructionRule(si)), checkCargo(si, ""))
+}
+
+func transportDocumentRule(td *bill_of_lading.TransportDocument) error {
+	return validation.ValidateStruct(td,
+		validation.Field(&td.TransportDocumentReference, validation.Required, validation.Length(1, 20)),
+		validation.Field(&td.CarrierCode, validation.Required, validation.Length(1, 4), validation.When(
+			carrierCodePatterns[td.CarrierCodeListProvider] != nil,
+			knownCode(errUnknownCarrierCode, func(data *ReferenceData, code string) bool {
+				return data.IsCarrierCode(td.CarrierCodeListProvider, code)
+			}),
+		)),
+		validation.Field(&td.CarrierCodeListProvider, validation.Required, validation.In(
+			bill_of_lading.SMDG_CarrierCodeListProvider,
+			bill_of_lading.NMFTA_CarrierCodeListProvider,
+		)),
+		validation.Field(&td.IssuingParty, validation.Required, validation.By(partyRule)),
+		validation.Field(&td.UniversalServiceReference, validation.Match(universalServiceReferencePattern)),
+		validation.Field(&td.UniversalExportVoyageReference, validation.Match(universalVoyageReferencePattern)),
+		validation.Field(&td.DeclaredValueCurrency, knownCode(errUnknownCurrency, (*ReferenceData).IsCurrency)),
+		validation.Field(&td.Transports, validation.Each(validation.By(transportRule))),
+		validation.Field(&td.InvoicePayableAt, validation.By(locationRule)),
+		validation.Field(&td.PlaceOfIssue, validation.By(locationRule)),
+		validation.Field(&td.ShippingInstruction, validation.Required, validation.By(func(v any) error {
+			return shippingInstructionRule(v.(*bill_of_lading.ShippingInstruction))
+		})),
+		validation.Field(&td.Charges, validation.Each(validation.By(chargeRule))),
+	)
+}
+
+func shippingInstructionRule(si *bill_of_lading.ShippingInstruction) error {
+	return validation.ValidateStruct(si,
+		validation.Field(&si.ShippingInstructionReference, validation.Required, validation.Length(1, 100)),
+		validation.Field(&si.TransportDocumentTypeCode, validation.Required, validation.In(
+			bill_of_lading.BOL_TransportDocumentTypeCode,
+			bill_of_lading.SWB_TransportDocumentTypeCode,
+		)),
+		validation.Field(&si.PlaceOfIssue, validation.By(locationRule)),
+		validation.Field(&si.ConsignmentItems, validation.Required, validation.Each(validation.By(consignmentItemRule))),
+		validation.Field(&si.UtilizedTransportEquipments, validation.Required, validation.Each(validation.By(utilizedTransportEquipmentRule))),
+		validation.Field(&si.DocumentParties, validation.Each(validation.By(documentPartyRule))),
+	)
+}
+
+func partyRule(v any) error {
+	party, _ := v.(*bill_of_lading.Party)
+	if party == nil {
+		return nil
+	}
+	return validation.ValidateStruct(party,
+		validation.Field(&party.PartyName, validation.Length(0, 100)),
+		// At least one contact detail is required.
+		validation.Field(&party.PartyContactDetails, validation.Required, validation.Each(validation.By(partyContactDetailRule))),
+		validation.Field(&party.IdentifyingCodes, validation.Each(validation.By(identifyingCodeRule))),
+	)
+}
+
+func identifyingCodeRule(v any) error {
+	code := v.(bill_of_lading.IdentifyingCode)
+	return validation.ValidateStruct(&code,
+		validation.Field(&code.DCSAResponsibleAgencyCode, validation.Required, validation.In(
+			bill_of_lading.ISO_DcsaResponsibleAgencyCode,
+			bill_of_lading.UNECE_DcsaResponsibleAgencyCode,
+			bill_of_lading.LLOYD_DcsaResponsibleAgencyCode,
+			bill_of_lading.BIC_DcsaResponsibleAgencyCode,
+			bill_of_lading.IMO_DcsaResponsibleAgencyCode,
+			bill_of_lading.SCAC_DcsaResponsibleAgencyCode,
+			bill_of_lading.ITIGG_DcsaResponsibleAgencyCode,
+			bill_of_lading.ITU_DcsaResponsibleAgencyCode,
+			bill_of_lading.SMDG_DcsaResponsibleAgencyCode,
+			bill_of_lading.EXIS_DcsaResponsibleAgencyCode,
+			bill_of_lading.FMC_DcsaResponsibleAgencyCode,
+			bill_of_lading.CBSA_DcsaResponsibleAgencyCode,
+			bill_of_lading.DID_DcsaResponsibleAgencyCode,
+			bill_of_lading.LEI_DcsaResponsibleAgencyCode,
+			bill_of_lading.EPI_DcsaResponsibleAgencyCode,
+			bill_of_lading.ZZZ_DcsaResponsibleAgencyCode,
+		)),
+		validation.Field(&code.PartyCode, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+func partyContactDetailRule(v any) error {
+	contact := v.(bill_of_lading.PartyContactDetail)
+	return validation.ValidateStruct(&contact,
+		validation.Field(&contact.Name, validation.Required, validation.Length(1, 100)),
+		// Either phone or email is required to reach the contact.
+		validation.Field(&contact.Phone, validation.When(contact.Email == "", validation.Required.Error("phone or email is required"))),
+		validation.Field(&contact.Email, validation.When(contact.Phone == "", validation.Required.Error("phone or email is required"))),
+	)
+}
+
+func documentPartyRule(v any) error {
+	documentParty := v.(bill_of_lading.DocumentParty)
+	return validation.ValidateStruct(&documentParty,
+		validation.Field(&documentParty.Party, validation.Required, validation.By(partyRule)),
+		validation.Field(&documentParty.PartyFunction, validation.Required, knownCode(errUnknownPartyFunction, (*ReferenceData).IsPartyFunction)),
+	)
+}
+
+func transportRule(v any) error {
+	transport := v.(bill_of_lading.Transport)
+	return validation.ValidateStruct(&transport,
+		validation.Field(&transport.LoadLocation, validation.Required, validation.By(locationRule)),
+		validation.Field(&transport.DischargeLocation, validation.Required, validation.By(locationRule)),
+		validation.Field(&transport.VesselIMONumber, validation.Match(vesselIMONumberPattern)),
+		validation.Field(&transport.UniversalImportVoyageReference, validation.Match(universalVoyageReferencePattern)),
+		validation.Field(&transport.UniversalExportVoyageReference, validation.Match(universalVoyageReferencePattern)),
+	)
+}
+
+func locationRule(v any) error {
+	location, _ := v.(*bill_of_lading.Location)
+	if location == nil {
+		return nil
+	}
+	return validation.ValidateStruct(location,
+		validation.Field(&location.UNLocationCode, knownCode(errUnknownUNLocationCode, (*ReferenceData).IsUNLocationCode)),
+		// The facility code is only meaningful with the provider of its code list.
+		validation.Field(&location.FacilityCodeListProvider,
+			validation.When(location.FacilityCode != "", validation.Required),
+			validation.In(bill_of_lading.SMDG_FacilityCodeListProvider, bill_of_lading.BIC_FacilityCodeListProvider),
+		),
+	)
+}
+
+func consignmentItemRule(v any) error {
+	item := v.(bill_of_lading.ConsignmentItem)
+	return validation.ValidateStruct(&item,
+		validation.Field(&item.DescriptionOfGoods, validation.Required, validation.Length(1, 5000)),
+		validation.Field(&item.HSCode, validation.Required),
+		validation.Field(&item.CargoItems, validation.Required, validation.Each(validation.By(cargoItemRule))),
+	)
+}
+
+func cargoItemRule(v any) error {
+	item := v.(bill_of_lading.CargoItem)
+	return validation.ValidateStruct(&item,
+		validation.Field(&item.EquipmentReference, validation.Required),
+		validation.Field(&item.Weight, validation.Required),
+		validation.Field(&item.WeightUnit, validation.Required, validation.In(bill_of_lading.KGM_WeightUnit, bill_of_lading.LBR_WeightUnit)),
+		validation.Field(&item.VolumeUnit, validation.When(item.Volume != nil, validation.Required), validation.In(bill_of_lading.MTQ_VolumeUnit, bill_of_lading.FTQ_VolumeUnit)),
+		validation.Field(&item.NumberOfPackages, validation.Required, validation.Min(int32(1))),
+		validation.Field(&item.PackageCode, validation.Required, validation.Length(1, 3)),
+	)
+}
+
+func utilizedTransportEquipmentRule(v any) error {
+	equipment := v.(bill_of_lading.UtilizedTransportEquipment)
+	return validation.ValidateStruct(&equipment,
+		validation.Field(&equipment.Equipment, validation.Required, validation.By(func(v any) error {
+			e := v.(*bill_of_lading.Equipment)
+			return validation.ValidateStruct(e, validation.Field(&e.EquipmentReference, validation.Required, validation.By(containerNumberRule)))
+		})),
+		validation.Field(&equipment.CargoGrossWeight, validation.Required),
+		validation.Field(&equipment.CargoGrossWeightUnit, validation.Required, validation.In(bill_of_lading.KGM_WeightUnit, bill_of_lading.LBR_WeightUnit)),
+		validation.Field(&equipment.CargoGrossVolumeUnit, validation.When(equipment.CargoGrossVolume != nil, validation.Required), validation.In(bill_of_lading.MTQ_VolumeUnit, bill_of_lading.FTQ_VolumeUnit)),
+	)
+}
+
+func chargeRule(v any) error {
+	charge := v.(bill_of_lading.Charge)
+	return validation.ValidateStruct(&charge,
+		validation.Field(&charge.ChargeType, validation.Required),
+		validation.Field(&charge.CurrencyAmount, validation.Required),
+		validation.Field(&charge.CurrencyCode, validation.Required, knownCode(errUnknownCurrency, (*ReferenceData).IsCurrency)),
+		validation.Field(&charge.PaymentTermCode, validation.Required, validation.In(
+			bill_of_lading.PRE_PaymentTermCode,
+			bill_of_lading.COL_PaymentTermCode,
+		)),
+	)
+}
+
+// knownCode checks a code against the reference data used to validate documents. Empty codes are skipped.
+func knownCode(err validation.Error, known func(data *ReferenceData, code string) bool) validation.Rule {
+	return validation.By(func(v any) error {
+		v, isNil := validation.Indirect(v)
+		if isNil || validation.IsEmpty(v) {
+			return nil
+		}
+		if !known(DefaultReferenceData(), reflect.ValueOf(v).String()) {
+			return err
+		}
+		return nil
+	})
+}
@@ -0,0 +1,126 @@
+package dcsa_validator_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document/dcsa_validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTransportDocument(t *testing.T) *bill_of_lading.TransportDocument {
+	raw, err := os.ReadFile("testdata/transport_document.json")
+	require.NoError(t, err)
+	var td bill_of_lading.TransportDocument
+	require.NoError(t, json.Unmarshal(raw, &td))
+	return &td
+}
+
+func pointers(t *testing.T, err error) []string {
+	var errs dcsa_validator.ValidationErrors
+	require.True(t, errors.As(err, &errs), "unexpected error: %v", err)
+	result := make([]string, 0, len(errs))
+	for _, fieldErr := range errs {
+		result = append(result, fieldErr.Pointer)
+	}
+	return result
+}
+
+func TestValidateTransportDocument(t *testing.T) {
+	td := loadTransportDocument(t)
+	assert.NoError(t, dcsa_validator.ValidateTransportDocument(td))
+
+	err := dcsa_validator.ValidateTransportDocument(nil)
+	assert.ErrorIs(t, err, model.ErrInvalidParameter)
+
+	td = loadTransportDocument(t)
+	td.TransportDocumentReference = ""
+	td.CarrierCode = ""
+	td.ShippingInstruction = nil
+	err = dcsa_validator.ValidateTransportDocument(td)
+	assert.ErrorIs(t, err, model.ErrInvalidParameter)
+	assert.Equal(t, []string{"/carrierCode", "/shippingInstruction", "/transportDocumentReference"}, pointers(t, err))
+}
+
+func TestValidateTransportDocumentPatterns(t *testing.T) {
+	td := loadTransportDocument(t)
+	td.UniversalServiceReference = "SR1234A"
+	td.UniversalExportVoyageReference = "2103X"
+	td.Transports[0].VesselIMONumber = "93214"
+	td.Transports[0].LoadLocation.UNLocationCode = "hamburg"
+	td.Charges[0].CurrencyCode = "usd"
+
+	err := dcsa_validator.ValidateTransportDocument(td)
+	var errs dcsa_validator.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(
+		t,
+		[]string{
+			"/charges/0/currencyCode",
+			"/transports/0/loadLocation/UNLocationCode",
+			"/transports/0/vesselIMONumber",
+			"/universalExportVoyageReference",
+			"/universalServiceReference",
+		},
+		pointers(t, err),
+	)
+	assert.Equal(t, "SR1234A", errs[4].Value)
+	assert.Equal(t, "validation_match_invalid", errs[4].Code)
+	assert.Equal(t, "must be in a valid format", errs[4].Message)
+}
+
+func TestValidateTransportDocumentParties(t *testing.T) {
+	td := loadTransportDocument(t)
+	td.IssuingParty.PartyContactDetails = nil
+	td.ShippingInstruction.DocumentParties[0].Party.PartyContactDetails[0].Phone = ""
+	td.ShippingInstruction.DocumentParties = append(td.ShippingInstruction.DocumentParties, bill_of_lading.DocumentParty{})
+
+	err := dcsa_validator.ValidateTransportDocument(td)
+	assert.Equal(
+		t,
+		[]string{
+			"/issuingParty/partyContactDetails",
+			"/shippingInstruction/documentParties/0/party/partyContactDetails/0/email",
+			"/shippingInstruction/documentParties/0/party/partyContactDetails/0/phone",
+			"/shippingInstruction/documentParties/1/party",
+			"/shippingInstruction/documentParties/1/partyFunction",
+		},
+		pointers(t, err),
+	)
+}
+
+func TestValidateShippingInstruction(t *testing.T) {
+	si := loadTransportDocument(t).ShippingInstruction
+	assert.NoError(t, dcsa_validator.ValidateShippingInstruction(si))
+
+	si.TransportDocumentTypeCode = "XXX"
+	si.ConsignmentItems[0].CargoItems[0].NumberOfPackages = 0
+	si.UtilizedTransportEquipments = nil
+	err := dcsa_validator.ValidateShippingInstruction(si)
+	assert.ErrorIs(t, err, model.ErrInvalidParameter)
+	assert.Equal(
+		t,
+		[]string{
+			"/consignmentItems/0/cargoItems/0/numberOfPackages",
+			"/transportDocumentTypeCode",
+			"/utilizedTransportEquipments",
+		},
+		pointers(t, err),
+	)
+
+	var errs dcsa_validator.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	detailed := errs.DetailedErrors()
+	require.Len(t, detailed, 3)
+	assert.Equal(t, bill_of_lading.DetailedError{
+		Field:   "/transportDocumentTypeCode",
+		Value:   "XXX",
+		Reason:  "validation_in_invalid",
+		Message: "must be a valid value",
+	}, detailed[1])
+}
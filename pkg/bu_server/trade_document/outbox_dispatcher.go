@@ -0,0 +1,255 @@
+package trade_document
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/storage"
+	"github.com/openebl/openebl/pkg/relay"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultOutboxPollInterval   = time.Second
+	defaultOutboxStatsInterval  = time.Minute
+	defaultOutboxBatchSize      = 100
+	defaultOutboxMinBackoff     = 5 * time.Second
+	defaultOutboxMaxBackoff     = 10 * time.Minute
+	defaultOutboxPublishTimeout = 30 * time.Second
+)
+
+// OutboxDispatcher publishes pending messages of the outbox to the relay server.
+//
+// A message is marked delivered only after the relay server acknowledges it. Failed messages are retried with
+// exponential backoff, so a message may be published more than once. That is fine because the relay server and
+// the receivers deduplicate events by their IDs.
+type OutboxDispatcher struct {
+	relayClient relay.RelayClient
+	storage     DeliveryStorage
+
+	pollInterval   time.Duration // How often the outbox is checked for due messages.
+	statsInterval  time.Duration // How often the depth of the outbox is logged.
+	batchSize      int
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+	publishTimeout time.Duration
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+type OutboxDispatcherOption func(d *OutboxDispatcher)
+
+func WithOutboxPollInterval(interval time.Duration) OutboxDispatcherOption {
+	return func(d *OutboxDispatcher) {
+		d.pollInterval = interval
+	}
+}
+
+func WithOutboxStatsInterval(interval time.Duration) OutboxDispatcherOption {
+	return func(d *OutboxDispatcher) {
+		d.statsInterval = interval
+	}
+}
+
+func WithOutboxBatchSize(size int) OutboxDispatcherOption {
+	return func(d *OutboxDispatcher) {
+		d.batchSize = size
+	}
+}
+
+// WithOutboxBackoff sets how long a failed message waits before it is retried. The wait doubles from min on every
+// failure of the message up to max.
+func WithOutboxBackoff(min, max time.Duration) OutboxDispatcherOption {
+	return func(d *OutboxDispatcher) {
+		d.minBackoff = min
+		d.maxBackoff = max
+	}
+}
+
+func WithOutboxPublishTimeout(timeout time.Duration) OutboxDispatcherOption {
+	return func(d *OutboxDispatcher) {
+		d.publishTimeout = timeout
+	}
+}
+
+func NewOutboxDispatcher(relayClient relay.RelayClient, storage DeliveryStorage, options ...OutboxDispatcherOption) *OutboxDispatcher {
+	dispatcher := &OutboxDispatcher{
+		relayClient:    relayClient,
+		storage:        storage,
+		pollInterval:   defaultOutboxPollInterval,
+		statsInterval:  defaultOutboxStatsInterval,
+		batchSize:      defaultOutboxBatchSize,
+		minBackoff:     defaultOutboxMinBackoff,
+		maxBackoff:     defaultOutboxMaxBackoff,
+		publishTimeout: defaultOutboxPublishTimeout,
+		closeChan:      make(chan struct{}),
+	}
+	for _, option := range options {
+		option(dispatcher)
+	}
+	return dispatcher
+}
+
+// Run dispatches the outbox until Close is called.
+func (d *OutboxDispatcher) Run() error {
+	pollTicker := time.NewTicker(d.pollInterval)
+	defer pollTicker.Stop()
+	statsTicker := time.NewTicker(d.statsInterval)
+	defer statsTicker.Stop()
+
+	for {
+		select {
+		case <-d.closeChan:
+			return nil
+		case <-statsTicker.C:
+			d.logStats(context.Background())
+		case <-pollTicker.C:
+			// Keep dispatching while a full batch is delivered, so a backlog is drained without waiting for the ticker.
+			for {
+				delivered, err := d.Dispatch(context.Background(), time.Now().Unix())
+				if err != nil {
+					logrus.Errorf("OutboxDispatcher: failed to dispatch outbox: %v", err)
+				}
+				if err != nil || delivered < d.batchSize || d.closed() {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (d *OutboxDispatcher) Close() error {
+	d.closeOnce.Do(func() { close(d.closeChan) })
+	return nil
+}
+
+func (d *OutboxDispatcher) closed() bool {
+	select {
+	case <-d.closeChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// Dispatch publishes a batch of messages due at ts and returns how many of them are delivered.
+//
+// The batch is claimed in a short transaction first, so no transaction is held while waiting for the relay server.
+// The result of every message is then recorded in its own transaction. A message claimed by a dispatcher which
+// never records its result is due again once the claim expires.
+func (d *OutboxDispatcher) Dispatch(ctx context.Context, ts int64) (int, error) {
+	msgs, err := d.claim(ctx, ts)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, msg := range msgs {
+		publishCtx, cancel := context.WithTimeout(ctx, d.publishTimeout)
+		publishErr := d.relayClient.Publish(publishCtx, msg.Type, msg.Data, msg.Tags...)
+		cancel()
+
+		msg.UpdatedAt = max(ts, msg.UpdatedAt)
+		if publishErr == nil {
+			msg.Status = model.OutboxMessageStatusDelivered
+			delivered++
+		} else {
+			msg.Attempts++
+			msg.NextAttemptAt = ts + int64(d.backoff(msg.Attempts)/time.Second)
+			msg.LastError = publishErr.Error()
+			logrus.Warnf("OutboxDispatcher: failed to publish message %q (attempt %d): %v", msg.ID, msg.Attempts, publishErr)
+		}
+
+		if err := d.record(ctx, msg); err != nil {
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
+
+// claim returns a batch of messages due at ts and postpones them until every one of them could have been published,
+// so other dispatchers skip them in the meantime.
+func (d *OutboxDispatcher) claim(ctx context.Context, ts int64) ([]model.OutboxMessage, error) {
+	tx, err := d.storage.CreateTx(ctx, storage.TxOptionWithWrite(true), storage.TxOptionWithIsolationLevel(sql.LevelReadCommitted))
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	msgs, err := d.storage.ListPendingOutboxMessages(ctx, tx, ts, d.batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	claimedUntil := ts + int64(time.Duration(len(msgs)+1)*d.publishTimeout/time.Second)
+	for _, msg := range msgs {
+		msg.NextAttemptAt = claimedUntil
+		if err := d.storage.StoreOutboxMessage(ctx, tx, msg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// record stores the result of publishing the message.
+func (d *OutboxDispatcher) record(ctx context.Context, msg model.OutboxMessage) error {
+	tx, err := d.storage.CreateTx(ctx, storage.TxOptionWithWrite(true), storage.TxOptionWithIsolationLevel(sql.LevelReadCommitted))
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := d.storage.StoreOutboxMessage(ctx, tx, msg); err != nil {
+		return err
+	}
+	if msg.Type == BillOfLadingPackEventType {
+		// Only packs have deliveries. Contents of files are delivered along with them.
+		if err := d.storage.StoreDelivery(ctx, tx, outboxMessageDelivery(msg)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (d *OutboxDispatcher) backoff(attempts int) time.Duration {
+	backoff := d.minBackoff
+	for i := 1; i < attempts && backoff < d.maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, d.maxBackoff)
+}
+
+// Stats returns the depth of the outbox and how many messages in it are failing.
+func (d *OutboxDispatcher) Stats(ctx context.Context) (model.OutboxStats, error) {
+	tx, err := d.storage.CreateTx(ctx)
+	if err != nil {
+		return model.OutboxStats{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	return d.storage.GetOutboxStats(ctx, tx)
+}
+
+func (d *OutboxDispatcher) logStats(ctx context.Context) {
+	stats, err := d.Stats(ctx)
+	if err != nil {
+		logrus.Errorf("OutboxDispatcher: failed to get outbox stats: %v", err)
+		return
+	}
+
+	if stats.Failing > 0 {
+		logrus.Warnf("OutboxDispatcher: %d messages pending, %d of them failing, the oldest since %d.", stats.Pending, stats.Failing, stats.OldestPendingAt)
+		return
+	}
+	logrus.Infof("OutboxDispatcher: %d messages pending.", stats.Pending)
+}
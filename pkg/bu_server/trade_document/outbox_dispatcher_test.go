@@ -0,0 +1,247 @@
+package trade_document_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openebl/openebl/pkg/bu_server/model"
+	"github.com/openebl/openebl/pkg/bu_server/trade_document"
+	mock_storage "github.com/openebl/openebl/test/mock/bu_server/storage"
+	mock_trade_document "github.com/openebl/openebl/test/mock/bu_server/trade_document"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeRelayClient records published events instead of sending them to a relay server.
+type fakeRelayClient struct {
+	err       error
+	events    []fakeRelayEvent
+	onPublish func()
+}
+
+type fakeRelayEvent struct {
+	evtType int
+	data    []byte
+	tags    []string
+}
+
+func (c *fakeRelayClient) Publish(ctx context.Context, evtType int, data []byte, tags ...string) error {
+	if c.onPublish != nil {
+		c.onPublish()
+	}
+	if c.err != nil {
+		return c.err
+	}
+	c.events = append(c.events, fakeRelayEvent{evtType: evtType, data: data, tags: tags})
+	return nil
+}
+
+func (c *fakeRelayClient) Subscribe(ctx context.Context, offset int64, recipients ...string) error {
+	return nil
+}
+
+func (c *fakeRelayClient) Close() error {
+	return nil
+}
+
+type OutboxDispatcherTestSuite struct {
+	suite.Suite
+	ctx             context.Context
+	ctrl            *gomock.Controller
+	deliveryStorage *mock_trade_document.MockDeliveryStorage
+	tx              *mock_storage.MockTx
+	relayClient     *fakeRelayClient
+	dispatcher      *trade_document.OutboxDispatcher
+
+	ts  int64
+	msg model.OutboxMessage
+}
+
+func TestOutboxDispatcher(t *testing.T) {
+	suite.Run(t, new(OutboxDispatcherTestSuite))
+}
+
+func (s *OutboxDispatcherTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.ctrl = gomock.NewController(s.T())
+	s.deliveryStorage = mock_trade_document.NewMockDeliveryStorage(s.ctrl)
+	s.tx = mock_storage.NewMockTx(s.ctrl)
+	s.relayClient = &fakeRelayClient{}
+	s.dispatcher = trade_document.NewOutboxDispatcher(
+		s.relayClient,
+		s.deliveryStorage,
+		trade_document.WithOutboxBatchSize(10),
+		trade_document.WithOutboxBackoff(5*time.Second, 20*time.Second),
+	)
+
+	s.ts = time.Now().Unix()
+	s.msg = model.OutboxMessage{
+		ID:            "event1",
+		Type:          trade_document.BillOfLadingPackEventType,
+		Data:          []byte("encrypted pack"),
+		Tags:          []string{trade_document.RecipientTag(bank), trade_document.RecipientTag(carrier), trade_document.RecipientTag(shipper)},
+		Recipients:    []string{bank, carrier, shipper},
+		PackID:        "pack1",
+		Version:       2,
+		Status:        model.OutboxMessageStatusPending,
+		NextAttemptAt: s.ts,
+		CreatedAt:     s.ts,
+		UpdatedAt:     s.ts,
+	}
+}
+
+func (s *OutboxDispatcherTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// expectClaim returns the calls claiming the message in a transaction of its own. The claimed message is stored in
+// claimed.
+func (s *OutboxDispatcherTestSuite) expectClaim(ts int64, msg model.OutboxMessage, claimed *model.OutboxMessage) []*gomock.Call {
+	return []*gomock.Call{
+		s.deliveryStorage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.deliveryStorage.EXPECT().ListPendingOutboxMessages(gomock.Any(), s.tx, ts, 10).Return([]model.OutboxMessage{msg}, nil),
+		s.deliveryStorage.EXPECT().StoreOutboxMessage(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, m model.OutboxMessage) error {
+				*claimed = m
+				return nil
+			},
+		),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	}
+}
+
+// expectDispatch expects a batch of the message to be dispatched and returns the stored message and delivery.
+func (s *OutboxDispatcherTestSuite) expectDispatch(ts int64, msg model.OutboxMessage) (*model.OutboxMessage, *model.BillOfLadingDelivery) {
+	claimedMsg := &model.OutboxMessage{}
+	storedMsg := &model.OutboxMessage{}
+	storedDelivery := &model.BillOfLadingDelivery{}
+	calls := append(s.expectClaim(ts, msg, claimedMsg),
+		s.deliveryStorage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.deliveryStorage.EXPECT().StoreOutboxMessage(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, m model.OutboxMessage) error {
+				*storedMsg = m
+				return nil
+			},
+		),
+		s.deliveryStorage.EXPECT().StoreDelivery(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, delivery model.BillOfLadingDelivery) error {
+				*storedDelivery = delivery
+				return nil
+			},
+		),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	gomock.InOrder(calls...)
+
+	// Nothing is recorded before the message is published. The claim lasts until the message could have been published.
+	s.relayClient.onPublish = func() {
+		s.Assert().Equal(ts+60, claimedMsg.NextAttemptAt)
+		s.Assert().Empty(storedMsg.ID)
+	}
+	return storedMsg, storedDelivery
+}
+
+func (s *OutboxDispatcherTestSuite) TestDispatch() {
+	storedMsg, storedDelivery := s.expectDispatch(s.ts, s.msg)
+
+	delivered, err := s.dispatcher.Dispatch(s.ctx, s.ts)
+	s.Require().NoError(err)
+	s.Assert().Equal(1, delivered)
+
+	s.Require().Len(s.relayClient.events, 1)
+	s.Assert().Equal(trade_document.BillOfLadingPackEventType, s.relayClient.events[0].evtType)
+	s.Assert().Equal(s.msg.Data, s.relayClient.events[0].data)
+	s.Assert().Equal(s.msg.Tags, s.relayClient.events[0].tags)
+
+	s.Assert().Equal(model.OutboxMessageStatusDelivered, storedMsg.Status)
+	s.Assert().Equal(model.BillOfLadingDelivery{
+		PackID:     "pack1",
+		Version:    2,
+		EventID:    "event1",
+		Recipients: []string{bank, carrier, shipper},
+		Status:     model.DeliveryStatusDelivered,
+		CreatedAt:  s.ts,
+		UpdatedAt:  s.ts,
+	}, *storedDelivery)
+}
+
+func (s *OutboxDispatcherTestSuite) TestDispatchFile() {
+	msg := s.msg
+	msg.ID = "event2"
+	msg.Type = trade_document.BillOfLadingFileEventType
+	msg.Data = []byte("encrypted file")
+
+	// Files have no deliveries of their own.
+	var claimedMsg, storedMsg model.OutboxMessage
+	calls := append(s.expectClaim(s.ts, msg, &claimedMsg),
+		s.deliveryStorage.EXPECT().CreateTx(gomock.Any(), gomock.Len(2)).Return(s.tx, nil),
+		s.deliveryStorage.EXPECT().StoreOutboxMessage(gomock.Any(), s.tx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tx any, m model.OutboxMessage) error {
+				storedMsg = m
+				return nil
+			},
+		),
+		s.tx.EXPECT().Commit(gomock.Any()).Return(nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+	gomock.InOrder(calls...)
+
+	delivered, err := s.dispatcher.Dispatch(s.ctx, s.ts)
+	s.Require().NoError(err)
+	s.Assert().Equal(1, delivered)
+	s.Require().Len(s.relayClient.events, 1)
+	s.Assert().Equal(trade_document.BillOfLadingFileEventType, s.relayClient.events[0].evtType)
+	s.Assert().Equal(model.OutboxMessageStatusDelivered, storedMsg.Status)
+}
+
+func (s *OutboxDispatcherTestSuite) TestDispatchWithBackoff() {
+	s.relayClient.err = errors.New("relay is down")
+
+	// The wait doubles on every failure up to the max backoff.
+	msg := s.msg
+	ts := s.ts
+	for _, backoff := range []int64{5, 10, 20, 20} {
+		storedMsg, storedDelivery := s.expectDispatch(ts, msg)
+
+		delivered, err := s.dispatcher.Dispatch(s.ctx, ts)
+		s.Require().NoError(err)
+		s.Assert().Equal(0, delivered)
+
+		s.Assert().Equal(model.OutboxMessageStatusPending, storedMsg.Status)
+		s.Assert().Equal(msg.Attempts+1, storedMsg.Attempts)
+		s.Assert().Equal(ts+backoff, storedMsg.NextAttemptAt)
+		s.Assert().Equal("relay is down", storedMsg.LastError)
+		s.Assert().Equal(model.DeliveryStatusFailed, storedDelivery.Status)
+		s.Assert().Equal("relay is down", storedDelivery.Error)
+
+		msg = *storedMsg
+		ts = msg.NextAttemptAt
+	}
+
+	// The message is delivered once the relay is back.
+	s.relayClient.err = nil
+	storedMsg, storedDelivery := s.expectDispatch(ts, msg)
+	delivered, err := s.dispatcher.Dispatch(s.ctx, ts)
+	s.Require().NoError(err)
+	s.Assert().Equal(1, delivered)
+	s.Assert().Equal(model.OutboxMessageStatusDelivered, storedMsg.Status)
+	s.Assert().Equal(model.DeliveryStatusDelivered, storedDelivery.Status)
+	s.Assert().Empty(storedDelivery.Error)
+}
+
+func (s *OutboxDispatcherTestSuite) TestStats() {
+	expected := model.OutboxStats{Pending: 3, Failing: 1, OldestPendingAt: s.ts}
+	gomock.InOrder(
+		s.deliveryStorage.EXPECT().CreateTx(gomock.Any()).Return(s.tx, nil),
+		s.deliveryStorage.EXPECT().GetOutboxStats(gomock.Any(), s.tx).Return(expected, nil),
+		s.tx.EXPECT().Rollback(gomock.Any()).Return(nil),
+	)
+
+	stats, err := s.dispatcher.Stats(s.ctx)
+	s.Require().NoError(err)
+	s.Assert().Equal(expected, stats)
+}
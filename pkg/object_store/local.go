@@ -0,0 +1,88 @@
+package object_store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type LocalConfig struct {
+	Directory string `yaml:"directory"`
+}
+
+// LocalObjectStore keeps objects as files under a directory.
+type LocalObjectStore struct {
+	dir string
+}
+
+func NewLocalObjectStore(config LocalConfig) (*LocalObjectStore, error) {
+	if config.Directory == "" {
+		return nil, errors.New("directory of the local object store is not specified")
+	}
+	if err := os.MkdirAll(config.Directory, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalObjectStore{dir: config.Directory}, nil
+}
+
+func (s *LocalObjectStore) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Write into a temporary file and rename it, so readers never see a partial object.
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (s *LocalObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return data, err
+}
+
+func (s *LocalObjectStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalObjectStore) path(key string) (string, error) {
+	localPath := filepath.FromSlash(key)
+	if !filepath.IsLocal(localPath) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.dir, localPath), nil
+}
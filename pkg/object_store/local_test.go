@@ -0,0 +1,48 @@
+package object_store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openebl/openebl/pkg/object_store"
+	"github.com/stretchr/testify/suite"
+)
+
+type LocalObjectStoreTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	storage object_store.ObjectStore
+}
+
+func TestLocalObjectStore(t *testing.T) {
+	suite.Run(t, new(LocalObjectStoreTestSuite))
+}
+
+func (s *LocalObjectStoreTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	storage, err := object_store.NewObjectStore(object_store.Config{
+		Type:  object_store.ObjectStoreTypeLocal,
+		Local: object_store.LocalConfig{Directory: s.T().TempDir()},
+	})
+	s.Require().NoError(err)
+	s.storage = storage
+}
+
+func (s *LocalObjectStoreTestSuite) TestPutGetDelete() {
+	s.Require().NoError(s.storage.Put(s.ctx, "segments/1.bin.gz", []byte("hello")))
+	s.Require().NoError(s.storage.Put(s.ctx, "segments/1.bin.gz", []byte("hello again")))
+
+	data, err := s.storage.Get(s.ctx, "segments/1.bin.gz")
+	s.Require().NoError(err)
+	s.Assert().Equal([]byte("hello again"), data)
+
+	s.Require().NoError(s.storage.Delete(s.ctx, "segments/1.bin.gz"))
+	s.Require().NoError(s.storage.Delete(s.ctx, "segments/1.bin.gz"))
+	_, err = s.storage.Get(s.ctx, "segments/1.bin.gz")
+	s.Assert().ErrorIs(err, object_store.ErrObjectNotFound)
+}
+
+func (s *LocalObjectStoreTestSuite) TestInvalidKey() {
+	s.Assert().Error(s.storage.Put(s.ctx, "../escape", []byte("hello")))
+	s.Assert().Error(s.storage.Put(s.ctx, "/absolute", []byte("hello")))
+}
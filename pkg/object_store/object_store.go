@@ -0,0 +1,46 @@
+package object_store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectStore is a flat key-value store of immutable objects.
+// Keys are slash separated paths like "segments/0001.bin.gz".
+type ObjectStore interface {
+	// Put stores data under the key. The old object with the same key is overwritten.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Get returns the data of the key. It returns ErrObjectNotFound if the key doesn't exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete removes the object of the key. Deleting a nonexistent key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+type ObjectStoreType string
+
+const (
+	ObjectStoreTypeLocal ObjectStoreType = "local"
+	ObjectStoreTypeS3    ObjectStoreType = "s3"
+)
+
+type Config struct {
+	Type  ObjectStoreType `yaml:"type"`
+	Local LocalConfig     `yaml:"local"`
+	S3    S3Config        `yaml:"s3"`
+}
+
+// NewObjectStore creates the object store described by the config.
+func NewObjectStore(config Config) (ObjectStore, error) {
+	switch config.Type {
+	case ObjectStoreTypeLocal:
+		return NewLocalObjectStore(config.Local)
+	case ObjectStoreTypeS3:
+		return NewS3ObjectStore(config.S3)
+	}
+	return nil, fmt.Errorf("unsupported object store type %q", config.Type)
+}
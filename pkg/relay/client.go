@@ -2,8 +2,10 @@ package relay
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/url"
 	"sync"
@@ -26,6 +28,10 @@ type NostrClient struct {
 	io.Closer
 
 	serverURL string
+	tlsConfig *tls.Config
+	signer    *EventSigner
+
+	retryInterval time.Duration // How long to wait before reconnecting to the server.
 
 	mux         sync.Mutex
 	closeChan   chan any
@@ -38,9 +44,10 @@ type NostrClient struct {
 
 func NewNostrClient(opts ...NostrClientOption) *NostrClient {
 	client := &NostrClient{
-		closeChan:   make(chan any),
-		outputChan:  make(chan NostrClientOutputMsg, 16),
-		responseMap: make(map[string]chan any),
+		retryInterval: 5 * time.Second,
+		closeChan:     make(chan any),
+		outputChan:    make(chan NostrClientOutputMsg, 16),
+		responseMap:   make(map[string]chan any),
 	}
 
 	for _, opt := range opts {
@@ -90,7 +97,7 @@ func (c *NostrClient) outputWorker() {
 		inputWorkerCancel = nil
 		wg.Wait()
 		c.emptyOutputQueue()
-		ShallowSleep(context.Background(), 5*time.Second, c.closeChan)
+		ShallowSleep(context.Background(), c.retryInterval, c.closeChan)
 	}
 
 	for {
@@ -100,9 +107,9 @@ func (c *NostrClient) outputWorker() {
 		default:
 		}
 		if conn == nil {
-			inputWorkerCtx, inputWorkerCancel = context.WithCancelCause(context.Background())
 			conn, err = c.prepareConnection(context.Background())
 			if conn != nil {
+				inputWorkerCtx, inputWorkerCancel = context.WithCancelCause(context.Background())
 				wg.Add(1)
 				go func() {
 					defer wg.Done()
@@ -112,7 +119,7 @@ func (c *NostrClient) outputWorker() {
 		}
 		if err != nil {
 			logrus.Errorf("NostrClient: failed to prepare connection to %q: %v", c.serverURL, err)
-			ShallowSleep(context.Background(), 5*time.Second, c.closeChan)
+			ShallowSleep(context.Background(), c.retryInterval, c.closeChan)
 			err = nil
 			continue
 		}
@@ -171,6 +178,11 @@ func (c *NostrClient) inputWorker(cancel context.CancelCauseFunc, conn *websocke
 }
 
 func (c *NostrClient) receiveEvent(resp *SubscribeResponse) {
+	if resp.Error != "" {
+		c.replyWaitingResponse(resp.SubscribeID, errors.New(resp.Error))
+		return
+	}
+
 	event := resp.Event
 	if event != nil {
 		_, err := c.eventSink(
@@ -202,16 +214,26 @@ func (c *NostrClient) receiveNotice(resp *RelayServerNotice) {
 	logrus.Errorf("NostrClient: received notice from %q: %v", c.serverURL, resp.Message)
 }
 
-func (c *NostrClient) Publish(ctx context.Context, evtType int, data []byte) error {
+func (c *NostrClient) Publish(ctx context.Context, evtType int, data []byte, tags ...string) error {
 	requestID := uuid.NewString()
+	publishRequest := &EventPublishRequest{
+		RequestID: requestID,
+		Type:      evtType,
+		Data:      data,
+		Tags:      tags,
+		CreatedAt: time.Now().Unix(),
+	}
+	if c.signer != nil {
+		signature, err := c.signer.Sign(evtType, publishRequest.CreatedAt, data, tags)
+		if err != nil {
+			return fmt.Errorf("failed to sign event: %w", err)
+		}
+		publishRequest.Signature = signature
+	}
 	msg := NostrClientOutputMsg{
 		requestID: requestID,
 		request: Request{
-			Publish: &EventPublishRequest{
-				RequestID: requestID,
-				Type:      evtType,
-				Data:      data,
-			},
+			Publish: publishRequest,
 		},
 		result: make(chan any, 1),
 	}
@@ -241,14 +263,22 @@ func (c *NostrClient) Publish(ctx context.Context, evtType int, data []byte) err
 	return nil
 }
 
-func (c *NostrClient) Subscribe(ctx context.Context, offset int64) error {
+func (c *NostrClient) Subscribe(ctx context.Context, offset int64, recipients ...string) error {
+	return c.SubscribeEventType(ctx, 0, offset, recipients...)
+}
+
+// SubscribeEventType subscribes events of the type only. It returns an error if the server doesn't allow the client to
+// subscribe the type.
+func (c *NostrClient) SubscribeEventType(ctx context.Context, evtType int, offset int64, recipients ...string) error {
 	subscriptionID := uuid.NewString()
 	msg := NostrClientOutputMsg{
 		requestID: subscriptionID,
 		request: Request{
 			Subscribe: &SubscribeRequest{
 				SubscribeID: subscriptionID,
+				Type:        evtType,
 				Offset:      offset,
+				Recipients:  recipients,
 			},
 		},
 		result: make(chan any, 1),
@@ -296,7 +326,9 @@ func (c *NostrClient) prepareConnection(ctx context.Context) (*websocket.Conn, e
 		return nil, err
 	}
 
-	conn, _, err := websocket.DefaultDialer.DialContext(ctx, serverURL.String(), nil)
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = c.tlsConfig
+	conn, _, err := dialer.DialContext(ctx, serverURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
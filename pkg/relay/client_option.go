@@ -1,5 +1,10 @@
 package relay
 
+import (
+	"crypto/tls"
+	"time"
+)
+
 func NostrClientWithServerURL(serverUrl string) NostrClientOption {
 	return func(c *NostrClient) {
 		c.serverURL = serverUrl
@@ -17,3 +22,24 @@ func NostrClientWithConnectionStatusCallback(callback ClientConnectionStatusCall
 		c.connectionStatusCallback = callback
 	}
 }
+
+// NostrClientWithSigner signs the created-at time of published events with the signer.
+func NostrClientWithSigner(signer EventSigner) NostrClientOption {
+	return func(c *NostrClient) {
+		c.signer = &signer
+	}
+}
+
+// NostrClientWithTLSConfig uses the config to connect wss:// servers, like presenting a client certificate.
+func NostrClientWithTLSConfig(config *tls.Config) NostrClientOption {
+	return func(c *NostrClient) {
+		c.tlsConfig = config
+	}
+}
+
+// NostrClientWithRetryInterval sets how long the client waits before reconnecting to the server. The default is 5 seconds.
+func NostrClientWithRetryInterval(interval time.Duration) NostrClientOption {
+	return func(c *NostrClient) {
+		c.retryInterval = interval
+	}
+}
@@ -2,11 +2,14 @@ package relay_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/openebl/openebl/pkg/relay"
+	"github.com/stretchr/testify/require"
 )
 
 func clientEventSink(ctx context.Context, event relay.Event) (string, error) {
@@ -42,3 +45,59 @@ func TestNostrRelayClient(t *testing.T) {
 		client.Publish(context.Background(), 1001, []byte(msg))
 	}
 }
+
+func TestNostrRelayClientSubscribeForbiddenEventType(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	registry, err := relay.NewEventTypeRegistry(
+		relay.EventTypePolicy{Type: 1001, Publishers: []string{relay.AnyIdentity}, Subscribers: []string{relay.AnyIdentity}},
+		relay.EventTypePolicy{Type: 1002, Publishers: []string{"alice"}, Subscribers: []string{"alice"}},
+	)
+	require.NoError(t, err)
+
+	eventSource := &CountingEventSource{}
+	srv := relay.NewNostrServer(
+		relay.NostrServerAddress(address),
+		relay.NostrServerWithEventSource(eventSource.Pull),
+		relay.NostrServerWithEventSink(eventSource.Sink),
+		relay.NostrServerWithIdentity("test-server"),
+		relay.NostrServerWithEventTypes(registry),
+	)
+	go func() {
+		srv.ListenAndServe()
+	}()
+	defer srv.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	connected := make(chan struct{}, 1)
+	client := relay.NewNostrClient(
+		relay.NostrClientWithServerURL("ws://"+address),
+		relay.NostrClientWithEventSink(eventSource.Sink),
+		relay.NostrClientWithConnectionStatusCallback(
+			func(ctx context.Context, cancel context.CancelCauseFunc, client relay.RelayClient, serverIdentity string, status bool) {
+				if status {
+					connected <- struct{}{}
+				}
+			},
+		),
+	)
+	defer client.Close()
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client is not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.SubscribeEventType(ctx, 1001, 0))
+
+	err = client.SubscribeEventType(ctx, 1002, 0)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, context.DeadlineExceeded))
+	require.Contains(t, err.Error(), "not allowed to subscribe event type 1002")
+}
@@ -0,0 +1,86 @@
+package relay
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/openebl/openebl/pkg/pkix"
+)
+
+// EventSignaturePayload is the payload of the JWS signed by the publisher of an event.
+// It binds the time the publisher created the event to the event.
+type EventSignaturePayload struct {
+	Type       int      `json:"type"`
+	CreatedAt  int64    `json:"created_at"`
+	DataSHA256 string   `json:"data_sha256"` // Hex encoded.
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// EventSigner signs events published by NostrClient.
+type EventSigner struct {
+	Algorithm envelope.SignatureAlgorithm
+	Key       any                 // Private key.
+	CertChain []*x509.Certificate // The first one is the certificate of Key.
+}
+
+func newEventSignaturePayload(evtType int, createdAt int64, data []byte, tags []string) EventSignaturePayload {
+	dataHash := sha256.Sum256(data)
+	return EventSignaturePayload{
+		Type:       evtType,
+		CreatedAt:  createdAt,
+		DataSHA256: hex.EncodeToString(dataHash[:]),
+		Tags:       tags,
+	}
+}
+
+// Sign returns the signature of the event in JWS JSON serialization.
+func (s EventSigner) Sign(evtType int, createdAt int64, data []byte, tags []string) (*envelope.JWS, error) {
+	payload, _ := json.Marshal(newEventSignaturePayload(evtType, createdAt, data, tags))
+	signature, err := envelope.Sign(payload, s.Algorithm, s.Key, s.CertChain)
+	if err != nil {
+		return nil, err
+	}
+	return &signature, nil
+}
+
+// VerifyEventSignature checks the signature is made by the key of the first certificate in its x5c header over
+// the given fields of the event, and the certificate chain in x5c chains to one of rootCerts (or the system trusted
+// certificates).
+func VerifyEventSignature(signature *envelope.JWS, rootCerts []*x509.Certificate, evtType int, createdAt int64, data []byte, tags []string) error {
+	if signature == nil {
+		return errors.New("missing signature")
+	}
+	if err := signature.VerifySignature(); err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	certChain, err := signature.GetCertificateChain()
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if err := pkix.Verify(certChain, rootCerts); err != nil {
+		return fmt.Errorf("untrusted signature certificate: %w", err)
+	}
+
+	rawPayload, err := signature.GetPayload()
+	if err != nil {
+		return fmt.Errorf("invalid signature payload: %w", err)
+	}
+	payload := EventSignaturePayload{}
+	if err := json.Unmarshal(rawPayload, &payload); err != nil {
+		return fmt.Errorf("invalid signature payload: %w", err)
+	}
+	expected := newEventSignaturePayload(evtType, createdAt, data, tags)
+	if payload.Type != expected.Type ||
+		payload.CreatedAt != expected.CreatedAt ||
+		payload.DataSHA256 != expected.DataSHA256 ||
+		!slices.Equal(payload.Tags, expected.Tags) {
+		return errors.New("signature doesn't match the event")
+	}
+	return nil
+}
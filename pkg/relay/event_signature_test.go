@@ -0,0 +1,93 @@
+package relay_test
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/openebl/openebl/pkg/relay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEventSigner returns a signer with a certificate issued by the returned root certificate.
+func newTestEventSigner(t *testing.T) (relay.EventSigner, *x509.Certificate) {
+	rootCert, rootKey := newTestCertificate(t, "root", nil, nil)
+	cert, key := newTestCertificate(t, "publisher", rootCert, rootKey)
+	return relay.EventSigner{
+		Algorithm: envelope.SignatureAlgorithm("ES256"),
+		Key:       key,
+		CertChain: []*x509.Certificate{cert},
+	}, rootCert
+}
+
+func TestEventSignature(t *testing.T) {
+	signer, rootCert := newTestEventSigner(t)
+	rootCerts := []*x509.Certificate{rootCert}
+	data := []byte("hello")
+	tags := []string{"alice", "bob"}
+
+	signature, err := signer.Sign(1001, 1700000000, data, tags)
+	require.NoError(t, err)
+	assert.NoError(t, relay.VerifyEventSignature(signature, rootCerts, 1001, 1700000000, data, tags))
+
+	assert.Error(t, relay.VerifyEventSignature(nil, rootCerts, 1001, 1700000000, data, tags))
+	assert.Error(t, relay.VerifyEventSignature(signature, rootCerts, 1002, 1700000000, data, tags))
+	assert.Error(t, relay.VerifyEventSignature(signature, rootCerts, 1001, 1700000001, data, tags))
+	assert.Error(t, relay.VerifyEventSignature(signature, rootCerts, 1001, 1700000000, []byte("hell0"), tags))
+	assert.Error(t, relay.VerifyEventSignature(signature, rootCerts, 1001, 1700000000, data, []string{"alice"}))
+
+	tampered := *signature
+	tampered.Signature = signature.Signature[:len(signature.Signature)-4] + "AAAA"
+	assert.Error(t, relay.VerifyEventSignature(&tampered, rootCerts, 1001, 1700000000, data, tags))
+
+	// The certificate of the signer has to be issued by a trusted root.
+	assert.ErrorContains(t, relay.VerifyEventSignature(signature, nil, 1001, 1700000000, data, tags), "untrusted")
+}
+
+func TestEventSignatureSelfSigned(t *testing.T) {
+	_, rootCert := newTestEventSigner(t)
+	cert, key := newTestCertificate(t, "publisher", nil, nil)
+	signer := relay.EventSigner{
+		Algorithm: envelope.SignatureAlgorithm("ES256"),
+		Key:       key,
+		CertChain: []*x509.Certificate{cert},
+	}
+	data := []byte("hello")
+
+	signature, err := signer.Sign(1001, 1700000000, data, nil)
+	require.NoError(t, err)
+	err = relay.VerifyEventSignature(signature, []*x509.Certificate{rootCert}, 1001, 1700000000, data, nil)
+	assert.ErrorContains(t, err, "untrusted")
+
+	request := &relay.EventPublishRequest{Type: 1001, Data: data, CreatedAt: 1700000000, Signature: signature}
+	assert.Error(t, relay.ValidateEventCreatedAt(request, time.Unix(1700000000, 0), 0, []*x509.Certificate{rootCert}))
+}
+
+func TestValidateEventCreatedAt(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	skew := 5 * time.Minute
+	signer, rootCert := newTestEventSigner(t)
+	rootCerts := []*x509.Certificate{rootCert}
+
+	request := &relay.EventPublishRequest{Type: 1001, Data: []byte("hello")}
+	assert.NoError(t, relay.ValidateEventCreatedAt(request, now, 0, rootCerts))
+	assert.Error(t, relay.ValidateEventCreatedAt(request, now, skew, rootCerts))
+
+	request.CreatedAt = now.Unix() - 300
+	assert.NoError(t, relay.ValidateEventCreatedAt(request, now, skew, rootCerts))
+	request.CreatedAt = now.Unix() + 300
+	assert.NoError(t, relay.ValidateEventCreatedAt(request, now, skew, rootCerts))
+	request.CreatedAt = now.Unix() - 301
+	assert.Error(t, relay.ValidateEventCreatedAt(request, now, skew, rootCerts))
+	request.CreatedAt = now.Unix() + 301
+	assert.Error(t, relay.ValidateEventCreatedAt(request, now, skew, rootCerts))
+
+	// The signature must match the created-at time if it is present.
+	request.CreatedAt = now.Unix()
+	request.Signature, _ = signer.Sign(request.Type, now.Unix()-1, request.Data, request.Tags)
+	assert.Error(t, relay.ValidateEventCreatedAt(request, now, skew, rootCerts))
+	request.Signature, _ = signer.Sign(request.Type, now.Unix(), request.Data, request.Tags)
+	assert.NoError(t, relay.ValidateEventCreatedAt(request, now, skew, rootCerts))
+}
@@ -0,0 +1,115 @@
+package relay
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwe"
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+type EventPayloadFormat string
+
+const (
+	EventPayloadFormatRaw EventPayloadFormat = "raw" // Any bytes.
+	EventPayloadFormatJWS EventPayloadFormat = "jws" // JWS in compact or JSON serialization.
+	EventPayloadFormatJWE EventPayloadFormat = "jwe" // JWE in compact or JSON serialization.
+)
+
+// AnyIdentity in Publishers or Subscribers of an EventTypePolicy allows every client,
+// including clients without a client certificate.
+const AnyIdentity = "*"
+
+// EventTypePolicy describes an event type accepted by the relay server.
+// Identities of clients are the common names of their verified TLS client certificates.
+type EventTypePolicy struct {
+	Type           int                `yaml:"type"`
+	Name           string             `yaml:"name"`
+	MaxPayloadSize int                `yaml:"max_payload_size"` // In bytes. 0 means no limit.
+	Format         EventPayloadFormat `yaml:"format"`           // Empty means raw.
+	Retention      time.Duration      `yaml:"retention"`        // Events older than it are not served to subscribers. 0 means forever.
+	Publishers     []string           `yaml:"publishers"`       // Identities allowed to publish events of the type.
+	Subscribers    []string           `yaml:"subscribers"`      // Identities allowed to receive events of the type.
+}
+
+// EventTypeRegistry is the set of event types accepted by the relay server.
+// An empty registry accepts every event type from everyone.
+type EventTypeRegistry map[int]EventTypePolicy
+
+func NewEventTypeRegistry(policies ...EventTypePolicy) (EventTypeRegistry, error) {
+	registry := make(EventTypeRegistry, len(policies))
+	for _, policy := range policies {
+		if policy.Type == 0 {
+			return nil, errors.New("event type 0 is reserved for subscribing all types")
+		}
+		if _, ok := registry[policy.Type]; ok {
+			return nil, fmt.Errorf("duplicated event type %d", policy.Type)
+		}
+		switch policy.Format {
+		case "":
+			policy.Format = EventPayloadFormatRaw
+		case EventPayloadFormatRaw, EventPayloadFormatJWS, EventPayloadFormatJWE:
+		default:
+			return nil, fmt.Errorf("unknown payload format %q of event type %d", policy.Format, policy.Type)
+		}
+		registry[policy.Type] = policy
+	}
+	return registry, nil
+}
+
+// CheckPublish returns an error if the identity is not allowed to publish the payload as an event of the type.
+func (r EventTypeRegistry) CheckPublish(identity string, evtType int, data []byte) error {
+	if len(r) == 0 {
+		return nil
+	}
+	policy, ok := r[evtType]
+	if !ok {
+		return fmt.Errorf("unknown event type %d", evtType)
+	}
+	if !identityAllowed(policy.Publishers, identity) {
+		return fmt.Errorf("not allowed to publish event type %d", evtType)
+	}
+	if policy.MaxPayloadSize > 0 && len(data) > policy.MaxPayloadSize {
+		return fmt.Errorf("payload is too large (%d > %d)", len(data), policy.MaxPayloadSize)
+	}
+
+	switch policy.Format {
+	case EventPayloadFormatJWS:
+		if _, err := jws.Parse(data); err != nil {
+			return fmt.Errorf("payload is not a JWS: %w", err)
+		}
+	case EventPayloadFormatJWE:
+		if _, err := jwe.Parse(data); err != nil {
+			return fmt.Errorf("payload is not a JWE: %w", err)
+		}
+	}
+	return nil
+}
+
+// CanSubscribe returns true if the identity is allowed to receive events of the type.
+func (r EventTypeRegistry) CanSubscribe(identity string, evtType int) bool {
+	if len(r) == 0 {
+		return true
+	}
+	policy, ok := r[evtType]
+	return ok && identityAllowed(policy.Subscribers, identity)
+}
+
+// Expired returns true if the event is out of the retention of its type at the time of now.
+func (r EventTypeRegistry) Expired(event Event, now time.Time) bool {
+	policy, ok := r[event.Type]
+	if !ok || policy.Retention <= 0 {
+		return false
+	}
+	return event.Timestamp < now.Add(-policy.Retention).Unix()
+}
+
+func identityAllowed(allowed []string, identity string) bool {
+	for _, id := range allowed {
+		if id == AnyIdentity || (identity != "" && id == identity) {
+			return true
+		}
+	}
+	return false
+}
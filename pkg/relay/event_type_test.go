@@ -0,0 +1,83 @@
+package relay_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/openebl/openebl/pkg/relay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventTypeRegistry(t *testing.T) {
+	_, err := relay.NewEventTypeRegistry(relay.EventTypePolicy{Type: 0})
+	assert.Error(t, err)
+	_, err = relay.NewEventTypeRegistry(relay.EventTypePolicy{Type: 1}, relay.EventTypePolicy{Type: 1})
+	assert.Error(t, err)
+	_, err = relay.NewEventTypeRegistry(relay.EventTypePolicy{Type: 1, Format: "xml"})
+	assert.Error(t, err)
+
+	registry, err := relay.NewEventTypeRegistry(
+		relay.EventTypePolicy{
+			Type:           1001,
+			Name:           "signed",
+			MaxPayloadSize: 1024,
+			Format:         relay.EventPayloadFormatJWS,
+			Publishers:     []string{relay.AnyIdentity},
+			Subscribers:    []string{"alice"},
+		},
+		relay.EventTypePolicy{
+			Type:        1002,
+			Name:        "encrypted",
+			Format:      relay.EventPayloadFormatJWE,
+			Retention:   time.Hour,
+			Publishers:  []string{"alice"},
+			Subscribers: []string{relay.AnyIdentity},
+		},
+		relay.EventTypePolicy{
+			Type:       1003,
+			Name:       "raw",
+			Publishers: []string{"alice"},
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, relay.EventPayloadFormatRaw, registry[1003].Format)
+
+	signed, err := jws.Sign([]byte("hello"), jws.WithKey(jwa.HS256, []byte("secret")))
+	require.NoError(t, err)
+	encrypted, err := jwe.Encrypt([]byte("hello"), jwe.WithKey(jwa.DIRECT, make([]byte, 32)), jwe.WithContentEncryption(jwa.A256GCM))
+	require.NoError(t, err)
+
+	// Publishing
+	assert.NoError(t, registry.CheckPublish("", 1001, signed))
+	assert.Error(t, registry.CheckPublish("", 1001, []byte("hello")))
+	assert.Error(t, registry.CheckPublish("", 1001, make([]byte, 1025)))
+	assert.NoError(t, registry.CheckPublish("alice", 1002, encrypted))
+	assert.Error(t, registry.CheckPublish("alice", 1002, signed))
+	assert.Error(t, registry.CheckPublish("bob", 1002, encrypted))
+	assert.Error(t, registry.CheckPublish("", 1002, encrypted))
+	assert.NoError(t, registry.CheckPublish("alice", 1003, []byte("anything")))
+	assert.Error(t, registry.CheckPublish("alice", 1004, []byte("anything")))
+
+	// Subscribing
+	assert.True(t, registry.CanSubscribe("alice", 1001))
+	assert.False(t, registry.CanSubscribe("bob", 1001))
+	assert.False(t, registry.CanSubscribe("", 1001))
+	assert.True(t, registry.CanSubscribe("", 1002))
+	assert.False(t, registry.CanSubscribe("alice", 1003))
+	assert.False(t, registry.CanSubscribe("alice", 1004))
+
+	// Retention
+	now := time.Unix(1700000000, 0)
+	assert.False(t, registry.Expired(relay.Event{Type: 1002, Timestamp: now.Unix() - 3600}, now))
+	assert.True(t, registry.Expired(relay.Event{Type: 1002, Timestamp: now.Unix() - 3601}, now))
+	assert.False(t, registry.Expired(relay.Event{Type: 1001, Timestamp: 0}, now))
+
+	// Empty registry accepts everything.
+	var empty relay.EventTypeRegistry
+	assert.NoError(t, empty.CheckPublish("", 1004, []byte("anything")))
+	assert.True(t, empty.CanSubscribe("", 1004))
+}
@@ -3,6 +3,8 @@ package relay
 import (
 	"context"
 	"io"
+
+	"github.com/openebl/openebl/pkg/envelope"
 )
 
 // Request is a request to the relay server.
@@ -13,16 +15,21 @@ type Request struct {
 
 // EventPublishRequest is a request from the client to publish an event to the relay server.
 type EventPublishRequest struct {
-	RequestID string `json:"request_id,omitempty"`
-	Type      int    `json:"type"`
-	Data      []byte `json:"data"`
+	RequestID string   `json:"request_id,omitempty"`
+	Type      int      `json:"type"`
+	Data      []byte   `json:"data"`
+	Tags      []string `json:"tags,omitempty"` // Routing tags of recipients, like hashed DIDs or key fingerprints.
+
+	CreatedAt int64         `json:"created_at,omitempty"` // Unix time when the publisher created the event.
+	Signature *envelope.JWS `json:"signature,omitempty"`  // Signature of the publisher over EventSignaturePayload.
 }
 
 // SubscribeRequest is a request from the client to subscribe an event from the relay server.
 type SubscribeRequest struct {
-	SubscribeID string `json:"subscribe_id,omitempty"`
-	Type        int    `json:"type"`
-	Offset      int64  `json:"offset"`
+	SubscribeID string   `json:"subscribe_id,omitempty"`
+	Type        int      `json:"type"`
+	Offset      int64    `json:"offset"`
+	Recipients  []string `json:"recipients,omitempty"` // Only events tagged with any of the recipients are sent if it is not empty.
 }
 
 // Response is a message from the relay server.
@@ -42,22 +49,42 @@ type EventPublishResponse struct {
 }
 
 type Event struct {
-	Timestamp int64
+	Timestamp int64 // Unix time when the event was received by the relay server where it was published.
 	Offset    int64
 	Type      int
 	Data      []byte
+	Tags      []string
+	CreatedAt int64         // Unix time when the publisher created the event. 0 if the publisher didn't provide it.
+	Signature *envelope.JWS // Signature of the publisher over EventSignaturePayload. nil if the event is not signed.
 }
 
 type SubscribeResponse struct {
 	SubscribeID string `json:"subscribe_id,omitempty"`
 	Event       *Event `json:"event,omitempty"`
 	EOS         bool   `json:"eos"`
+	Error       string `json:"error,omitempty"` // Set if the subscription is refused.
 }
 
 type RelayServerIdentifyResponse struct {
 	Identity string `json:"identify"`
 }
 
+// RelayInfo is served by the relay server to HTTP requests with "Accept: application/nostr+json".
+type RelayInfo struct {
+	Identity   string          `json:"identity"`
+	EventTypes []EventTypeInfo `json:"event_types,omitempty"` // Empty means every event type is accepted from everyone.
+}
+
+type EventTypeInfo struct {
+	Type             int                `json:"type"`
+	Name             string             `json:"name"`
+	MaxPayloadSize   int                `json:"max_payload_size,omitempty"`
+	Format           EventPayloadFormat `json:"format"`
+	RetentionSeconds int64              `json:"retention_seconds,omitempty"`
+	Publishers       []string           `json:"publishers"`
+	Subscribers      []string           `json:"subscribers"`
+}
+
 type RelayServerNotice struct {
 	Message string `json:"message"`
 }
@@ -74,17 +101,18 @@ type ClientConnectionStatusCallback func(ctx context.Context, cancelFunc context
 type RelayClient interface {
 	io.Closer
 
-	// Send sends a message to the relay server.
-	Publish(ctx context.Context, evtType int, data []byte) error
+	// Send sends a message to the relay server. The event is tagged with the routing tags of its recipients.
+	Publish(ctx context.Context, evtType int, data []byte, tags ...string) error
 
-	// Subscribe event.
-	Subscribe(ctx context.Context, offset int64) error
+	// Subscribe event. If recipients are given, only events tagged with any of them are received.
+	Subscribe(ctx context.Context, offset int64, recipients ...string) error
 }
 
 type EventSourcePullingRequest struct {
-	Offset int64
-	Type   int
-	Length int
+	Offset     int64
+	Type       int
+	Recipients []string
+	Length     int
 }
 type EventSourcePullingResponse struct {
 	Events    []Event
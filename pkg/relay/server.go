@@ -2,10 +2,14 @@ package relay
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,16 +19,36 @@ import (
 
 type NostrServerOption func(s *NostrServer)
 
+const (
+	MaxEventTags      = 64
+	MaxEventTagLength = 256
+
+	// DefaultSendTimeout is how long events wait for the output queue of a slow client before it is disconnected.
+	DefaultSendTimeout = 10 * time.Second
+)
+
 type NostrServer struct {
 	httpServer *http.Server
 	address    string
 	certFile   *string
 	keyFile    *string
+	tlsConfig  *tls.Config
 
 	wsUpgrader  websocket.Upgrader
 	identity    string
 	eventSource EventSource
 	eventSink   EventSink
+	eventTypes  EventTypeRegistry
+	clockSkew   time.Duration
+	sendTimeout time.Duration
+
+	signatureRootCerts []*x509.Certificate
+
+	tailCacheSize int
+	tailCache     *eventTailCache
+
+	newEventsMux sync.Mutex
+	newEvents    chan struct{} // Closed and replaced when NotifyNewEvents is called. Used when the tail cache is disabled.
 
 	clientMux sync.Mutex
 	clients   map[string]*NostrClientStub // map[remote address]*NostrClientStub
@@ -34,6 +58,7 @@ type NostrClientSubscription struct {
 	SubscribeID string
 	Type        int
 	Offset      int64
+	Recipients  []string
 	CloseChan   chan any
 }
 
@@ -47,6 +72,7 @@ type NoStrClientSubscriptionFilter struct {
 type NostrClientStub struct {
 	nostrServer *NostrServer
 	conn        *websocket.Conn
+	identity    string // Common name of the verified client certificate. Empty if the client has no certificate.
 
 	clientMux     sync.Mutex
 	subscriptions map[string]NostrClientSubscription // map[subscription id]NostrClientSubscription
@@ -57,13 +83,19 @@ type NostrClientStub struct {
 
 func NewNostrServer(opts ...NostrServerOption) *NostrServer {
 	server := &NostrServer{
-		clients: make(map[string]*NostrClientStub),
+		clients:     make(map[string]*NostrClientStub),
+		newEvents:   make(chan struct{}),
+		sendTimeout: DefaultSendTimeout,
 	}
 
 	for _, opt := range opts {
 		opt(server)
 	}
 
+	if server.tailCacheSize > 0 && server.eventSource != nil {
+		server.tailCache = newEventTailCache(server.eventSource, server.tailCacheSize)
+	}
+
 	return server
 }
 
@@ -76,14 +108,22 @@ func (s *NostrServer) ListenAndServe() error {
 	serverMux.Handle("/", s)
 
 	s.httpServer = &http.Server{
-		Addr:    s.address,
-		Handler: serverMux,
+		Addr:      s.address,
+		Handler:   serverMux,
+		TLSConfig: s.tlsConfig,
+	}
+
+	if s.tailCache != nil {
+		s.tailCache.start()
+		defer s.tailCache.stop()
 	}
 
 	if s.certFile != nil && s.keyFile != nil {
 		return s.httpServer.ListenAndServeTLS(*s.certFile, *s.keyFile)
 	} else if !(s.certFile == nil && s.keyFile == nil) {
 		return errors.New("both certFile and keyFile must be specified")
+	} else if s.tlsConfig != nil {
+		return s.httpServer.ListenAndServeTLS("", "")
 	}
 
 	return s.httpServer.ListenAndServe()
@@ -107,6 +147,10 @@ func (s *NostrServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// A brief checking about if the client wants to upgrade to websocket.
 	// If not, just return a 200 OK.
 	if r.Header.Get("Upgrade") == "" {
+		if strings.Contains(r.Header.Get("Accept"), "application/nostr+json") {
+			s.serveRelayInfo(w)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Nostr Relay Server is working."))
 		return
@@ -121,6 +165,7 @@ func (s *NostrServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	client := &NostrClientStub{
 		nostrServer:   s,
 		conn:          c,
+		identity:      clientIdentity(r),
 		subscriptions: make(map[string]NostrClientSubscription),
 		closeChan:     make(chan struct{}),
 		outputChan:    make(chan []byte, 16),
@@ -130,6 +175,35 @@ func (s *NostrServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	go client.Run()
 }
 
+func (s *NostrServer) serveRelayInfo(w http.ResponseWriter) {
+	info := RelayInfo{
+		Identity: s.identity,
+	}
+	for _, policy := range s.eventTypes {
+		info.EventTypes = append(info.EventTypes, EventTypeInfo{
+			Type:             policy.Type,
+			Name:             policy.Name,
+			MaxPayloadSize:   policy.MaxPayloadSize,
+			Format:           policy.Format,
+			RetentionSeconds: int64(policy.Retention.Seconds()),
+			Publishers:       policy.Publishers,
+			Subscribers:      policy.Subscribers,
+		})
+	}
+	sort.Slice(info.EventTypes, func(i, j int) bool { return info.EventTypes[i].Type < info.EventTypes[j].Type })
+
+	w.Header().Set("Content-Type", "application/nostr+json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(info)
+}
+
+func clientIdentity(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
+}
+
 func (s *NostrServer) addClient(client *NostrClientStub) {
 	remoteAddr := client.conn.RemoteAddr().String()
 
@@ -246,6 +320,23 @@ func (c *NostrClientStub) send(msg []byte, blocking bool) error {
 	return nil
 }
 
+// sendWithTimeout waits for the output queue for at most timeout, so a client which stops reading can't stall
+// its subscriptions forever.
+func (c *NostrClientStub) sendWithTimeout(msg []byte, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-c.closeChan:
+		return errors.New("client closed")
+	case c.outputChan <- msg:
+	case <-timer.C:
+		return errors.New("timeout to send to output channel")
+	}
+
+	return nil
+}
+
 func (c *NostrClientStub) sendNotice(msg string) error {
 	resp := Response{
 		Notice: &RelayServerNotice{
@@ -257,10 +348,23 @@ func (c *NostrClientStub) sendNotice(msg string) error {
 }
 
 func (c *NostrClientStub) subscribe(req *SubscribeRequest) {
+	if req.Type != 0 && !c.nostrServer.eventTypes.CanSubscribe(c.identity, req.Type) {
+		resp := Response{
+			SubscribeResponse: &SubscribeResponse{
+				SubscribeID: req.SubscribeID,
+				Error:       fmt.Sprintf("not allowed to subscribe event type %d", req.Type),
+			},
+		}
+		respRaw, _ := json.Marshal(resp)
+		c.send(respRaw, true)
+		return
+	}
+
 	subScription := NostrClientSubscription{
 		SubscribeID: req.SubscribeID,
 		Type:        req.Type,
 		Offset:      req.Offset,
+		Recipients:  req.Recipients,
 		CloseChan:   make(chan any),
 	}
 
@@ -286,14 +390,16 @@ func (c *NostrClientStub) unsubscribe(subscriptionID string) {
 
 func (c *NostrClientStub) subscriptionPullingTask(subscription NostrClientSubscription) {
 	eventSourceRequest := EventSourcePullingRequest{
-		Offset: subscription.Offset,
-		Type:   subscription.Type,
-		Length: 100,
+		Offset:     subscription.Offset,
+		Type:       subscription.Type,
+		Recipients: subscription.Recipients,
+		Length:     100,
 	}
 
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
+	var newEvents <-chan struct{}
 	firstBatch := true
 	for {
 		if firstBatch {
@@ -311,10 +417,12 @@ func (c *NostrClientStub) subscriptionPullingTask(subscription NostrClientSubscr
 			case <-subscription.CloseChan:
 				return
 			case <-ticker.C:
+			case <-newEvents:
 			}
 		}
 
-		eventSourceResponse, err := c.nostrServer.eventSource(context.Background(), eventSourceRequest)
+		eventSourceResponse, updated, err := c.nostrServer.pullEvents(eventSourceRequest)
+		newEvents = updated
 		if err != nil {
 			logrus.Errorf("failed to pull events: %v", err)
 			c.close()
@@ -338,7 +446,12 @@ func (c *NostrClientStub) subscriptionPullingTask(subscription NostrClientSubscr
 			continue
 		}
 
+		now := time.Now()
 		for _, event := range eventSourceResponse.Events {
+			// Subscriptions of all types only receive the types allowed for the client.
+			if !c.nostrServer.eventTypes.CanSubscribe(c.identity, event.Type) || c.nostrServer.eventTypes.Expired(event, now) {
+				continue
+			}
 			// TODO: EventSource should provide enough information to generate a valid nostr.Event.
 			eventEnvelope := Response{
 				SubscribeResponse: &SubscribeResponse{
@@ -348,11 +461,14 @@ func (c *NostrClientStub) subscriptionPullingTask(subscription NostrClientSubscr
 						Offset:    event.Offset,
 						Type:      event.Type,
 						Data:      event.Data,
+						Tags:      event.Tags,
+						CreatedAt: event.CreatedAt,
+						Signature: event.Signature,
 					},
 				},
 			}
 			eventEnvelopeRaw, _ := json.Marshal(&eventEnvelope)
-			if err := c.send(eventEnvelopeRaw, false); err != nil {
+			if err := c.sendWithTimeout(eventEnvelopeRaw, c.nostrServer.sendTimeout); err != nil {
 				logrus.Errorf("failed to send event: %v", err)
 				c.close()
 				return
@@ -362,24 +478,106 @@ func (c *NostrClientStub) subscriptionPullingTask(subscription NostrClientSubscr
 	}
 }
 
+// NotifyNewEvents wakes up subscriptions waiting for new events.
+// It is called when the EventSource gets new events from somewhere else than clients of the server,
+// like other relay server instances sharing the same data store.
+func (s *NostrServer) NotifyNewEvents() {
+	if s.tailCache != nil {
+		s.tailCache.poke()
+		return
+	}
+
+	s.newEventsMux.Lock()
+	defer s.newEventsMux.Unlock()
+	close(s.newEvents)
+	s.newEvents = make(chan struct{})
+}
+
+// pullEvents serves the request from the tail cache if possible, otherwise from the EventSource.
+// The returned channel is closed when there may be new events in the EventSource.
+func (s *NostrServer) pullEvents(request EventSourcePullingRequest) (EventSourcePullingResponse, <-chan struct{}, error) {
+	if s.tailCache == nil {
+		s.newEventsMux.Lock()
+		newEvents := s.newEvents
+		s.newEventsMux.Unlock()
+
+		response, err := s.eventSource(context.Background(), request)
+		return response, newEvents, err
+	}
+
+	// Get the channel before pulling, so events added in between will not be missed.
+	updated := s.tailCache.Updated()
+	if response, ok := s.tailCache.Pull(request); ok {
+		return response, updated, nil
+	}
+	response, err := s.eventSource(context.Background(), request)
+	return response, updated, err
+}
+
+// ValidateEventTags checks the number and the length of routing tags of an event.
+func ValidateEventTags(tags []string) error {
+	if len(tags) > MaxEventTags {
+		return fmt.Errorf("too many tags (%d > %d)", len(tags), MaxEventTags)
+	}
+	for _, tag := range tags {
+		if tag == "" || len(tag) > MaxEventTagLength {
+			return fmt.Errorf("invalid tag %q", tag)
+		}
+	}
+	return nil
+}
+
+// ValidateEventCreatedAt checks the created-at time and the signature of the publisher.
+// The created-at time must be within skew from now if skew is not 0. The signature is optional,
+// but it must match the event and be certified by one of rootCerts if it is present.
+func ValidateEventCreatedAt(evt *EventPublishRequest, now time.Time, skew time.Duration, rootCerts []*x509.Certificate) error {
+	if skew > 0 {
+		if evt.CreatedAt == 0 {
+			return errors.New("missing created_at")
+		}
+		createdAt := time.Unix(evt.CreatedAt, 0)
+		if createdAt.Before(now.Add(-skew)) || createdAt.After(now.Add(skew)) {
+			return fmt.Errorf("created_at %d is out of the window of %v", evt.CreatedAt, skew)
+		}
+	}
+	if evt.Signature != nil {
+		return VerifyEventSignature(evt.Signature, rootCerts, evt.Type, evt.CreatedAt, evt.Data, evt.Tags)
+	}
+	return nil
+}
+
 func (c *NostrClientStub) receiveEvent(evt *EventPublishRequest) {
+	now := time.Now()
 	event := Event{
-		Timestamp: time.Now().Unix(),
+		Timestamp: now.Unix(),
 		Type:      evt.Type,
 		Data:      evt.Data,
+		Tags:      evt.Tags,
+		CreatedAt: evt.CreatedAt,
+		Signature: evt.Signature,
 	}
 
 	resp := EventPublishResponse{
 		RequestID: evt.RequestID,
 	}
 
-	if eventID, err := c.nostrServer.eventSink(context.Background(), event); err != nil {
+	if err := ValidateEventTags(evt.Tags); err != nil {
+		resp.OK = false
+		resp.Reason = err.Error()
+	} else if err := c.nostrServer.eventTypes.CheckPublish(c.identity, evt.Type, evt.Data); err != nil {
+		resp.OK = false
+		resp.Reason = err.Error()
+	} else if err := ValidateEventCreatedAt(evt, now, c.nostrServer.clockSkew, c.nostrServer.signatureRootCerts); err != nil {
+		resp.OK = false
+		resp.Reason = err.Error()
+	} else if eventID, err := c.nostrServer.eventSink(context.Background(), event); err != nil {
 		logrus.Errorf("failed to sink event: %v", err)
 		resp.OK = false
 		resp.Reason = fmt.Sprintf("failed to sink event: %v", err)
 	} else {
 		resp.OK = true
 		resp.EventID = eventID
+		c.nostrServer.NotifyNewEvents()
 	}
 
 	respEnvelop := Response{
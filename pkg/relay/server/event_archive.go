@@ -0,0 +1,367 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/openebl/openebl/pkg/relay/server/storage"
+)
+
+type EventArchiveFormat string
+
+const (
+	EventArchiveFormatNDJSON EventArchiveFormat = "ndjson"
+	EventArchiveFormatBinary EventArchiveFormat = "binary"
+
+	exportBatchSize = 1000
+)
+
+// binaryArchiveMagic is the header of the binary archive. It is followed by a byte of the version of the record layout.
+var binaryArchiveMagic = []byte("OEBLEVT")
+
+const (
+	binaryArchiveVersion1 byte = 1 // Without tags.
+	binaryArchiveVersion2 byte = 2 // With tags.
+	binaryArchiveVersion3 byte = 3 // With tags, created-at time and signature of the publisher.
+)
+
+// ArchivedEvent is the portable presentation of an event in an archive.
+type ArchivedEvent struct {
+	ID        string          `json:"id"`
+	Timestamp int64           `json:"timestamp"`
+	Offset    int64           `json:"offset"` // Offset of the event in the relay server which exported it.
+	Type      int             `json:"type"`
+	Data      []byte          `json:"data"`
+	Tags      []string        `json:"tags,omitempty"`
+	CreatedAt int64           `json:"created_at,omitempty"` // Time when the publisher created the event.
+	Signature json.RawMessage `json:"signature,omitempty"`  // Signature of the publisher in JWS JSON serialization.
+}
+
+type EventArchiveWriter interface {
+	Write(event ArchivedEvent) error
+	Flush() error
+}
+
+type EventArchiveReader interface {
+	// Read returns the next event of the archive. It returns io.EOF when there is no more event.
+	Read() (ArchivedEvent, error)
+}
+
+type ndjsonArchiveWriter struct {
+	w       *bufio.Writer
+	encoder *json.Encoder
+}
+
+type ndjsonArchiveReader struct {
+	decoder *json.Decoder
+}
+
+type binaryArchiveWriter struct {
+	w             *bufio.Writer
+	headerWritten bool
+}
+
+type binaryArchiveReader struct {
+	r       *bufio.Reader
+	version byte
+}
+
+// NewEventArchiveWriter returns a writer which writes events into w with the given format.
+func NewEventArchiveWriter(w io.Writer, format EventArchiveFormat) (EventArchiveWriter, error) {
+	switch format {
+	case EventArchiveFormatNDJSON:
+		bufWriter := bufio.NewWriter(w)
+		return &ndjsonArchiveWriter{w: bufWriter, encoder: json.NewEncoder(bufWriter)}, nil
+	case EventArchiveFormatBinary:
+		return &binaryArchiveWriter{w: bufio.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("unsupported archive format %q", format)
+}
+
+// NewEventArchiveReader returns a reader of the archive. The format of the archive is detected automatically.
+func NewEventArchiveReader(r io.Reader) (EventArchiveReader, error) {
+	bufReader := bufio.NewReader(r)
+	header, err := bufReader.Peek(len(binaryArchiveMagic) + 1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if len(header) == len(binaryArchiveMagic)+1 && bytes.Equal(header[:len(binaryArchiveMagic)], binaryArchiveMagic) {
+		version := header[len(binaryArchiveMagic)]
+		if version < binaryArchiveVersion1 || version > binaryArchiveVersion3 {
+			return nil, fmt.Errorf("unsupported binary archive version %d", version)
+		}
+		if _, err := bufReader.Discard(len(header)); err != nil {
+			return nil, err
+		}
+		return &binaryArchiveReader{r: bufReader, version: version}, nil
+	}
+
+	return &ndjsonArchiveReader{decoder: json.NewDecoder(bufReader)}, nil
+}
+
+func (w *ndjsonArchiveWriter) Write(event ArchivedEvent) error {
+	return w.encoder.Encode(event)
+}
+
+func (w *ndjsonArchiveWriter) Flush() error {
+	return w.w.Flush()
+}
+
+func (r *ndjsonArchiveReader) Read() (ArchivedEvent, error) {
+	event := ArchivedEvent{}
+	if err := r.decoder.Decode(&event); err != nil {
+		return ArchivedEvent{}, err
+	}
+	return event, nil
+}
+
+// Write writes an event with the layout (version 3):
+//
+//	offset (int64) | timestamp (int64) | type (int32) | len(id) (uint16) | id | len(data) (uint32) | data |
+//	len(tags) (uint16) | [len(tag) (uint16) | tag]... | created_at (int64) | len(signature) (uint32) | signature
+//
+// All integers are big-endian. Version 1 ends at data and version 2 ends at tags.
+func (w *binaryArchiveWriter) Write(event ArchivedEvent) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	if len(event.ID) > 0xFFFF {
+		return fmt.Errorf("event ID of %q is too long", event.ID)
+	}
+	if len(event.Tags) > 0xFFFF {
+		return fmt.Errorf("event %q has too many tags", event.ID)
+	}
+
+	fields := []any{
+		event.Offset,
+		event.Timestamp,
+		int32(event.Type),
+		uint16(len(event.ID)),
+		[]byte(event.ID),
+		uint32(len(event.Data)),
+		event.Data,
+		uint16(len(event.Tags)),
+	}
+	for _, tag := range event.Tags {
+		if len(tag) > 0xFFFF {
+			return fmt.Errorf("tag of event %q is too long", event.ID)
+		}
+		fields = append(fields, uint16(len(tag)), []byte(tag))
+	}
+	fields = append(fields, event.CreatedAt, uint32(len(event.Signature)), []byte(event.Signature))
+	for _, field := range fields {
+		if err := binary.Write(w.w, binary.BigEndian, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *binaryArchiveWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+func (w *binaryArchiveWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	if _, err := w.w.Write(binaryArchiveMagic); err != nil {
+		return err
+	}
+	if err := w.w.WriteByte(binaryArchiveVersion3); err != nil {
+		return err
+	}
+	w.headerWritten = true
+	return nil
+}
+
+func (r *binaryArchiveReader) Read() (ArchivedEvent, error) {
+	event := ArchivedEvent{}
+	if err := binary.Read(r.r, binary.BigEndian, &event.Offset); err != nil {
+		// A clean EOF is only allowed at the boundary of records.
+		return ArchivedEvent{}, err
+	}
+
+	var eventType int32
+	var idLen uint16
+	var dataLen uint32
+	if err := binary.Read(r.r, binary.BigEndian, &event.Timestamp); err != nil {
+		return ArchivedEvent{}, unexpectedEOF(err)
+	}
+	if err := binary.Read(r.r, binary.BigEndian, &eventType); err != nil {
+		return ArchivedEvent{}, unexpectedEOF(err)
+	}
+	event.Type = int(eventType)
+
+	if err := binary.Read(r.r, binary.BigEndian, &idLen); err != nil {
+		return ArchivedEvent{}, unexpectedEOF(err)
+	}
+	id := make([]byte, idLen)
+	if _, err := io.ReadFull(r.r, id); err != nil {
+		return ArchivedEvent{}, unexpectedEOF(err)
+	}
+	event.ID = string(id)
+
+	if err := binary.Read(r.r, binary.BigEndian, &dataLen); err != nil {
+		return ArchivedEvent{}, unexpectedEOF(err)
+	}
+	event.Data = make([]byte, dataLen)
+	if _, err := io.ReadFull(r.r, event.Data); err != nil {
+		return ArchivedEvent{}, unexpectedEOF(err)
+	}
+
+	if r.version < binaryArchiveVersion2 {
+		return event, nil
+	}
+	var tagCount uint16
+	if err := binary.Read(r.r, binary.BigEndian, &tagCount); err != nil {
+		return ArchivedEvent{}, unexpectedEOF(err)
+	}
+	for i := 0; i < int(tagCount); i++ {
+		var tagLen uint16
+		if err := binary.Read(r.r, binary.BigEndian, &tagLen); err != nil {
+			return ArchivedEvent{}, unexpectedEOF(err)
+		}
+		tag := make([]byte, tagLen)
+		if _, err := io.ReadFull(r.r, tag); err != nil {
+			return ArchivedEvent{}, unexpectedEOF(err)
+		}
+		event.Tags = append(event.Tags, string(tag))
+	}
+
+	if r.version < binaryArchiveVersion3 {
+		return event, nil
+	}
+	var signatureLen uint32
+	if err := binary.Read(r.r, binary.BigEndian, &event.CreatedAt); err != nil {
+		return ArchivedEvent{}, unexpectedEOF(err)
+	}
+	if err := binary.Read(r.r, binary.BigEndian, &signatureLen); err != nil {
+		return ArchivedEvent{}, unexpectedEOF(err)
+	}
+	if signatureLen > 0 {
+		event.Signature = make([]byte, signatureLen)
+		if _, err := io.ReadFull(r.r, event.Signature); err != nil {
+			return ArchivedEvent{}, unexpectedEOF(err)
+		}
+	}
+
+	return event, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// ExportEvents writes all events in the data store into the archive writer in the order of their offsets.
+// It returns the number of exported events.
+func ExportEvents(ctx context.Context, dataStore storage.RelayServerDataStore, w EventArchiveWriter) (int64, error) {
+	total, _, err := exportEvents(ctx, dataStore, w, 0)
+	return total, err
+}
+
+// exportEvents writes events with offset not greater than untilOffset into the archive writer.
+// If untilOffset is 0, all events are exported.
+// It returns the number of exported events and the max offset of them.
+func exportEvents(ctx context.Context, dataStore storage.RelayServerDataStore, w EventArchiveWriter, untilOffset int64) (int64, int64, error) {
+	request := storage.ListEventRequest{
+		Offset: 0,
+		Limit:  exportBatchSize,
+	}
+
+	var total, maxOffset int64
+	for {
+		result, err := dataStore.ListEvents(ctx, request)
+		if err != nil {
+			return total, maxOffset, err
+		}
+		if len(result.Events) == 0 {
+			break
+		}
+
+		reachEnd := false
+		for _, event := range result.Events {
+			if untilOffset != 0 && event.Offset > untilOffset {
+				reachEnd = true
+				break
+			}
+			if event.ArchiveSegmentID != "" && event.Data == nil {
+				return total, maxOffset, fmt.Errorf("event %q (offset %d) is archived in %q, but the archive store is not available", event.ID, event.Offset, event.ArchiveSegmentID)
+			}
+			archivedEvent := ArchivedEvent{
+				ID:        event.ID,
+				Timestamp: event.Timestamp,
+				Offset:    event.Offset,
+				Type:      event.Type,
+				Data:      event.Data,
+				Tags:      event.Tags,
+				CreatedAt: event.CreatedAt,
+				Signature: event.Signature,
+			}
+			if err := w.Write(archivedEvent); err != nil {
+				return total, maxOffset, err
+			}
+			total++
+			maxOffset = max(maxOffset, event.Offset)
+		}
+		if reachEnd {
+			break
+		}
+		request.Offset = result.MaxOffset + 1
+	}
+
+	return total, maxOffset, w.Flush()
+}
+
+// ImportEvents loads all events from the archive reader into the data store.
+// The event IDs are kept. Events already in the data store are skipped.
+// It returns the number of imported events and the number of skipped events.
+func ImportEvents(ctx context.Context, dataStore storage.RelayServerDataStore, r EventArchiveReader) (int64, int64, error) {
+	var imported, skipped int64
+	for {
+		event, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return imported, skipped, nil
+		}
+		if err != nil {
+			return imported, skipped, err
+		}
+
+		if event.ID != GetEventID(event.Data) {
+			return imported, skipped, fmt.Errorf("event %q (offset %d) doesn't match its data", event.ID, event.Offset)
+		}
+
+		storageEvent := storage.Event{
+			ID:        event.ID,
+			Timestamp: event.Timestamp,
+			Type:      event.Type,
+			Data:      event.Data,
+			Tags:      event.Tags,
+			CreatedAt: event.CreatedAt,
+			Signature: event.Signature,
+		}
+		_, err = dataStore.StoreEventWithOffsetInfo(ctx, storageEvent, 0, "")
+		if errors.Is(err, storage.ErrDuplicateEvent) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return imported, skipped, err
+		}
+		imported++
+	}
+}
@@ -0,0 +1,137 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/openebl/openebl/pkg/relay/server"
+	"github.com/openebl/openebl/pkg/relay/server/storage"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventArchiveTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func TestEventArchive(t *testing.T) {
+	suite.Run(t, new(EventArchiveTestSuite))
+}
+
+func (s *EventArchiveTestSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func (s *EventArchiveTestSuite) TestExportAndImport() {
+	source := NewServerDataStore("source")
+	for i := 0; i < 2500; i++ {
+		data := []byte(fmt.Sprintf("event %d", i))
+		event := storage.Event{Timestamp: int64(1000 + i), ID: server.GetEventID(data), Type: 1001 + i%2, Data: data}
+		if i%3 == 0 {
+			event.Tags = []string{fmt.Sprintf("recipient-%d", i%5), "recipient-x"}
+		}
+		if i%4 == 0 {
+			event.CreatedAt = int64(990 + i)
+			event.Signature = []byte(fmt.Sprintf(`{"protected":"p%d","payload":"q","signature":"r"}`, i))
+		}
+		_, err := source.StoreEventWithOffsetInfo(s.ctx, event, 0, "")
+		s.Require().NoError(err)
+	}
+
+	for _, format := range []server.EventArchiveFormat{server.EventArchiveFormatNDJSON, server.EventArchiveFormatBinary} {
+		buf := &bytes.Buffer{}
+		w, err := server.NewEventArchiveWriter(buf, format)
+		s.Require().NoError(err)
+		total, err := server.ExportEvents(s.ctx, source, w)
+		s.Require().NoError(err)
+		s.Assert().EqualValues(2500, total, format)
+
+		target := NewServerDataStore("target")
+		r, err := server.NewEventArchiveReader(bytes.NewReader(buf.Bytes()))
+		s.Require().NoError(err)
+		imported, skipped, err := server.ImportEvents(s.ctx, target, r)
+		s.Require().NoError(err)
+		s.Assert().EqualValues(2500, imported, format)
+		s.Assert().Zero(skipped, format)
+
+		stripOffset := func(evt storage.Event, _ int) storage.Event {
+			evt.Offset = 0
+			return evt
+		}
+		s.Assert().Equal(
+			lo.Map(source.GetEvents(), stripOffset),
+			lo.Map(target.GetEvents(), stripOffset),
+			format,
+		)
+	}
+}
+
+func (s *EventArchiveTestSuite) TestEmptyArchive() {
+	for _, format := range []server.EventArchiveFormat{server.EventArchiveFormatNDJSON, server.EventArchiveFormatBinary} {
+		buf := &bytes.Buffer{}
+		w, err := server.NewEventArchiveWriter(buf, format)
+		s.Require().NoError(err)
+		total, err := server.ExportEvents(s.ctx, NewServerDataStore("source"), w)
+		s.Require().NoError(err)
+		s.Assert().Zero(total)
+
+		r, err := server.NewEventArchiveReader(buf)
+		s.Require().NoError(err)
+		_, err = r.Read()
+		s.Assert().ErrorIs(err, io.EOF, format)
+	}
+}
+
+func (s *EventArchiveTestSuite) TestImportRejectsTamperedEvent() {
+	buf := &bytes.Buffer{}
+	w, err := server.NewEventArchiveWriter(buf, server.EventArchiveFormatBinary)
+	s.Require().NoError(err)
+	s.Require().NoError(w.Write(server.ArchivedEvent{ID: server.GetEventID([]byte("original")), Type: 1001, Data: []byte("tampered")}))
+	s.Require().NoError(w.Flush())
+
+	r, err := server.NewEventArchiveReader(buf)
+	s.Require().NoError(err)
+	_, _, err = server.ImportEvents(s.ctx, NewServerDataStore("target"), r)
+	s.Assert().Error(err)
+}
+
+func (s *EventArchiveTestSuite) TestTruncatedBinaryArchive() {
+	buf := &bytes.Buffer{}
+	w, err := server.NewEventArchiveWriter(buf, server.EventArchiveFormatBinary)
+	s.Require().NoError(err)
+	data := []byte("hello")
+	s.Require().NoError(w.Write(server.ArchivedEvent{ID: server.GetEventID(data), Type: 1001, Data: data}))
+	s.Require().NoError(w.Flush())
+
+	r, err := server.NewEventArchiveReader(bytes.NewReader(buf.Bytes()[:buf.Len()-2]))
+	s.Require().NoError(err)
+	_, err = r.Read()
+	s.Assert().ErrorIs(err, io.ErrUnexpectedEOF)
+}
+
+func (s *EventArchiveTestSuite) TestReadBinaryArchiveVersion1() {
+	data := []byte("hello")
+	eventID := server.GetEventID(data)
+	buf := &bytes.Buffer{}
+	buf.WriteString("OEBLEVT\x01")
+	binary.Write(buf, binary.BigEndian, int64(7))    // offset
+	binary.Write(buf, binary.BigEndian, int64(1000)) // timestamp
+	binary.Write(buf, binary.BigEndian, int32(1001)) // type
+	binary.Write(buf, binary.BigEndian, uint16(len(eventID)))
+	buf.WriteString(eventID)
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.Write(data)
+
+	r, err := server.NewEventArchiveReader(buf)
+	s.Require().NoError(err)
+	event, err := r.Read()
+	s.Require().NoError(err)
+	s.Assert().Equal(server.ArchivedEvent{ID: eventID, Timestamp: 1000, Offset: 7, Type: 1001, Data: data}, event)
+	_, err = r.Read()
+	s.Assert().ErrorIs(err, io.EOF)
+}
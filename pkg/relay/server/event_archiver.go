@@ -0,0 +1,300 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/openebl/openebl/pkg/object_store"
+	"github.com/openebl/openebl/pkg/relay/server/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const archiveSegmentCacheSize = 8
+
+type EventArchiveConfig struct {
+	OlderThan   time.Duration `yaml:"older_than"`   // Events older than it are moved into the archive store.
+	SegmentSize int           `yaml:"segment_size"` // Number of events in a segment.
+	Interval    time.Duration `yaml:"interval"`     // How often the archiver looks for events to archive.
+}
+
+// EventArchiver moves the data of old events from the data store into compressed segments in the object store.
+// A segment is a gzip compressed binary event archive. The ID, offset and type of archived events stay in the data
+// store, so offsets and deduplication of events are not affected.
+type EventArchiver struct {
+	dataStore   storage.EventArchiveDataStore
+	objectStore object_store.ObjectStore
+	config      EventArchiveConfig
+}
+
+func NewEventArchiver(dataStore storage.EventArchiveDataStore, objectStore object_store.ObjectStore, config EventArchiveConfig) *EventArchiver {
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = 10000
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	return &EventArchiver{
+		dataStore:   dataStore,
+		objectStore: objectStore,
+		config:      config,
+	}
+}
+
+// Run archives events periodically until ctx is done.
+func (a *EventArchiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		segments, err := a.ArchiveOnce(ctx, time.Now())
+		if err != nil {
+			logrus.Errorf("failed to archive events: %v", err)
+		} else if segments > 0 {
+			logrus.Infof("%d archive segments are created.", segments)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ArchiveOnce creates segments until there are not enough events older than the threshold to fill a segment.
+// It returns the number of created segments.
+func (a *EventArchiver) ArchiveOnce(ctx context.Context, now time.Time) (int, error) {
+	threshold := now.Add(-a.config.OlderThan).Unix()
+
+	segments := 0
+	for {
+		events, err := a.dataStore.ListEventsToArchive(ctx, int64(a.config.SegmentSize))
+		if err != nil {
+			return segments, err
+		}
+		// Segments cover continuous offsets, so stop at the first event which is not old enough.
+		for i, event := range events {
+			if event.Timestamp >= threshold {
+				events = events[:i]
+				break
+			}
+		}
+		if len(events) < a.config.SegmentSize {
+			return segments, nil
+		}
+
+		if err := a.archive(ctx, now.Unix(), events); err != nil {
+			return segments, err
+		}
+		segments++
+	}
+}
+
+func (a *EventArchiver) archive(ctx context.Context, ts int64, events []storage.Event) error {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	w, _ := NewEventArchiveWriter(gzipWriter, EventArchiveFormatBinary)
+	eventIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		archivedEvent := ArchivedEvent{
+			ID:        event.ID,
+			Timestamp: event.Timestamp,
+			Offset:    event.Offset,
+			Type:      event.Type,
+			Data:      event.Data,
+			Tags:      event.Tags,
+			CreatedAt: event.CreatedAt,
+			Signature: event.Signature,
+		}
+		if err := w.Write(archivedEvent); err != nil {
+			return err
+		}
+		eventIDs = append(eventIDs, event.ID)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(buf.Bytes())
+	segment := storage.ArchiveSegment{
+		ID:         fmt.Sprintf("segments/%020d-%020d.bin.gz", events[0].Offset, events[len(events)-1].Offset),
+		FromOffset: events[0].Offset,
+		ToOffset:   events[len(events)-1].Offset,
+		EventCount: int64(len(events)),
+		Size:       int64(buf.Len()),
+		SHA256:     hex.EncodeToString(hash[:]),
+		CreatedAt:  ts,
+	}
+
+	// Upload the segment before dropping the data in the data store.
+	// A segment left by a failed attempt is overwritten by the next attempt of the same range.
+	if err := a.objectStore.Put(ctx, segment.ID, buf.Bytes()); err != nil {
+		return fmt.Errorf("upload segment %q: %w", segment.ID, err)
+	}
+	return a.dataStore.ArchiveEvents(ctx, segment, eventIDs)
+}
+
+// ArchiveReadingDataStore fills the data of archived events listed by ListEvents from segments in the object store,
+// so readers of the data store don't notice whether events are archived or not.
+type ArchiveReadingDataStore struct {
+	storage.EventArchiveDataStore
+	objectStore object_store.ObjectStore
+
+	cacheMux sync.Mutex
+	cache    map[string]map[string][]byte // map[segment id]map[event id]data
+	cacheLRU []string                     // Segment IDs. The most recently used one is the last one.
+}
+
+func NewArchiveReadingDataStore(dataStore storage.EventArchiveDataStore, objectStore object_store.ObjectStore) *ArchiveReadingDataStore {
+	return &ArchiveReadingDataStore{
+		EventArchiveDataStore: dataStore,
+		objectStore:           objectStore,
+		cache:                 make(map[string]map[string][]byte),
+	}
+}
+
+func (s *ArchiveReadingDataStore) ListEvents(ctx context.Context, request storage.ListEventRequest) (storage.ListEventResult, error) {
+	result, err := s.EventArchiveDataStore.ListEvents(ctx, request)
+	if err != nil {
+		return result, err
+	}
+	return s.fillArchivedData(ctx, result)
+}
+
+// ReadSnapshot reads a snapshot of the underlying data store, filling the data of archived events as ListEvents does.
+func (s *ArchiveReadingDataStore) ReadSnapshot(ctx context.Context, read func(ctx context.Context, snapshot storage.RelayServerDataStore) error) error {
+	dataStore, ok := s.EventArchiveDataStore.(storage.SnapshotDataStore)
+	if !ok {
+		return errors.New("the data store can't read a snapshot")
+	}
+	return dataStore.ReadSnapshot(ctx, func(ctx context.Context, snapshot storage.RelayServerDataStore) error {
+		return read(ctx, &archiveReadingSnapshot{RelayServerDataStore: snapshot, archive: s})
+	})
+}
+
+func (s *ArchiveReadingDataStore) fillArchivedData(ctx context.Context, result storage.ListEventResult) (storage.ListEventResult, error) {
+	events := make([]storage.Event, len(result.Events))
+	copy(events, result.Events)
+	for i := range events {
+		if events[i].ArchiveSegmentID == "" || events[i].Data != nil {
+			continue
+		}
+		segment, err := s.loadSegment(ctx, events[i].ArchiveSegmentID)
+		if err != nil {
+			return storage.ListEventResult{}, err
+		}
+		data, ok := segment[events[i].ID]
+		if !ok {
+			return storage.ListEventResult{}, fmt.Errorf("event %q is not in segment %q", events[i].ID, events[i].ArchiveSegmentID)
+		}
+		events[i].Data = data
+	}
+	result.Events = events
+
+	return result, nil
+}
+
+func (s *ArchiveReadingDataStore) loadSegment(ctx context.Context, segmentID string) (map[string][]byte, error) {
+	s.cacheMux.Lock()
+	if segment, ok := s.cache[segmentID]; ok {
+		s.touchSegment(segmentID)
+		s.cacheMux.Unlock()
+		return segment, nil
+	}
+	s.cacheMux.Unlock()
+
+	// Download without holding the lock, so a slow object store doesn't block readers of cached segments.
+	segment, err := s.downloadSegment(ctx, segmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheMux.Lock()
+	defer s.cacheMux.Unlock()
+	if cached, ok := s.cache[segmentID]; ok {
+		// Another reader downloaded the same segment in the meantime.
+		s.touchSegment(segmentID)
+		return cached, nil
+	}
+	s.cache[segmentID] = segment
+	s.touchSegment(segmentID)
+	if len(s.cacheLRU) > archiveSegmentCacheSize {
+		delete(s.cache, s.cacheLRU[0])
+		s.cacheLRU = s.cacheLRU[1:]
+	}
+	return segment, nil
+}
+
+func (s *ArchiveReadingDataStore) downloadSegment(ctx context.Context, segmentID string) (map[string][]byte, error) {
+	segmentInfo, err := s.EventArchiveDataStore.GetArchiveSegment(ctx, segmentID)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := s.objectStore.Get(ctx, segmentID)
+	if err != nil {
+		return nil, fmt.Errorf("download segment %q: %w", segmentID, err)
+	}
+	hash := sha256.Sum256(raw)
+	if hex.EncodeToString(hash[:]) != segmentInfo.SHA256 {
+		return nil, fmt.Errorf("segment %q doesn't match its hash", segmentID)
+	}
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("decompress segment %q: %w", segmentID, err)
+	}
+	r, err := NewEventArchiveReader(gzipReader)
+	if err != nil {
+		return nil, err
+	}
+	segment := make(map[string][]byte, segmentInfo.EventCount)
+	for {
+		event, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read segment %q: %w", segmentID, err)
+		}
+		if event.ID != GetEventID(event.Data) {
+			return nil, fmt.Errorf("event %q in segment %q doesn't match its data", event.ID, segmentID)
+		}
+		segment[event.ID] = event.Data
+	}
+	return segment, nil
+}
+
+func (s *ArchiveReadingDataStore) touchSegment(segmentID string) {
+	for i, id := range s.cacheLRU {
+		if id == segmentID {
+			s.cacheLRU = append(s.cacheLRU[:i], s.cacheLRU[i+1:]...)
+			break
+		}
+	}
+	s.cacheLRU = append(s.cacheLRU, segmentID)
+}
+
+// archiveReadingSnapshot fills the data of archived events listed from a snapshot of the data store.
+type archiveReadingSnapshot struct {
+	storage.RelayServerDataStore
+	archive *ArchiveReadingDataStore
+}
+
+func (s *archiveReadingSnapshot) ListEvents(ctx context.Context, request storage.ListEventRequest) (storage.ListEventResult, error) {
+	result, err := s.RelayServerDataStore.ListEvents(ctx, request)
+	if err != nil {
+		return result, err
+	}
+	return s.archive.fillArchivedData(ctx, result)
+}
@@ -0,0 +1,165 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/object_store"
+	"github.com/openebl/openebl/pkg/relay/server"
+	"github.com/openebl/openebl/pkg/relay/server/storage"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventArchiverTestSuite struct {
+	suite.Suite
+	ctx         context.Context
+	now         time.Time
+	dataStore   *ServerDataStore
+	objectStore object_store.ObjectStore
+	archiver    *server.EventArchiver
+	original    []storage.Event
+}
+
+func TestEventArchiver(t *testing.T) {
+	suite.Run(t, new(EventArchiverTestSuite))
+}
+
+func (s *EventArchiverTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.now = time.Unix(1700000000, 0)
+	s.dataStore = NewServerDataStore("relay")
+	for i := 0; i < 25; i++ {
+		data := []byte(fmt.Sprintf("event %d", i))
+		// Events before the 20th one are older than 30 days.
+		ts := s.now.Add(-40 * 24 * time.Hour).Add(time.Duration(i) * time.Hour)
+		if i >= 20 {
+			ts = s.now.Add(-time.Hour)
+		}
+		_, err := s.dataStore.StoreEventWithOffsetInfo(s.ctx, storage.Event{Timestamp: ts.Unix(), ID: server.GetEventID(data), Type: 1001, Data: data}, 0, "")
+		s.Require().NoError(err)
+	}
+	s.original = append([]storage.Event(nil), s.dataStore.GetEvents()...)
+
+	objectStore, err := object_store.NewLocalObjectStore(object_store.LocalConfig{Directory: s.T().TempDir()})
+	s.Require().NoError(err)
+	s.objectStore = objectStore
+	s.archiver = server.NewEventArchiver(s.dataStore, s.objectStore, server.EventArchiveConfig{
+		OlderThan:   30 * 24 * time.Hour,
+		SegmentSize: 8,
+	})
+}
+
+func (s *EventArchiverTestSuite) TestArchiveAndRead() {
+	segments, err := s.archiver.ArchiveOnce(s.ctx, s.now)
+	s.Require().NoError(err)
+	s.Assert().Equal(2, segments) // 20 old events fill 2 segments of 8 events. The other 4 wait for more old events.
+
+	events := s.dataStore.GetEvents()
+	for i, event := range events {
+		if i < 16 {
+			s.Assert().Nil(event.Data)
+			s.Assert().NotEmpty(event.ArchiveSegmentID)
+		} else {
+			s.Assert().NotNil(event.Data)
+			s.Assert().Empty(event.ArchiveSegmentID)
+		}
+	}
+	segment, err := s.dataStore.GetArchiveSegment(s.ctx, events[8].ArchiveSegmentID)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(8, segment.FromOffset)
+	s.Assert().EqualValues(15, segment.ToOffset)
+	s.Assert().EqualValues(8, segment.EventCount)
+
+	// Readers get the same events as before archiving.
+	reader := server.NewArchiveReadingDataStore(s.dataStore, s.objectStore)
+	result, err := reader.ListEvents(s.ctx, storage.ListEventRequest{Offset: 5, Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(result.Events, 10)
+	for i, event := range result.Events {
+		s.Assert().Equal(s.original[5+i].Data, event.Data)
+	}
+	s.Assert().EqualValues(14, result.MaxOffset)
+
+	// Nothing to archive before new events come.
+	segments, err = s.archiver.ArchiveOnce(s.ctx, s.now)
+	s.Require().NoError(err)
+	s.Assert().Zero(segments)
+}
+
+func (s *EventArchiverTestSuite) TestExportArchivedEvents() {
+	_, err := s.archiver.ArchiveOnce(s.ctx, s.now)
+	s.Require().NoError(err)
+
+	w, _ := server.NewEventArchiveWriter(io.Discard, server.EventArchiveFormatBinary)
+	_, err = server.ExportEvents(s.ctx, s.dataStore, w)
+	s.Assert().Error(err, "archived events can not be exported without the archive store")
+
+	total, err := server.ExportEvents(s.ctx, server.NewArchiveReadingDataStore(s.dataStore, s.objectStore), w)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(25, total)
+}
+
+func (s *EventArchiverTestSuite) TestTamperedSegment() {
+	_, err := s.archiver.ArchiveOnce(s.ctx, s.now)
+	s.Require().NoError(err)
+
+	segmentID := s.dataStore.GetEvents()[0].ArchiveSegmentID
+	raw, err := s.objectStore.Get(s.ctx, segmentID)
+	s.Require().NoError(err)
+	raw[len(raw)-1] ^= 0xFF
+	s.Require().NoError(s.objectStore.Put(s.ctx, segmentID, raw))
+
+	reader := server.NewArchiveReadingDataStore(s.dataStore, s.objectStore)
+	_, err = reader.ListEvents(s.ctx, storage.ListEventRequest{Offset: 0, Limit: 10})
+	s.Assert().ErrorContains(err, "hash")
+}
+
+// blockingObjectStore holds downloads of a segment until release is closed.
+type blockingObjectStore struct {
+	object_store.ObjectStore
+	blockedKey string
+	blocked    chan struct{}
+	release    chan struct{}
+}
+
+func (s *blockingObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if key == s.blockedKey {
+		close(s.blocked)
+		<-s.release
+	}
+	return s.ObjectStore.Get(ctx, key)
+}
+
+func (s *EventArchiverTestSuite) TestReadCachedSegmentWhileDownloading() {
+	_, err := s.archiver.ArchiveOnce(s.ctx, s.now)
+	s.Require().NoError(err)
+
+	events := s.dataStore.GetEvents()
+	objectStore := &blockingObjectStore{
+		ObjectStore: s.objectStore,
+		blockedKey:  events[8].ArchiveSegmentID,
+		blocked:     make(chan struct{}),
+		release:     make(chan struct{}),
+	}
+	reader := server.NewArchiveReadingDataStore(s.dataStore, objectStore)
+	_, err = reader.ListEvents(s.ctx, storage.ListEventRequest{Offset: 0, Limit: 8})
+	s.Require().NoError(err)
+
+	slowRead := make(chan error, 1)
+	go func() {
+		_, err := reader.ListEvents(s.ctx, storage.ListEventRequest{Offset: 8, Limit: 8})
+		slowRead <- err
+	}()
+	<-objectStore.blocked
+
+	// The cached segment is readable while the other one is being downloaded.
+	result, err := reader.ListEvents(s.ctx, storage.ListEventRequest{Offset: 0, Limit: 8})
+	s.Require().NoError(err)
+	s.Assert().Equal(s.original[0].Data, result.Events[0].Data)
+
+	close(objectStore.release)
+	s.Require().NoError(<-slowRead)
+}
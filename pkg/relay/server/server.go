@@ -2,10 +2,18 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/openebl/openebl/pkg/envelope"
+	"github.com/openebl/openebl/pkg/object_store"
 	"github.com/openebl/openebl/pkg/relay"
 	"github.com/openebl/openebl/pkg/relay/server/storage"
 	"github.com/openebl/openebl/pkg/util"
@@ -19,6 +27,11 @@ type ServerConfig struct {
 	OtherPeers   []string                    `yaml:"other_peers"` // Set the server to connect to other servers to pull data from them.
 }
 
+const (
+	defaultPeerLeaseTTL      = 30 * time.Second
+	defaultPeerRetryInterval = 5 * time.Second
+)
+
 type Server struct {
 	io.Closer
 
@@ -28,6 +41,27 @@ type Server struct {
 	eventSink    relay.EventSink
 	relayServer  *relay.NostrServer
 
+	tailCacheSize int
+	eventTypes    relay.EventTypeRegistry
+	tlsConfig     *tls.Config
+	clockSkew     time.Duration
+
+	signatureRootCerts []*x509.Certificate
+
+	archiveStore  object_store.ObjectStore
+	archiveConfig EventArchiveConfig
+	archiver      *EventArchiver
+
+	instanceID        string
+	peerLeaseTTL      time.Duration
+	peerRetryInterval time.Duration
+	eventNotifier     storage.EventNotifier
+	leaseStore        storage.PeerLeaseDataStore
+
+	stopBackgroundTasks context.CancelFunc
+	backgroundTasks     sync.WaitGroup
+
+	peerMux    sync.Mutex
 	otherPeers map[string]*ClientCallback // map[remote address]RelayClient
 }
 
@@ -63,20 +97,74 @@ func (c *ClientCallback) OnConnectionStatusChange(
 }
 
 func (c *ClientCallback) EventSink(ctx context.Context, event relay.Event) (string, error) {
-	evtID := GetEventID(event.Data)
-	_, err := c.server.dataStore.StoreEventWithOffsetInfo(ctx, event.Timestamp, evtID, event.Type, event.Data, event.Offset, c.serverIdentity)
+	storageEvent := toStorageEvent(event)
+	evtID := storageEvent.ID
+	_, err := c.server.dataStore.StoreEventWithOffsetInfo(ctx, storageEvent, event.Offset, c.serverIdentity)
 	if err != nil && !errors.Is(err, storage.ErrDuplicateEvent) {
 		return "", err
 	}
 	return evtID, nil
 }
 
+func toStorageEvent(event relay.Event) storage.Event {
+	storageEvent := storage.Event{
+		ID:        GetEventID(event.Data),
+		Timestamp: event.Timestamp,
+		Type:      event.Type,
+		Data:      event.Data,
+		Tags:      event.Tags,
+		CreatedAt: event.CreatedAt,
+	}
+	if event.Signature != nil {
+		storageEvent.Signature, _ = json.Marshal(event.Signature)
+	}
+	return storageEvent
+}
+
+func toRelayEvent(event storage.Event) relay.Event {
+	relayEvent := relay.Event{
+		Timestamp: event.Timestamp,
+		Offset:    event.Offset,
+		Type:      event.Type,
+		Data:      event.Data,
+		Tags:      event.Tags,
+		CreatedAt: event.CreatedAt,
+	}
+	if len(event.Signature) > 0 {
+		signature := &envelope.JWS{}
+		if err := json.Unmarshal(event.Signature, signature); err != nil {
+			logrus.Warnf("event %q has an invalid signature: %v", event.ID, err)
+		} else {
+			relayEvent.Signature = signature
+		}
+	}
+	return relayEvent
+}
+
 func NewServer(options ...ServerOption) (*Server, error) {
-	server := &Server{}
+	server := &Server{
+		instanceID:        uuid.NewString(),
+		peerLeaseTTL:      defaultPeerLeaseTTL,
+		peerRetryInterval: defaultPeerRetryInterval,
+	}
 	for _, option := range options {
 		option(server)
 	}
 
+	// Instances sharing the data store wake each other up and take turns replicating from peers.
+	server.eventNotifier, _ = server.dataStore.(storage.EventNotifier)
+	server.leaseStore, _ = server.dataStore.(storage.PeerLeaseDataStore)
+
+	// Read archived events from the archive store transparently.
+	if server.archiveStore != nil {
+		archiveDataStore, ok := server.dataStore.(storage.EventArchiveDataStore)
+		if !ok {
+			return nil, errors.New("the storage doesn't support event archive")
+		}
+		server.archiver = NewEventArchiver(archiveDataStore, server.archiveStore, server.archiveConfig)
+		server.dataStore = NewArchiveReadingDataStore(archiveDataStore, server.archiveStore)
+	}
+
 	// Get data storage identity
 	dataStoreID, err := server.dataStore.GetIdentity(context.Background())
 	if err != nil {
@@ -87,25 +175,17 @@ func NewServer(options ...ServerOption) (*Server, error) {
 	// Prepare event source
 	eventSource := func(ctx context.Context, request relay.EventSourcePullingRequest) (relay.EventSourcePullingResponse, error) {
 		dsRequest := storage.ListEventRequest{
-			Limit:  int64(request.Length),
-			Offset: int64(request.Offset),
+			Limit:      int64(request.Length),
+			Offset:     int64(request.Offset),
+			EventType:  request.Type,
+			Recipients: request.Recipients,
 		}
 		dsResult, err := server.dataStore.ListEvents(ctx, dsRequest)
 		if err != nil {
 			return relay.EventSourcePullingResponse{}, err
 		}
 
-		events := lo.Map(
-			dsResult.Events,
-			func(event storage.Event, _ int) relay.Event {
-				return relay.Event{
-					Timestamp: event.Timestamp,
-					Offset:    event.Offset,
-					Type:      event.Type,
-					Data:      event.Data,
-				}
-			},
-		)
+		events := lo.Map(dsResult.Events, func(event storage.Event, _ int) relay.Event { return toRelayEvent(event) })
 
 		return relay.EventSourcePullingResponse{
 			Events:    events,
@@ -115,8 +195,9 @@ func NewServer(options ...ServerOption) (*Server, error) {
 
 	// Prepare EventSink
 	serverEventSink := func(ctx context.Context, event relay.Event) (string, error) {
-		evtID := GetEventID(event.Data)
-		_, err := server.dataStore.StoreEventWithOffsetInfo(ctx, event.Timestamp, evtID, event.Type, event.Data, 0, "")
+		storageEvent := toStorageEvent(event)
+		evtID := storageEvent.ID
+		_, err := server.dataStore.StoreEventWithOffsetInfo(ctx, storageEvent, 0, "")
 		if err != nil && !errors.Is(err, storage.ErrDuplicateEvent) {
 			return "", err
 		}
@@ -125,32 +206,52 @@ func NewServer(options ...ServerOption) (*Server, error) {
 	server.eventSink = serverEventSink
 
 	// Prepare NostrServer
-	relayServer := relay.NewNostrServer(
+	relayServerOptions := []relay.NostrServerOption{
 		relay.NostrServerAddress(server.localAddress),
 		relay.NostrServerWithEventSource(eventSource),
 		relay.NostrServerWithEventSink(serverEventSink),
 		relay.NostrServerWithIdentity(dataStoreID),
-	)
+	}
+	if server.tailCacheSize > 0 {
+		relayServerOptions = append(relayServerOptions, relay.NostrServerWithTailCache(server.tailCacheSize))
+	}
+	if len(server.eventTypes) > 0 {
+		relayServerOptions = append(relayServerOptions, relay.NostrServerWithEventTypes(server.eventTypes))
+	}
+	if server.clockSkew > 0 {
+		relayServerOptions = append(relayServerOptions, relay.NostrServerWithClockSkew(server.clockSkew))
+	}
+	if len(server.signatureRootCerts) > 0 {
+		relayServerOptions = append(relayServerOptions, relay.NostrServerWithSignatureRootCerts(server.signatureRootCerts))
+	}
+	if server.tlsConfig != nil {
+		relayServerOptions = append(relayServerOptions, relay.NostrServerWithTLSConfig(server.tlsConfig))
+	}
+	relayServer := relay.NewNostrServer(relayServerOptions...)
 	server.relayServer = relayServer
 
 	return server, nil
 }
 
 func (s *Server) Run() error {
-	for peerAddress := range s.otherPeers {
-		peerAddress := peerAddress
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopBackgroundTasks = cancel
 
-		clientCallback := &ClientCallback{
-			server: s,
+	logrus.Infof("relay server instance %q of %q is starting.", s.instanceID, s.dataStoreID)
+	for peerAddress := range s.otherPeers {
+		if s.leaseStore == nil {
+			s.startPeerClient(peerAddress)
+			continue
 		}
+		s.runBackgroundTask(ctx, func(ctx context.Context) { s.peerLeaseTask(ctx, peerAddress) })
+	}
+
+	if s.eventNotifier != nil {
+		s.runBackgroundTask(ctx, s.listenEventsTask)
+	}
 
-		client := relay.NewNostrClient(
-			relay.NostrClientWithServerURL(peerAddress),
-			relay.NostrClientWithEventSink(clientCallback.EventSink),
-			relay.NostrClientWithConnectionStatusCallback(clientCallback.OnConnectionStatusChange),
-		)
-		clientCallback.client = client
-		s.otherPeers[peerAddress] = clientCallback
+	if s.archiver != nil {
+		s.runBackgroundTask(ctx, s.archiver.Run)
 	}
 
 	err := s.relayServer.ListenAndServe()
@@ -161,9 +262,99 @@ func (s *Server) Run() error {
 }
 
 func (s *Server) Close() error {
-	for _, clientCallback := range s.otherPeers {
-		defer clientCallback.client.Close()
+	if s.stopBackgroundTasks != nil {
+		s.stopBackgroundTasks()
+	}
+	s.backgroundTasks.Wait()
+
+	for peerAddress := range s.otherPeers {
+		defer s.stopPeerClient(peerAddress)
 	}
 
 	return s.relayServer.Close()
 }
+
+func (s *Server) runBackgroundTask(ctx context.Context, task func(ctx context.Context)) {
+	s.backgroundTasks.Add(1)
+	go func() {
+		defer s.backgroundTasks.Done()
+		task(ctx)
+	}()
+}
+
+func (s *Server) startPeerClient(peerAddress string) {
+	s.peerMux.Lock()
+	defer s.peerMux.Unlock()
+
+	if s.otherPeers[peerAddress] != nil {
+		return
+	}
+
+	clientCallback := &ClientCallback{
+		server: s,
+	}
+
+	client := relay.NewNostrClient(
+		relay.NostrClientWithServerURL(peerAddress),
+		relay.NostrClientWithEventSink(clientCallback.EventSink),
+		relay.NostrClientWithConnectionStatusCallback(clientCallback.OnConnectionStatusChange),
+		relay.NostrClientWithTLSConfig(s.tlsConfig),
+		relay.NostrClientWithRetryInterval(s.peerRetryInterval),
+	)
+	clientCallback.client = client
+	s.otherPeers[peerAddress] = clientCallback
+}
+
+func (s *Server) stopPeerClient(peerAddress string) {
+	s.peerMux.Lock()
+	defer s.peerMux.Unlock()
+
+	clientCallback := s.otherPeers[peerAddress]
+	if clientCallback == nil {
+		return
+	}
+	clientCallback.client.Close()
+	s.otherPeers[peerAddress] = nil
+}
+
+// peerLeaseTask replicates events from the peer only while this instance holds the lease of the peer.
+// The lease is renewed 3 times in its TTL. If the renewal fails, the replication stops until the lease is acquired again.
+func (s *Server) peerLeaseTask(ctx context.Context, peerAddress string) {
+	ticker := time.NewTicker(s.peerLeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		acquired, err := s.leaseStore.AcquirePeerLease(ctx, now.Unix(), peerAddress, s.instanceID, now.Add(s.peerLeaseTTL).Unix())
+		if err != nil && ctx.Err() == nil {
+			logrus.Errorf("failed to acquire the lease of peer %q: %v", peerAddress, err)
+		}
+		if acquired {
+			s.startPeerClient(peerAddress)
+		} else {
+			s.stopPeerClient(peerAddress)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.stopPeerClient(peerAddress)
+			if err := s.leaseStore.ReleasePeerLease(context.Background(), peerAddress, s.instanceID); err != nil {
+				logrus.Errorf("failed to release the lease of peer %q: %v", peerAddress, err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// listenEventsTask wakes up subscriptions when other instances sharing the data store receive new events.
+func (s *Server) listenEventsTask(ctx context.Context) {
+	for {
+		err := s.eventNotifier.ListenEvents(ctx, s.relayServer.NotifyNewEvents)
+		if ctx.Err() != nil {
+			return
+		}
+		logrus.Errorf("failed to listen to new events: %v", err)
+		relay.ShallowSleep(ctx, 5*time.Second, nil)
+	}
+}
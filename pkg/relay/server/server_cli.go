@@ -1,16 +1,26 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/alecthomas/kong"
 	formatter "github.com/bluexlab/logrus-formatter"
 	"github.com/gobuffalo/pop"
 	"github.com/gobuffalo/pop/logging"
 	"github.com/openebl/openebl/pkg/config"
+	"github.com/openebl/openebl/pkg/object_store"
+	"github.com/openebl/openebl/pkg/pkix"
+	"github.com/openebl/openebl/pkg/relay"
+	"github.com/openebl/openebl/pkg/relay/server/storage"
 	"github.com/openebl/openebl/pkg/relay/server/storage/postgres"
 	"github.com/openebl/openebl/pkg/util"
 	"github.com/sirupsen/logrus"
@@ -19,17 +29,64 @@ import (
 type RelayServerApp struct{}
 
 type RelayServerCli struct {
-	Server  struct{} `cmd help:"Run relay server."`
+	Server  struct{} `cmd:"" help:"Run relay server."`
 	Migrate struct {
 		Migrations string `type:"path" default:"migrations" help:"Path to migration folder."`
-	} `cmd help:"Migrate database."`
+	} `cmd:"" help:"Migrate database."`
+	Tail struct {
+		Server    string   `default:"ws://localhost:9001" help:"URL of the relay server."`
+		Offset    int64    `default:"0" help:"Offset to start from."`
+		Type      int      `default:"0" help:"Print only events of the type. 0 means all types."`
+		Recipient []string `help:"Print only events tagged with any of the recipients. Can be repeated."`
+	} `cmd:"" help:"Print events of a relay server as NDJSON."`
+	Publish struct {
+		Server  string        `default:"ws://localhost:9001" help:"URL of the relay server."`
+		Type    int           `required:"" help:"Type of the event."`
+		Tag     []string      `help:"Recipient tag of the event. Can be repeated."`
+		Timeout time.Duration `default:"30s" help:"Timeout of waiting for the relay server to accept the event."`
+		File    string        `arg:"" type:"existingfile" help:"Path to the file to be published as event data."`
+	} `cmd:"" help:"Publish the content of a file as an event."`
+	Export struct {
+		Output string `required:"" help:"Path to the archive file. Use - for stdout."`
+		Format string `enum:"ndjson,binary" default:"ndjson" help:"Format of the archive (ndjson, binary)."`
+	} `cmd:"" help:"Export the event log of the data store into an archive."`
+	Import struct {
+		Input string `arg:"" type:"existingfile" help:"Path to the archive file."`
+	} `cmd:"" help:"Import an archive into the data store. Event IDs are kept."`
+	Snapshot struct {
+		Create struct {
+			Output string `type:"path" required:"" help:"Path to the snapshot directory."`
+			Offset int64  `default:"0" help:"Include events up to the offset. Offsets of peers are left out if it is before the last event. 0 means all events."`
+		} `cmd:"" help:"Create a snapshot of the event log."`
+		Load struct {
+			Input string `arg:"" type:"existingdir" help:"Path to the snapshot directory."`
+		} `cmd:"" help:"Load a snapshot into a new data store and seed offsets of peers."`
+	} `cmd:"" help:"Create or load a snapshot for bootstrapping new relay servers."`
 	Config string `type:"path" default:"config.yaml" help:"Path to config file."`
 }
 
 type RelayServerConfig struct {
-	Database     util.PostgresDatabaseConfig `yaml:"database"`
-	LocalAddress string                      `yaml:"local_address"`
-	OtherPeers   []string                    `yaml:"other_peers"`
+	Database        util.PostgresDatabaseConfig `yaml:"database"`
+	LocalAddress    string                      `yaml:"local_address"`
+	OtherPeers      []string                    `yaml:"other_peers"`
+	TailCacheSize   int                         `yaml:"tail_cache_size"`   // Number of recent events kept in memory for subscriptions. 0 disables the cache.
+	Archive         *RelayServerArchiveConfig   `yaml:"archive"`           // Archive old events into an object store. nil disables the archive.
+	InstanceID      string                      `yaml:"instance_id"`       // ID of the instance among instances sharing the database. Random if empty.
+	TLS             *RelayServerTLSConfig       `yaml:"tls"`               // Serve wss:// and connect peers with the certificate. nil disables TLS.
+	EventTypes      []relay.EventTypePolicy     `yaml:"event_types"`       // Accepted event types. Empty accepts every event type from everyone.
+	ClockSkew       time.Duration               `yaml:"clock_skew"`        // Reject events created by publishers more than it away from now. 0 disables the check.
+	SignatureCAFile string                      `yaml:"signature_ca_file"` // CA certificates trusted to sign events, besides the system trusted certificates.
+}
+
+type RelayServerTLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	CAFile   string `yaml:"ca_file"` // CA certificates to verify client certificates and peer servers.
+}
+
+type RelayServerArchiveConfig struct {
+	EventArchiveConfig `yaml:",inline"`
+	ObjectStore        object_store.Config `yaml:"object_store"`
 }
 
 func (r *RelayServerApp) Run() error {
@@ -42,6 +99,18 @@ func (r *RelayServerApp) Run() error {
 		return r.runServer(cli)
 	case "migrate":
 		return r.runMigrate(cli)
+	case "tail":
+		return r.runTail(cli)
+	case "publish <file>":
+		return r.runPublish(cli)
+	case "export":
+		return r.runExport(cli)
+	case "import <input>":
+		return r.runImport(cli)
+	case "snapshot create":
+		return r.runCreateSnapshot(cli)
+	case "snapshot load <input>":
+		return r.runLoadSnapshot(cli)
 	}
 
 	return nil
@@ -60,11 +129,59 @@ func (r *RelayServerApp) runServer(cli RelayServerCli) error {
 		os.Exit(1)
 	}
 
-	relayServer, err := NewServer(
+	options := []ServerOption{
 		WithLocalAddress(cfg.LocalAddress),
 		WithPeers(cfg.OtherPeers),
 		WithStorage(eventStorage),
-	)
+		WithTailCacheSize(cfg.TailCacheSize),
+		WithClockSkew(cfg.ClockSkew),
+	}
+	if cfg.InstanceID != "" {
+		options = append(options, WithInstanceID(cfg.InstanceID))
+	}
+	if len(cfg.EventTypes) > 0 {
+		registry, err := relay.NewEventTypeRegistry(cfg.EventTypes...)
+		if err != nil {
+			logrus.Errorf("invalid event types: %v", err)
+			os.Exit(1)
+		}
+		options = append(options, WithEventTypes(registry))
+	}
+	if cfg.SignatureCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.SignatureCAFile)
+		if err != nil {
+			logrus.Errorf("failed to load signature CA file: %v", err)
+			os.Exit(1)
+		}
+		certs, err := pkix.ParseCertificate(caPEM)
+		if err != nil {
+			logrus.Errorf("failed to parse signature CA file: %v", err)
+			os.Exit(1)
+		}
+		rootCerts := make([]*x509.Certificate, 0, len(certs))
+		for i := range certs {
+			rootCerts = append(rootCerts, &certs[i])
+		}
+		options = append(options, WithSignatureRootCerts(rootCerts))
+	}
+	if cfg.TLS != nil {
+		tlsConfig, err := r.tlsConfig(*cfg.TLS)
+		if err != nil {
+			logrus.Errorf("failed to load TLS config: %v", err)
+			os.Exit(1)
+		}
+		options = append(options, WithTLSConfig(tlsConfig))
+	}
+	if cfg.Archive != nil {
+		archiveStore, err := object_store.NewObjectStore(cfg.Archive.ObjectStore)
+		if err != nil {
+			logrus.Errorf("failed to create archive store: %v", err)
+			os.Exit(1)
+		}
+		options = append(options, WithEventArchive(archiveStore, cfg.Archive.EventArchiveConfig))
+	}
+
+	relayServer, err := NewServer(options...)
 	if err != nil {
 		logrus.Errorf("failed to create relay server: %v", err)
 		os.Exit(1)
@@ -125,6 +242,261 @@ func (r *RelayServerApp) runMigrate(cli RelayServerCli) error {
 	return nil
 }
 
+func (r *RelayServerApp) runTail(cli RelayServerCli) error {
+	// Resume after the last received event when the connection is reestablished.
+	var offset atomic.Int64
+	offset.Store(cli.Tail.Offset)
+	output, _ := NewEventArchiveWriter(os.Stdout, EventArchiveFormatNDJSON)
+	eventSink := func(ctx context.Context, event relay.Event) (string, error) {
+		storageEvent := toStorageEvent(event)
+		eventID := storageEvent.ID
+		if cli.Tail.Type != 0 && cli.Tail.Type != event.Type {
+			offset.Store(event.Offset + 1)
+			return eventID, nil
+		}
+
+		archivedEvent := ArchivedEvent{
+			ID:        eventID,
+			Timestamp: event.Timestamp,
+			Offset:    event.Offset,
+			Type:      event.Type,
+			Data:      event.Data,
+			Tags:      event.Tags,
+			CreatedAt: event.CreatedAt,
+			Signature: storageEvent.Signature,
+		}
+		if err := output.Write(archivedEvent); err != nil {
+			return "", err
+		}
+		offset.Store(event.Offset + 1)
+		return eventID, output.Flush()
+	}
+	client := relay.NewNostrClient(
+		relay.NostrClientWithServerURL(cli.Tail.Server),
+		relay.NostrClientWithEventSink(eventSink),
+		relay.NostrClientWithConnectionStatusCallback(
+			func(ctx context.Context, cancel context.CancelCauseFunc, client relay.RelayClient, serverIdentity string, status bool) {
+				if !status {
+					return
+				}
+				logrus.Infof("connected to relay server %q. subscribe from offset %d.", serverIdentity, offset.Load())
+				if err := client.Subscribe(ctx, offset.Load(), cli.Tail.Recipient...); err != nil {
+					logrus.Errorf("failed to subscribe: %v", err)
+					cancel(err)
+				}
+			},
+		),
+	)
+
+	r.waitForInterrupt()
+	client.Close()
+	return nil
+}
+
+func (r *RelayServerApp) runPublish(cli RelayServerCli) error {
+	data, err := os.ReadFile(cli.Publish.File)
+	if err != nil {
+		logrus.Errorf("failed to read file: %v", err)
+		os.Exit(1)
+	}
+
+	client := relay.NewNostrClient(
+		relay.NostrClientWithServerURL(cli.Publish.Server),
+		relay.NostrClientWithConnectionStatusCallback(
+			func(ctx context.Context, cancel context.CancelCauseFunc, client relay.RelayClient, serverIdentity string, status bool) {
+			},
+		),
+	)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cli.Publish.Timeout)
+	defer cancel()
+	if err := client.Publish(ctx, cli.Publish.Type, data, cli.Publish.Tag...); err != nil {
+		logrus.Errorf("failed to publish: %v", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(GetEventID(data))
+	return nil
+}
+
+func (r *RelayServerApp) runExport(cli RelayServerCli) error {
+	cfg := RelayServerConfig{}
+	if err := config.FromFile(cli.Config, &cfg); err != nil {
+		logrus.Errorf("failed to load config: %v", err)
+		os.Exit(1)
+	}
+
+	eventStorage, err := postgres.NewEventStorageWithConfig(cfg.Database)
+	if err != nil {
+		logrus.Errorf("failed to create event storage: %v", err)
+		os.Exit(1)
+	}
+	defer eventStorage.Close()
+
+	dataStore, err := r.archiveReadingDataStore(cfg, eventStorage)
+	if err != nil {
+		logrus.Errorf("failed to create archive store: %v", err)
+		os.Exit(1)
+	}
+
+	var output io.Writer = os.Stdout
+	if cli.Export.Output != "-" {
+		f, err := os.Create(cli.Export.Output)
+		if err != nil {
+			logrus.Errorf("failed to create archive file: %v", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		output = f
+	}
+
+	archiveWriter, err := NewEventArchiveWriter(output, EventArchiveFormat(cli.Export.Format))
+	if err != nil {
+		logrus.Errorf("failed to create archive writer: %v", err)
+		os.Exit(1)
+	}
+
+	total, err := ExportEvents(context.Background(), dataStore, archiveWriter)
+	if err != nil {
+		logrus.Errorf("failed to export events: %v", err)
+		os.Exit(1)
+	}
+
+	logrus.Infof("%d events exported.", total)
+	return nil
+}
+
+func (r *RelayServerApp) runImport(cli RelayServerCli) error {
+	cfg := RelayServerConfig{}
+	if err := config.FromFile(cli.Config, &cfg); err != nil {
+		logrus.Errorf("failed to load config: %v", err)
+		os.Exit(1)
+	}
+
+	eventStorage, err := postgres.NewEventStorageWithConfig(cfg.Database)
+	if err != nil {
+		logrus.Errorf("failed to create event storage: %v", err)
+		os.Exit(1)
+	}
+	defer eventStorage.Close()
+
+	f, err := os.Open(cli.Import.Input)
+	if err != nil {
+		logrus.Errorf("failed to open archive file: %v", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	archiveReader, err := NewEventArchiveReader(f)
+	if err != nil {
+		logrus.Errorf("failed to read archive file: %v", err)
+		os.Exit(1)
+	}
+
+	imported, skipped, err := ImportEvents(context.Background(), eventStorage, archiveReader)
+	if err != nil {
+		logrus.Errorf("failed to import events (%d imported, %d skipped): %v", imported, skipped, err)
+		os.Exit(1)
+	}
+
+	logrus.Infof("%d events imported. %d events already exist and are skipped.", imported, skipped)
+	return nil
+}
+
+func (r *RelayServerApp) runCreateSnapshot(cli RelayServerCli) error {
+	cfg := RelayServerConfig{}
+	if err := config.FromFile(cli.Config, &cfg); err != nil {
+		logrus.Errorf("failed to load config: %v", err)
+		os.Exit(1)
+	}
+
+	eventStorage, err := postgres.NewEventStorageWithConfig(cfg.Database)
+	if err != nil {
+		logrus.Errorf("failed to create event storage: %v", err)
+		os.Exit(1)
+	}
+	defer eventStorage.Close()
+
+	dataStore, err := r.archiveReadingDataStore(cfg, eventStorage)
+	if err != nil {
+		logrus.Errorf("failed to create archive store: %v", err)
+		os.Exit(1)
+	}
+
+	manifest, err := CreateSnapshot(context.Background(), dataStore, cli.Snapshot.Create.Output, cli.Snapshot.Create.Offset, time.Now().Unix())
+	if err != nil {
+		logrus.Errorf("failed to create snapshot: %v", err)
+		os.Exit(1)
+	}
+
+	logrus.Infof("snapshot of %d events up to offset %d is created (sha256: %s).", manifest.EventCount, manifest.UpToOffset, manifest.SHA256)
+	return nil
+}
+
+func (r *RelayServerApp) runLoadSnapshot(cli RelayServerCli) error {
+	cfg := RelayServerConfig{}
+	if err := config.FromFile(cli.Config, &cfg); err != nil {
+		logrus.Errorf("failed to load config: %v", err)
+		os.Exit(1)
+	}
+
+	eventStorage, err := postgres.NewEventStorageWithConfig(cfg.Database)
+	if err != nil {
+		logrus.Errorf("failed to create event storage: %v", err)
+		os.Exit(1)
+	}
+	defer eventStorage.Close()
+
+	manifest, err := LoadSnapshot(context.Background(), eventStorage, cli.Snapshot.Load.Input, time.Now().Unix())
+	if err != nil {
+		logrus.Errorf("failed to load snapshot: %v", err)
+		os.Exit(1)
+	}
+
+	logrus.Infof("snapshot of %q is loaded. %d events up to offset %d.", manifest.SourceIdentity, manifest.EventCount, manifest.UpToOffset)
+	return nil
+}
+
+// archiveReadingDataStore returns a data store which reads archived events from the archive store if the archive is enabled.
+func (r *RelayServerApp) archiveReadingDataStore(cfg RelayServerConfig, eventStorage *postgres.EventStorage) (storage.RelayServerDataStore, error) {
+	if cfg.Archive == nil {
+		return eventStorage, nil
+	}
+	archiveStore, err := object_store.NewObjectStore(cfg.Archive.ObjectStore)
+	if err != nil {
+		return nil, err
+	}
+	return NewArchiveReadingDataStore(eventStorage, archiveStore), nil
+}
+
+// tlsConfig identifies clients by verified certificates if they present one.
+// Clients without a certificate are still served as anonymous.
+func (r *RelayServerApp) tlsConfig(cfg RelayServerTLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificate in %q", cfg.CAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.RootCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
 func (r *RelayServerApp) waitForInterrupt() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -1,6 +1,14 @@
 package server
 
-import "github.com/openebl/openebl/pkg/relay/server/storage"
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+
+	"github.com/openebl/openebl/pkg/object_store"
+	"github.com/openebl/openebl/pkg/relay"
+	"github.com/openebl/openebl/pkg/relay/server/storage"
+)
 
 type ServerOption func(s *Server)
 
@@ -24,3 +32,70 @@ func WithPeers(peers []string) ServerOption {
 		}
 	}
 }
+
+func WithTailCacheSize(size int) ServerOption {
+	return func(s *Server) {
+		s.tailCacheSize = size
+	}
+}
+
+// WithEventArchive moves old events into the object store. The storage must implement storage.EventArchiveDataStore.
+func WithEventArchive(objectStore object_store.ObjectStore, config EventArchiveConfig) ServerOption {
+	return func(s *Server) {
+		s.archiveStore = objectStore
+		s.archiveConfig = config
+	}
+}
+
+// WithInstanceID sets the ID of the instance among relay server instances sharing the same data store.
+// A random one is used if it is not set.
+func WithInstanceID(instanceID string) ServerOption {
+	return func(s *Server) {
+		s.instanceID = instanceID
+	}
+}
+
+// WithPeerLeaseTTL sets how long an instance holds the lease of replicating events from a peer without renewing it.
+func WithPeerLeaseTTL(ttl time.Duration) ServerOption {
+	return func(s *Server) {
+		s.peerLeaseTTL = ttl
+	}
+}
+
+// WithEventTypes restricts publishing and subscribing to the event types in the registry.
+// Peers replicating from the server must be allowed to subscribe every event type.
+func WithEventTypes(registry relay.EventTypeRegistry) ServerOption {
+	return func(s *Server) {
+		s.eventTypes = registry
+	}
+}
+
+// WithTLSConfig serves clients with TLS and connects peers with the same config.
+// The certificate of the config identifies the server when it connects to peers.
+func WithTLSConfig(config *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.tlsConfig = config
+	}
+}
+
+// WithClockSkew rejects events published to the server whose created-at time is more than skew away from now.
+func WithClockSkew(skew time.Duration) ServerOption {
+	return func(s *Server) {
+		s.clockSkew = skew
+	}
+}
+
+// WithSignatureRootCerts trusts certificates issued by rootCerts, besides the system trusted certificates,
+// to sign events published to the server.
+func WithSignatureRootCerts(rootCerts []*x509.Certificate) ServerOption {
+	return func(s *Server) {
+		s.signatureRootCerts = rootCerts
+	}
+}
+
+// WithPeerRetryInterval sets how long the server waits before reconnecting to a peer after the connection is lost.
+func WithPeerRetryInterval(interval time.Duration) ServerOption {
+	return func(s *Server) {
+		s.peerRetryInterval = interval
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/sha512"
 	"encoding/hex"
+	"fmt"
+	"slices"
 	"sort"
 	"sync"
 	"testing"
@@ -32,16 +34,18 @@ func (s *ClientEventSink) Sink(ctx context.Context, event relay.Event) (string,
 
 // Mockup of storage.RelayServerDataStore
 type ServerDataStore struct {
-	mtx    sync.Mutex
-	ID     string
-	Events []storage.Event
-	Offset map[string]int64
+	mtx      sync.Mutex
+	ID       string
+	Events   []storage.Event
+	Offset   map[string]int64
+	Segments map[string]storage.ArchiveSegment
 }
 
 func NewServerDataStore(id string) *ServerDataStore {
 	return &ServerDataStore{
-		ID:     id,
-		Offset: make(map[string]int64),
+		ID:       id,
+		Offset:   make(map[string]int64),
+		Segments: make(map[string]storage.ArchiveSegment),
 	}
 }
 
@@ -51,10 +55,7 @@ func (s *ServerDataStore) GetIdentity(ctx context.Context) (string, error) {
 
 func (s *ServerDataStore) StoreEventWithOffsetInfo(
 	ctx context.Context,
-	ts int64,
-	eventID string,
-	eventType int,
-	event []byte,
+	event storage.Event,
 	offset int64,
 	peerId string,
 ) (int64, error) {
@@ -64,16 +65,10 @@ func (s *ServerDataStore) StoreEventWithOffsetInfo(
 	if _, dup := lo.Find(
 		s.Events,
 		func(e storage.Event) bool {
-			return e.ID == eventID
+			return e.ID == event.ID
 		},
 	); !dup {
-		event := storage.Event{
-			ID:        eventID,
-			Timestamp: ts,
-			Offset:    int64(len(s.Events)),
-			Type:      eventType,
-			Data:      event,
-		}
+		event.Offset = int64(len(s.Events))
 		s.Events = append(s.Events, event)
 	}
 
@@ -89,19 +84,24 @@ func (s *ServerDataStore) ListEvents(ctx context.Context, request storage.ListEv
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	if request.Offset >= int64(len(s.Events)) {
-		return storage.ListEventResult{}, nil
-	}
-	end := request.Offset + request.Limit
-	if end >= int64(len(s.Events)) {
-		end = int64(len(s.Events))
+	result := storage.ListEventResult{}
+	for _, event := range s.Events {
+		if int64(len(result.Events)) >= request.Limit {
+			break
+		}
+		if event.Offset < request.Offset {
+			continue
+		}
+		if request.EventType != 0 && event.Type != request.EventType {
+			continue
+		}
+		if len(request.Recipients) > 0 && len(lo.Intersect(event.Tags, request.Recipients)) == 0 {
+			continue
+		}
+		result.Events = append(result.Events, event)
+		result.MaxOffset = event.Offset
 	}
-
-	eventResult := s.Events[request.Offset:end]
-	return storage.ListEventResult{
-		Events:    eventResult,
-		MaxOffset: int64(eventResult[len(eventResult)-1].Offset),
-	}, nil
+	return result, nil
 }
 
 // StoreOffset stores the offset of the peer.
@@ -121,6 +121,64 @@ func (s *ServerDataStore) GetOffset(ctx context.Context, peerId string) (int64,
 	return s.Offset[peerId], nil
 }
 
+// ListOffsets returns the offsets of all peers.
+func (s *ServerDataStore) ListOffsets(ctx context.Context) (map[string]int64, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return lo.Assign(s.Offset), nil
+}
+
+func (s *ServerDataStore) ListEventsToArchive(ctx context.Context, limit int64) ([]storage.Event, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	events := lo.Filter(s.Events, func(e storage.Event, _ int) bool { return e.ArchiveSegmentID == "" })
+	if int64(len(events)) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (s *ServerDataStore) ArchiveEvents(ctx context.Context, segment storage.ArchiveSegment, eventIDs []string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.Segments[segment.ID] = segment
+	for i := range s.Events {
+		if lo.Contains(eventIDs, s.Events[i].ID) {
+			s.Events[i].Data = nil
+			s.Events[i].ArchiveSegmentID = segment.ID
+		}
+	}
+	return nil
+}
+
+func (s *ServerDataStore) GetArchiveSegment(ctx context.Context, id string) (storage.ArchiveSegment, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	segment, ok := s.Segments[id]
+	if !ok {
+		return storage.ArchiveSegment{}, storage.ErrArchiveSegmentNotFound
+	}
+	return segment, nil
+}
+
+// ReadSnapshot calls read with a copy of the data store.
+func (s *ServerDataStore) ReadSnapshot(ctx context.Context, read func(ctx context.Context, snapshot storage.RelayServerDataStore) error) error {
+	s.mtx.Lock()
+	snapshot := &ServerDataStore{
+		ID:       s.ID,
+		Events:   slices.Clone(s.Events),
+		Offset:   lo.Assign(s.Offset),
+		Segments: lo.Assign(s.Segments),
+	}
+	s.mtx.Unlock()
+
+	return read(ctx, snapshot)
+}
+
 func (s *ServerDataStore) GetEvents() []storage.Event {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
@@ -146,8 +204,8 @@ func (s *ServerTestSuite) TestClientServerServerClientInteraction() {
 	storage1 := NewServerDataStore("server1")
 	storage2 := NewServerDataStore("server2")
 
-	storage1.StoreEventWithOffsetInfo(ctx, 100, server.GetEventID([]byte("event on server1")), 1001, []byte("event on server1"), 0, "")
-	storage2.StoreEventWithOffsetInfo(ctx, 102, server.GetEventID([]byte("event on server2")), 1001, []byte("event on server2"), 0, "")
+	storage1.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: 100, ID: server.GetEventID([]byte("event on server1")), Type: 1001, Data: []byte("event on server1")}, 0, "")
+	storage2.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: 102, ID: server.GetEventID([]byte("event on server2")), Type: 1001, Data: []byte("event on server2")}, 0, "")
 
 	srv1, err := server.NewServer(
 		server.WithLocalAddress("localhost:9003"),
@@ -278,3 +336,123 @@ func TestServer(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+type peerLease struct {
+	holder    string
+	expiresAt int64
+}
+
+// Mockup of storage.RelayServerDataStore shared by multiple relay server instances.
+type SharedServerDataStore struct {
+	*ServerDataStore
+	leaseMtx sync.Mutex
+	leases   map[string]peerLease
+}
+
+func NewSharedServerDataStore(id string) *SharedServerDataStore {
+	return &SharedServerDataStore{
+		ServerDataStore: NewServerDataStore(id),
+		leases:          make(map[string]peerLease),
+	}
+}
+
+func (s *SharedServerDataStore) AcquirePeerLease(ctx context.Context, ts int64, peer string, holder string, expiresAt int64) (bool, error) {
+	s.leaseMtx.Lock()
+	defer s.leaseMtx.Unlock()
+
+	lease, ok := s.leases[peer]
+	if ok && lease.holder != holder && lease.expiresAt >= ts {
+		return false, nil
+	}
+	s.leases[peer] = peerLease{holder: holder, expiresAt: expiresAt}
+	return true, nil
+}
+
+func (s *SharedServerDataStore) ReleasePeerLease(ctx context.Context, peer string, holder string) error {
+	s.leaseMtx.Lock()
+	defer s.leaseMtx.Unlock()
+
+	if s.leases[peer].holder == holder {
+		delete(s.leases, peer)
+	}
+	return nil
+}
+
+func (s *SharedServerDataStore) LeaseHolder(peer string) string {
+	s.leaseMtx.Lock()
+	defer s.leaseMtx.Unlock()
+	return s.leases[peer].holder
+}
+
+func (s *ServerTestSuite) TestInstancesSharingDataStore() {
+	// The test case test with the configuration:
+	// [instance-a] ---+
+	//                 +---> [server3] <--> [client3]
+	// [instance-b] ---+
+	// instance-a and instance-b share the same data store. Only one of them replicates events from server3.
+	ctx := context.Background()
+	const server3Address = "ws://localhost:9007"
+
+	storage3 := NewServerDataStore("server3")
+	storage3.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: 100, ID: server.GetEventID([]byte("event on server3")), Type: 1001, Data: []byte("event on server3")}, 0, "")
+	sharedStorage := NewSharedServerDataStore("shared")
+
+	srv3, err := server.NewServer(
+		server.WithLocalAddress("localhost:9007"),
+		server.WithStorage(storage3),
+	)
+	s.Require().NoError(err)
+	instances := make(map[string]*server.Server)
+	for i, instanceID := range []string{"instance-a", "instance-b"} {
+		srv, err := server.NewServer(
+			server.WithLocalAddress(fmt.Sprintf("localhost:%d", 9005+i)),
+			server.WithStorage(sharedStorage),
+			server.WithPeers([]string{server3Address}),
+			server.WithInstanceID(instanceID),
+			server.WithPeerLeaseTTL(3*time.Second),
+		)
+		s.Require().NoError(err)
+		instances[instanceID] = srv
+	}
+
+	wg := sync.WaitGroup{}
+	for _, srv := range append(lo.Values(instances), srv3) {
+		srv := srv
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			srv.Run()
+		}()
+	}
+
+	client3 := relay.NewNostrClient(
+		relay.NostrClientWithServerURL(server3Address),
+		relay.NostrClientWithConnectionStatusCallback(
+			func(ctx context.Context, cancel context.CancelCauseFunc, client relay.RelayClient, remoteServerIdentity string, status bool) {
+			},
+		),
+	)
+	defer client3.Close()
+
+	time.Sleep(2 * time.Second)
+	holder := sharedStorage.LeaseHolder(server3Address)
+	s.Require().Contains(instances, holder)
+	s.Require().NoError(client3.Publish(ctx, 1001, []byte("first event from client3")))
+	time.Sleep(2 * time.Second)
+
+	// The other instance takes over the replication after the holder stops.
+	instances[holder].Close()
+	delete(instances, holder)
+	time.Sleep(2 * time.Second)
+	newHolder := sharedStorage.LeaseHolder(server3Address)
+	s.Require().Contains(instances, newHolder)
+	s.Require().NoError(client3.Publish(ctx, 1001, []byte("second event from client3")))
+	time.Sleep(2 * time.Second)
+
+	instances[newHolder].Close()
+	srv3.Close()
+	wg.Wait()
+
+	sharedEvents := lo.Map(sharedStorage.GetEvents(), func(evt storage.Event, _ int) string { return string(evt.Data) })
+	s.Assert().ElementsMatch([]string{"event on server3", "first event from client3", "second event from client3"}, sharedEvents)
+}
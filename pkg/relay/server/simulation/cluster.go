@@ -0,0 +1,381 @@
+// Package simulation runs relay servers in process and injects network faults between them.
+// It is used to test the replication of events among peers.
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openebl/openebl/pkg/relay"
+	"github.com/openebl/openebl/pkg/relay/server"
+	"github.com/openebl/openebl/pkg/relay/server/storage"
+	"github.com/openebl/openebl/pkg/relay/server/storage/memory"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultRetryInterval = 200 * time.Millisecond
+
+// Node is a relay server in the cluster. Its data store survives restarts.
+type Node struct {
+	Index     int
+	Address   string
+	DataStore *memory.EventStorage
+
+	peers  []string // URLs of links to other nodes.
+	server *server.Server
+	done   chan struct{}
+}
+
+// Cluster is a full mesh of relay servers. Every node replicates events from all other nodes through Links.
+type Cluster struct {
+	retryInterval time.Duration
+
+	mtx   sync.Mutex
+	nodes []*Node
+	links [][]*Link // links[from][to] carries events of node "to" to node "from".
+}
+
+type ClusterOption func(c *Cluster)
+
+// WithRetryInterval sets how long nodes wait before reconnecting to peers.
+func WithRetryInterval(interval time.Duration) ClusterOption {
+	return func(c *Cluster) {
+		c.retryInterval = interval
+	}
+}
+
+// NewCluster starts size relay servers on in-memory data stores.
+func NewCluster(size int, options ...ClusterOption) (*Cluster, error) {
+	cluster := &Cluster{
+		retryInterval: defaultRetryInterval,
+	}
+	for _, option := range options {
+		option(cluster)
+	}
+
+	for i := 0; i < size; i++ {
+		address, err := freeAddress()
+		if err != nil {
+			cluster.Close()
+			return nil, err
+		}
+		cluster.nodes = append(cluster.nodes, &Node{
+			Index:     i,
+			Address:   address,
+			DataStore: memory.NewEventStorage(fmt.Sprintf("node-%d", i)),
+		})
+	}
+
+	cluster.links = make([][]*Link, size)
+	for from := 0; from < size; from++ {
+		cluster.links[from] = make([]*Link, size)
+		for to := 0; to < size; to++ {
+			if from == to {
+				continue
+			}
+			link, err := NewLink("ws://"+cluster.nodes[to].Address, int64(from*size+to))
+			if err != nil {
+				cluster.Close()
+				return nil, err
+			}
+			cluster.links[from][to] = link
+			cluster.nodes[from].peers = append(cluster.nodes[from].peers, link.URL())
+		}
+	}
+
+	for i := range cluster.nodes {
+		if err := cluster.Start(i); err != nil {
+			cluster.Close()
+			return nil, err
+		}
+	}
+	return cluster, nil
+}
+
+func (c *Cluster) Size() int {
+	return len(c.nodes)
+}
+
+func (c *Cluster) Node(i int) *Node {
+	return c.nodes[i]
+}
+
+// Link returns the link which node "from" replicates events of node "to" through.
+func (c *Cluster) Link(from, to int) *Link {
+	return c.links[from][to]
+}
+
+// Links returns all links between nodes in the group and nodes out of the group in both directions.
+func (c *Cluster) Links(group ...int) []*Link {
+	in := make(map[int]bool)
+	for _, i := range group {
+		in[i] = true
+	}
+
+	var links []*Link
+	for from := range c.links {
+		for to, link := range c.links[from] {
+			if link != nil && in[from] != in[to] {
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+func (c *Cluster) allLinks() []*Link {
+	var links []*Link
+	for from := range c.links {
+		for _, link := range c.links[from] {
+			if link != nil {
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+// Partition separates the nodes in the group from the other nodes.
+func (c *Cluster) Partition(group ...int) {
+	for _, link := range c.Links(group...) {
+		link.Partition()
+	}
+}
+
+// Heal removes all partitions.
+func (c *Cluster) Heal() {
+	for _, link := range c.allLinks() {
+		link.Heal()
+	}
+}
+
+// SetDelay delays every message between nodes.
+func (c *Cluster) SetDelay(delay time.Duration) {
+	for _, link := range c.allLinks() {
+		link.SetDelay(delay)
+	}
+}
+
+// SetDropRate sets the probability of losing a message (and the connection carrying it) between nodes.
+func (c *Cluster) SetDropRate(rate float64) {
+	for _, link := range c.allLinks() {
+		link.SetDropRate(rate)
+	}
+}
+
+// Start starts the relay server of the node if it is not running.
+func (c *Cluster) Start(i int) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	node := c.nodes[i]
+	if node.server != nil {
+		return nil
+	}
+
+	srv, err := server.NewServer(
+		server.WithLocalAddress(node.Address),
+		server.WithPeers(node.peers),
+		server.WithStorage(node.DataStore),
+		server.WithPeerRetryInterval(c.retryInterval),
+	)
+	if err != nil {
+		return err
+	}
+	node.server = srv
+	node.done = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		if err := srv.Run(); err != nil {
+			logrus.Errorf("node %d stopped: %v", i, err)
+		}
+	}(node.done)
+
+	return waitForListening(node.Address, 5*time.Second)
+}
+
+// Stop stops the relay server of the node. Connections to and from the node are broken.
+func (c *Cluster) Stop(i int) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	node := c.nodes[i]
+	if node.server == nil {
+		return nil
+	}
+
+	err := node.server.Close()
+	<-node.done
+	node.server = nil
+	// Connections accepted by the relay server outlive it, so break them explicitly.
+	for _, link := range c.Links(i) {
+		link.Reset()
+	}
+	return err
+}
+
+// Restart stops and starts the relay server of the node.
+func (c *Cluster) Restart(i int) error {
+	if err := c.Stop(i); err != nil {
+		return err
+	}
+	return c.Start(i)
+}
+
+// Publish publishes an event to the node like a client of the relay server.
+func (c *Cluster) Publish(ctx context.Context, i int, evtType int, data []byte, tags ...string) error {
+	client := relay.NewNostrClient(
+		relay.NostrClientWithServerURL("ws://"+c.nodes[i].Address),
+		relay.NostrClientWithRetryInterval(c.retryInterval),
+		relay.NostrClientWithConnectionStatusCallback(
+			func(ctx context.Context, cancel context.CancelCauseFunc, client relay.RelayClient, serverIdentity string, status bool) {
+			},
+		),
+	)
+	defer client.Close()
+
+	return client.Publish(ctx, evtType, data, tags...)
+}
+
+// EventIDs returns IDs of all events in the data store of the node in the order of their offsets.
+func (c *Cluster) EventIDs(i int) ([]string, error) {
+	var ids []string
+	request := storage.ListEventRequest{Limit: 1000}
+	for {
+		result, err := c.nodes[i].DataStore.ListEvents(context.Background(), request)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Events) == 0 {
+			return ids, nil
+		}
+		for _, event := range result.Events {
+			ids = append(ids, event.ID)
+		}
+		request.Offset = result.MaxOffset + 1
+	}
+}
+
+// CheckInvariants checks every node has no duplicated events and offsets of its events are strictly increasing.
+func (c *Cluster) CheckInvariants() error {
+	var errs []error
+	for i, node := range c.nodes {
+		result, err := node.DataStore.ListEvents(context.Background(), storage.ListEventRequest{Limit: 1 << 30})
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool)
+		var lastOffset int64
+		for _, event := range result.Events {
+			if seen[event.ID] {
+				errs = append(errs, fmt.Errorf("node %d: duplicated event %q", i, event.ID))
+			}
+			seen[event.ID] = true
+			if event.Offset <= lastOffset {
+				errs = append(errs, fmt.Errorf("node %d: offset %d of event %q is not after %d", i, event.Offset, event.ID, lastOffset))
+			}
+			lastOffset = event.Offset
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WaitForConvergence waits until every node has the same set of events and the set contains all expected event IDs.
+// It returns the difference among nodes if they don't converge before ctx is done.
+func (c *Cluster) WaitForConvergence(ctx context.Context, expected ...string) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		diff, err := c.difference(expected)
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("not converged: %s", diff)
+		case <-ticker.C:
+		}
+	}
+}
+
+// difference describes events missing on each node. It returns an empty string if all nodes converge.
+func (c *Cluster) difference(expected []string) (string, error) {
+	all := make(map[string]bool)
+	for _, id := range expected {
+		all[id] = true
+	}
+	sets := make([]map[string]bool, len(c.nodes))
+	for i := range c.nodes {
+		ids, err := c.EventIDs(i)
+		if err != nil {
+			return "", err
+		}
+		sets[i] = make(map[string]bool, len(ids))
+		for _, id := range ids {
+			sets[i][id] = true
+			all[id] = true
+		}
+	}
+
+	var descriptions []string
+	for i, set := range sets {
+		var missing []string
+		for id := range all {
+			if !set[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			descriptions = append(descriptions, fmt.Sprintf("node %d misses %d events %v", i, len(missing), missing))
+		}
+	}
+	return strings.Join(descriptions, "; "), nil
+}
+
+// Close stops all nodes and links.
+func (c *Cluster) Close() {
+	for i, node := range c.nodes {
+		if node.server != nil {
+			c.Stop(i)
+		}
+	}
+	for _, link := range c.allLinks() {
+		link.Close()
+	}
+}
+
+func freeAddress() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	return listener.Addr().String(), nil
+}
+
+func waitForListening(address string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", address, 100*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("relay server at %q is not listening: %w", address, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
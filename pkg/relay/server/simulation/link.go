@@ -0,0 +1,208 @@
+package simulation
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// Link is a websocket proxy between a relay server and one of its peers.
+// The relay server connects to the proxy instead of the peer, so faults can be injected into the connection.
+type Link struct {
+	target     string // URL of the peer.
+	listener   net.Listener
+	httpServer *http.Server
+	wsUpgrader websocket.Upgrader
+
+	mtx         sync.Mutex
+	partitioned bool
+	delay       time.Duration
+	dropRate    float64
+	rand        *rand.Rand
+	conns       map[*websocket.Conn]struct{}
+	forwarded   int64
+	dropped     int64
+	connections int64
+}
+
+func NewLink(target string, seed int64) (*Link, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	link := &Link{
+		target:   target,
+		listener: listener,
+		rand:     rand.New(rand.NewSource(seed)),
+		conns:    make(map[*websocket.Conn]struct{}),
+	}
+	link.httpServer = &http.Server{Handler: http.HandlerFunc(link.serve)}
+	go link.httpServer.Serve(listener)
+	return link, nil
+}
+
+// URL returns the URL the relay server connects to instead of the peer.
+func (l *Link) URL() string {
+	return "ws://" + l.listener.Addr().String()
+}
+
+// Partition breaks the connection and rejects new connections until Heal is called.
+func (l *Link) Partition() {
+	l.mtx.Lock()
+	l.partitioned = true
+	l.mtx.Unlock()
+	l.Reset()
+}
+
+func (l *Link) Heal() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.partitioned = false
+}
+
+// SetDelay delays every message in both directions. The order of messages is kept.
+func (l *Link) SetDelay(delay time.Duration) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.delay = delay
+}
+
+// SetDropRate sets the probability of losing a message. Websocket runs on TCP, so a lost message comes with
+// a broken connection. The link is reset when a message is dropped.
+func (l *Link) SetDropRate(rate float64) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.dropRate = rate
+}
+
+// Reset closes the connections through the link. The relay server reconnects later if the link is not partitioned.
+func (l *Link) Reset() {
+	l.mtx.Lock()
+	conns := make([]*websocket.Conn, 0, len(l.conns))
+	for conn := range l.conns {
+		conns = append(conns, conn)
+	}
+	l.mtx.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// Connections returns the number of connections made through the link.
+func (l *Link) Connections() int64 {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.connections
+}
+
+// Stats returns the number of forwarded and dropped messages.
+func (l *Link) Stats() (forwarded, dropped int64) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.forwarded, l.dropped
+}
+
+func (l *Link) Close() error {
+	err := l.httpServer.Close()
+	l.Reset()
+	return err
+}
+
+func (l *Link) serve(w http.ResponseWriter, r *http.Request) {
+	l.mtx.Lock()
+	partitioned := l.partitioned
+	l.mtx.Unlock()
+	if partitioned {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	peerConn, _, err := websocket.DefaultDialer.DialContext(r.Context(), l.target, nil)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	clientConn, err := l.wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		peerConn.Close()
+		return
+	}
+	l.addConn(clientConn, peerConn)
+	l.mtx.Lock()
+	l.connections++
+	l.mtx.Unlock()
+
+	done := make(chan struct{}, 2)
+	go l.pump(peerConn, clientConn, done)
+	go l.pump(clientConn, peerConn, done)
+	<-done
+
+	clientConn.Close()
+	peerConn.Close()
+	<-done
+	l.removeConn(clientConn, peerConn)
+}
+
+// pump forwards messages from src to dst until any of them is closed or a message is dropped.
+func (l *Link) pump(src, dst *websocket.Conn, done chan<- struct{}) {
+	defer func() { done <- struct{}{} }()
+
+	for {
+		messageType, message, err := src.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		delay, err := l.fault()
+		if err != nil {
+			logrus.Debugf("link to %q: %v", l.target, err)
+			return
+		}
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		if err := dst.WriteMessage(messageType, message); err != nil {
+			return
+		}
+	}
+}
+
+// fault decides what happens to the next message.
+func (l *Link) fault() (time.Duration, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.partitioned {
+		return 0, errors.New("partitioned")
+	}
+	if l.dropRate > 0 && l.rand.Float64() < l.dropRate {
+		l.dropped++
+		return 0, errors.New("message dropped")
+	}
+	l.forwarded++
+	return l.delay, nil
+}
+
+func (l *Link) addConn(conns ...*websocket.Conn) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	for _, conn := range conns {
+		l.conns[conn] = struct{}{}
+	}
+}
+
+func (l *Link) removeConn(conns ...*websocket.Conn) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	for _, conn := range conns {
+		delete(l.conns, conn)
+	}
+}
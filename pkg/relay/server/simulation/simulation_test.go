@@ -0,0 +1,162 @@
+package simulation_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebl/openebl/pkg/relay/server"
+	"github.com/openebl/openebl/pkg/relay/server/simulation"
+	"github.com/stretchr/testify/suite"
+)
+
+type SimulationTestSuite struct {
+	suite.Suite
+
+	ctx     context.Context
+	cluster *simulation.Cluster
+	count   int
+}
+
+func TestSimulation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip simulation in short mode")
+	}
+	suite.Run(t, new(SimulationTestSuite))
+}
+
+func (s *SimulationTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	cluster, err := simulation.NewCluster(3)
+	s.Require().NoError(err)
+	s.cluster = cluster
+}
+
+func (s *SimulationTestSuite) TearDownTest() {
+	s.cluster.Close()
+}
+
+// publish publishes n events to the node and returns their IDs.
+func (s *SimulationTestSuite) publish(node int, n int) []string {
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		s.count++
+		data := []byte(fmt.Sprintf("event %d from node %d", s.count, node))
+		ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+		err := s.cluster.Publish(ctx, node, 1001, data)
+		cancel()
+		s.Require().NoError(err)
+		ids = append(ids, server.GetEventID(data))
+	}
+	return ids
+}
+
+func (s *SimulationTestSuite) requireConvergence(timeout time.Duration, expected []string) {
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+	s.Require().NoError(s.cluster.WaitForConvergence(ctx, expected...))
+	s.Require().NoError(s.cluster.CheckInvariants())
+}
+
+func (s *SimulationTestSuite) TestConvergence() {
+	var expected []string
+	for node := 0; node < s.cluster.Size(); node++ {
+		expected = append(expected, s.publish(node, 5)...)
+	}
+	s.requireConvergence(10*time.Second, expected)
+}
+
+func (s *SimulationTestSuite) TestPartitionAndHeal() {
+	s.cluster.Partition(0)
+
+	expected := s.publish(0, 5)
+	expected = append(expected, s.publish(1, 5)...)
+	expected = append(expected, s.publish(2, 5)...)
+
+	// The majority side converges without node 0.
+	s.Require().Eventually(func() bool {
+		ids1, _ := s.cluster.EventIDs(1)
+		ids2, _ := s.cluster.EventIDs(2)
+		return len(ids1) == 10 && len(ids2) == 10
+	}, 10*time.Second, 100*time.Millisecond)
+	ids, err := s.cluster.EventIDs(0)
+	s.Require().NoError(err)
+	s.Assert().Len(ids, 5)
+
+	s.cluster.Heal()
+	s.requireConvergence(10*time.Second, expected)
+}
+
+func (s *SimulationTestSuite) TestSplitBrain() {
+	// Every node is isolated and keeps accepting events.
+	s.cluster.Partition(0)
+	s.cluster.Partition(1)
+
+	var expected []string
+	for node := 0; node < s.cluster.Size(); node++ {
+		expected = append(expected, s.publish(node, 10)...)
+	}
+
+	s.cluster.Heal()
+	s.requireConvergence(10*time.Second, expected)
+}
+
+func (s *SimulationTestSuite) TestDelayAndDrops() {
+	s.cluster.SetDelay(20 * time.Millisecond)
+	s.cluster.SetDropRate(0.05)
+
+	var expected []string
+	for node := 0; node < s.cluster.Size(); node++ {
+		expected = append(expected, s.publish(node, 20)...)
+	}
+
+	// Dropped messages break connections. Nodes have to resume from their offsets without losing events.
+	time.Sleep(2 * time.Second)
+	s.cluster.SetDropRate(0)
+	s.requireConvergence(20*time.Second, expected)
+
+	dropped := int64(0)
+	for from := 0; from < s.cluster.Size(); from++ {
+		for to := 0; to < s.cluster.Size(); to++ {
+			if from != to {
+				_, d := s.cluster.Link(from, to).Stats()
+				dropped += d
+			}
+		}
+	}
+	s.Assert().NotZero(dropped)
+}
+
+func (s *SimulationTestSuite) TestCatchUpThroughSlowLink() {
+	s.cluster.Partition(1)
+	expected := s.publish(0, 300)
+	s.Require().Equal(int64(1), s.cluster.Link(1, 0).Connections())
+
+	// Node 1 pulls the backlog from node 0 faster than the slow link forwards it. It has to catch up over one
+	// connection instead of being dropped whenever its output queue is full.
+	s.cluster.SetDelay(time.Millisecond)
+	s.cluster.Heal()
+	s.requireConvergence(20*time.Second, expected)
+	s.Assert().Equal(int64(2), s.cluster.Link(1, 0).Connections())
+}
+
+func (s *SimulationTestSuite) TestRestart() {
+	expected := s.publish(0, 5)
+	s.requireConvergence(10*time.Second, expected)
+
+	// Events published while node 1 is down are replicated after it comes back.
+	s.Require().NoError(s.cluster.Stop(1))
+	expected = append(expected, s.publish(0, 5)...)
+	expected = append(expected, s.publish(2, 5)...)
+	s.Require().NoError(s.cluster.Start(1))
+	expected = append(expected, s.publish(1, 5)...)
+	s.requireConvergence(10*time.Second, expected)
+
+	// Restarting repeatedly doesn't duplicate or lose events.
+	for i := 0; i < 3; i++ {
+		s.Require().NoError(s.cluster.Restart(i % s.cluster.Size()))
+		expected = append(expected, s.publish((i+1)%s.cluster.Size(), 3)...)
+	}
+	s.requireConvergence(10*time.Second, expected)
+}
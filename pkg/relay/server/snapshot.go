@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/openebl/openebl/pkg/relay/server/storage"
+)
+
+const (
+	snapshotManifestFile = "manifest.json"
+	snapshotEventsFile   = "events.bin"
+	snapshotVersion      = 1
+)
+
+// SnapshotManifest describes a snapshot of the event log of a relay server.
+// A snapshot is a directory with the manifest and the events file in binary archive format.
+type SnapshotManifest struct {
+	Version        int              `json:"version"`
+	SourceIdentity string           `json:"source_identity"` // Identity of the relay server which produced the snapshot.
+	CreatedAt      int64            `json:"created_at"`      // Unix Time (in second) when the snapshot was created.
+	UpToOffset     int64            `json:"up_to_offset"`    // All events with offset not greater than it (in the source relay server) are in the snapshot.
+	EventCount     int64            `json:"event_count"`     // Number of events in the snapshot.
+	PeerOffsets    map[string]int64 `json:"peer_offsets"`    // Offsets of the peers of the source relay server. Empty if the snapshot is not taken at the latest event.
+	EventsFile     string           `json:"events_file"`     // File name of the events file.
+	SHA256         string           `json:"sha256"`          // Hex encoded SHA256 of the events file.
+}
+
+// CreateSnapshot writes a snapshot of the event log of dataStore into dir. The data store has to be a
+// storage.SnapshotDataStore, so events and offsets of peers are read at a single point in time.
+// Every event recorded by those offsets is in the snapshot, so the new node can continue pulling from those peers
+// without missing anything.
+//
+// Only events with offset not greater than upToOffset are included. If upToOffset is 0, all events in the data store
+// are included. Offsets of peers are only known for the latest state of the event log, so they are left out of the
+// snapshot if there are events after upToOffset. The new node then pulls those peers from the start, and events it
+// already has are skipped.
+func CreateSnapshot(ctx context.Context, dataStore storage.RelayServerDataStore, dir string, upToOffset int64, ts int64) (SnapshotManifest, error) {
+	snapshotDataStore, ok := dataStore.(storage.SnapshotDataStore)
+	if !ok {
+		return SnapshotManifest{}, errors.New("the data store can't read a snapshot")
+	}
+
+	var manifest SnapshotManifest
+	err := snapshotDataStore.ReadSnapshot(ctx, func(ctx context.Context, snapshot storage.RelayServerDataStore) error {
+		var err error
+		manifest, err = createSnapshot(ctx, snapshot, dir, upToOffset, ts)
+		return err
+	})
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	return manifest, nil
+}
+
+func createSnapshot(ctx context.Context, dataStore storage.RelayServerDataStore, dir string, upToOffset int64, ts int64) (SnapshotManifest, error) {
+	identity, err := dataStore.GetIdentity(ctx)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+
+	peerOffsets, err := dataStore.ListOffsets(ctx)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+
+	if upToOffset != 0 {
+		result, err := dataStore.ListEvents(ctx, storage.ListEventRequest{Offset: upToOffset + 1, Limit: 1})
+		if err != nil {
+			return SnapshotManifest{}, err
+		}
+		if len(result.Events) > 0 {
+			peerOffsets = nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return SnapshotManifest{}, err
+	}
+	// Remove the old manifest first. The snapshot is valid only when the manifest exists.
+	if err := os.Remove(filepath.Join(dir, snapshotManifestFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return SnapshotManifest{}, err
+	}
+
+	eventsFile, err := os.Create(filepath.Join(dir, snapshotEventsFile))
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	defer eventsFile.Close()
+
+	hash := sha256.New()
+	w, _ := NewEventArchiveWriter(io.MultiWriter(eventsFile, hash), EventArchiveFormatBinary)
+	eventCount, maxOffset, err := exportEvents(ctx, dataStore, w, upToOffset)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	if err := eventsFile.Sync(); err != nil {
+		return SnapshotManifest{}, err
+	}
+	manifest := SnapshotManifest{
+		Version:        snapshotVersion,
+		SourceIdentity: identity,
+		CreatedAt:      ts,
+		UpToOffset:     maxOffset,
+		EventCount:     eventCount,
+		PeerOffsets:    peerOffsets,
+		EventsFile:     snapshotEventsFile,
+		SHA256:         hex.EncodeToString(hash.Sum(nil)),
+	}
+	manifestRaw, _ := json.MarshalIndent(manifest, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, snapshotManifestFile), manifestRaw, 0o644); err != nil {
+		return SnapshotManifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// LoadSnapshot verifies the snapshot in dir and loads it into dataStore.
+//
+// After the events are imported, the offset of the source relay server is set to UpToOffset and the offsets of
+// other peers are seeded with the ones in the snapshot. The node can subscribe to the source relay server and
+// other peers from there instead of replaying the whole history.
+func LoadSnapshot(ctx context.Context, dataStore storage.RelayServerDataStore, dir string, ts int64) (SnapshotManifest, error) {
+	manifestRaw, err := os.ReadFile(filepath.Join(dir, snapshotManifestFile))
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	manifest := SnapshotManifest{}
+	if err := json.Unmarshal(manifestRaw, &manifest); err != nil {
+		return SnapshotManifest{}, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.Version != snapshotVersion {
+		return SnapshotManifest{}, fmt.Errorf("unsupported snapshot version %d", manifest.Version)
+	}
+	if manifest.EventsFile != filepath.Base(manifest.EventsFile) {
+		return SnapshotManifest{}, fmt.Errorf("invalid events file %q", manifest.EventsFile)
+	}
+
+	identity, err := dataStore.GetIdentity(ctx)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	if identity == manifest.SourceIdentity {
+		return SnapshotManifest{}, errors.New("the snapshot is produced by the same data store")
+	}
+
+	eventsFilePath := filepath.Join(dir, manifest.EventsFile)
+	if err := verifySnapshotEvents(eventsFilePath, manifest.SHA256); err != nil {
+		return SnapshotManifest{}, err
+	}
+
+	eventsFile, err := os.Open(eventsFilePath)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	defer eventsFile.Close()
+
+	r, err := NewEventArchiveReader(eventsFile)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	imported, skipped, err := ImportEvents(ctx, dataStore, r)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	if imported+skipped != manifest.EventCount {
+		return SnapshotManifest{}, fmt.Errorf("the snapshot should have %d events, but %d events are loaded", manifest.EventCount, imported+skipped)
+	}
+
+	peerOffsets := make(map[string]int64, len(manifest.PeerOffsets)+1)
+	for peer, offset := range manifest.PeerOffsets {
+		if peer == identity {
+			continue
+		}
+		peerOffsets[peer] = offset
+	}
+	peerOffsets[manifest.SourceIdentity] = manifest.UpToOffset
+
+	for peer, offset := range peerOffsets {
+		oldOffset, err := dataStore.GetOffset(ctx, peer)
+		if err != nil {
+			return SnapshotManifest{}, err
+		}
+		if oldOffset >= offset {
+			continue
+		}
+		if err := dataStore.StoreOffset(ctx, ts, peer, offset); err != nil {
+			return SnapshotManifest{}, err
+		}
+	}
+
+	return manifest, nil
+}
+
+func verifySnapshotEvents(path string, expectedHash string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != expectedHash {
+		return errors.New("the events file doesn't match the hash in the manifest")
+	}
+	return nil
+}
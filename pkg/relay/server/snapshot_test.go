@@ -0,0 +1,162 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openebl/openebl/pkg/relay/server"
+	"github.com/openebl/openebl/pkg/relay/server/storage"
+	"github.com/stretchr/testify/suite"
+)
+
+type SnapshotTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	source *ServerDataStore
+}
+
+func TestSnapshot(t *testing.T) {
+	suite.Run(t, new(SnapshotTestSuite))
+}
+
+func (s *SnapshotTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.source = NewServerDataStore("source")
+	for i := 0; i < 300; i++ {
+		data := []byte(fmt.Sprintf("event %d", i))
+		_, err := s.source.StoreEventWithOffsetInfo(s.ctx, storage.Event{Timestamp: int64(1000 + i), ID: server.GetEventID(data), Type: 1001, Data: data}, int64(i+10), "peer1")
+		s.Require().NoError(err)
+	}
+	s.Require().NoError(s.source.StoreOffset(s.ctx, 1000, "peer2", 77))
+	s.Require().NoError(s.source.StoreOffset(s.ctx, 1000, "target", 5))
+}
+
+func (s *SnapshotTestSuite) TestCreateAndLoadSnapshot() {
+	dir := s.T().TempDir()
+
+	manifest, err := server.CreateSnapshot(s.ctx, s.source, dir, 0, 2000)
+	s.Require().NoError(err)
+	s.Assert().Equal("source", manifest.SourceIdentity)
+	s.Assert().EqualValues(299, manifest.UpToOffset)
+	s.Assert().EqualValues(300, manifest.EventCount)
+	s.Assert().Equal(map[string]int64{"peer1": 309, "peer2": 77, "target": 5}, manifest.PeerOffsets)
+
+	target := NewServerDataStore("target")
+	s.Require().NoError(target.StoreOffset(s.ctx, 1000, "peer2", 100))
+	loaded, err := server.LoadSnapshot(s.ctx, target, dir, 3000)
+	s.Require().NoError(err)
+	s.Assert().Equal(manifest, loaded)
+
+	events := target.GetEvents()
+	s.Require().Len(events, 300)
+	s.Assert().Equal(s.source.GetEvents(), events)
+
+	offsets, err := target.ListOffsets(s.ctx)
+	s.Require().NoError(err)
+	s.Assert().Equal(
+		map[string]int64{
+			"source": 299, // Continue from the end of the snapshot.
+			"peer1":  309,
+			"peer2":  100, // Keep the offset which is newer than the snapshot.
+		},
+		offsets,
+	)
+}
+
+func (s *SnapshotTestSuite) TestCreateSnapshotOfAllEvents() {
+	dir := s.T().TempDir()
+
+	manifest, err := server.CreateSnapshot(s.ctx, s.source, dir, 0, 2000)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(299, manifest.UpToOffset)
+	s.Assert().EqualValues(300, manifest.EventCount)
+}
+
+func (s *SnapshotTestSuite) TestCreateSnapshotUpToOffset() {
+	dir := s.T().TempDir()
+
+	// Offsets of peers are only known for the latest event, so they are left out.
+	manifest, err := server.CreateSnapshot(s.ctx, s.source, dir, 199, 2000)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(199, manifest.UpToOffset)
+	s.Assert().EqualValues(200, manifest.EventCount)
+	s.Assert().Empty(manifest.PeerOffsets)
+
+	target := NewServerDataStore("target")
+	_, err = server.LoadSnapshot(s.ctx, target, dir, 3000)
+	s.Require().NoError(err)
+	s.Assert().Equal(s.source.GetEvents()[:200], target.GetEvents())
+	offsets, err := target.ListOffsets(s.ctx)
+	s.Require().NoError(err)
+	s.Assert().Equal(map[string]int64{"source": 199}, offsets)
+
+	manifest, err = server.CreateSnapshot(s.ctx, s.source, dir, 299, 2000)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(299, manifest.UpToOffset)
+	s.Assert().EqualValues(300, manifest.EventCount)
+	s.Assert().Equal(map[string]int64{"peer1": 309, "peer2": 77, "target": 5}, manifest.PeerOffsets)
+
+	// The snapshot ends at the last event.
+	manifest, err = server.CreateSnapshot(s.ctx, s.source, dir, 500, 2000)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(299, manifest.UpToOffset)
+	s.Assert().EqualValues(300, manifest.EventCount)
+}
+
+// writingDataStore stores an event while a snapshot is being read.
+type writingDataStore struct {
+	*ServerDataStore
+}
+
+func (s writingDataStore) ReadSnapshot(ctx context.Context, read func(ctx context.Context, snapshot storage.RelayServerDataStore) error) error {
+	return s.ServerDataStore.ReadSnapshot(ctx, func(ctx context.Context, snapshot storage.RelayServerDataStore) error {
+		data := []byte("event written during the snapshot")
+		if _, err := s.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: 1500, ID: server.GetEventID(data), Type: 1001, Data: data}, 310, "peer1"); err != nil {
+			return err
+		}
+		return read(ctx, snapshot)
+	})
+}
+
+func (s *SnapshotTestSuite) TestCreateSnapshotWhileWriting() {
+	dir := s.T().TempDir()
+
+	// Neither the event nor the offset of peer1 written after the snapshot started are in the snapshot.
+	manifest, err := server.CreateSnapshot(s.ctx, writingDataStore{s.source}, dir, 0, 2000)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(299, manifest.UpToOffset)
+	s.Assert().EqualValues(300, manifest.EventCount)
+	s.Assert().Equal(map[string]int64{"peer1": 309, "peer2": 77, "target": 5}, manifest.PeerOffsets)
+	s.Require().Len(s.source.GetEvents(), 301)
+}
+
+func (s *SnapshotTestSuite) TestLoadTamperedSnapshot() {
+	dir := s.T().TempDir()
+
+	_, err := server.CreateSnapshot(s.ctx, s.source, dir, 0, 2000)
+	s.Require().NoError(err)
+
+	eventsFile := filepath.Join(dir, "events.bin")
+	raw, err := os.ReadFile(eventsFile)
+	s.Require().NoError(err)
+	raw[len(raw)-1] ^= 0xFF
+	s.Require().NoError(os.WriteFile(eventsFile, raw, 0o644))
+
+	target := NewServerDataStore("target")
+	_, err = server.LoadSnapshot(s.ctx, target, dir, 3000)
+	s.Require().Error(err)
+	s.Assert().Empty(target.GetEvents())
+}
+
+func (s *SnapshotTestSuite) TestLoadSnapshotIntoSourceDataStore() {
+	dir := s.T().TempDir()
+
+	_, err := server.CreateSnapshot(s.ctx, s.source, dir, 0, 2000)
+	s.Require().NoError(err)
+
+	_, err = server.LoadSnapshot(s.ctx, s.source, dir, 3000)
+	s.Require().Error(err)
+}
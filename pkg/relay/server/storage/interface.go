@@ -6,11 +6,13 @@ import (
 )
 
 var ErrDuplicateEvent = errors.New("duplicate event")
+var ErrArchiveSegmentNotFound = errors.New("archive segment not found")
 
 type ListEventRequest struct {
-	Offset    int64
-	EventType int
-	Limit     int64
+	Offset     int64
+	EventType  int
+	Recipients []string // Only events tagged with any of the recipients are listed if it is not empty.
+	Limit      int64
 }
 
 type ListEventResult struct {
@@ -24,20 +26,34 @@ type Event struct {
 	Offset    int64
 	Type      int
 	Data      []byte
+	Tags      []string // Routing tags of the recipients of the event.
+	CreatedAt int64    // Unix time when the publisher created the event. 0 if unknown.
+	Signature []byte   // Signature of the publisher in JWS JSON serialization. nil if the event is not signed.
+
+	ArchiveSegmentID string // Not empty if the data of the event is moved into the archive segment.
+}
+
+// ArchiveSegment is a compressed file in the archive store which keeps the data of a range of events.
+type ArchiveSegment struct {
+	ID         string // Key of the segment in the archive store.
+	FromOffset int64
+	ToOffset   int64
+	EventCount int64
+	Size       int64  // Size of the segment file in bytes.
+	SHA256     string // Hex encoded SHA256 of the segment file.
+	CreatedAt  int64
 }
 
 type RelayServerDataStore interface {
 	// GetIdentity returns the identity of the data storage.
 	GetIdentity(ctx context.Context) (string, error)
 
-	// StoreEventWithOffsetInfo returns the offset of the event in the storage.
+	// StoreEventWithOffsetInfo stores the event and returns the offset of the event in the storage.
+	// The ID, Timestamp, Type, Data, Tags, CreatedAt and Signature of the event are stored. The Offset of the event is ignored.
 	// If peerId is empty, the offset will be ignored.
 	StoreEventWithOffsetInfo(
 		ctx context.Context,
-		ts int64,
-		eventID string,
-		eventType int,
-		event []byte,
+		event Event,
 		offset int64,
 		peerId string,
 	) (int64, error)
@@ -50,4 +66,49 @@ type RelayServerDataStore interface {
 
 	// GetOffset returns the offset of the peer.
 	GetOffset(ctx context.Context, peerId string) (int64, error)
+
+	// ListOffsets returns the offsets of all peers.
+	ListOffsets(ctx context.Context) (map[string]int64, error)
+}
+
+// SnapshotDataStore is a RelayServerDataStore which can read its event log and the offsets of peers at a single
+// point in time.
+type SnapshotDataStore interface {
+	RelayServerDataStore
+
+	// ReadSnapshot calls read with a data store which reads the state of the data store when ReadSnapshot is called.
+	// Changes made after that are not seen by it. It may not be able to write.
+	ReadSnapshot(ctx context.Context, read func(ctx context.Context, snapshot RelayServerDataStore) error) error
+}
+
+// EventArchiveDataStore is a RelayServerDataStore which can move the data of events into archive segments.
+// Archived events are still listed by ListEvents with empty Data and the ArchiveSegmentID.
+type EventArchiveDataStore interface {
+	RelayServerDataStore
+
+	// ListEventsToArchive returns the oldest events which are not archived yet in the order of their offsets.
+	ListEventsToArchive(ctx context.Context, limit int64) ([]Event, error)
+
+	// ArchiveEvents records the segment and drops the data of the events in the segment.
+	ArchiveEvents(ctx context.Context, segment ArchiveSegment, eventIDs []string) error
+
+	// GetArchiveSegment returns the segment with the ID. It returns ErrArchiveSegmentNotFound if there is no such segment.
+	GetArchiveSegment(ctx context.Context, id string) (ArchiveSegment, error)
+}
+
+// EventNotifier is implemented by data stores which can be shared by multiple relay server instances.
+type EventNotifier interface {
+	// ListenEvents calls notify whenever new events are stored by any instance. It blocks until ctx is done or
+	// the connection to the data store is broken.
+	ListenEvents(ctx context.Context, notify func()) error
+}
+
+// PeerLeaseDataStore lets relay server instances sharing the data store decide which one replicates events from a peer.
+type PeerLeaseDataStore interface {
+	// AcquirePeerLease acquires or renews the lease of the peer for the holder until expiresAt.
+	// It returns false if the lease is held by another holder and is not expired at ts.
+	AcquirePeerLease(ctx context.Context, ts int64, peer string, holder string, expiresAt int64) (bool, error)
+
+	// ReleasePeerLease releases the lease of the peer if it is held by the holder.
+	ReleasePeerLease(ctx context.Context, peer string, holder string) error
 }
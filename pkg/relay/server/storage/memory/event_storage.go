@@ -0,0 +1,121 @@
+// Package memory implements the relay server data store in memory.
+// It behaves like the postgres implementation and is meant for tests and simulations.
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/openebl/openebl/pkg/relay/server/storage"
+	"github.com/samber/lo"
+)
+
+type EventStorage struct {
+	identity string
+
+	mtx     sync.RWMutex
+	events  []storage.Event  // Ordered by offset.
+	ids     map[string]int64 // map[event id]offset
+	offsets map[string]int64 // map[peer]offset
+}
+
+var _ storage.SnapshotDataStore = (*EventStorage)(nil)
+
+func NewEventStorage(identity string) *EventStorage {
+	return &EventStorage{
+		identity: identity,
+		ids:      make(map[string]int64),
+		offsets:  make(map[string]int64),
+	}
+}
+
+func (s *EventStorage) GetIdentity(ctx context.Context) (string, error) {
+	return s.identity, nil
+}
+
+func (s *EventStorage) StoreEventWithOffsetInfo(ctx context.Context, event storage.Event, offset int64, peerId string) (int64, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// Like the postgres implementation, the offset of the peer is stored even if the event is duplicated.
+	if peerId != "" {
+		s.offsets[peerId] = offset
+	}
+	if _, ok := s.ids[event.ID]; ok {
+		return 0, storage.ErrDuplicateEvent
+	}
+
+	event.Offset = int64(len(s.events)) + 1
+	event.Tags = slices.Clone(event.Tags)
+	event.ArchiveSegmentID = ""
+	s.events = append(s.events, event)
+	s.ids[event.ID] = event.Offset
+	return event.Offset, nil
+}
+
+func (s *EventStorage) ListEvents(ctx context.Context, request storage.ListEventRequest) (storage.ListEventResult, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	result := storage.ListEventResult{}
+	start := max(request.Offset-1, 0)
+	for i := start; i < int64(len(s.events)) && int64(len(result.Events)) < request.Limit; i++ {
+		event := s.events[i]
+		if request.EventType != 0 && event.Type != request.EventType {
+			continue
+		}
+		if len(request.Recipients) > 0 && len(lo.Intersect(event.Tags, request.Recipients)) == 0 {
+			continue
+		}
+		result.Events = append(result.Events, event)
+		result.MaxOffset = event.Offset
+	}
+	return result, nil
+}
+
+func (s *EventStorage) StoreOffset(ctx context.Context, ts int64, peerId string, offset int64) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.offsets[peerId] = offset
+	return nil
+}
+
+func (s *EventStorage) GetOffset(ctx context.Context, peerId string) (int64, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.offsets[peerId], nil
+}
+
+func (s *EventStorage) ListOffsets(ctx context.Context) (map[string]int64, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	offsets := make(map[string]int64, len(s.offsets))
+	for peer, offset := range s.offsets {
+		offsets[peer] = offset
+	}
+	return offsets, nil
+}
+
+// ReadSnapshot calls read with a copy of the data store.
+func (s *EventStorage) ReadSnapshot(ctx context.Context, read func(ctx context.Context, snapshot storage.RelayServerDataStore) error) error {
+	s.mtx.RLock()
+	snapshot := &EventStorage{
+		identity: s.identity,
+		events:   slices.Clone(s.events),
+		ids:      make(map[string]int64, len(s.ids)),
+		offsets:  make(map[string]int64, len(s.offsets)),
+	}
+	for id, offset := range s.ids {
+		snapshot.ids[id] = offset
+	}
+	for peer, offset := range s.offsets {
+		snapshot.offsets[peer] = offset
+	}
+	s.mtx.RUnlock()
+
+	return read(ctx, snapshot)
+}
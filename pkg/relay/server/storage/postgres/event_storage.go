@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
@@ -10,6 +11,15 @@ import (
 	"github.com/openebl/openebl/pkg/util"
 )
 
+const eventNotificationChannel = "relay_event"
+
+// eventOffsetLock is the advisory lock held from allocating the offset of a new event until the transaction commits.
+// Events are committed in the order of their offsets, so an event with a smaller offset never shows up after one
+// with a larger offset.
+const eventOffsetLock = `SELECT pg_advisory_xact_lock(hashtext('relay_event_offset'))`
+
+var errReadOnlySnapshot = errors.New("snapshot is read only")
+
 // EventStorage implements RelayServerDataStore interface.
 type EventStorage struct {
 	dbPool *pgxpool.Pool
@@ -40,6 +50,10 @@ func (s *EventStorage) GetIdentity(ctx context.Context) (string, error) {
 	}
 	defer tx.Rollback(ctx)
 
+	return s.getIdentity(ctx, tx)
+}
+
+func (s *EventStorage) getIdentity(ctx context.Context, tx pgx.Tx) (string, error) {
 	query := `SELECT id FROM storage_identify`
 	row := tx.QueryRow(ctx, query)
 	var id string
@@ -52,10 +66,7 @@ func (s *EventStorage) GetIdentity(ctx context.Context) (string, error) {
 
 func (s *EventStorage) StoreEventWithOffsetInfo(
 	ctx context.Context,
-	ts int64,
-	eventID string,
-	eventType int,
-	event []byte,
+	event storage.Event,
 	offset int64,
 	peerId string,
 ) (int64, error) {
@@ -71,14 +82,14 @@ func (s *EventStorage) StoreEventWithOffsetInfo(
 
 	// Store Offset information when it's available
 	if peerId != "" {
-		if err := s.storeOffset(ctx, tx, ts, peerId, offset); err != nil {
+		if err := s.storeOffset(ctx, tx, event.Timestamp, peerId, offset); err != nil {
 			return 0, err
 		}
 	}
 
 	// Check if the event is already stored
 	query := `SELECT id FROM "event" WHERE id = $1`
-	row := tx.QueryRow(ctx, query, eventID)
+	row := tx.QueryRow(ctx, query, event.ID)
 	var oldID string
 	err = row.Scan(&oldID)
 	if err != nil && err != pgx.ErrNoRows {
@@ -101,13 +112,25 @@ func (s *EventStorage) StoreEventWithOffsetInfo(
 	}
 
 	// Store Event
+	if _, err := tx.Exec(ctx, eventOffsetLock); err != nil {
+		return 0, fmt.Errorf("lock event offset: %w", err)
+	}
 	var newOffset int64
-	query = `INSERT INTO "event" (id, "type", created_at, "event") VALUES ($1, $2, $3, $4) RETURNING "offset"`
-	row = tx.QueryRow(ctx, query, eventID, eventType, ts, event)
+	tags := event.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	query = `INSERT INTO "event" (id, "type", created_at, "event", tags, publisher_created_at, signature) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING "offset"`
+	row = tx.QueryRow(ctx, query, event.ID, event.Type, event.Timestamp, event.Data, tags, event.CreatedAt, event.Signature)
 	if err := row.Scan(&newOffset); err != nil {
 		return 0, fmt.Errorf("scan offset: %w", err)
 	}
 
+	// Wake up other relay server instances sharing the database after the transaction is committed.
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, '')`, eventNotificationChannel); err != nil {
+		return 0, fmt.Errorf("notify: %w", err)
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return 0, fmt.Errorf("commit transaction: %w", err)
 	}
@@ -125,21 +148,34 @@ func (s *EventStorage) ListEvents(ctx context.Context, request storage.ListEvent
 	}
 	defer tx.Rollback(ctx)
 
+	return s.listEvents(ctx, tx, request)
+}
+
+func (s *EventStorage) listEvents(ctx context.Context, tx pgx.Tx, request storage.ListEventRequest) (storage.ListEventResult, error) {
 	query := `
 	SELECT 
 		id,
 		created_at,
 		"offset",
 		"type",
-		"event"
+		"event",
+		tags,
+		publisher_created_at,
+		signature,
+		COALESCE(segment_id, '')
 	FROM "event"
 	WHERE
 		($2 = 0 OR "offset" >= $2) AND
-		($3 = 0 OR "type" = $3)
+		($3 = 0 OR "type" = $3) AND
+		(cardinality($4::TEXT[]) = 0 OR tags && $4)
 	ORDER BY "offset" ASC
 	LIMIT $1`
 
-	rows, err := tx.Query(ctx, query, request.Limit, request.Offset, request.EventType)
+	recipients := request.Recipients
+	if recipients == nil {
+		recipients = []string{}
+	}
+	rows, err := tx.Query(ctx, query, request.Limit, request.Offset, request.EventType, recipients)
 	if err != nil {
 		return storage.ListEventResult{}, fmt.Errorf("query: %w", err)
 	}
@@ -154,6 +190,10 @@ func (s *EventStorage) ListEvents(ctx context.Context, request storage.ListEvent
 			&event.Offset,
 			&event.Type,
 			&event.Data,
+			&event.Tags,
+			&event.CreatedAt,
+			&event.Signature,
+			&event.ArchiveSegmentID,
 		); err != nil {
 			return storage.ListEventResult{}, fmt.Errorf("scan: %w", err)
 		}
@@ -222,6 +262,10 @@ func (s *EventStorage) GetOffset(ctx context.Context, peerAddress string) (int64
 	}
 	defer tx.Rollback(ctx)
 
+	return s.getOffset(ctx, tx, peerAddress)
+}
+
+func (s *EventStorage) getOffset(ctx context.Context, tx pgx.Tx, peerAddress string) (int64, error) {
 	query := `SELECT "offset" FROM "offset" WHERE peer = $1`
 	row := tx.QueryRow(ctx, query, peerAddress)
 	var offset int64
@@ -232,7 +276,282 @@ func (s *EventStorage) GetOffset(ctx context.Context, peerAddress string) (int64
 	return offset, nil
 }
 
+func (s *EventStorage) ListOffsets(ctx context.Context) (map[string]int64, error) {
+	txOption := pgx.TxOptions{
+		AccessMode: pgx.ReadOnly,
+	}
+	tx, err := s.dbPool.BeginTx(ctx, txOption)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	return s.listOffsets(ctx, tx)
+}
+
+func (s *EventStorage) listOffsets(ctx context.Context, tx pgx.Tx) (map[string]int64, error) {
+	query := `SELECT peer, "offset" FROM "offset"`
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	offsets := make(map[string]int64)
+	for rows.Next() {
+		var peer string
+		var offset int64
+		if err := rows.Scan(&peer, &offset); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		offsets[peer] = offset
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+
+	return offsets, nil
+}
+
+// ReadSnapshot calls read with a data store which reads events and offsets in a single repeatable read transaction.
+func (s *EventStorage) ReadSnapshot(ctx context.Context, read func(ctx context.Context, snapshot storage.RelayServerDataStore) error) error {
+	txOption := pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	}
+	tx, err := s.dbPool.BeginTx(ctx, txOption)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	return read(ctx, &snapshotEventStorage{storage: s, tx: tx})
+}
+
+func (s *EventStorage) ListEventsToArchive(ctx context.Context, limit int64) ([]storage.Event, error) {
+	txOption := pgx.TxOptions{
+		AccessMode: pgx.ReadOnly,
+	}
+	tx, err := s.dbPool.BeginTx(ctx, txOption)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT
+		id,
+		created_at,
+		"offset",
+		"type",
+		"event",
+		tags,
+		publisher_created_at,
+		signature
+	FROM "event"
+	WHERE segment_id IS NULL
+	ORDER BY "offset" ASC
+	LIMIT $1`
+
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	var events []storage.Event
+	for rows.Next() {
+		event := storage.Event{}
+		if err := rows.Scan(
+			&event.ID,
+			&event.Timestamp,
+			&event.Offset,
+			&event.Type,
+			&event.Data,
+			&event.Tags,
+			&event.CreatedAt,
+			&event.Signature,
+		); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *EventStorage) ArchiveEvents(ctx context.Context, segment storage.ArchiveSegment, eventIDs []string) error {
+	txOption := pgx.TxOptions{
+		IsoLevel:   pgx.Serializable,
+		AccessMode: pgx.ReadWrite,
+	}
+	tx, err := s.dbPool.BeginTx(ctx, txOption)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	INSERT INTO archive_segment (id, from_offset, to_offset, event_count, "size", sha256, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	if _, err := tx.Exec(
+		ctx,
+		query,
+		segment.ID,
+		segment.FromOffset,
+		segment.ToOffset,
+		segment.EventCount,
+		segment.Size,
+		segment.SHA256,
+		segment.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("insert archive segment: %w", err)
+	}
+
+	query = `UPDATE "event" SET "event" = NULL, segment_id = $1 WHERE id = ANY($2) AND segment_id IS NULL`
+	cmdTag, err := tx.Exec(ctx, query, segment.ID, eventIDs)
+	if err != nil {
+		return fmt.Errorf("archive events: %w", err)
+	}
+	if cmdTag.RowsAffected() != int64(len(eventIDs)) {
+		return fmt.Errorf("%d events are expected to be archived, but %d events are archived", len(eventIDs), cmdTag.RowsAffected())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *EventStorage) GetArchiveSegment(ctx context.Context, id string) (storage.ArchiveSegment, error) {
+	txOption := pgx.TxOptions{
+		AccessMode: pgx.ReadOnly,
+	}
+	tx, err := s.dbPool.BeginTx(ctx, txOption)
+	if err != nil {
+		return storage.ArchiveSegment{}, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, from_offset, to_offset, event_count, "size", sha256, created_at
+	FROM archive_segment
+	WHERE id = $1`
+	segment := storage.ArchiveSegment{}
+	err = tx.QueryRow(ctx, query, id).Scan(
+		&segment.ID,
+		&segment.FromOffset,
+		&segment.ToOffset,
+		&segment.EventCount,
+		&segment.Size,
+		&segment.SHA256,
+		&segment.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return storage.ArchiveSegment{}, storage.ErrArchiveSegmentNotFound
+	} else if err != nil {
+		return storage.ArchiveSegment{}, fmt.Errorf("scan: %w", err)
+	}
+
+	return segment, nil
+}
+
+func (s *EventStorage) ListenEvents(ctx context.Context, notify func()) error {
+	conn, err := s.dbPool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+	// The connection is not reusable after LISTEN. Close it before it goes back to the pool.
+	defer conn.Conn().Close(context.Background())
+
+	if _, err := conn.Exec(ctx, fmt.Sprintf("LISTEN %s", eventNotificationChannel)); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	for {
+		if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+		notify()
+	}
+}
+
+func (s *EventStorage) AcquirePeerLease(ctx context.Context, ts int64, peer string, holder string, expiresAt int64) (bool, error) {
+	txOption := pgx.TxOptions{
+		IsoLevel:   pgx.Serializable,
+		AccessMode: pgx.ReadWrite,
+	}
+	tx, err := s.dbPool.BeginTx(ctx, txOption)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	INSERT INTO peer_lease (peer, holder, expires_at, updated_at) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (peer)
+	DO UPDATE
+	SET
+		holder = excluded.holder,
+		expires_at = excluded.expires_at,
+		updated_at = excluded.updated_at
+	WHERE peer_lease.holder = excluded.holder OR peer_lease.expires_at < excluded.updated_at`
+	cmdTag, err := tx.Exec(ctx, query, peer, holder, expiresAt, ts)
+	if err != nil {
+		return false, fmt.Errorf("exec: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return cmdTag.RowsAffected() == 1, nil
+}
+
+func (s *EventStorage) ReleasePeerLease(ctx context.Context, peer string, holder string) error {
+	query := `DELETE FROM peer_lease WHERE peer = $1 AND holder = $2`
+	if _, err := s.dbPool.Exec(ctx, query, peer, holder); err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+	return nil
+}
+
 func (s *EventStorage) Close() error {
 	s.dbPool.Close()
 	return nil
 }
+
+// snapshotEventStorage reads the event storage in the transaction of ReadSnapshot. It can't write.
+type snapshotEventStorage struct {
+	storage *EventStorage
+	tx      pgx.Tx
+}
+
+func (s *snapshotEventStorage) GetIdentity(ctx context.Context) (string, error) {
+	return s.storage.getIdentity(ctx, s.tx)
+}
+
+func (s *snapshotEventStorage) StoreEventWithOffsetInfo(ctx context.Context, event storage.Event, offset int64, peerId string) (int64, error) {
+	return 0, errReadOnlySnapshot
+}
+
+func (s *snapshotEventStorage) ListEvents(ctx context.Context, request storage.ListEventRequest) (storage.ListEventResult, error) {
+	return s.storage.listEvents(ctx, s.tx, request)
+}
+
+func (s *snapshotEventStorage) StoreOffset(ctx context.Context, ts int64, peerId string, offset int64) error {
+	return errReadOnlySnapshot
+}
+
+func (s *snapshotEventStorage) GetOffset(ctx context.Context, peerId string) (int64, error) {
+	return s.storage.getOffset(ctx, s.tx, peerId)
+}
+
+func (s *snapshotEventStorage) ListOffsets(ctx context.Context) (map[string]int64, error) {
+	return s.storage.listOffsets(ctx, s.tx)
+}
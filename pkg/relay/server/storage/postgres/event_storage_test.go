@@ -55,6 +55,8 @@ func (s *EventStorageTestSuite) SetupSuite() {
 	tableNames := []string{
 		"event",
 		"offset",
+		"archive_segment",
+		"peer_lease",
 	}
 	for _, tableName := range tableNames {
 		_, err := pool.Exec(context.Background(), fmt.Sprintf(`TRUNCATE TABLE %q`, tableName))
@@ -80,11 +82,11 @@ func (s *EventStorageTestSuite) TestStoreEvent() {
 	eventType := 1001
 	event := []byte("test_event")
 
-	offset, err := s.storage.StoreEventWithOffsetInfo(ctx, ts, eventID, eventType, event, 0, "")
+	offset, err := s.storage.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: ts, ID: eventID, Type: eventType, Data: event}, 0, "")
 	s.Require().NoError(err)
 	s.Require().NotZero(offset)
 
-	offset, err = s.storage.StoreEventWithOffsetInfo(ctx, ts, eventID+eventID, eventType, event, 9876, "bluex")
+	offset, err = s.storage.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: ts, ID: eventID + eventID, Type: eventType, Data: event}, 9876, "bluex")
 	s.Require().NoError(err)
 	s.Require().NotZero(offset)
 
@@ -93,15 +95,25 @@ func (s *EventStorageTestSuite) TestStoreEvent() {
 	s.Assert().Equal(int64(9876), peerOffset)
 
 	// Check if duplicated event ID can get ErrDuplicateEvent error.
-	_, err = s.storage.StoreEventWithOffsetInfo(ctx, ts, eventID, eventType, event, 0, "")
+	_, err = s.storage.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: ts, ID: eventID, Type: eventType, Data: event}, 0, "")
 	s.Require().ErrorIs(err, storage.ErrDuplicateEvent)
 
 	// Check if duplicated event ID can get ErrDuplicateEvent error and store offset correctly.
-	_, err = s.storage.StoreEventWithOffsetInfo(ctx, ts, eventID, eventType, event, 9877, "bluex")
+	_, err = s.storage.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: ts, ID: eventID, Type: eventType, Data: event}, 9877, "bluex")
 	s.Require().ErrorIs(err, storage.ErrDuplicateEvent)
 	offset, err = s.storage.GetOffset(ctx, "bluex")
 	s.Require().NoError(err)
 	s.Assert().Equal(int64(9877), offset)
+
+	// Check if the created-at time and the signature of the publisher are stored.
+	signature := []byte(`{"payload": "cGF5bG9hZA", "protected": "e30", "signature": "c2ln"}`)
+	offset, err = s.storage.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: ts, ID: "signed_event_id", Type: eventType, Data: event, CreatedAt: ts - 10, Signature: signature}, 0, "")
+	s.Require().NoError(err)
+	result, err := s.storage.ListEvents(ctx, storage.ListEventRequest{Offset: offset, Limit: 1})
+	s.Require().NoError(err)
+	s.Require().Len(result.Events, 1)
+	s.Assert().Equal(ts-10, result.Events[0].CreatedAt)
+	s.Assert().JSONEq(string(signature), string(result.Events[0].Signature))
 }
 
 func (s *EventStorageTestSuite) TestListEvents() {
@@ -179,6 +191,27 @@ func (s *EventStorageTestSuite) TestListEvents() {
 	s.Assert().Equal(int64(103), result.MaxOffset)
 	s.Assert().Equal("cert2 content", string(result.Events[0].Data))
 	// End of Filtered by Offset
+
+	// Filtered by Recipients
+	request = storage.ListEventRequest{
+		Offset:     0,
+		EventType:  0,
+		Recipients: []string{"did:example:alice", "did:example:carol"},
+		Limit:      10,
+	}
+	result, err = s.storage.ListEvents(ctx, request)
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(result.Events))
+	s.Assert().Equal(int64(100), result.MaxOffset)
+	s.Assert().Equal([]string{"did:example:alice", "did:example:bob"}, result.Events[0].Tags)
+
+	request.Recipients = []string{"did:example:bob"}
+	result, err = s.storage.ListEvents(ctx, request)
+	s.Require().NoError(err)
+	s.Require().Equal(2, len(result.Events))
+	s.Assert().Equal("event1 content", string(result.Events[0].Data))
+	s.Assert().Equal("event2 content", string(result.Events[1].Data))
+	// End of Filtered by Recipients
 }
 
 func (s *EventStorageTestSuite) TestStoreOffsetAndGetOffset() {
@@ -196,4 +229,202 @@ func (s *EventStorageTestSuite) TestStoreOffsetAndGetOffset() {
 	offset, err = s.storage.GetOffset(ctx, "empty peer address")
 	s.Require().NoError(err)
 	s.Assert().Zero(offset)
+
+	err = s.storage.StoreOffset(ctx, 101, "test_peer_address_2", 2000)
+	s.Require().NoError(err)
+	offsets, err := s.storage.ListOffsets(ctx)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(1000, offsets["test_peer_address"])
+	s.Assert().EqualValues(2000, offsets["test_peer_address_2"])
+}
+
+func (s *EventStorageTestSuite) TestReadSnapshot() {
+	ctx := context.Background()
+	dataStore := s.storage.(*postgres.EventStorage)
+	s.Require().NoError(dataStore.StoreOffset(ctx, 100, "snapshot_peer", 10))
+
+	err := dataStore.ReadSnapshot(ctx, func(ctx context.Context, snapshot storage.RelayServerDataStore) error {
+		offsets, err := snapshot.ListOffsets(ctx)
+		s.Require().NoError(err)
+		s.Assert().EqualValues(10, offsets["snapshot_peer"])
+
+		// Changes made after the snapshot is read are not seen.
+		s.Require().NoError(dataStore.StoreOffset(ctx, 101, "snapshot_peer", 20))
+		offset, err := snapshot.GetOffset(ctx, "snapshot_peer")
+		s.Require().NoError(err)
+		s.Assert().EqualValues(10, offset)
+
+		s.Assert().Error(snapshot.StoreOffset(ctx, 102, "snapshot_peer", 30))
+		return nil
+	})
+	s.Require().NoError(err)
+
+	offset, err := dataStore.GetOffset(ctx, "snapshot_peer")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(20, offset)
+}
+
+func (s *EventStorageTestSuite) TestArchiveEvents() {
+	ctx := context.Background()
+	db := stdlib.OpenDBFromPool(s.pgPool)
+	fixtures, err := testfixtures.New(
+		testfixtures.Database(db),
+		testfixtures.Dialect("postgres"),
+		testfixtures.Directory("testdata/list_event"),
+	)
+	s.Require().NoError(err)
+	s.Require().NoError(fixtures.Load())
+
+	archiveStorage := s.storage.(storage.EventArchiveDataStore)
+	events, err := archiveStorage.ListEventsToArchive(ctx, 2)
+	s.Require().NoError(err)
+	s.Require().Len(events, 2)
+	s.Assert().Equal("event1", events[0].ID)
+	s.Assert().Equal("cert1", events[1].ID)
+
+	segment := storage.ArchiveSegment{
+		ID:         "segments/100-101.bin.gz",
+		FromOffset: 100,
+		ToOffset:   101,
+		EventCount: 2,
+		Size:       1234,
+		SHA256:     "hash",
+		CreatedAt:  1700733100,
+	}
+	s.Require().NoError(archiveStorage.ArchiveEvents(ctx, segment, []string{"event1", "cert1"}))
+	// Events can not be archived twice.
+	s.Require().Error(archiveStorage.ArchiveEvents(ctx, storage.ArchiveSegment{ID: "another"}, []string{"event1"}))
+
+	storedSegment, err := archiveStorage.GetArchiveSegment(ctx, segment.ID)
+	s.Require().NoError(err)
+	s.Assert().Equal(segment, storedSegment)
+	_, err = archiveStorage.GetArchiveSegment(ctx, "another")
+	s.Assert().ErrorIs(err, storage.ErrArchiveSegmentNotFound)
+
+	result, err := s.storage.ListEvents(ctx, storage.ListEventRequest{Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(result.Events, 4)
+	s.Assert().Nil(result.Events[0].Data)
+	s.Assert().Equal(segment.ID, result.Events[0].ArchiveSegmentID)
+	s.Assert().Equal("event2 content", string(result.Events[2].Data))
+	s.Assert().Empty(result.Events[2].ArchiveSegmentID)
+
+	events, err = archiveStorage.ListEventsToArchive(ctx, 10)
+	s.Require().NoError(err)
+	s.Require().Len(events, 2)
+	s.Assert().Equal("event2", events[0].ID)
+}
+
+func (s *EventStorageTestSuite) TestPeerLease() {
+	ctx := context.Background()
+	leaseStorage := s.storage.(storage.PeerLeaseDataStore)
+
+	acquired, err := leaseStorage.AcquirePeerLease(ctx, 100, "ws://peer", "instance1", 130)
+	s.Require().NoError(err)
+	s.Assert().True(acquired)
+
+	// The lease is held by instance1 until 130.
+	acquired, err = leaseStorage.AcquirePeerLease(ctx, 110, "ws://peer", "instance2", 140)
+	s.Require().NoError(err)
+	s.Assert().False(acquired)
+
+	// instance1 can renew the lease.
+	acquired, err = leaseStorage.AcquirePeerLease(ctx, 120, "ws://peer", "instance1", 150)
+	s.Require().NoError(err)
+	s.Assert().True(acquired)
+
+	// instance2 takes over the expired lease.
+	acquired, err = leaseStorage.AcquirePeerLease(ctx, 151, "ws://peer", "instance2", 181)
+	s.Require().NoError(err)
+	s.Assert().True(acquired)
+
+	// Only the holder can release the lease.
+	s.Require().NoError(leaseStorage.ReleasePeerLease(ctx, "ws://peer", "instance1"))
+	acquired, err = leaseStorage.AcquirePeerLease(ctx, 160, "ws://peer", "instance1", 190)
+	s.Require().NoError(err)
+	s.Assert().False(acquired)
+	s.Require().NoError(leaseStorage.ReleasePeerLease(ctx, "ws://peer", "instance2"))
+	acquired, err = leaseStorage.AcquirePeerLease(ctx, 160, "ws://peer", "instance1", 190)
+	s.Require().NoError(err)
+	s.Assert().True(acquired)
+}
+
+func (s *EventStorageTestSuite) TestListenEvents() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notified := make(chan struct{}, 1)
+	listenResult := make(chan error, 1)
+	go func() {
+		listenResult <- s.storage.(storage.EventNotifier).ListenEvents(ctx, func() {
+			select {
+			case notified <- struct{}{}:
+			default:
+			}
+		})
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	_, err := s.storage.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: time.Now().Unix(), ID: "notified_event_id", Type: 1001, Data: []byte("notified")}, 0, "")
+	s.Require().NoError(err)
+
+	select {
+	case <-notified:
+	case <-time.After(5 * time.Second):
+		s.Fail("no notification of the new event")
+	}
+
+	cancel()
+	s.Assert().Error(<-listenResult)
+}
+
+func (s *EventStorageTestSuite) TestStoreEventsInOffsetOrder() {
+	ctx := context.Background()
+	ts := time.Now().Unix()
+	const eventType = 3001
+
+	// Connection A is a local insert which holds a small offset and hasn't committed yet.
+	txA, err := s.pgPool.Begin(ctx)
+	s.Require().NoError(err)
+	defer txA.Rollback(ctx)
+	_, err = txA.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('relay_event_offset'))`)
+	s.Require().NoError(err)
+	var offsetA int64
+	s.Require().NoError(txA.QueryRow(
+		ctx,
+		`INSERT INTO "event" (id, "type", created_at, "event", tags) VALUES ($1, $2, $3, $4, '{}') RETURNING "offset"`,
+		"in_order_event_a", eventType, ts, []byte("event a"),
+	).Scan(&offsetA))
+
+	// Connection B replicates an event from a peer. It has stored the peer offset, but it can't commit its event
+	// before the event of connection A.
+	type storeResult struct {
+		offset int64
+		err    error
+	}
+	resultB := make(chan storeResult, 1)
+	go func() {
+		offset, err := s.storage.StoreEventWithOffsetInfo(ctx, storage.Event{Timestamp: ts, ID: "in_order_event_b", Type: eventType, Data: []byte("event b")}, 6, "in_order_peer")
+		resultB <- storeResult{offset: offset, err: err}
+	}()
+
+	select {
+	case r := <-resultB:
+		s.FailNow("event b is committed before event a", "offset %d, err %v", r.offset, r.err)
+	case <-time.After(500 * time.Millisecond):
+	}
+	result, err := s.storage.ListEvents(ctx, storage.ListEventRequest{EventType: eventType, Limit: 10})
+	s.Require().NoError(err)
+	s.Assert().Empty(result.Events)
+
+	s.Require().NoError(txA.Commit(ctx))
+	r := <-resultB
+	s.Require().NoError(r.err)
+	s.Assert().Greater(r.offset, offsetA)
+
+	result, err = s.storage.ListEvents(ctx, storage.ListEventRequest{EventType: eventType, Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(result.Events, 2)
+	s.Assert().Equal("in_order_event_a", result.Events[0].ID)
+	s.Assert().Equal("in_order_event_b", result.Events[1].ID)
 }
@@ -0,0 +1,4 @@
+DROP INDEX event_not_archived_offset_idx;
+ALTER TABLE event DROP COLUMN segment_id;
+ALTER TABLE event ALTER COLUMN "event" SET NOT NULL;
+DROP TABLE archive_segment;
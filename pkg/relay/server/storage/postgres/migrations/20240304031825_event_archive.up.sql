@@ -0,0 +1,14 @@
+CREATE TABLE archive_segment (
+    id TEXT PRIMARY KEY,
+    from_offset BIGINT NOT NULL,
+    to_offset BIGINT NOT NULL,
+    event_count BIGINT NOT NULL,
+    "size" BIGINT NOT NULL,
+    sha256 TEXT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+ALTER TABLE event ALTER COLUMN "event" DROP NOT NULL;
+ALTER TABLE event ADD COLUMN segment_id TEXT;
+
+CREATE INDEX event_not_archived_offset_idx ON event ("offset") WHERE segment_id IS NULL;
@@ -0,0 +1 @@
+DROP TABLE peer_lease;
@@ -0,0 +1,6 @@
+CREATE TABLE peer_lease (
+    peer TEXT PRIMARY KEY,
+    holder TEXT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
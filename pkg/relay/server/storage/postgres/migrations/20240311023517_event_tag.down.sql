@@ -0,0 +1,2 @@
+DROP INDEX event_tags_idx;
+ALTER TABLE event DROP COLUMN tags;
@@ -0,0 +1,4 @@
+-- Routing tags of the recipients of the event, like hashed DIDs or key fingerprints.
+ALTER TABLE event ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX event_tags_idx ON event USING GIN (tags);
@@ -0,0 +1,2 @@
+ALTER TABLE event DROP COLUMN signature;
+ALTER TABLE event DROP COLUMN publisher_created_at;
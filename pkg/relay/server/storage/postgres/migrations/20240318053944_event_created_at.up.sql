@@ -0,0 +1,4 @@
+-- The time the publisher created the event and the signature of the publisher over it.
+-- created_at is the time the event was received by the relay server where it was published.
+ALTER TABLE event ADD COLUMN publisher_created_at BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE event ADD COLUMN signature JSONB;
@@ -1,5 +1,11 @@
 package relay
 
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
+
 func NostrServerAddress(address string) NostrServerOption {
 	return func(s *NostrServer) {
 		s.address = address
@@ -30,3 +36,52 @@ func NostrServerWithIdentity(identity string) NostrServerOption {
 		s.identity = identity
 	}
 }
+
+// NostrServerWithTailCache enables the shared cache of the most recent events with the given capacity.
+// Subscriptions near the head of the event log are served from the cache instead of querying the EventSource.
+func NostrServerWithTailCache(capacity int) NostrServerOption {
+	return func(s *NostrServer) {
+		s.tailCacheSize = capacity
+	}
+}
+
+// NostrServerWithTLSConfig serves TLS with the config. Set ClientCAs and ClientAuth of the config to identify clients
+// by their certificates. Certificates of the config can be left empty if NostrServerTLS is used.
+func NostrServerWithTLSConfig(config *tls.Config) NostrServerOption {
+	return func(s *NostrServer) {
+		s.tlsConfig = config
+	}
+}
+
+// NostrServerWithEventTypes enforces the registry on publishing and subscribing events.
+func NostrServerWithEventTypes(registry EventTypeRegistry) NostrServerOption {
+	return func(s *NostrServer) {
+		s.eventTypes = registry
+	}
+}
+
+// NostrServerWithClockSkew rejects events whose created-at time is missing or differs from the time of the server
+// by more than skew. Events are not checked if skew is 0.
+func NostrServerWithClockSkew(skew time.Duration) NostrServerOption {
+	return func(s *NostrServer) {
+		s.clockSkew = skew
+	}
+}
+
+// NostrServerWithSignatureRootCerts trusts certificates issued by rootCerts, besides the system trusted
+// certificates, to sign events published to the server.
+func NostrServerWithSignatureRootCerts(rootCerts []*x509.Certificate) NostrServerOption {
+	return func(s *NostrServer) {
+		s.signatureRootCerts = rootCerts
+	}
+}
+
+// NostrServerWithSendTimeout sets how long events wait for the output queue of a slow client before the client is
+// disconnected. DefaultSendTimeout is used if timeout is not positive.
+func NostrServerWithSendTimeout(timeout time.Duration) NostrServerOption {
+	return func(s *NostrServer) {
+		if timeout > 0 {
+			s.sendTimeout = timeout
+		}
+	}
+}
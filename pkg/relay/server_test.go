@@ -2,15 +2,27 @@ package relay_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"testing"
 
 	"github.com/openebl/openebl/pkg/relay"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"golang.org/x/time/rate"
 )
@@ -153,11 +165,320 @@ func (s *NostrRelayServerTestSuite) TestReceiveEvent() {
 	assert.Len(s.T(), eventSink.GetEvents(), 2)
 	receivedEvents := eventSink.GetEvents()[:]
 	for i := range receivedEvents {
+		assert.NotZero(s.T(), receivedEvents[i].CreatedAt)
 		receivedEvents[i].Timestamp = 0
+		receivedEvents[i].CreatedAt = 0
 	}
 	assert.ElementsMatchf(s.T(), receivedEvents, events, "client and server should have the same events")
 }
 
+// CountingEventSource is a thread-safe event source and sink which counts how many times it is pulled.
+type CountingEventSource struct {
+	mtx       sync.Mutex
+	source    ServerEventSourceAndSink
+	pullCount atomic.Int64
+}
+
+func (s *CountingEventSource) Pull(ctx context.Context, request relay.EventSourcePullingRequest) (relay.EventSourcePullingResponse, error) {
+	s.pullCount.Add(1)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	response, err := s.source.Pull(ctx, request)
+	response.Events = append([]relay.Event(nil), response.Events...)
+	return response, err
+}
+
+func (s *CountingEventSource) Sink(ctx context.Context, event relay.Event) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.source.Sink(ctx, event)
+}
+
+func (s *NostrRelayServerTestSuite) TestSubscriptionWithTailCache() {
+	const numOfClients = 10
+
+	eventSource := &CountingEventSource{}
+	for i := 0; i < 4; i++ {
+		eventSource.Sink(context.Background(), relay.Event{Type: 1001, Data: []byte(fmt.Sprintf("hello %d", i))})
+	}
+
+	srv := relay.NewNostrServer(
+		relay.NostrServerAddress("localhost:8083"),
+		relay.NostrServerWithEventSource(eventSource.Pull),
+		relay.NostrServerWithEventSink(eventSource.Sink),
+		relay.NostrServerWithIdentity("test-server"),
+		relay.NostrServerWithTailCache(16),
+	)
+	go func() {
+		srv.ListenAndServe()
+	}()
+	defer srv.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	clients := make([]*relay.NostrClient, 0, numOfClients)
+	clientEventSinks := make([]*CountingEventSource, 0, numOfClients)
+	for i := 0; i < numOfClients; i++ {
+		clientEventSink := &CountingEventSource{}
+		client := relay.NewNostrClient(
+			relay.NostrClientWithServerURL("ws://localhost:8083"),
+			relay.NostrClientWithEventSink(clientEventSink.Sink),
+			relay.NostrClientWithConnectionStatusCallback(
+				func(ctx context.Context, cancel context.CancelCauseFunc, client relay.RelayClient, remoteServerIdentity string, status bool) {
+					if !status {
+						return
+					}
+					client.Subscribe(context.Background(), 0)
+				},
+			),
+		)
+		defer client.Close()
+		clients = append(clients, client)
+		clientEventSinks = append(clientEventSinks, clientEventSink)
+	}
+
+	time.Sleep(2 * time.Second)
+	err := clients[0].Publish(context.Background(), 1001, []byte("hello 4"))
+	s.Require().NoError(err)
+	time.Sleep(1 * time.Second)
+
+	for _, clientEventSink := range clientEventSinks {
+		clientEventSink.mtx.Lock()
+		s.Assert().Len(clientEventSink.source.GetEvents(), 5)
+		clientEventSink.mtx.Unlock()
+	}
+	// Without the tail cache, every subscription pulls the event source once per second.
+	s.Assert().Less(eventSource.pullCount.Load(), int64(numOfClients))
+}
+
+func (s *NostrRelayServerTestSuite) TestSubscriptionWithRecipients() {
+	eventSource := &CountingEventSource{}
+	eventSource.Sink(context.Background(), relay.Event{Type: 1001, Data: []byte("to alice"), Tags: []string{"alice"}})
+	eventSource.Sink(context.Background(), relay.Event{Type: 1001, Data: []byte("to bob"), Tags: []string{"bob"}})
+	eventSource.Sink(context.Background(), relay.Event{Type: 1001, Data: []byte("broadcast")})
+
+	srv := relay.NewNostrServer(
+		relay.NostrServerAddress("localhost:8084"),
+		relay.NostrServerWithEventSource(eventSource.Pull),
+		relay.NostrServerWithEventSink(eventSource.Sink),
+		relay.NostrServerWithIdentity("test-server"),
+		relay.NostrServerWithTailCache(16),
+	)
+	go func() {
+		srv.ListenAndServe()
+	}()
+	defer srv.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	clientEventSink := &CountingEventSource{}
+	client := relay.NewNostrClient(
+		relay.NostrClientWithServerURL("ws://localhost:8084"),
+		relay.NostrClientWithEventSink(clientEventSink.Sink),
+		relay.NostrClientWithConnectionStatusCallback(
+			func(ctx context.Context, cancel context.CancelCauseFunc, client relay.RelayClient, remoteServerIdentity string, status bool) {
+				if !status {
+					return
+				}
+				client.Subscribe(context.Background(), 0, "bob", "carol")
+			},
+		),
+	)
+	defer client.Close()
+
+	time.Sleep(1 * time.Second)
+	s.Require().NoError(client.Publish(context.Background(), 1001, []byte("to carol"), "carol"))
+	s.Require().NoError(client.Publish(context.Background(), 1001, []byte("to dave"), "dave"))
+	s.Assert().Error(client.Publish(context.Background(), 1001, []byte("invalid tag"), ""))
+	time.Sleep(1 * time.Second)
+
+	clientEventSink.mtx.Lock()
+	defer clientEventSink.mtx.Unlock()
+	received := make([]string, 0)
+	for _, event := range clientEventSink.source.GetEvents() {
+		received = append(received, string(event.Data))
+	}
+	s.Assert().Equal([]string{"to bob", "to carol"}, received)
+	s.Assert().Equal([]string{"carol"}, clientEventSink.source.GetEvents()[1].Tags)
+}
+
+func (s *NostrRelayServerTestSuite) TestEventTypePolicies() {
+	caCert, caKey := newTestCertificate(s.T(), "test-ca", nil, nil)
+	serverX509Cert, serverKey := newTestCertificate(s.T(), "localhost", caCert, caKey)
+	serverCert := tls.Certificate{Certificate: [][]byte{serverX509Cert.Raw}, PrivateKey: serverKey}
+	aliceX509Cert, aliceKey := newTestCertificate(s.T(), "alice", caCert, caKey)
+	aliceCert := tls.Certificate{Certificate: [][]byte{aliceX509Cert.Raw}, PrivateKey: aliceKey}
+	caPool := x509.NewCertPool()
+	caPool.AddCert(caCert)
+
+	registry, err := relay.NewEventTypeRegistry(
+		relay.EventTypePolicy{
+			Type:        1001,
+			Name:        "open",
+			Publishers:  []string{relay.AnyIdentity},
+			Subscribers: []string{relay.AnyIdentity},
+		},
+		relay.EventTypePolicy{
+			Type:           1002,
+			Name:           "restricted",
+			MaxPayloadSize: 16,
+			Retention:      time.Hour,
+			Publishers:     []string{"alice"},
+			Subscribers:    []string{"alice"},
+		},
+	)
+	s.Require().NoError(err)
+
+	eventSource := &CountingEventSource{}
+	srv := relay.NewNostrServer(
+		relay.NostrServerAddress("localhost:8085"),
+		relay.NostrServerWithEventSource(eventSource.Pull),
+		relay.NostrServerWithEventSink(eventSource.Sink),
+		relay.NostrServerWithIdentity("test-server"),
+		relay.NostrServerWithEventTypes(registry),
+		relay.NostrServerWithTLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    caPool,
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+		}),
+	)
+	go func() {
+		srv.ListenAndServe()
+	}()
+	defer srv.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	// Relay info reports the registry.
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: caPool}}}
+	req, _ := http.NewRequest(http.MethodGet, "https://localhost:8085", nil)
+	req.Header.Set("Accept", "application/nostr+json")
+	resp, err := httpClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	info := relay.RelayInfo{}
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&info))
+	s.Assert().Equal("test-server", info.Identity)
+	s.Require().Len(info.EventTypes, 2)
+	s.Assert().Equal("open", info.EventTypes[0].Name)
+	s.Assert().Equal(relay.EventPayloadFormatRaw, info.EventTypes[0].Format)
+	s.Assert().Equal(1002, info.EventTypes[1].Type)
+	s.Assert().Equal(int64(3600), info.EventTypes[1].RetentionSeconds)
+	s.Assert().Equal([]string{"alice"}, info.EventTypes[1].Publishers)
+
+	newClient := func(tlsConfig *tls.Config, sink *CountingEventSource) *relay.NostrClient {
+		return relay.NewNostrClient(
+			relay.NostrClientWithServerURL("wss://localhost:8085"),
+			relay.NostrClientWithTLSConfig(tlsConfig),
+			relay.NostrClientWithEventSink(sink.Sink),
+			relay.NostrClientWithConnectionStatusCallback(
+				func(ctx context.Context, cancel context.CancelCauseFunc, client relay.RelayClient, remoteServerIdentity string, status bool) {
+					if status {
+						client.Subscribe(context.Background(), 0)
+					}
+				},
+			),
+		)
+	}
+	aliceSink := &CountingEventSource{}
+	alice := newClient(&tls.Config{RootCAs: caPool, Certificates: []tls.Certificate{aliceCert}}, aliceSink)
+	defer alice.Close()
+	anonymousSink := &CountingEventSource{}
+	anonymous := newClient(&tls.Config{RootCAs: caPool}, anonymousSink)
+	defer anonymous.Close()
+
+	ctx := context.Background()
+	s.Require().NoError(alice.Publish(ctx, 1001, []byte("open from alice")))
+	s.Require().NoError(alice.Publish(ctx, 1002, []byte("for alice")))
+	s.Assert().Error(alice.Publish(ctx, 1002, []byte("too large payload for the type")))
+	s.Assert().Error(alice.Publish(ctx, 1003, []byte("unknown type")))
+	s.Require().NoError(anonymous.Publish(ctx, 1001, []byte("open from anonymous")))
+	s.Assert().Error(anonymous.Publish(ctx, 1002, []byte("from anonymous")))
+	time.Sleep(1500 * time.Millisecond)
+
+	received := func(sink *CountingEventSource) []string {
+		sink.mtx.Lock()
+		defer sink.mtx.Unlock()
+		data := make([]string, 0)
+		for _, event := range sink.source.GetEvents() {
+			data = append(data, string(event.Data))
+		}
+		return data
+	}
+	s.Assert().Equal([]string{"open from alice", "for alice", "open from anonymous"}, received(aliceSink))
+	s.Assert().Equal([]string{"open from alice", "open from anonymous"}, received(anonymousSink))
+}
+
+func (s *NostrRelayServerTestSuite) TestSignedCreatedAt() {
+	signer, rootCert := newTestEventSigner(s.T())
+	eventSource := &CountingEventSource{}
+	srv := relay.NewNostrServer(
+		relay.NostrServerAddress("localhost:8086"),
+		relay.NostrServerWithEventSource(eventSource.Pull),
+		relay.NostrServerWithEventSink(eventSource.Sink),
+		relay.NostrServerWithIdentity("test-server"),
+		relay.NostrServerWithClockSkew(time.Minute),
+		relay.NostrServerWithSignatureRootCerts([]*x509.Certificate{rootCert}),
+	)
+	go func() {
+		srv.ListenAndServe()
+	}()
+	defer srv.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	clientEventSink := &CountingEventSource{}
+	client := relay.NewNostrClient(
+		relay.NostrClientWithServerURL("ws://localhost:8086"),
+		relay.NostrClientWithSigner(signer),
+		relay.NostrClientWithEventSink(clientEventSink.Sink),
+		relay.NostrClientWithConnectionStatusCallback(
+			func(ctx context.Context, cancel context.CancelCauseFunc, client relay.RelayClient, remoteServerIdentity string, status bool) {
+				if status {
+					client.Subscribe(context.Background(), 0)
+				}
+			},
+		),
+	)
+	defer client.Close()
+
+	before := time.Now().Unix()
+	s.Require().NoError(client.Publish(context.Background(), 1001, []byte("signed"), "alice"))
+	time.Sleep(1500 * time.Millisecond)
+
+	clientEventSink.mtx.Lock()
+	defer clientEventSink.mtx.Unlock()
+	events := clientEventSink.source.GetEvents()
+	s.Require().Len(events, 1)
+	event := events[0]
+	s.Assert().GreaterOrEqual(event.CreatedAt, before)
+	s.Assert().GreaterOrEqual(event.Timestamp, event.CreatedAt)
+	s.Require().NotNil(event.Signature)
+	s.Assert().NoError(relay.VerifyEventSignature(event.Signature, []*x509.Certificate{rootCert}, event.Type, event.CreatedAt, event.Data, event.Tags))
+}
+
+// newTestCertificate issues a certificate for the common name. It is self-signed if parent is nil.
+func newTestCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{commonName},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
 var limiter = rate.NewLimiter(0.2, 1)
 
 func eventSource(ctx context.Context, request relay.EventSourcePullingRequest) (relay.EventSourcePullingResponse, error) {
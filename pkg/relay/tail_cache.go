@@ -0,0 +1,199 @@
+package relay
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const tailCachePullingLength = 500
+
+// eventTailCache keeps the most recent events of the EventSource in a ring buffer.
+// It is shared by all subscriptions of a NostrServer. Only one goroutine pulls the EventSource for the head of
+// the event log, so subscriptions near the head don't have to query the EventSource by themselves.
+type eventTailCache struct {
+	eventSource EventSource
+	capacity    int
+
+	mtx        sync.RWMutex
+	ready      bool          // The cache has caught up with the head of the EventSource.
+	events     []Event       // Ring buffer of events ordered by offset.
+	head       int           // Index of the oldest event in the ring buffer.
+	count      int           // Number of events in the ring buffer.
+	lowOffset  int64         // All events with offset not less than lowOffset are in the cache.
+	nextOffset int64         // The offset to pull from the EventSource next time.
+	updated    chan struct{} // Closed and replaced whenever new events are added.
+
+	runMux    sync.Mutex
+	pokeChan  chan struct{}
+	closeChan chan struct{}
+	doneChan  chan struct{}
+}
+
+func newEventTailCache(eventSource EventSource, capacity int) *eventTailCache {
+	return &eventTailCache{
+		eventSource: eventSource,
+		capacity:    capacity,
+		events:      make([]Event, capacity),
+		updated:     make(chan struct{}),
+		pokeChan:    make(chan struct{}, 1),
+	}
+}
+
+func (c *eventTailCache) start() {
+	c.runMux.Lock()
+	defer c.runMux.Unlock()
+
+	if c.closeChan != nil {
+		return
+	}
+	c.closeChan = make(chan struct{})
+	c.doneChan = make(chan struct{})
+	go c.pullingTask(c.closeChan, c.doneChan)
+}
+
+func (c *eventTailCache) stop() {
+	c.runMux.Lock()
+	defer c.runMux.Unlock()
+
+	if c.closeChan == nil {
+		return
+	}
+	close(c.closeChan)
+	<-c.doneChan
+	c.closeChan = nil
+	c.doneChan = nil
+}
+
+// poke asks the cache to pull the EventSource as soon as possible.
+func (c *eventTailCache) poke() {
+	select {
+	case c.pokeChan <- struct{}{}:
+	default:
+	}
+}
+
+// Updated returns a channel which is closed when new events are added into the cache.
+func (c *eventTailCache) Updated() <-chan struct{} {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.updated
+}
+
+// Pull serves the request from the cache. The second return value is false if the requested offset is
+// not covered by the cache. The caller has to fall back to the EventSource in that case.
+func (c *eventTailCache) Pull(request EventSourcePullingRequest) (EventSourcePullingResponse, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	if !c.ready || request.Offset < c.lowOffset {
+		return EventSourcePullingResponse{}, false
+	}
+
+	response := EventSourcePullingResponse{}
+	for i := 0; i < c.count && len(response.Events) < request.Length; i++ {
+		event := c.events[(c.head+i)%c.capacity]
+		if event.Offset < request.Offset {
+			continue
+		}
+		if request.Type != 0 && event.Type != request.Type {
+			continue
+		}
+		if !EventTaggedWithAny(event, request.Recipients) {
+			continue
+		}
+		response.Events = append(response.Events, event)
+		response.MaxOffset = event.Offset
+	}
+	return response, true
+}
+
+func (c *eventTailCache) pullingTask(closeChan, doneChan chan struct{}) {
+	defer close(doneChan)
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		c.mtx.RLock()
+		request := EventSourcePullingRequest{
+			Offset: c.nextOffset,
+			Length: tailCachePullingLength,
+		}
+		c.mtx.RUnlock()
+
+		response, err := c.eventSource(context.Background(), request)
+		if err != nil {
+			logrus.Errorf("tail cache failed to pull events: %v", err)
+		} else {
+			c.append(response)
+			if len(response.Events) == request.Length {
+				// There are more events to pull.
+				select {
+				case <-closeChan:
+					return
+				default:
+				}
+				continue
+			}
+			c.markReady()
+		}
+
+		select {
+		case <-closeChan:
+			return
+		case <-c.pokeChan:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *eventTailCache) append(response EventSourcePullingResponse) {
+	if len(response.Events) == 0 {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, event := range response.Events {
+		if event.Offset < c.nextOffset {
+			continue
+		}
+		if c.count == c.capacity {
+			evicted := c.events[c.head]
+			c.head = (c.head + 1) % c.capacity
+			c.count--
+			c.lowOffset = evicted.Offset + 1
+		}
+		c.events[(c.head+c.count)%c.capacity] = event
+		c.count++
+	}
+	c.nextOffset = response.MaxOffset + 1
+
+	close(c.updated)
+	c.updated = make(chan struct{})
+}
+
+func (c *eventTailCache) markReady() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.ready = true
+}
+
+// EventTaggedWithAny returns true if recipients is empty or the event is tagged with any of recipients.
+func EventTaggedWithAny(event Event, recipients []string) bool {
+	if len(recipients) == 0 {
+		return true
+	}
+	for _, tag := range event.Tags {
+		for _, recipient := range recipients {
+			if tag == recipient {
+				return true
+			}
+		}
+	}
+	return false
+}
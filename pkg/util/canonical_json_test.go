@@ -0,0 +1,46 @@
+package util_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/openebl/openebl/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	type inner struct {
+		Zeta  string `json:"zeta"`
+		Alpha []int  `json:"alpha"`
+	}
+	type outer struct {
+		Name  string         `json:"name"`
+		Inner inner          `json:"inner"`
+		Extra map[string]any `json:"extra"`
+	}
+
+	value := outer{
+		Name:  "<a&b>",
+		Inner: inner{Zeta: "z", Alpha: []int{3, 2, 1}},
+		Extra: map[string]any{"b": nil, "a": true},
+	}
+	expected := `{"extra":{"a":true,"b":null},"inner":{"alpha":[3,2,1],"zeta":"z"},"name":"<a&b>"}`
+
+	result, err := util.CanonicalJSON(value)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(result))
+
+	// The same value written in another way has the same canonical form. Numbers keep their precision.
+	raw := json.RawMessage(" {\"name\" : \"\\u003ca\\u0026b\\u003e\",\n \"inner\": {\"zeta\": \"z\", \"alpha\": [3, 2, 1]}, \"extra\": {\"b\": null, \"a\": true}} ")
+	result, err = util.CanonicalJSON(raw)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(result))
+
+	result, err = util.CanonicalJSON(json.RawMessage(`{"weight": 12345678901234567890.123456789}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"weight":12345678901234567890.123456789}`, string(result))
+
+	_, err = util.CanonicalJSON(json.RawMessage(`{"a": 1} {"b": 2}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,202 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/bu_server/trade_document/bill_of_lading_controller.go
+
+// Package mock_trade_document is a generated GoMock package.
+package mock_trade_document
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/openebl/openebl/pkg/bu_server/model"
+	trade_document "github.com/openebl/openebl/pkg/bu_server/trade_document"
+)
+
+// MockBillOfLadingController is a mock of BillOfLadingController interface.
+type MockBillOfLadingController struct {
+	ctrl     *gomock.Controller
+	recorder *MockBillOfLadingControllerMockRecorder
+}
+
+// MockBillOfLadingControllerMockRecorder is the mock recorder for MockBillOfLadingController.
+type MockBillOfLadingControllerMockRecorder struct {
+	mock *MockBillOfLadingController
+}
+
+// NewMockBillOfLadingController creates a new mock instance.
+func NewMockBillOfLadingController(ctrl *gomock.Controller) *MockBillOfLadingController {
+	mock := &MockBillOfLadingController{ctrl: ctrl}
+	mock.recorder = &MockBillOfLadingControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBillOfLadingController) EXPECT() *MockBillOfLadingControllerMockRecorder {
+	return m.recorder
+}
+
+// Amend mocks base method.
+func (m *MockBillOfLadingController) Amend(ctx context.Context, ts int64, req trade_document.BillOfLadingActionRequest) (trade_document.BillOfLadingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Amend", ctx, ts, req)
+	ret0, _ := ret[0].(trade_document.BillOfLadingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Amend indicates an expected call of Amend.
+func (mr *MockBillOfLadingControllerMockRecorder) Amend(ctx, ts, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Amend", reflect.TypeOf((*MockBillOfLadingController)(nil).Amend), ctx, ts, req)
+}
+
+// AmendmentRequest mocks base method.
+func (m *MockBillOfLadingController) AmendmentRequest(ctx context.Context, ts int64, req trade_document.BillOfLadingActionRequest) (trade_document.BillOfLadingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AmendmentRequest", ctx, ts, req)
+	ret0, _ := ret[0].(trade_document.BillOfLadingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AmendmentRequest indicates an expected call of AmendmentRequest.
+func (mr *MockBillOfLadingControllerMockRecorder) AmendmentRequest(ctx, ts, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AmendmentRequest", reflect.TypeOf((*MockBillOfLadingController)(nil).AmendmentRequest), ctx, ts, req)
+}
+
+// Create mocks base method.
+func (m *MockBillOfLadingController) Create(ctx context.Context, ts int64, req trade_document.CreateBillOfLadingRequest) (trade_document.BillOfLadingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, ts, req)
+	ret0, _ := ret[0].(trade_document.BillOfLadingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockBillOfLadingControllerMockRecorder) Create(ctx, ts, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBillOfLadingController)(nil).Create), ctx, ts, req)
+}
+
+// Get mocks base method.
+func (m *MockBillOfLadingController) Get(ctx context.Context, req trade_document.GetBillOfLadingRequest) (trade_document.BillOfLadingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, req)
+	ret0, _ := ret[0].(trade_document.BillOfLadingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBillOfLadingControllerMockRecorder) Get(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBillOfLadingController)(nil).Get), ctx, req)
+}
+
+// GetFile mocks base method.
+func (m *MockBillOfLadingController) GetFile(ctx context.Context, req trade_document.GetBillOfLadingFileRequest) (model.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFile", ctx, req)
+	ret0, _ := ret[0].(model.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFile indicates an expected call of GetFile.
+func (mr *MockBillOfLadingControllerMockRecorder) GetFile(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockBillOfLadingController)(nil).GetFile), ctx, req)
+}
+
+// GetHistory mocks base method.
+func (m *MockBillOfLadingController) GetHistory(ctx context.Context, req trade_document.GetBillOfLadingRequest) ([]trade_document.BillOfLadingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, req)
+	ret0, _ := ret[0].([]trade_document.BillOfLadingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockBillOfLadingControllerMockRecorder) GetHistory(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockBillOfLadingController)(nil).GetHistory), ctx, req)
+}
+
+// List mocks base method.
+func (m *MockBillOfLadingController) List(ctx context.Context, req trade_document.ListBillOfLadingRequest) (trade_document.ListBillOfLadingResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, req)
+	ret0, _ := ret[0].(trade_document.ListBillOfLadingResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockBillOfLadingControllerMockRecorder) List(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockBillOfLadingController)(nil).List), ctx, req)
+}
+
+// PrintToPaper mocks base method.
+func (m *MockBillOfLadingController) PrintToPaper(ctx context.Context, ts int64, req trade_document.BillOfLadingActionRequest) (trade_document.BillOfLadingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrintToPaper", ctx, ts, req)
+	ret0, _ := ret[0].(trade_document.BillOfLadingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PrintToPaper indicates an expected call of PrintToPaper.
+func (mr *MockBillOfLadingControllerMockRecorder) PrintToPaper(ctx, ts, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrintToPaper", reflect.TypeOf((*MockBillOfLadingController)(nil).PrintToPaper), ctx, ts, req)
+}
+
+// Return mocks base method.
+func (m *MockBillOfLadingController) Return(ctx context.Context, ts int64, req trade_document.BillOfLadingActionRequest) (trade_document.BillOfLadingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Return", ctx, ts, req)
+	ret0, _ := ret[0].(trade_document.BillOfLadingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Return indicates an expected call of Return.
+func (mr *MockBillOfLadingControllerMockRecorder) Return(ctx, ts, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Return", reflect.TypeOf((*MockBillOfLadingController)(nil).Return), ctx, ts, req)
+}
+
+// Surrender mocks base method.
+func (m *MockBillOfLadingController) Surrender(ctx context.Context, ts int64, req trade_document.BillOfLadingActionRequest) (trade_document.BillOfLadingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Surrender", ctx, ts, req)
+	ret0, _ := ret[0].(trade_document.BillOfLadingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Surrender indicates an expected call of Surrender.
+func (mr *MockBillOfLadingControllerMockRecorder) Surrender(ctx, ts, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Surrender", reflect.TypeOf((*MockBillOfLadingController)(nil).Surrender), ctx, ts, req)
+}
+
+// Transfer mocks base method.
+func (m *MockBillOfLadingController) Transfer(ctx context.Context, ts int64, req trade_document.BillOfLadingActionRequest) (trade_document.BillOfLadingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", ctx, ts, req)
+	ret0, _ := ret[0].(trade_document.BillOfLadingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockBillOfLadingControllerMockRecorder) Transfer(ctx, ts, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockBillOfLadingController)(nil).Transfer), ctx, ts, req)
+}
@@ -0,0 +1,210 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/bu_server/trade_document/bill_of_lading_publisher.go
+
+// Package mock_trade_document is a generated GoMock package.
+package mock_trade_document
+
+import (
+	context "context"
+	x509 "crypto/x509"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/openebl/openebl/pkg/bu_server/model"
+	storage "github.com/openebl/openebl/pkg/bu_server/storage"
+	trade_document "github.com/openebl/openebl/pkg/bu_server/trade_document"
+	envelope "github.com/openebl/openebl/pkg/envelope"
+)
+
+// MockCertificateResolver is a mock of CertificateResolver interface.
+type MockCertificateResolver struct {
+	ctrl     *gomock.Controller
+	recorder *MockCertificateResolverMockRecorder
+}
+
+// MockCertificateResolverMockRecorder is the mock recorder for MockCertificateResolver.
+type MockCertificateResolverMockRecorder struct {
+	mock *MockCertificateResolver
+}
+
+// NewMockCertificateResolver creates a new mock instance.
+func NewMockCertificateResolver(ctrl *gomock.Controller) *MockCertificateResolver {
+	mock := &MockCertificateResolver{ctrl: ctrl}
+	mock.recorder = &MockCertificateResolverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCertificateResolver) EXPECT() *MockCertificateResolverMockRecorder {
+	return m.recorder
+}
+
+// ResolveCertificates mocks base method.
+func (m *MockCertificateResolver) ResolveCertificates(ctx context.Context, ts int64, businessUnit string) ([]*x509.Certificate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveCertificates", ctx, ts, businessUnit)
+	ret0, _ := ret[0].([]*x509.Certificate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveCertificates indicates an expected call of ResolveCertificates.
+func (mr *MockCertificateResolverMockRecorder) ResolveCertificates(ctx, ts, businessUnit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveCertificates", reflect.TypeOf((*MockCertificateResolver)(nil).ResolveCertificates), ctx, ts, businessUnit)
+}
+
+// MockDeliveryStorage is a mock of DeliveryStorage interface.
+type MockDeliveryStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeliveryStorageMockRecorder
+}
+
+// MockDeliveryStorageMockRecorder is the mock recorder for MockDeliveryStorage.
+type MockDeliveryStorageMockRecorder struct {
+	mock *MockDeliveryStorage
+}
+
+// NewMockDeliveryStorage creates a new mock instance.
+func NewMockDeliveryStorage(ctrl *gomock.Controller) *MockDeliveryStorage {
+	mock := &MockDeliveryStorage{ctrl: ctrl}
+	mock.recorder = &MockDeliveryStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeliveryStorage) EXPECT() *MockDeliveryStorageMockRecorder {
+	return m.recorder
+}
+
+// CreateTx mocks base method.
+func (m *MockDeliveryStorage) CreateTx(ctx context.Context, options ...storage.CreateTxOption) (storage.Tx, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateTx", varargs...)
+	ret0, _ := ret[0].(storage.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTx indicates an expected call of CreateTx.
+func (mr *MockDeliveryStorageMockRecorder) CreateTx(ctx interface{}, options ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTx", reflect.TypeOf((*MockDeliveryStorage)(nil).CreateTx), varargs...)
+}
+
+// GetOutboxStats mocks base method.
+func (m *MockDeliveryStorage) GetOutboxStats(ctx context.Context, tx storage.Tx) (model.OutboxStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOutboxStats", ctx, tx)
+	ret0, _ := ret[0].(model.OutboxStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOutboxStats indicates an expected call of GetOutboxStats.
+func (mr *MockDeliveryStorageMockRecorder) GetOutboxStats(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutboxStats", reflect.TypeOf((*MockDeliveryStorage)(nil).GetOutboxStats), ctx, tx)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockDeliveryStorage) ListDeliveries(ctx context.Context, tx storage.Tx, req trade_document.ListDeliveriesRequest) (trade_document.ListDeliveriesResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", ctx, tx, req)
+	ret0, _ := ret[0].(trade_document.ListDeliveriesResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockDeliveryStorageMockRecorder) ListDeliveries(ctx, tx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockDeliveryStorage)(nil).ListDeliveries), ctx, tx, req)
+}
+
+// ListPendingOutboxMessages mocks base method.
+func (m *MockDeliveryStorage) ListPendingOutboxMessages(ctx context.Context, tx storage.Tx, ts int64, limit int) ([]model.OutboxMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingOutboxMessages", ctx, tx, ts, limit)
+	ret0, _ := ret[0].([]model.OutboxMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingOutboxMessages indicates an expected call of ListPendingOutboxMessages.
+func (mr *MockDeliveryStorageMockRecorder) ListPendingOutboxMessages(ctx, tx, ts, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingOutboxMessages", reflect.TypeOf((*MockDeliveryStorage)(nil).ListPendingOutboxMessages), ctx, tx, ts, limit)
+}
+
+// StoreDelivery mocks base method.
+func (m *MockDeliveryStorage) StoreDelivery(ctx context.Context, tx storage.Tx, delivery model.BillOfLadingDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreDelivery", ctx, tx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreDelivery indicates an expected call of StoreDelivery.
+func (mr *MockDeliveryStorageMockRecorder) StoreDelivery(ctx, tx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreDelivery", reflect.TypeOf((*MockDeliveryStorage)(nil).StoreDelivery), ctx, tx, delivery)
+}
+
+// StoreOutboxMessage mocks base method.
+func (m *MockDeliveryStorage) StoreOutboxMessage(ctx context.Context, tx storage.Tx, msg model.OutboxMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreOutboxMessage", ctx, tx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreOutboxMessage indicates an expected call of StoreOutboxMessage.
+func (mr *MockDeliveryStorageMockRecorder) StoreOutboxMessage(ctx, tx, msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreOutboxMessage", reflect.TypeOf((*MockDeliveryStorage)(nil).StoreOutboxMessage), ctx, tx, msg)
+}
+
+// MockBillOfLadingPublisher is a mock of BillOfLadingPublisher interface.
+type MockBillOfLadingPublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockBillOfLadingPublisherMockRecorder
+}
+
+// MockBillOfLadingPublisherMockRecorder is the mock recorder for MockBillOfLadingPublisher.
+type MockBillOfLadingPublisherMockRecorder struct {
+	mock *MockBillOfLadingPublisher
+}
+
+// NewMockBillOfLadingPublisher creates a new mock instance.
+func NewMockBillOfLadingPublisher(ctrl *gomock.Controller) *MockBillOfLadingPublisher {
+	mock := &MockBillOfLadingPublisher{ctrl: ctrl}
+	mock.recorder = &MockBillOfLadingPublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBillOfLadingPublisher) EXPECT() *MockBillOfLadingPublisherMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockBillOfLadingPublisher) Publish(ctx context.Context, tx storage.Tx, ts int64, signedPack envelope.JWS) (*envelope.JWE, model.BillOfLadingDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, tx, ts, signedPack)
+	ret0, _ := ret[0].(*envelope.JWE)
+	ret1, _ := ret[1].(model.BillOfLadingDelivery)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockBillOfLadingPublisherMockRecorder) Publish(ctx, tx, ts, signedPack interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockBillOfLadingPublisher)(nil).Publish), ctx, tx, ts, signedPack)
+}
@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/bu_server/trade_document/bill_of_lading_signature.go
+
+// Package mock_trade_document is a generated GoMock package.
+package mock_trade_document
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	bill_of_lading "github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	envelope "github.com/openebl/openebl/pkg/envelope"
+)
+
+// MockBillOfLadingPackSigner is a mock of BillOfLadingPackSigner interface.
+type MockBillOfLadingPackSigner struct {
+	ctrl     *gomock.Controller
+	recorder *MockBillOfLadingPackSignerMockRecorder
+}
+
+// MockBillOfLadingPackSignerMockRecorder is the mock recorder for MockBillOfLadingPackSigner.
+type MockBillOfLadingPackSignerMockRecorder struct {
+	mock *MockBillOfLadingPackSigner
+}
+
+// NewMockBillOfLadingPackSigner creates a new mock instance.
+func NewMockBillOfLadingPackSigner(ctrl *gomock.Controller) *MockBillOfLadingPackSigner {
+	mock := &MockBillOfLadingPackSigner{ctrl: ctrl}
+	mock.recorder = &MockBillOfLadingPackSignerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBillOfLadingPackSigner) EXPECT() *MockBillOfLadingPackSignerMockRecorder {
+	return m.recorder
+}
+
+// Sign mocks base method.
+func (m *MockBillOfLadingPackSigner) Sign(ctx context.Context, ts int64, pack bill_of_lading.BillOfLadingPack) (envelope.JWS, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sign", ctx, ts, pack)
+	ret0, _ := ret[0].(envelope.JWS)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sign indicates an expected call of Sign.
+func (mr *MockBillOfLadingPackSignerMockRecorder) Sign(ctx, ts, pack interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sign", reflect.TypeOf((*MockBillOfLadingPackSigner)(nil).Sign), ctx, ts, pack)
+}
@@ -0,0 +1,421 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/bu_server/trade_document/bill_of_lading_storage.go
+
+// Package mock_trade_document is a generated GoMock package.
+package mock_trade_document
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/openebl/openebl/pkg/bu_server/model"
+	storage "github.com/openebl/openebl/pkg/bu_server/storage"
+	trade_document "github.com/openebl/openebl/pkg/bu_server/trade_document"
+)
+
+// MockTradeDocumentStorage is a mock of TradeDocumentStorage interface.
+type MockTradeDocumentStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockTradeDocumentStorageMockRecorder
+}
+
+// MockTradeDocumentStorageMockRecorder is the mock recorder for MockTradeDocumentStorage.
+type MockTradeDocumentStorageMockRecorder struct {
+	mock *MockTradeDocumentStorage
+}
+
+// NewMockTradeDocumentStorage creates a new mock instance.
+func NewMockTradeDocumentStorage(ctrl *gomock.Controller) *MockTradeDocumentStorage {
+	mock := &MockTradeDocumentStorage{ctrl: ctrl}
+	mock.recorder = &MockTradeDocumentStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTradeDocumentStorage) EXPECT() *MockTradeDocumentStorageMockRecorder {
+	return m.recorder
+}
+
+// CreateTx mocks base method.
+func (m *MockTradeDocumentStorage) CreateTx(ctx context.Context, options ...storage.CreateTxOption) (storage.Tx, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateTx", varargs...)
+	ret0, _ := ret[0].(storage.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTx indicates an expected call of CreateTx.
+func (mr *MockTradeDocumentStorageMockRecorder) CreateTx(ctx interface{}, options ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTx", reflect.TypeOf((*MockTradeDocumentStorage)(nil).CreateTx), varargs...)
+}
+
+// GetBillOfLadingPackHistory mocks base method.
+func (m *MockTradeDocumentStorage) GetBillOfLadingPackHistory(ctx context.Context, tx storage.Tx, packID string) ([]trade_document.BillOfLadingPackRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBillOfLadingPackHistory", ctx, tx, packID)
+	ret0, _ := ret[0].([]trade_document.BillOfLadingPackRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBillOfLadingPackHistory indicates an expected call of GetBillOfLadingPackHistory.
+func (mr *MockTradeDocumentStorageMockRecorder) GetBillOfLadingPackHistory(ctx, tx, packID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBillOfLadingPackHistory", reflect.TypeOf((*MockTradeDocumentStorage)(nil).GetBillOfLadingPackHistory), ctx, tx, packID)
+}
+
+// ListBillOfLadingPacks mocks base method.
+func (m *MockTradeDocumentStorage) ListBillOfLadingPacks(ctx context.Context, tx storage.Tx, req trade_document.ListBillOfLadingPacksRequest) (trade_document.ListBillOfLadingPacksResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBillOfLadingPacks", ctx, tx, req)
+	ret0, _ := ret[0].(trade_document.ListBillOfLadingPacksResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBillOfLadingPacks indicates an expected call of ListBillOfLadingPacks.
+func (mr *MockTradeDocumentStorageMockRecorder) ListBillOfLadingPacks(ctx, tx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBillOfLadingPacks", reflect.TypeOf((*MockTradeDocumentStorage)(nil).ListBillOfLadingPacks), ctx, tx, req)
+}
+
+// StoreBillOfLadingPack mocks base method.
+func (m *MockTradeDocumentStorage) StoreBillOfLadingPack(ctx context.Context, tx storage.Tx, record trade_document.BillOfLadingPackRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreBillOfLadingPack", ctx, tx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreBillOfLadingPack indicates an expected call of StoreBillOfLadingPack.
+func (mr *MockTradeDocumentStorageMockRecorder) StoreBillOfLadingPack(ctx, tx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreBillOfLadingPack", reflect.TypeOf((*MockTradeDocumentStorage)(nil).StoreBillOfLadingPack), ctx, tx, record)
+}
+
+// MockBillOfLadingInboxStorage is a mock of BillOfLadingInboxStorage interface.
+type MockBillOfLadingInboxStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockBillOfLadingInboxStorageMockRecorder
+}
+
+// MockBillOfLadingInboxStorageMockRecorder is the mock recorder for MockBillOfLadingInboxStorage.
+type MockBillOfLadingInboxStorageMockRecorder struct {
+	mock *MockBillOfLadingInboxStorage
+}
+
+// NewMockBillOfLadingInboxStorage creates a new mock instance.
+func NewMockBillOfLadingInboxStorage(ctrl *gomock.Controller) *MockBillOfLadingInboxStorage {
+	mock := &MockBillOfLadingInboxStorage{ctrl: ctrl}
+	mock.recorder = &MockBillOfLadingInboxStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBillOfLadingInboxStorage) EXPECT() *MockBillOfLadingInboxStorageMockRecorder {
+	return m.recorder
+}
+
+// CreateTx mocks base method.
+func (m *MockBillOfLadingInboxStorage) CreateTx(ctx context.Context, options ...storage.CreateTxOption) (storage.Tx, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateTx", varargs...)
+	ret0, _ := ret[0].(storage.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTx indicates an expected call of CreateTx.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) CreateTx(ctx interface{}, options ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTx", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).CreateTx), varargs...)
+}
+
+// DeletePendingFiles mocks base method.
+func (m *MockBillOfLadingInboxStorage) DeletePendingFiles(ctx context.Context, tx storage.Tx, packID string, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePendingFiles", ctx, tx, packID, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePendingFiles indicates an expected call of DeletePendingFiles.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) DeletePendingFiles(ctx, tx, packID, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingFiles", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).DeletePendingFiles), ctx, tx, packID, version)
+}
+
+// GetBillOfLadingPackHistory mocks base method.
+func (m *MockBillOfLadingInboxStorage) GetBillOfLadingPackHistory(ctx context.Context, tx storage.Tx, packID string) ([]trade_document.BillOfLadingPackRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBillOfLadingPackHistory", ctx, tx, packID)
+	ret0, _ := ret[0].([]trade_document.BillOfLadingPackRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBillOfLadingPackHistory indicates an expected call of GetBillOfLadingPackHistory.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) GetBillOfLadingPackHistory(ctx, tx, packID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBillOfLadingPackHistory", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).GetBillOfLadingPackHistory), ctx, tx, packID)
+}
+
+// GetRelayCheckpoint mocks base method.
+func (m *MockBillOfLadingInboxStorage) GetRelayCheckpoint(ctx context.Context, tx storage.Tx, relayServer string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRelayCheckpoint", ctx, tx, relayServer)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelayCheckpoint indicates an expected call of GetRelayCheckpoint.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) GetRelayCheckpoint(ctx, tx, relayServer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRelayCheckpoint", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).GetRelayCheckpoint), ctx, tx, relayServer)
+}
+
+// GetRelayEvent mocks base method.
+func (m *MockBillOfLadingInboxStorage) GetRelayEvent(ctx context.Context, tx storage.Tx, eventID string) (*model.RelayEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRelayEvent", ctx, tx, eventID)
+	ret0, _ := ret[0].(*model.RelayEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelayEvent indicates an expected call of GetRelayEvent.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) GetRelayEvent(ctx, tx, eventID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRelayEvent", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).GetRelayEvent), ctx, tx, eventID)
+}
+
+// ListBillOfLadingPacks mocks base method.
+func (m *MockBillOfLadingInboxStorage) ListBillOfLadingPacks(ctx context.Context, tx storage.Tx, req trade_document.ListBillOfLadingPacksRequest) (trade_document.ListBillOfLadingPacksResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBillOfLadingPacks", ctx, tx, req)
+	ret0, _ := ret[0].(trade_document.ListBillOfLadingPacksResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBillOfLadingPacks indicates an expected call of ListBillOfLadingPacks.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) ListBillOfLadingPacks(ctx, tx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBillOfLadingPacks", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).ListBillOfLadingPacks), ctx, tx, req)
+}
+
+// ListPendingFiles mocks base method.
+func (m *MockBillOfLadingInboxStorage) ListPendingFiles(ctx context.Context, tx storage.Tx, packID string, version int64) ([]trade_document.PendingBillOfLadingFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingFiles", ctx, tx, packID, version)
+	ret0, _ := ret[0].([]trade_document.PendingBillOfLadingFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingFiles indicates an expected call of ListPendingFiles.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) ListPendingFiles(ctx, tx, packID, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingFiles", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).ListPendingFiles), ctx, tx, packID, version)
+}
+
+// StoreBillOfLadingPack mocks base method.
+func (m *MockBillOfLadingInboxStorage) StoreBillOfLadingPack(ctx context.Context, tx storage.Tx, record trade_document.BillOfLadingPackRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreBillOfLadingPack", ctx, tx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreBillOfLadingPack indicates an expected call of StoreBillOfLadingPack.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) StoreBillOfLadingPack(ctx, tx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreBillOfLadingPack", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).StoreBillOfLadingPack), ctx, tx, record)
+}
+
+// StorePendingFile mocks base method.
+func (m *MockBillOfLadingInboxStorage) StorePendingFile(ctx context.Context, tx storage.Tx, file trade_document.PendingBillOfLadingFile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorePendingFile", ctx, tx, file)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StorePendingFile indicates an expected call of StorePendingFile.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) StorePendingFile(ctx, tx, file interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePendingFile", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).StorePendingFile), ctx, tx, file)
+}
+
+// StoreRelayCheckpoint mocks base method.
+func (m *MockBillOfLadingInboxStorage) StoreRelayCheckpoint(ctx context.Context, tx storage.Tx, ts int64, relayServer string, offset int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreRelayCheckpoint", ctx, tx, ts, relayServer, offset)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreRelayCheckpoint indicates an expected call of StoreRelayCheckpoint.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) StoreRelayCheckpoint(ctx, tx, ts, relayServer, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreRelayCheckpoint", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).StoreRelayCheckpoint), ctx, tx, ts, relayServer, offset)
+}
+
+// StoreRelayEvent mocks base method.
+func (m *MockBillOfLadingInboxStorage) StoreRelayEvent(ctx context.Context, tx storage.Tx, event model.RelayEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreRelayEvent", ctx, tx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreRelayEvent indicates an expected call of StoreRelayEvent.
+func (mr *MockBillOfLadingInboxStorageMockRecorder) StoreRelayEvent(ctx, tx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreRelayEvent", reflect.TypeOf((*MockBillOfLadingInboxStorage)(nil).StoreRelayEvent), ctx, tx, event)
+}
+
+// MockDCSAStorage is a mock of DCSAStorage interface.
+type MockDCSAStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockDCSAStorageMockRecorder
+}
+
+// MockDCSAStorageMockRecorder is the mock recorder for MockDCSAStorage.
+type MockDCSAStorageMockRecorder struct {
+	mock *MockDCSAStorage
+}
+
+// NewMockDCSAStorage creates a new mock instance.
+func NewMockDCSAStorage(ctrl *gomock.Controller) *MockDCSAStorage {
+	mock := &MockDCSAStorage{ctrl: ctrl}
+	mock.recorder = &MockDCSAStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDCSAStorage) EXPECT() *MockDCSAStorageMockRecorder {
+	return m.recorder
+}
+
+// CreateTx mocks base method.
+func (m *MockDCSAStorage) CreateTx(ctx context.Context, options ...storage.CreateTxOption) (storage.Tx, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateTx", varargs...)
+	ret0, _ := ret[0].(storage.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTx indicates an expected call of CreateTx.
+func (mr *MockDCSAStorageMockRecorder) CreateTx(ctx interface{}, options ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTx", reflect.TypeOf((*MockDCSAStorage)(nil).CreateTx), varargs...)
+}
+
+// GetBillOfLadingPackHistory mocks base method.
+func (m *MockDCSAStorage) GetBillOfLadingPackHistory(ctx context.Context, tx storage.Tx, packID string) ([]trade_document.BillOfLadingPackRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBillOfLadingPackHistory", ctx, tx, packID)
+	ret0, _ := ret[0].([]trade_document.BillOfLadingPackRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBillOfLadingPackHistory indicates an expected call of GetBillOfLadingPackHistory.
+func (mr *MockDCSAStorageMockRecorder) GetBillOfLadingPackHistory(ctx, tx, packID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBillOfLadingPackHistory", reflect.TypeOf((*MockDCSAStorage)(nil).GetBillOfLadingPackHistory), ctx, tx, packID)
+}
+
+// ListBillOfLadingPacks mocks base method.
+func (m *MockDCSAStorage) ListBillOfLadingPacks(ctx context.Context, tx storage.Tx, req trade_document.ListBillOfLadingPacksRequest) (trade_document.ListBillOfLadingPacksResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBillOfLadingPacks", ctx, tx, req)
+	ret0, _ := ret[0].(trade_document.ListBillOfLadingPacksResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBillOfLadingPacks indicates an expected call of ListBillOfLadingPacks.
+func (mr *MockDCSAStorageMockRecorder) ListBillOfLadingPacks(ctx, tx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBillOfLadingPacks", reflect.TypeOf((*MockDCSAStorage)(nil).ListBillOfLadingPacks), ctx, tx, req)
+}
+
+// ListShipmentEvents mocks base method.
+func (m *MockDCSAStorage) ListShipmentEvents(ctx context.Context, tx storage.Tx, req trade_document.ListShipmentEventsRequest) (trade_document.ListShipmentEventsResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShipmentEvents", ctx, tx, req)
+	ret0, _ := ret[0].(trade_document.ListShipmentEventsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShipmentEvents indicates an expected call of ListShipmentEvents.
+func (mr *MockDCSAStorageMockRecorder) ListShipmentEvents(ctx, tx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShipmentEvents", reflect.TypeOf((*MockDCSAStorage)(nil).ListShipmentEvents), ctx, tx, req)
+}
+
+// ListShippingInstructions mocks base method.
+func (m *MockDCSAStorage) ListShippingInstructions(ctx context.Context, tx storage.Tx, req trade_document.ListShippingInstructionsRequest) (trade_document.ListShippingInstructionsResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShippingInstructions", ctx, tx, req)
+	ret0, _ := ret[0].(trade_document.ListShippingInstructionsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShippingInstructions indicates an expected call of ListShippingInstructions.
+func (mr *MockDCSAStorageMockRecorder) ListShippingInstructions(ctx, tx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShippingInstructions", reflect.TypeOf((*MockDCSAStorage)(nil).ListShippingInstructions), ctx, tx, req)
+}
+
+// StoreBillOfLadingPack mocks base method.
+func (m *MockDCSAStorage) StoreBillOfLadingPack(ctx context.Context, tx storage.Tx, record trade_document.BillOfLadingPackRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreBillOfLadingPack", ctx, tx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreBillOfLadingPack indicates an expected call of StoreBillOfLadingPack.
+func (mr *MockDCSAStorageMockRecorder) StoreBillOfLadingPack(ctx, tx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreBillOfLadingPack", reflect.TypeOf((*MockDCSAStorage)(nil).StoreBillOfLadingPack), ctx, tx, record)
+}
+
+// StoreShippingInstruction mocks base method.
+func (m *MockDCSAStorage) StoreShippingInstruction(ctx context.Context, tx storage.Tx, record trade_document.ShippingInstructionRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreShippingInstruction", ctx, tx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreShippingInstruction indicates an expected call of StoreShippingInstruction.
+func (mr *MockDCSAStorageMockRecorder) StoreShippingInstruction(ctx, tx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreShippingInstruction", reflect.TypeOf((*MockDCSAStorage)(nil).StoreShippingInstruction), ctx, tx, record)
+}
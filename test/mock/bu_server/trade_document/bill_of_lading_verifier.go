@@ -0,0 +1,67 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/bu_server/trade_document/bill_of_lading_verifier.go
+
+// Package mock_trade_document is a generated GoMock package.
+package mock_trade_document
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	trade_document "github.com/openebl/openebl/pkg/bu_server/trade_document"
+	envelope "github.com/openebl/openebl/pkg/envelope"
+)
+
+// MockBillOfLadingVerifier is a mock of BillOfLadingVerifier interface.
+type MockBillOfLadingVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockBillOfLadingVerifierMockRecorder
+}
+
+// MockBillOfLadingVerifierMockRecorder is the mock recorder for MockBillOfLadingVerifier.
+type MockBillOfLadingVerifierMockRecorder struct {
+	mock *MockBillOfLadingVerifier
+}
+
+// NewMockBillOfLadingVerifier creates a new mock instance.
+func NewMockBillOfLadingVerifier(ctrl *gomock.Controller) *MockBillOfLadingVerifier {
+	mock := &MockBillOfLadingVerifier{ctrl: ctrl}
+	mock.recorder = &MockBillOfLadingVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBillOfLadingVerifier) EXPECT() *MockBillOfLadingVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifyHash mocks base method.
+func (m *MockBillOfLadingVerifier) VerifyHash(ctx context.Context, ts int64, req trade_document.VerifyBillOfLadingHashRequest) (trade_document.BillOfLadingVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyHash", ctx, ts, req)
+	ret0, _ := ret[0].(trade_document.BillOfLadingVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyHash indicates an expected call of VerifyHash.
+func (mr *MockBillOfLadingVerifierMockRecorder) VerifyHash(ctx, ts, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyHash", reflect.TypeOf((*MockBillOfLadingVerifier)(nil).VerifyHash), ctx, ts, req)
+}
+
+// VerifySignedPack mocks base method.
+func (m *MockBillOfLadingVerifier) VerifySignedPack(ctx context.Context, ts int64, signedPack envelope.JWS) (trade_document.BillOfLadingVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySignedPack", ctx, ts, signedPack)
+	ret0, _ := ret[0].(trade_document.BillOfLadingVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifySignedPack indicates an expected call of VerifySignedPack.
+func (mr *MockBillOfLadingVerifierMockRecorder) VerifySignedPack(ctx, ts, signedPack interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySignedPack", reflect.TypeOf((*MockBillOfLadingVerifier)(nil).VerifySignedPack), ctx, ts, signedPack)
+}
@@ -0,0 +1,142 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/bu_server/trade_document/dcsa_controller.go
+
+// Package mock_trade_document is a generated GoMock package.
+package mock_trade_document
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	bill_of_lading "github.com/openebl/openebl/pkg/bu_server/model/trade_document/bill_of_lading"
+	trade_document "github.com/openebl/openebl/pkg/bu_server/trade_document"
+)
+
+// MockDCSAController is a mock of DCSAController interface.
+type MockDCSAController struct {
+	ctrl     *gomock.Controller
+	recorder *MockDCSAControllerMockRecorder
+}
+
+// MockDCSAControllerMockRecorder is the mock recorder for MockDCSAController.
+type MockDCSAControllerMockRecorder struct {
+	mock *MockDCSAController
+}
+
+// NewMockDCSAController creates a new mock instance.
+func NewMockDCSAController(ctrl *gomock.Controller) *MockDCSAController {
+	mock := &MockDCSAController{ctrl: ctrl}
+	mock.recorder = &MockDCSAControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDCSAController) EXPECT() *MockDCSAControllerMockRecorder {
+	return m.recorder
+}
+
+// CreateShippingInstruction mocks base method.
+func (m *MockDCSAController) CreateShippingInstruction(ctx context.Context, ts int64, req trade_document.CreateShippingInstructionRequest) (bill_of_lading.ShippingInstructionRefStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShippingInstruction", ctx, ts, req)
+	ret0, _ := ret[0].(bill_of_lading.ShippingInstructionRefStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateShippingInstruction indicates an expected call of CreateShippingInstruction.
+func (mr *MockDCSAControllerMockRecorder) CreateShippingInstruction(ctx, ts, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShippingInstruction", reflect.TypeOf((*MockDCSAController)(nil).CreateShippingInstruction), ctx, ts, req)
+}
+
+// GetShippingInstruction mocks base method.
+func (m *MockDCSAController) GetShippingInstruction(ctx context.Context, req trade_document.GetDCSADocumentRequest) (bill_of_lading.ShippingInstruction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShippingInstruction", ctx, req)
+	ret0, _ := ret[0].(bill_of_lading.ShippingInstruction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShippingInstruction indicates an expected call of GetShippingInstruction.
+func (mr *MockDCSAControllerMockRecorder) GetShippingInstruction(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShippingInstruction", reflect.TypeOf((*MockDCSAController)(nil).GetShippingInstruction), ctx, req)
+}
+
+// GetTransportDocument mocks base method.
+func (m *MockDCSAController) GetTransportDocument(ctx context.Context, req trade_document.GetDCSADocumentRequest) (bill_of_lading.TransportDocument, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransportDocument", ctx, req)
+	ret0, _ := ret[0].(bill_of_lading.TransportDocument)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransportDocument indicates an expected call of GetTransportDocument.
+func (mr *MockDCSAControllerMockRecorder) GetTransportDocument(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransportDocument", reflect.TypeOf((*MockDCSAController)(nil).GetTransportDocument), ctx, req)
+}
+
+// ListEvents mocks base method.
+func (m *MockDCSAController) ListEvents(ctx context.Context, req trade_document.ListDCSAEventsRequest) (trade_document.ListShipmentEventsResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", ctx, req)
+	ret0, _ := ret[0].(trade_document.ListShipmentEventsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEvents indicates an expected call of ListEvents.
+func (mr *MockDCSAControllerMockRecorder) ListEvents(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockDCSAController)(nil).ListEvents), ctx, req)
+}
+
+// ListShippingInstructions mocks base method.
+func (m *MockDCSAController) ListShippingInstructions(ctx context.Context, req trade_document.ListShippingInstructionSummariesRequest) (trade_document.ListShippingInstructionSummariesResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShippingInstructions", ctx, req)
+	ret0, _ := ret[0].(trade_document.ListShippingInstructionSummariesResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShippingInstructions indicates an expected call of ListShippingInstructions.
+func (mr *MockDCSAControllerMockRecorder) ListShippingInstructions(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShippingInstructions", reflect.TypeOf((*MockDCSAController)(nil).ListShippingInstructions), ctx, req)
+}
+
+// ListTransportDocuments mocks base method.
+func (m *MockDCSAController) ListTransportDocuments(ctx context.Context, req trade_document.ListTransportDocumentSummariesRequest) (trade_document.ListTransportDocumentSummariesResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransportDocuments", ctx, req)
+	ret0, _ := ret[0].(trade_document.ListTransportDocumentSummariesResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransportDocuments indicates an expected call of ListTransportDocuments.
+func (mr *MockDCSAControllerMockRecorder) ListTransportDocuments(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransportDocuments", reflect.TypeOf((*MockDCSAController)(nil).ListTransportDocuments), ctx, req)
+}
+
+// UpdateShippingInstruction mocks base method.
+func (m *MockDCSAController) UpdateShippingInstruction(ctx context.Context, ts int64, req trade_document.UpdateShippingInstructionRequest) (bill_of_lading.ShippingInstructionRefStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateShippingInstruction", ctx, ts, req)
+	ret0, _ := ret[0].(bill_of_lading.ShippingInstructionRefStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateShippingInstruction indicates an expected call of UpdateShippingInstruction.
+func (mr *MockDCSAControllerMockRecorder) UpdateShippingInstruction(ctx, ts, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateShippingInstruction", reflect.TypeOf((*MockDCSAController)(nil).UpdateShippingInstruction), ctx, ts, req)
+}